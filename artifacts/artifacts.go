@@ -0,0 +1,282 @@
+package artifacts
+
+import (
+	// std
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	// environment variables of the artifact store, artifacts are collected
+	// only when ARTIFACT_DIR is set
+	DIR_ENV               string = "ARTIFACT_DIR"
+	MAX_FILE_BYTES_ENV    string = "ARTIFACT_MAX_FILE_BYTES"
+	MAX_COMMAND_BYTES_ENV string = "ARTIFACT_MAX_COMMAND_BYTES"
+
+	DEFAULT_MAX_FILE_BYTES    int64 = 100 << 20
+	DEFAULT_MAX_COMMAND_BYTES int64 = 500 << 20
+	// files of a command that are collected at most
+	MAX_FILES int = 1000
+)
+
+var checksumRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+var errTooLarge = errors.New("file is too large")
+
+// Store keeps the files of artifacts in a directory by their sha256, a file
+// collected by many commands is stored once.
+type Store struct {
+	Dir string
+	// size of a file and of all files of a command that are stored at most
+	MaxFileBytes    int64
+	MaxCommandBytes int64
+}
+
+// StoreFromEnv returns the store of ARTIFACT_DIR, nil if it isn't set.
+func StoreFromEnv() (*Store, error) {
+	dir := os.Getenv(DIR_ENV)
+	if dir == "" {
+		return nil, nil
+	}
+	store := &Store{Dir: dir, MaxFileBytes: DEFAULT_MAX_FILE_BYTES, MaxCommandBytes: DEFAULT_MAX_COMMAND_BYTES}
+	for _, limit := range []struct {
+		env   string
+		value *int64
+	}{
+		{MAX_FILE_BYTES_ENV, &store.MaxFileBytes},
+		{MAX_COMMAND_BYTES_ENV, &store.MaxCommandBytes},
+	} {
+		value := os.Getenv(limit.env)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %v %q, it must be a positive number of bytes", limit.env, value)
+		}
+		*limit.value = parsed
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("unable to create artifact dir: %w", err)
+	}
+	return store, nil
+}
+
+func (store *Store) path(checksum string) string {
+	return filepath.Join(store.Dir, checksum[:2], checksum)
+}
+
+// Open returns the stored file with the checksum.
+func (store *Store) Open(checksum string) (*os.File, error) {
+	if !checksumRegexp.MatchString(checksum) {
+		return nil, fmt.Errorf("invalid checksum %q", checksum)
+	}
+	return os.Open(store.path(checksum))
+}
+
+// put stores up to limit bytes of the reader and returns their checksum and
+// size, errTooLarge if the reader has more.
+func (store *Store) put(reader io.Reader, limit int64) (string, int64, error) {
+	temp, err := os.CreateTemp(store.Dir, ".tmp-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(temp, hash), io.LimitReader(reader, limit+1))
+	if err != nil {
+		return "", 0, err
+	}
+	if size > limit {
+		return "", 0, errTooLarge
+	}
+	if err := temp.Close(); err != nil {
+		return "", 0, err
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err := os.MkdirAll(filepath.Dir(store.path(checksum)), 0o750); err != nil {
+		return "", 0, err
+	}
+	// a file that is stored already gets a new modification time, so that
+	// it isn't swept before the artifact referring to it is stored
+	if err := os.Rename(temp.Name(), store.path(checksum)); err != nil {
+		return "", 0, err
+	}
+	return checksum, size, nil
+}
+
+// ValidatePatterns returns an error if a pattern isn't a valid glob of paths
+// inside the working directory.
+func ValidatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" || path.IsAbs(pattern) {
+			return fmt.Errorf("invalid artifact pattern %q, it must be relative to the working directory", pattern)
+		}
+		for _, segment := range strings.Split(pattern, "/") {
+			if segment == ".." {
+				return fmt.Errorf("invalid artifact pattern %q, it can't leave the working directory", pattern)
+			}
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid artifact pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Match returns whether the slash separated path matches the pattern. The
+// segments of the pattern are matched like path.Match, ** matches any number
+// of directories.
+func Match(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], name[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// Collect stores the regular files of the directory that match one of the
+// patterns. Symbolic links aren't followed, so only the files the command
+// wrote are collected. The files over the limits are returned as skipped,
+// after MAX_FILES files a single skipped file ends the list.
+func (store *Store) Collect(dir string, patterns []string) ([]models.Artifacts, error) {
+	artifacts := []models.Artifacts{}
+	var total int64
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		matched := false
+		for _, pattern := range patterns {
+			if Match(pattern, rel) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		artifact := models.Artifacts{Path: rel, SizeBytes: info.Size()}
+		if len(artifacts) >= MAX_FILES {
+			// the first file over the limit stands for all the rest, which
+			// aren't walked
+			artifact.Skipped = fmt.Sprintf("more than %v files, the rest isn't listed", MAX_FILES)
+			artifacts = append(artifacts, artifact)
+			return fs.SkipAll
+		}
+		switch {
+		case info.Size() > store.MaxFileBytes:
+			artifact.Skipped = fmt.Sprintf("larger than %v bytes", store.MaxFileBytes)
+		case total+info.Size() > store.MaxCommandBytes:
+			artifact.Skipped = fmt.Sprintf("artifacts of the command are over %v bytes", store.MaxCommandBytes)
+		default:
+			if err := store.collectFile(file, &artifact); err != nil {
+				return err
+			}
+			total += artifact.SizeBytes
+		}
+		artifacts = append(artifacts, artifact)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect artifacts: %w", err)
+	}
+	return artifacts, nil
+}
+
+// collectFile stores the file of the artifact, a file that has grown over
+// the limit since it was listed is skipped.
+func (store *Store) collectFile(file string, artifact *models.Artifacts) error {
+	reader, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	checksum, size, err := store.put(reader, store.MaxFileBytes)
+	if errors.Is(err, errTooLarge) {
+		artifact.Skipped = fmt.Sprintf("larger than %v bytes", store.MaxFileBytes)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	artifact.Sha256, artifact.SizeBytes = checksum, size
+	return nil
+}
+
+// Sweep removes the stored files that aren't referenced and were stored
+// before the time, and returns how many are removed. Files stored later may
+// belong to commands that aren't stored yet.
+func (store *Store) Sweep(referenced map[string]bool, before time.Time) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(store.Dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		name := entry.Name()
+		if referenced[name] || (!checksumRegexp.MatchString(name) && !strings.HasPrefix(name, ".tmp-")) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(before) {
+			return nil
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
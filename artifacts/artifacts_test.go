@@ -0,0 +1,196 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/golang/mock/gomock"
+)
+
+func TestStoreFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	var tests = []struct {
+		testName string
+		env      map[string]string
+		want     *Store
+		wantErr  bool
+	}{
+		{"nothing set", map[string]string{}, nil, false},
+		{"defaults", map[string]string{DIR_ENV: dir},
+			&Store{Dir: dir, MaxFileBytes: DEFAULT_MAX_FILE_BYTES, MaxCommandBytes: DEFAULT_MAX_COMMAND_BYTES}, false},
+		{"limits", map[string]string{DIR_ENV: dir, MAX_FILE_BYTES_ENV: "1024", MAX_COMMAND_BYTES_ENV: "4096"},
+			&Store{Dir: dir, MaxFileBytes: 1024, MaxCommandBytes: 4096}, false},
+		{"invalid limit", map[string]string{DIR_ENV: dir, MAX_FILE_BYTES_ENV: "0"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			for _, env := range []string{DIR_ENV, MAX_FILE_BYTES_ENV, MAX_COMMAND_BYTES_ENV} {
+				t.Setenv(env, tt.env[env])
+			}
+			got, err := StoreFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StoreFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("StoreFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"report.xml", "report.xml", true},
+		{"*.xml", "report.xml", true},
+		{"*.xml", "out/report.xml", false},
+		{"out/*.xml", "out/report.xml", true},
+		{"**/*.xml", "report.xml", true},
+		{"**/*.xml", "out/junit/report.xml", true},
+		{"dist/**", "dist/app/bin", true},
+		{"dist/**", "build/app", false},
+		{"out/**/report.xml", "out/report.xml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := Match(tt.pattern, tt.name); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"dist/**", false},
+		{"", true},
+		{"/etc/passwd", true},
+		{"../secrets", true},
+		{"out/[", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := ValidatePatterns([]string{tt.pattern}); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePatterns(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStore_Collect(t *testing.T) {
+	store := &Store{Dir: t.TempDir(), MaxFileBytes: 8, MaxCommandBytes: 12}
+	dir := t.TempDir()
+	files := map[string]string{
+		"out/a.txt":   "aaaaaa",
+		"out/b.txt":   "bbbbbb",
+		"out/big.txt": "too large file",
+		"out/c.log":   "not matched",
+		"README":      "hello",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// links aren't followed out of the working directory
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(outside, []byte("secret"), 0o644)
+	if err := os.Symlink(outside, filepath.Join(dir, "out", "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	artifacts, err := store.Collect(dir, []string{"out/*.txt", "README"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, artifact := range artifacts {
+		got = append(got, fmt.Sprintf("%v %v %v", artifact.Path, artifact.SizeBytes, artifact.Skipped != ""))
+		if artifact.Skipped == "" {
+			file, err := store.Open(artifact.Sha256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file.Close()
+		}
+	}
+	want := []string{"README 5 false", "out/a.txt 6 false", "out/b.txt 6 true", "out/big.txt 14 true"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("expected artifacts %q but got %q", want, got)
+	}
+}
+
+func TestStore_CollectMaxFiles(t *testing.T) {
+	store := &Store{Dir: t.TempDir(), MaxFileBytes: 8, MaxCommandBytes: 1 << 20}
+	dir := t.TempDir()
+	for i := 0; i < MAX_FILES+10; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%04d.txt", i)), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifacts, err := store.Collect(dir, []string{"*.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != MAX_FILES+1 {
+		t.Fatalf("expected %v artifacts but got %v", MAX_FILES+1, len(artifacts))
+	}
+	for _, artifact := range artifacts[:MAX_FILES] {
+		if artifact.Skipped != "" {
+			t.Fatalf("expected %v to be collected but it is skipped: %v", artifact.Path, artifact.Skipped)
+		}
+	}
+	if last := artifacts[MAX_FILES]; last.Path != fmt.Sprintf("%04d.txt", MAX_FILES) || last.Skipped == "" {
+		t.Errorf("expected the file over the limit to be skipped but got %+v", last)
+	}
+}
+
+func TestStore_Sweep(t *testing.T) {
+	store := &Store{Dir: t.TempDir(), MaxFileBytes: 1024, MaxCommandBytes: 1024}
+	kept, _, err := store.put(strings.NewReader("kept"), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swept, _, err := store.put(strings.NewReader("swept"), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, _, err := store.put(strings.NewReader("fresh"), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * SWEEP_GRACE)
+	for _, checksum := range []string{kept, swept} {
+		os.Chtimes(store.path(checksum), old, old)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := mock_database.NewMockDBWorker(ctrl)
+	db.EXPECT().GettingArtifactChecksumsQuery(gomock.Any()).Return(map[string]bool{kept: true}, nil)
+	NewSweeper(db, store).sweep(context.Background(), time.Now())
+
+	for checksum, wantExists := range map[string]bool{kept: true, swept: false, fresh: true} {
+		_, err := os.Stat(store.path(checksum))
+		if (err == nil) != wantExists {
+			t.Errorf("file %v exists: %v, want %v", checksum, err == nil, wantExists)
+		}
+	}
+}
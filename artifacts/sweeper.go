@@ -0,0 +1,56 @@
+package artifacts
+
+import (
+	// std
+	"context"
+	"log"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+)
+
+const (
+	SWEEP_INTERVAL time.Duration = time.Hour
+	// stored files younger than this are kept, their commands may not be
+	// stored yet
+	SWEEP_GRACE time.Duration = time.Hour
+)
+
+// Sweeper removes the stored files of artifacts whose commands are deleted.
+type Sweeper struct {
+	db    database.DBWorker
+	store *Store
+}
+
+func NewSweeper(db database.DBWorker, store *Store) *Sweeper {
+	return &Sweeper{db: db, store: store}
+}
+
+// Run sweeps until ctx is cancelled.
+func (sweeper *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(SWEEP_INTERVAL)
+	defer ticker.Stop()
+	for {
+		sweeper.sweep(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sweeper *Sweeper) sweep(ctx context.Context, now time.Time) {
+	referenced, err := sweeper.db.GettingArtifactChecksumsQuery(ctx)
+	if err != nil {
+		log.Printf("artifacts: database query error: %v\n", err)
+		return
+	}
+	removed, err := sweeper.store.Sweep(referenced, now.Add(-SWEEP_GRACE))
+	if err != nil {
+		log.Printf("artifacts: sweep error: %v\n", err)
+	}
+	if removed != 0 {
+		log.Printf("artifacts: %v files are removed\n", removed)
+	}
+}
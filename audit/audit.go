@@ -0,0 +1,158 @@
+package audit
+
+import (
+	// std
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// statusRecorder remembers the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(buf []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(buf)
+}
+
+// MAX_DRAINED_BODY_BYTES is how much of the body a handler didn't read is
+// still read for the hash, the rest of it isn't read at all.
+const MAX_DRAINED_BODY_BYTES int64 = 1 << 20
+
+// hashingBody hashes a request body while the handler reads it. The part the
+// handler doesn't read is hashed when it closes the body or returns, up to
+// MAX_DRAINED_BODY_BYTES.
+type hashingBody struct {
+	body    io.ReadCloser
+	hash    hash.Hash
+	drained bool
+}
+
+func newHashingBody(r *http.Request) *hashingBody {
+	body := &hashingBody{body: r.Body, hash: sha256.New()}
+	io.WriteString(body.hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	return body
+}
+
+func (body *hashingBody) Read(buf []byte) (int, error) {
+	n, err := body.body.Read(buf)
+	body.hash.Write(buf[:n])
+	return n, err
+}
+
+func (body *hashingBody) Close() error {
+	body.drain()
+	return body.body.Close()
+}
+
+func (body *hashingBody) drain() {
+	if !body.drained {
+		body.drained = true
+		io.Copy(body.hash, io.LimitReader(body.body, MAX_DRAINED_BODY_BYTES))
+	}
+}
+
+// sum returns the hash, drain hashes the part of the body the handler didn't
+// read too.
+func (body *hashingBody) sum(drain bool) string {
+	if drain {
+		body.drain()
+	}
+	return hex.EncodeToString(body.hash.Sum(nil))
+}
+
+// Middleware records every request to a route that needs an api key in the
+// audit log once it has been served. authenticate wraps next with the
+// authentication, so requests it rejects with 401 or 403 are recorded too,
+// without a key but with the address of the client. Their hash covers only
+// the part of the body that was read, so a client without a key can't make
+// the server read a large body. Public routes aren't recorded.
+//
+// The entry is appended after the response is written, so a failed append
+// can't fail the request: it is logged with the whole entry instead, and the
+// chain continues from the last stored entry.
+func Middleware(db database.DBWorker, mux *http.ServeMux, authenticate func(http.Handler) http.Handler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		if auth.RequiredScope(pattern) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// postgres keeps microseconds, the hash has to match the stored time
+		createdAt := time.Now().UTC().Truncate(time.Microsecond)
+		body := newHashingBody(r)
+		r.Body = body
+		recorder := &statusRecorder{ResponseWriter: w}
+		var key *models.ApiKeys
+		authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key = auth.ApiKeyFromContext(r.Context())
+			next.ServeHTTP(w, r)
+		})).ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		entry := models.AuditEntries{
+			CreatedAt:   createdAt,
+			SourceIp:    auth.RemoteIp(r),
+			Action:      pattern,
+			Target:      r.URL.Path,
+			Status:      recorder.status,
+			RequestHash: body.sum(key != nil),
+		}
+		if key != nil {
+			entry.ApiKeyId, entry.Actor = &key.Id, key.Name
+		}
+		if err := db.AppendAuditEntryQuery(&entry, context.Background()); err != nil {
+			log.Printf("audit log error: %v, entry %+v\n", err, entry)
+		}
+	})
+}
+
+// Verify walks the hash chain of the audit log and returns the first entry
+// that doesn't match its hash or doesn't link to the previous entry.
+func Verify(db database.DBWorker, ctx context.Context) (*models.AuditVerification, error) {
+	verification := models.AuditVerification{Valid: true}
+	err := db.WalkAuditLogQuery(func(entry *models.AuditEntries) error {
+		verification.Entries++
+		reason := ""
+		switch {
+		case entry.PrevHash != verification.LastHash:
+			reason = "entry doesn't link to the previous entry"
+		case entry.ChainHash() != entry.Hash:
+			reason = "entry doesn't match its hash"
+		}
+		if reason != "" && verification.Valid {
+			id := entry.Id
+			verification.Valid, verification.FirstInvalidId, verification.Reason = false, &id, reason
+		}
+		verification.LastHash = entry.Hash
+		return nil
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMiddleware(t *testing.T) {
+	body := `{"bash_strings": ["ls"]}`
+	hashOf := func(hashed string) string {
+		sum := sha256.Sum256([]byte("POST /bash/create-command?wait=true\n" + hashed))
+		return hex.EncodeToString(sum[:])
+	}
+
+	var tests = []struct {
+		testName   string
+		path       string
+		key        *models.ApiKeys
+		readBytes  int64
+		status     int
+		wantRecord bool
+		wantHashed string
+	}{
+		{"whole body", "/bash/create-command", &models.ApiKeys{Id: 2, Name: "ci"}, -1, http.StatusOK, true, body},
+		{"part of the body", "/bash/create-command", &models.ApiKeys{Id: 2, Name: "ci"}, 4, http.StatusForbidden, true, body},
+		// the body of a rejected request isn't read
+		{"rejected by the authentication", "/bash/create-command", nil, 0, http.StatusUnauthorized, true, ""},
+		{"public route", "/swagger/index.html", nil, -1, http.StatusOK, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			if tt.wantRecord {
+				db.EXPECT().AppendAuditEntryQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(entry *models.AuditEntries, ctx context.Context) error {
+						if tt.key == nil && (entry.ApiKeyId != nil || entry.Actor != "") {
+							t.Errorf("unexpected key of the entry %+v", entry)
+						}
+						if tt.key != nil && (entry.ApiKeyId == nil || *entry.ApiKeyId != 2 || entry.Actor != "ci") {
+							t.Errorf("unexpected key of the entry %+v", entry)
+						}
+						if entry.SourceIp != "10.0.0.1" ||
+							entry.Action != "POST /bash/create-command" || entry.Target != "/bash/create-command" ||
+							entry.Status != tt.status || entry.RequestHash != hashOf(tt.wantHashed) {
+							t.Errorf("unexpected entry %+v", entry)
+						}
+						if entry.CreatedAt.Nanosecond()%1000 != 0 {
+							t.Errorf("the time of the entry has nanoseconds: %v", entry.CreatedAt)
+						}
+						return nil
+					},
+				)
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /bash/create-command", func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				if tt.readBytes < 0 {
+					io.ReadAll(r.Body)
+				} else {
+					io.CopyN(io.Discard, r.Body, tt.readBytes)
+				}
+				w.WriteHeader(tt.status)
+			})
+			mux.HandleFunc("GET /swagger/", func(w http.ResponseWriter, r *http.Request) {})
+			authenticate := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if tt.key == nil {
+						http.Error(w, "api key required", http.StatusUnauthorized)
+						return
+					}
+					next.ServeHTTP(w, r.WithContext(auth.WithApiKey(r.Context(), tt.key)))
+				})
+			}
+
+			w := httptest.NewRecorder()
+			method := http.MethodPost
+			if tt.path == "/swagger/index.html" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, tt.path+"?wait=true", strings.NewReader(body))
+			r.RemoteAddr = "10.0.0.1:5000"
+			Middleware(db, mux, authenticate, mux).ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status code %v but got %v", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestHashingBody_DrainLimit(t *testing.T) {
+	body := strings.Repeat("a", int(MAX_DRAINED_BODY_BYTES)+10)
+	r := httptest.NewRequest(http.MethodPost, "/bash/create-command", strings.NewReader(body))
+	hashing := newHashingBody(r)
+
+	sum := sha256.Sum256([]byte("POST /bash/create-command\n" + body[:MAX_DRAINED_BODY_BYTES]))
+	if hash := hashing.sum(true); hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the hash of the first %v bytes of the body", MAX_DRAINED_BODY_BYTES)
+	}
+}
+
+// chain returns entries linked into a valid hash chain.
+func chain(n int) []models.AuditEntries {
+	entries := make([]models.AuditEntries, n)
+	prevHash := ""
+	for i := range entries {
+		keyId := uint(1)
+		entries[i] = models.AuditEntries{
+			Id:          uint(i + 1),
+			CreatedAt:   time.Date(2026, 10, 19, 12, 0, i, 1000, time.UTC),
+			ApiKeyId:    &keyId,
+			Actor:       "ci",
+			SourceIp:    "10.0.0.1",
+			Action:      "GET /bash/get-commands/{id}",
+			Target:      "/bash/get-commands/1",
+			Status:      http.StatusOK,
+			RequestHash: "hash",
+			PrevHash:    prevHash,
+		}
+		entries[i].Hash = entries[i].ChainHash()
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+func TestVerify(t *testing.T) {
+	var tests = []struct {
+		testName  string
+		tamper    func([]models.AuditEntries) []models.AuditEntries
+		wantValid bool
+		wantId    uint
+	}{
+		{"valid chain", func(entries []models.AuditEntries) []models.AuditEntries { return entries }, true, 0},
+		{"changed entry", func(entries []models.AuditEntries) []models.AuditEntries {
+			entries[1].Status = http.StatusForbidden
+			return entries
+		}, false, 2},
+		{"changed entry with its hash", func(entries []models.AuditEntries) []models.AuditEntries {
+			entries[1].Actor = "admin"
+			entries[1].Hash = entries[1].ChainHash()
+			return entries
+		}, false, 3},
+		{"removed entry", func(entries []models.AuditEntries) []models.AuditEntries {
+			return append(entries[:1], entries[2:]...)
+		}, false, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			entries := tt.tamper(chain(4))
+			db.EXPECT().WalkAuditLogQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(walk func(*models.AuditEntries) error, ctx context.Context) error {
+					for i := range entries {
+						if err := walk(&entries[i]); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			)
+
+			verification, err := Verify(db, context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verification.Valid != tt.wantValid || verification.Entries != len(entries) ||
+				verification.LastHash != entries[len(entries)-1].Hash {
+				t.Errorf("unexpected verification %+v", verification)
+			}
+			if !tt.wantValid && (verification.FirstInvalidId == nil || *verification.FirstInvalidId != tt.wantId) {
+				t.Errorf("expected the first invalid entry %v but got %+v", tt.wantId, verification)
+			}
+		})
+	}
+}
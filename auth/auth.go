@@ -0,0 +1,297 @@
+package auth
+
+import (
+	// std
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	KEY_PREFIX string = "tk_"
+	// random bytes of a generated key
+	KEY_BYTES int = 32
+	// length of the stored prefix that tells keys apart, KEY_PREFIX included
+	DISPLAY_PREFIX_LENGTH int    = 11
+	API_KEY_HEADER        string = "X-Api-Key"
+	// name of the key created from ADMIN_API_KEY
+	BOOTSTRAP_KEY_NAME string = "bootstrap"
+	// prefix shown for the users of tokens, they have no key
+	TOKEN_USER_PREFIX string = "oidc"
+)
+
+type contextKey struct{}
+
+type policyContextKey struct{}
+
+// GenerateKey returns a new random key.
+func GenerateKey() (string, error) {
+	buf := make([]byte, KEY_BYTES)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate key error: %v", err)
+	}
+	return KEY_PREFIX + hex.EncodeToString(buf), nil
+}
+
+// HashKey returns the stored hash of a key. The keys are random, so a fast
+// hash is enough to make a leaked table useless.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayPrefix returns the prefix of a key that is stored in clear.
+func DisplayPrefix(key string) string {
+	return key[:min(len(key), DISPLAY_PREFIX_LENGTH)]
+}
+
+// WithApiKey returns a context carrying the authenticated key.
+func WithApiKey(ctx context.Context, key *models.ApiKeys) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// ApiKeyFromContext returns the key that authenticated the request, nil if
+// the route is public.
+func ApiKeyFromContext(ctx context.Context) *models.ApiKeys {
+	key, _ := ctx.Value(contextKey{}).(*models.ApiKeys)
+	return key
+}
+
+// ApiKeyId returns the id of the key that authenticated the request, nil if
+// the route is public.
+func ApiKeyId(ctx context.Context) *uint {
+	if key := ApiKeyFromContext(ctx); key != nil {
+		return &key.Id
+	}
+	return nil
+}
+
+// WithPolicy returns a context carrying the command policy of the role of the
+// authenticated key.
+func WithPolicy(ctx context.Context, policy *models.RolePolicies) context.Context {
+	return context.WithValue(ctx, policyContextKey{}, policy)
+}
+
+// PolicyFromContext returns the command policy of the request, nil if the
+// route is public.
+func PolicyFromContext(ctx context.Context) *models.RolePolicies {
+	policy, _ := ctx.Value(policyContextKey{}).(*models.RolePolicies)
+	return policy
+}
+
+// CommandsOwner returns the api key whose commands, schedules and workflows
+// the request may see, nil if it may see all of them.
+func CommandsOwner(ctx context.Context) *uint {
+	if policy := PolicyFromContext(ctx); policy != nil && policy.OwnCommandsOnly {
+		return ApiKeyId(ctx)
+	}
+	return nil
+}
+
+// CheckCommands returns an error if the policy of the request doesn't allow
+// one of the bash strings. Every program a bash string runs has to be allowed,
+// bash strings whose programs can't be told before running are rejected.
+func CheckCommands(ctx context.Context, bashStrings []string) error {
+	policy := PolicyFromContext(ctx)
+	if policy == nil || len(policy.AllowedBinaries) == 0 {
+		return nil
+	}
+	for _, bashString := range bashStrings {
+		binaries, err := bash.CommandBinaries(bashString)
+		if err != nil {
+			return fmt.Errorf("command %q isn't allowed for role %v: %v", bashString, policy.Role, err)
+		}
+		for _, binary := range binaries {
+			if !slices.Contains(policy.AllowedBinaries, binary) {
+				return fmt.Errorf("role %v may not run %q", policy.Role, binary)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckOptions checks the bash strings of the commands with CheckCommands and
+// the names of their env variables and secrets with CheckEnv.
+func CheckOptions(ctx context.Context, options []bash.CommandOptions) error {
+	bashStrings := make([]string, len(options))
+	envNames := []string{}
+	for i, option := range options {
+		bashStrings[i] = option.BashString
+		for name := range option.Env {
+			envNames = append(envNames, name)
+		}
+		for name := range option.Secrets {
+			envNames = append(envNames, name)
+		}
+		for name := range option.ProcessEnv {
+			envNames = append(envNames, name)
+		}
+	}
+	if err := CheckCommands(ctx, bashStrings); err != nil {
+		return err
+	}
+	return CheckEnv(ctx, envNames)
+}
+
+// CheckEnv returns an error if the policy of the request restricts the
+// programs and one of the env variables may run programs the policy doesn't
+// see, such as LD_PRELOAD or PATH.
+func CheckEnv(ctx context.Context, names []string) error {
+	policy := PolicyFromContext(ctx)
+	if policy == nil || len(policy.AllowedBinaries) == 0 {
+		return nil
+	}
+	for _, name := range names {
+		if bash.IsRestrictedEnvName(name) {
+			return fmt.Errorf("role %v may not set env variable %v", policy.Role, name)
+		}
+	}
+	return nil
+}
+
+// RequiredScope returns the scope a route pattern of the mux needs, empty for
+// public routes. Reading needs commands:read, changing anything needs
+// commands:execute, and api keys, roles, webhooks and secrets are managed and
+// the audit log is read by admins.
+func RequiredScope(pattern string) string {
+	method, path, _ := strings.Cut(pattern, " ")
+	switch {
+	case strings.HasPrefix(path, "/swagger/"):
+		return ""
+	case strings.HasPrefix(path, "/bash/api-keys"), strings.HasPrefix(path, "/bash/webhooks"),
+		strings.HasPrefix(path, "/bash/roles"), strings.HasPrefix(path, "/bash/audit"),
+		strings.HasPrefix(path, "/bash/secrets"), path == "/bash/commands/import":
+		return models.SCOPE_ADMIN
+	case method == http.MethodGet:
+		return models.SCOPE_COMMANDS_READ
+	default:
+		// unknown routes are answered by the mux only to clients with a key
+		return models.SCOPE_COMMANDS_EXECUTE
+	}
+}
+
+// requestKey returns the key of the Authorization: Bearer or X-Api-Key header.
+func requestKey(r *http.Request) string {
+	if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(key)
+	}
+	return r.Header.Get(API_KEY_HEADER)
+}
+
+// RemoteIp returns the ip address of the client of the request.
+func RemoteIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware authenticates the requests to the routes of the mux and checks
+// the scope they need before passing them on to next with the key and the
+// command policy of its role. With a verifier, bearer JWTs are accepted
+// besides api keys and their users are stored as keys without a secret.
+func Middleware(db database.DBWorker, verifier *Verifier, mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		scope := RequiredScope(pattern)
+		if scope == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requested := requestKey(r)
+		if requested == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "api key required", http.StatusUnauthorized)
+			return
+		}
+		var key *models.ApiKeys
+		var err error
+		if verifier != nil && IsToken(requested) {
+			user, verifyErr := verifier.Verify(requested, time.Now())
+			if verifyErr != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, fmt.Sprintf("invalid token: %v", verifyErr), http.StatusUnauthorized)
+				return
+			}
+			key, err = tokenUser(db, user)
+		} else {
+			key, err = db.AuthenticateApiKeyQuery(HashKey(requested), context.Background())
+		}
+		if err != nil {
+			log.Printf("database query error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+		if !key.HasScope(scope) {
+			http.Error(w, fmt.Sprintf("api key has no %v scope", scope), http.StatusForbidden)
+			return
+		}
+
+		policy, err := db.GettingRolePolicyQuery(key.Role, context.Background())
+		if err != nil {
+			log.Printf("database query error: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPolicy(WithApiKey(r.Context(), key), policy)))
+	})
+}
+
+// tokenUser returns the stored user of a verified token, nil if the user is
+// revoked. Users get all scopes of the role of their token.
+func tokenUser(db database.DBWorker, user *TokenUser) (*models.ApiKeys, error) {
+	key := models.ApiKeys{
+		Name:    user.Name,
+		Role:    user.Role,
+		Prefix:  TOKEN_USER_PREFIX,
+		Subject: &user.Subject,
+		Scopes:  models.RoleScopes[user.Role],
+	}
+	if err := db.UpsertTokenUserQuery(&key, context.Background()); err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+// Bootstrap stores the given key as an admin key unless it is already stored,
+// so that the first keys can be created through the api.
+func Bootstrap(db database.DBWorker, key string, ctx context.Context) error {
+	stored, err := db.AuthenticateApiKeyQuery(HashKey(key), ctx)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		return nil
+	}
+	bootstrap := models.ApiKeys{
+		Name:   BOOTSTRAP_KEY_NAME,
+		Role:   models.ROLE_ADMIN,
+		Prefix: DisplayPrefix(key),
+		Scopes: models.RoleScopes[models.ROLE_ADMIN],
+	}
+	_, err = db.CreateNewApiKeyQuery(&bootstrap, HashKey(key), ctx)
+	return err
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestMiddleware(t *testing.T) {
+	readKey := &models.ApiKeys{Id: 1, Role: models.ROLE_VIEWER, Scopes: []string{models.SCOPE_COMMANDS_READ}}
+	executeKey := &models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR, Scopes: []string{models.SCOPE_COMMANDS_READ, models.SCOPE_COMMANDS_EXECUTE}}
+	adminKey := &models.ApiKeys{Id: 2, Role: models.ROLE_ADMIN, Scopes: []string{models.SCOPE_ADMIN}}
+
+	var tests = []struct {
+		testName   string
+		method     string
+		path       string
+		header     string
+		value      string
+		storedKey  *models.ApiKeys
+		lookupErr  error
+		wantStatus int
+		wantKeyId  uint
+	}{
+		{"public route", http.MethodGet, "/swagger/*", "", "", nil, nil, http.StatusOK, 0},
+		{"no key", http.MethodGet, "/bash/get-commands", "", "", nil, nil, http.StatusUnauthorized, 0},
+		{"unknown key", http.MethodGet, "/bash/get-commands", "Authorization", "Bearer tk_unknown", nil, nil, http.StatusUnauthorized, 0},
+		{"bearer key", http.MethodGet, "/bash/get-commands", "Authorization", "Bearer tk_read", readKey, nil, http.StatusOK, 1},
+		{"header key", http.MethodGet, "/bash/get-commands/3", "X-Api-Key", "tk_read", readKey, nil, http.StatusOK, 1},
+		{"missing scope", http.MethodPost, "/bash/create-command", "X-Api-Key", "tk_read", readKey, nil, http.StatusForbidden, 0},
+		{"admin route", http.MethodGet, "/bash/api-keys", "X-Api-Key", "tk_read", readKey, nil, http.StatusForbidden, 0},
+		{"roles route", http.MethodPut, "/bash/roles/viewer", "X-Api-Key", "tk_read", readKey, nil, http.StatusForbidden, 0},
+		{"audit route", http.MethodGet, "/bash/audit", "X-Api-Key", "tk_read", readKey, nil, http.StatusForbidden, 0},
+		{"secrets route", http.MethodGet, "/bash/secrets", "X-Api-Key", "tk_read", readKey, nil, http.StatusForbidden, 0},
+		{"import route", http.MethodPost, "/bash/commands/import", "X-Api-Key", "tk_execute", executeKey, nil, http.StatusForbidden, 0},
+		{"admin key", http.MethodPost, "/bash/create-command", "X-Api-Key", "tk_admin", adminKey, nil, http.StatusOK, 2},
+		{"db querry error", http.MethodGet, "/bash/get-commands", "X-Api-Key", "tk_read", nil, fmt.Errorf("some db error"),
+			http.StatusInternalServerError, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			if tt.header != "" {
+				db.EXPECT().AuthenticateApiKeyQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(keyHash string, ctx context.Context) (*models.ApiKeys, error) {
+						if keyHash == tt.value || len(keyHash) != 64 {
+							t.Errorf("the key isn't hashed: %q", keyHash)
+						}
+						return tt.storedKey, tt.lookupErr
+					},
+				)
+			}
+			if tt.wantKeyId != 0 {
+				db.EXPECT().GettingRolePolicyQuery(tt.storedKey.Role, gomock.Any()).Return(
+					&models.RolePolicies{Role: tt.storedKey.Role}, nil,
+				)
+			}
+
+			var gotKeyId uint
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if key := ApiKeyFromContext(r.Context()); key != nil {
+					gotKeyId = key.Id
+					if policy := PolicyFromContext(r.Context()); policy == nil || policy.Role != key.Role {
+						t.Errorf("unexpected policy %+v of key %+v", policy, key)
+					}
+				}
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /swagger/*", handler)
+			mux.HandleFunc("GET /bash/get-commands", handler)
+			mux.HandleFunc("GET /bash/get-commands/{id}", handler)
+			mux.HandleFunc("POST /bash/create-command", handler)
+			mux.HandleFunc("GET /bash/api-keys", handler)
+			mux.HandleFunc("PUT /bash/roles/{role}", handler)
+			mux.HandleFunc("GET /bash/audit", handler)
+			mux.HandleFunc("GET /bash/secrets", handler)
+			mux.HandleFunc("POST /bash/commands/import", handler)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			Middleware(db, nil, mux, mux).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status code %v but got %v", tt.wantStatus, w.Code)
+			}
+			if gotKeyId != tt.wantKeyId {
+				t.Errorf("expected key %v in the context but got %v", tt.wantKeyId, gotKeyId)
+			}
+		})
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := mock_database.NewMockDBWorker(ctrl)
+
+	key := "tk_0123456789abcdef"
+	db.EXPECT().AuthenticateApiKeyQuery(HashKey(key), gomock.Any()).Return(nil, nil)
+	db.EXPECT().CreateNewApiKeyQuery(gomock.Any(), HashKey(key), gomock.Any()).DoAndReturn(
+		func(stored *models.ApiKeys, keyHash string, ctx context.Context) (uint, error) {
+			if !stored.HasScope(models.SCOPE_ADMIN) || stored.Prefix != "tk_01234567" {
+				t.Errorf("unexpected bootstrap key %+v", stored)
+			}
+			return 1, nil
+		},
+	)
+	if err := Bootstrap(db, key, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	db.EXPECT().AuthenticateApiKeyQuery(HashKey(key), gomock.Any()).Return(&models.ApiKeys{Id: 1}, nil)
+	if err := Bootstrap(db, key, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckCommands(t *testing.T) {
+	operator := &models.RolePolicies{Role: models.ROLE_OPERATOR, AllowedBinaries: []string{"ls", "grep", "echo"}}
+	var tests = []struct {
+		testName    string
+		policy      *models.RolePolicies
+		bashStrings []string
+		wantErr     bool
+	}{
+		{"public route", nil, []string{"rm -rf /"}, false},
+		{"any binary", &models.RolePolicies{Role: models.ROLE_ADMIN}, []string{"rm -rf /tmp/x"}, false},
+		{"allowed binaries", operator, []string{"ls -la | grep go", "echo done"}, false},
+		{"binary isn't allowed", operator, []string{"ls", "ls; rm -rf /"}, true},
+		{"unknown binary", operator, []string{"echo $(rm -rf /)"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.policy != nil {
+				ctx = WithPolicy(WithApiKey(ctx, &models.ApiKeys{Id: 1, Role: tt.policy.Role}), tt.policy)
+			}
+			if err := CheckCommands(ctx, tt.bashStrings); (err != nil) != tt.wantErr {
+				t.Errorf("CheckCommands() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckEnv(t *testing.T) {
+	operator := &models.RolePolicies{Role: models.ROLE_OPERATOR, AllowedBinaries: []string{"ls"}}
+	var tests = []struct {
+		testName string
+		policy   *models.RolePolicies
+		names    []string
+		wantErr  bool
+	}{
+		{"public route", nil, []string{"LD_PRELOAD"}, false},
+		{"any binary", &models.RolePolicies{Role: models.ROLE_ADMIN}, []string{"PATH"}, false},
+		{"plain variables", operator, []string{"NAME", "LD", "PATHS", "STEPS_BUILD_STDOUT"}, false},
+		{"dynamic loader", operator, []string{"NAME", "LD_PRELOAD"}, true},
+		{"search path", operator, []string{"PATH"}, true},
+		{"startup file", operator, []string{"BASH_ENV"}, true},
+		{"word splitting", operator, []string{"IFS"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.policy != nil {
+				ctx = WithPolicy(WithApiKey(ctx, &models.ApiKeys{Id: 1, Role: tt.policy.Role}), tt.policy)
+			}
+			if err := CheckEnv(ctx, tt.names); (err != nil) != tt.wantErr {
+				t.Errorf("CheckEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandsOwner(t *testing.T) {
+	key := &models.ApiKeys{Id: 4, Role: models.ROLE_OPERATOR}
+	ctx := WithApiKey(context.Background(), key)
+	if owner := CommandsOwner(WithPolicy(ctx, &models.RolePolicies{OwnCommandsOnly: false})); owner != nil {
+		t.Errorf("expected all commands but got the commands of key %v", *owner)
+	}
+	if owner := CommandsOwner(WithPolicy(ctx, &models.RolePolicies{OwnCommandsOnly: true})); owner == nil || *owner != 4 {
+		t.Errorf("expected the commands of key 4 but got %v", owner)
+	}
+	if owner := CommandsOwner(context.Background()); owner != nil {
+		t.Errorf("expected all commands without a key but got %v", *owner)
+	}
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	// std
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// a key set from a url is fetched again for an unknown key id, but not
+	// more often than this
+	JWKS_MIN_REFRESH_INTERVAL time.Duration = time.Minute
+	JWKS_FETCH_TIMEOUT        time.Duration = 10 * time.Second
+)
+
+// jsonWebKey is a public key of a JWKS document, RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet holds the public keys of a JWKS file or url by their key ids.
+type KeySet struct {
+	source string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewKeySet loads the keys of a JWKS document from a file path or an http(s)
+// url.
+func NewKeySet(source string) (*KeySet, error) {
+	keySet := &KeySet{source: source, client: &http.Client{Timeout: JWKS_FETCH_TIMEOUT}}
+	if err := keySet.load(); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+// Key returns the key with the given id. A key set from a url is fetched again
+// when the id is unknown, issuers add new keys before signing with them.
+func (keySet *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	keySet.mu.Lock()
+	key, ok := keySet.keys[kid]
+	isStale := keySet.isUrl() && time.Since(keySet.fetchedAt) > JWKS_MIN_REFRESH_INTERVAL
+	keySet.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if isStale {
+		if err := keySet.load(); err != nil {
+			return nil, err
+		}
+		keySet.mu.Lock()
+		key, ok = keySet.keys[kid]
+		keySet.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (keySet *KeySet) isUrl() bool {
+	return strings.HasPrefix(keySet.source, "http://") || strings.HasPrefix(keySet.source, "https://")
+}
+
+func (keySet *KeySet) load() error {
+	var buf []byte
+	var err error
+	if keySet.isUrl() {
+		buf, err = keySet.fetch()
+	} else {
+		buf, err = os.ReadFile(keySet.source)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read jwks: %v", err)
+	}
+
+	keys, err := parseJwks(buf)
+	if err != nil {
+		return err
+	}
+	keySet.mu.Lock()
+	keySet.keys, keySet.fetchedAt = keys, time.Now()
+	keySet.mu.Unlock()
+	return nil
+}
+
+func (keySet *KeySet) fetch() ([]byte, error) {
+	response, err := keySet.client.Get(keySet.source)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v responded with %v", keySet.source, response.StatusCode)
+	}
+	return io.ReadAll(response.Body)
+}
+
+// parseJwks returns the signing keys of a JWKS document, keys of other types
+// and uses are skipped.
+func parseJwks(buf []byte) (map[string]crypto.PublicKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(buf, &document); err != nil {
+		return nil, fmt.Errorf("jwks unmarshal error: %v", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaKey()
+		case "EC":
+			key, err = jwk.ecdsaKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %v", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks has no RSA or EC signing keys")
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %v", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+func (jwk jsonWebKey) ecdsaKey() (*ecdsa.PublicKey, error) {
+	if jwk.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x: %v", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y: %v", err)
+	}
+	if len(x) != 32 || len(y) != 32 {
+		return nil, fmt.Errorf("invalid P-256 coordinates")
+	}
+	// ecdh checks that the point is on the curve
+	if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, fmt.Errorf("invalid P-256 point: %v", err)
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
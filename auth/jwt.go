@@ -0,0 +1,270 @@
+package auth
+
+import (
+	// std
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// environment variables of the token verifier, tokens are accepted only when
+// OIDC_JWKS is set
+const (
+	OIDC_JWKS_ENV       string = "OIDC_JWKS"
+	OIDC_ISSUER_ENV     string = "OIDC_ISSUER"
+	OIDC_AUDIENCE_ENV   string = "OIDC_AUDIENCE"
+	OIDC_CLOCK_SKEW_ENV string = "OIDC_CLOCK_SKEW"
+	OIDC_ROLE_CLAIM_ENV string = "OIDC_ROLE_CLAIM"
+	OIDC_NAME_CLAIM_ENV string = "OIDC_NAME_CLAIM"
+)
+
+const (
+	DEFAULT_CLOCK_SKEW time.Duration = time.Minute
+	DEFAULT_ROLE_CLAIM string        = "role"
+	DEFAULT_NAME_CLAIM string        = "preferred_username"
+)
+
+// JwtConfig describes the tokens the verifier accepts.
+type JwtConfig struct {
+	// JWKS file path or http(s) url
+	Jwks     string
+	Issuer   string
+	Audience string
+	// allowed difference between the clocks of the issuer and the server
+	ClockSkew time.Duration
+	// claim with the role of the user, a string or a list of strings
+	RoleClaim string
+	// claim with the display name of the user, sub when it is missing
+	NameClaim string
+}
+
+// JwtConfigFromEnv returns the verifier config of the environment, nil if
+// OIDC_JWKS isn't set.
+func JwtConfigFromEnv() (*JwtConfig, error) {
+	config := &JwtConfig{
+		Jwks:      os.Getenv(OIDC_JWKS_ENV),
+		Issuer:    os.Getenv(OIDC_ISSUER_ENV),
+		Audience:  os.Getenv(OIDC_AUDIENCE_ENV),
+		ClockSkew: DEFAULT_CLOCK_SKEW,
+		RoleClaim: DEFAULT_ROLE_CLAIM,
+		NameClaim: DEFAULT_NAME_CLAIM,
+	}
+	if config.Jwks == "" {
+		return nil, nil
+	}
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, fmt.Errorf("%v and %v are required with %v", OIDC_ISSUER_ENV, OIDC_AUDIENCE_ENV, OIDC_JWKS_ENV)
+	}
+	if skew := os.Getenv(OIDC_CLOCK_SKEW_ENV); skew != "" {
+		var err error
+		if config.ClockSkew, err = time.ParseDuration(skew); err != nil || config.ClockSkew < 0 {
+			return nil, fmt.Errorf("%v isn't a non-negative duration: %q", OIDC_CLOCK_SKEW_ENV, skew)
+		}
+	}
+	if claim := os.Getenv(OIDC_ROLE_CLAIM_ENV); claim != "" {
+		config.RoleClaim = claim
+	}
+	if claim := os.Getenv(OIDC_NAME_CLAIM_ENV); claim != "" {
+		config.NameClaim = claim
+	}
+	return config, nil
+}
+
+// TokenUser is the user a verified token is issued to.
+type TokenUser struct {
+	Subject string
+	Name    string
+	Role    string
+}
+
+// Verifier checks bearer JWTs signed with RS256 or ES256 by the keys of a
+// JWKS.
+type Verifier struct {
+	config JwtConfig
+	keys   *KeySet
+}
+
+func NewVerifier(config JwtConfig) (*Verifier, error) {
+	keys, err := NewKeySet(config.Jwks)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{config: config, keys: keys}, nil
+}
+
+// IsToken reports whether a bearer credential looks like a JWT rather than an
+// api key.
+func IsToken(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// audience is the aud claim, a string or a list of strings.
+type audience []string
+
+func (aud *audience) UnmarshalJSON(buf []byte) error {
+	var single string
+	if err := json.Unmarshal(buf, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(buf, &list); err != nil {
+		return fmt.Errorf("aud is neither a string nor a list of strings")
+	}
+	*aud = list
+	return nil
+}
+
+type jwtClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	IssuedAt  *int64   `json:"iat"`
+}
+
+// Verify checks the signature and the claims of a token at the given time and
+// returns its user.
+func (verifier *Verifier) Verify(token string, now time.Time) (*TokenUser, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token must have 3 parts")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token signature: %v", err)
+	}
+	key, err := verifier.keys.Key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %v", err)
+	}
+	var rawClaims map[string]any
+	if err := decodeSegment(parts[1], &rawClaims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %v", err)
+	}
+	if err := verifier.checkClaims(&claims, now); err != nil {
+		return nil, err
+	}
+
+	role, err := claimRole(rawClaims[verifier.config.RoleClaim])
+	if err != nil {
+		return nil, err
+	}
+	user := &TokenUser{Subject: claims.Subject, Name: claims.Subject, Role: role}
+	if name, ok := rawClaims[verifier.config.NameClaim].(string); ok && name != "" {
+		user.Name = name
+	}
+	return user, nil
+}
+
+func (verifier *Verifier) checkClaims(claims *jwtClaims, now time.Time) error {
+	skew := verifier.config.ClockSkew
+	switch {
+	case claims.Issuer != verifier.config.Issuer:
+		return fmt.Errorf("unexpected token issuer %q", claims.Issuer)
+	case !slices.Contains(claims.Audience, verifier.config.Audience):
+		return fmt.Errorf("token isn't issued for audience %q", verifier.config.Audience)
+	case claims.Subject == "":
+		return fmt.Errorf("token has no subject")
+	case claims.ExpiresAt == nil:
+		return fmt.Errorf("token has no expiration time")
+	case !now.Before(time.Unix(*claims.ExpiresAt, 0).Add(skew)):
+		return fmt.Errorf("token is expired")
+	case claims.NotBefore != nil && now.Add(skew).Before(time.Unix(*claims.NotBefore, 0)):
+		return fmt.Errorf("token isn't valid yet")
+	case claims.IssuedAt != nil && now.Add(skew).Before(time.Unix(*claims.IssuedAt, 0)):
+		return fmt.Errorf("token is issued in the future")
+	}
+	return nil
+}
+
+// claimRole returns the role of the role claim, the highest one of a list.
+// Users without a role claim are viewers.
+func claimRole(claim any) (string, error) {
+	var roles []string
+	switch claim := claim.(type) {
+	case nil:
+		return models.ROLE_VIEWER, nil
+	case string:
+		roles = []string{claim}
+	case []any:
+		for _, role := range claim {
+			if role, ok := role.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	for _, role := range []string{models.ROLE_ADMIN, models.ROLE_OPERATOR, models.ROLE_VIEWER} {
+		if slices.Contains(roles, role) {
+			return role, nil
+		}
+	}
+	return "", fmt.Errorf("token has no known role in %v", claim)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("token key isn't an RSA key")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("invalid token signature")
+		}
+	case "ES256":
+		ecdsaKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("token key isn't an EC key")
+		}
+		// the signature is r and s of 32 bytes each, RFC 7518
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid token signature")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecdsaKey, digest[:], r, s) {
+			return fmt.Errorf("invalid token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	buf, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
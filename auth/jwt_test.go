@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+const (
+	testIssuer   = "https://issuer.test"
+	testAudience = "termapi"
+)
+
+// testSigner signs tokens with locally generated keys and publishes their
+// public parts as a JWKS.
+type testSigner struct {
+	rsaKey   *rsa.PrivateKey
+	ecdsaKey *ecdsa.PrivateKey
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{rsaKey: rsaKey, ecdsaKey: ecdsaKey}
+}
+
+func (signer *testSigner) jwks(t *testing.T, rsaKid, ecdsaKid string) []byte {
+	encode := base64.RawURLEncoding.EncodeToString
+	buf, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": rsaKid, "use": "sig", "alg": "RS256",
+			"n": encode(signer.rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(signer.rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": ecdsaKid, "use": "sig", "alg": "ES256", "crv": "P-256",
+			"x": encode(signer.ecdsaKey.X.FillBytes(make([]byte, 32))), "y": encode(signer.ecdsaKey.Y.FillBytes(make([]byte, 32)))},
+		// keys of other uses are skipped
+		{"kty": "oct", "kid": "hmac", "use": "enc", "k": "c2VjcmV0"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func (signer *testSigner) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	var err error
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, signer.rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, signer.ecdsaKey, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case "HS256":
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func testClaims(now time.Time, changes map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss":                testIssuer,
+		"aud":                testAudience,
+		"sub":                "user-1",
+		"preferred_username": "alice",
+		"role":               "operator",
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+	}
+	for claim, value := range changes {
+		if value == nil {
+			delete(claims, claim)
+		} else {
+			claims[claim] = value
+		}
+	}
+	return claims
+}
+
+func newTestVerifier(t *testing.T, signer *testSigner) *Verifier {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, signer.jwks(t, "rsa-1", "ec-1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewVerifier(JwtConfig{
+		Jwks:      path,
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+		ClockSkew: time.Minute,
+		RoleClaim: DEFAULT_ROLE_CLAIM,
+		NameClaim: DEFAULT_NAME_CLAIM,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+	verifier := newTestVerifier(t, signer)
+	now := time.Now()
+
+	var tests = []struct {
+		testName string
+		token    string
+		wantRole string
+		wantErr  bool
+	}{
+		{"RS256", signer.sign(t, "RS256", "rsa-1", testClaims(now, nil)), models.ROLE_OPERATOR, false},
+		{"ES256", signer.sign(t, "ES256", "ec-1", testClaims(now, nil)), models.ROLE_OPERATOR, false},
+		{"audience list", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"aud": []string{"other", testAudience}})),
+			models.ROLE_OPERATOR, false},
+		{"role list", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"role": []string{"viewer", "admin"}})),
+			models.ROLE_ADMIN, false},
+		{"no role", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"role": nil})), models.ROLE_VIEWER, false},
+		{"unknown role", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"role": "root"})), "", true},
+		{"expired within skew", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"exp": now.Add(-30 * time.Second).Unix()})),
+			models.ROLE_OPERATOR, false},
+		{"expired", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})), "", true},
+		{"no expiration", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"exp": nil})), "", true},
+		{"not valid yet within skew", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"nbf": now.Add(30 * time.Second).Unix()})),
+			models.ROLE_OPERATOR, false},
+		{"not valid yet", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"nbf": now.Add(2 * time.Minute).Unix()})), "", true},
+		{"wrong issuer", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"iss": "https://evil.test"})), "", true},
+		{"wrong audience", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"aud": "other"})), "", true},
+		{"no subject", signer.sign(t, "RS256", "rsa-1", testClaims(now, map[string]any{"sub": nil})), "", true},
+		{"wrong signature", other.sign(t, "RS256", "rsa-1", testClaims(now, nil)), "", true},
+		{"wrong key type", signer.sign(t, "ES256", "rsa-1", testClaims(now, nil)), "", true},
+		{"unknown key id", signer.sign(t, "RS256", "rsa-2", testClaims(now, nil)), "", true},
+		{"HS256", signer.sign(t, "HS256", "rsa-1", testClaims(now, nil)), "", true},
+		{"alg none", signer.sign(t, "none", "rsa-1", testClaims(now, nil)), "", true},
+		{"malformed", "a.b", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			user, err := verifier.Verify(tt.token, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (user.Role != tt.wantRole || user.Subject != "user-1" || user.Name != "alice") {
+				t.Errorf("unexpected user %+v", user)
+			}
+		})
+	}
+}
+
+func TestKeySet_Url(t *testing.T) {
+	signer := newTestSigner(t)
+	jwks := signer.jwks(t, "rsa-1", "ec-1")
+	var fetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write(jwks)
+	}))
+	defer server.Close()
+
+	keySet, err := NewKeySet(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keySet.Key("rsa-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a rotated key isn't fetched again right away
+	jwks = signer.jwks(t, "rsa-2", "ec-2")
+	if _, err := keySet.Key("rsa-2"); err == nil || fetches != 1 {
+		t.Fatalf("expected an unknown key without a fetch, got %v after %v fetches", err, fetches)
+	}
+	keySet.fetchedAt = time.Now().Add(-2 * JWKS_MIN_REFRESH_INTERVAL)
+	if _, err := keySet.Key("rsa-2"); err != nil || fetches != 2 {
+		t.Fatalf("expected the rotated key after a fetch, got %v after %v fetches", err, fetches)
+	}
+}
+
+func TestMiddleware_Token(t *testing.T) {
+	signer := newTestSigner(t)
+	verifier := newTestVerifier(t, signer)
+	revokedAt := time.Now()
+
+	var tests = []struct {
+		testName   string
+		token      string
+		revoked    bool
+		wantStatus int
+	}{
+		{"operator token", signer.sign(t, "ES256", "ec-1", testClaims(time.Now(), nil)), false, http.StatusOK},
+		{"viewer token", signer.sign(t, "RS256", "rsa-1", testClaims(time.Now(), map[string]any{"role": "viewer"})), false,
+			http.StatusForbidden},
+		{"expired token", signer.sign(t, "RS256", "rsa-1", testClaims(time.Now(), map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+			false, http.StatusUnauthorized},
+		{"revoked user", signer.sign(t, "RS256", "rsa-1", testClaims(time.Now(), nil)), true, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			if tt.wantStatus != http.StatusUnauthorized || tt.revoked {
+				db.EXPECT().UpsertTokenUserQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(user *models.ApiKeys, ctx context.Context) error {
+						if user.Subject == nil || *user.Subject != "user-1" || user.Name != "alice" || user.Prefix != TOKEN_USER_PREFIX {
+							t.Errorf("unexpected user %+v", user)
+						}
+						user.Id = 7
+						if tt.revoked {
+							user.RevokedAt = &revokedAt
+						}
+						return nil
+					},
+				)
+			}
+			if tt.wantStatus == http.StatusOK {
+				db.EXPECT().GettingRolePolicyQuery(models.ROLE_OPERATOR, gomock.Any()).Return(
+					&models.RolePolicies{Role: models.ROLE_OPERATOR}, nil,
+				)
+			}
+
+			var gotKeyId uint
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /bash/create-command", func(w http.ResponseWriter, r *http.Request) {
+				gotKeyId = *ApiKeyId(r.Context())
+			})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/create-command", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			Middleware(db, verifier, mux, mux).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status code %v but got %v", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusOK && gotKeyId != 7 {
+				t.Errorf("expected user 7 in the context but got %v", gotKeyId)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package bash
+
+import (
+	// std
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// ValidateAssertions checks the assertions before the command runs, so that
+// an invalid regex is rejected with the request.
+func ValidateAssertions(assertions *models.Assertions) error {
+	if _, err := regexp.Compile(assertions.StdoutRegex); err != nil {
+		return fmt.Errorf("invalid assertion stdout_regex: %v", err)
+	}
+	return nil
+}
+
+// EvaluateAssertions checks a finished command against its assertions and
+// returns a result per assertion.
+func EvaluateAssertions(assertions *models.Assertions, command *models.CommandsWithoutID) []models.AssertionResult {
+	results := []models.AssertionResult{}
+	if assertions.ExitCode != nil {
+		result := models.AssertionResult{Name: "exit_code", Passed: command.ExitCode == *assertions.ExitCode}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("expected exit code %v, got %v", *assertions.ExitCode, command.ExitCode)
+		}
+		results = append(results, result)
+	}
+	if assertions.StdoutRegex != "" {
+		result := models.AssertionResult{Name: "stdout_regex"}
+		re, err := regexp.Compile(assertions.StdoutRegex)
+		switch {
+		case err != nil:
+			result.Message = fmt.Sprintf("invalid regex: %v", err)
+		case re.MatchString(command.Stdout):
+			result.Passed = true
+		default:
+			result.Message = fmt.Sprintf("stdout doesn't match %q", assertions.StdoutRegex)
+		}
+		results = append(results, result)
+	}
+	if assertions.StdoutContains != "" {
+		result := models.AssertionResult{Name: "stdout_contains", Passed: strings.Contains(command.Stdout, assertions.StdoutContains)}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("stdout doesn't contain %q", assertions.StdoutContains)
+		}
+		results = append(results, result)
+	}
+	if assertions.StderrEmpty {
+		result := models.AssertionResult{Name: "stderr_empty", Passed: command.Stderr == ""}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("stderr has %v bytes", len(command.Stderr))
+		}
+		results = append(results, result)
+	}
+	if assertions.MaxDurationMs > 0 {
+		result := models.AssertionResult{Name: "max_duration_ms", Passed: command.DurationMs <= assertions.MaxDurationMs}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("took %vms, limit is %vms", command.DurationMs, assertions.MaxDurationMs)
+		}
+		results = append(results, result)
+	}
+	if len(assertions.JsonPath) != 0 {
+		var stdout any
+		stdoutErr := json.Unmarshal([]byte(command.Stdout), &stdout)
+		for _, jsonPath := range assertions.JsonPath {
+			result := models.AssertionResult{Name: "json_path " + jsonPath.Path}
+			if stdoutErr != nil {
+				result.Message = fmt.Sprintf("stdout is not JSON: %v", stdoutErr)
+				results = append(results, result)
+				continue
+			}
+			value, err := lookupJsonPath(stdout, jsonPath.Path)
+			switch {
+			case err != nil:
+				result.Message = err.Error()
+			case jsonPath.Equals != nil && !reflect.DeepEqual(value, jsonPath.Equals):
+				result.Message = fmt.Sprintf("expected %v, got %v", jsonPath.Equals, value)
+			default:
+				result.Passed = true
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// lookupJsonPath returns the value at a path like $.items[0].name inside a
+// document decoded by encoding/json.
+func lookupJsonPath(document any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("path %q must start with $", path)
+	}
+	value := document
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%q is not an object at .%v", path, key)
+			}
+			if value, ok = object[key]; !ok {
+				return nil, fmt.Errorf("%q has no key %q", path, key)
+			}
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has unclosed [", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("path %q has invalid index %q", path, rest[1:end])
+			}
+			array, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%q is not an array at [%v]", path, index)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("%q index %v is out of range", path, index)
+			}
+			value = array[index]
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %q is invalid at %q", path, rest)
+		}
+	}
+	return value, nil
+}
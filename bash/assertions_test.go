@@ -0,0 +1,96 @@
+package bash
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+func TestEvaluateAssertions(t *testing.T) {
+	zero := 0
+	command := &models.CommandsWithoutID{
+		ExitCode: 0,
+		DurationMs: 120,
+		Stdout: `{"status": "ok", "items": [{"name": "db", "up": true}], "count": 2}`,
+		Stderr: "warning: slow\n",
+	}
+
+	var tests = []struct {
+		testName string
+		assertions *models.Assertions
+		want []bool
+	}{
+		{"exit code", &models.Assertions{ExitCode: &zero}, []bool{true}},
+		{"stdout regex", &models.Assertions{StdoutRegex: `"status":\s*"ok"`}, []bool{true}},
+		{"invalid regex", &models.Assertions{StdoutRegex: `(`}, []bool{false}},
+		{"stdout contains", &models.Assertions{StdoutContains: "missing"}, []bool{false}},
+		{"stderr empty", &models.Assertions{StderrEmpty: true}, []bool{false}},
+		{"max duration", &models.Assertions{MaxDurationMs: 100}, []bool{false}},
+		{
+			"json path",
+			&models.Assertions{JsonPath: []models.JsonPathAssertion{
+				{Path: "$.status", Equals: "ok"},
+				{Path: "$.items[0].up", Equals: true},
+				{Path: "$.count", Equals: float64(2)},
+				{Path: "$.items[0].name"},
+				{Path: "$.items[1].name"},
+				{Path: "$.status", Equals: "down"},
+			}},
+			[]bool{true, true, true, true, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			results := EvaluateAssertions(tt.assertions, command)
+			if len(results) != len(tt.want) {
+				t.Fatalf("got %v results, want %v", len(results), len(tt.want))
+			}
+			for i, result := range results {
+				if result.Passed != tt.want[i] {
+					t.Errorf("assertion %v: got passed %v, want %v (%v)", result.Name, result.Passed, tt.want[i], result.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAssertions(t *testing.T) {
+	if err := ValidateAssertions(&models.Assertions{StdoutRegex: `"status":\s*"ok"`}); err != nil {
+		t.Errorf("valid regex was rejected: %v", err)
+	}
+	if err := ValidateAssertions(&models.Assertions{StdoutRegex: `(`}); err == nil {
+		t.Errorf("invalid regex was accepted")
+	}
+}
+
+func TestExecCommandsAssertions(t *testing.T) {
+	one := 1
+	result, err := bash.ExecCommands(&ReqCreateNewCommandBody{
+		BashStrings: []string{"echo first"},
+		Commands: []CommandOptions{
+			{BashString: "echo healthy", Assertions: &models.Assertions{StdoutContains: "healthy"}},
+			{BashString: "echo broken", Assertions: &models.Assertions{ExitCode: &one}},
+		},
+	}, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*result) != 3 || (*result)[0].Command != "echo first" || (*result)[1].Command != "echo healthy" {
+		t.Fatalf("results are not in the order of the request: %v", *result)
+	}
+	if (*result)[0].AssertionsPassed != nil {
+		t.Errorf("command without assertions has a verdict")
+	}
+	if passed := (*result)[1].AssertionsPassed; passed == nil || !*passed {
+		t.Errorf("expected assertions of %q to pass", (*result)[1].Command)
+	}
+	if passed := (*result)[2].AssertionsPassed; passed == nil || *passed {
+		t.Errorf("expected assertions of %q to fail", (*result)[2].Command)
+	}
+	if verdict := models.BatchVerdict(*result); verdict != models.VERDICT_FAILED {
+		t.Errorf("expected batch verdict %v but got %v", models.VERDICT_FAILED, verdict)
+	}
+}
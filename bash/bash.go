@@ -2,53 +2,406 @@ package bash
 
 import (
 	// std
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"syscall"
 	"log"
-	"io"
+	"time"
 	// local
+	"github.com/Vy4cheSlave/test-task-postgres/artifacts"
 	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
+	"github.com/Vy4cheSlave/test-task-postgres/workspaces"
 )
 
 //go:generate mockgen -source=bash.go -destination=mock/mock.go
 
+// interruptWaitDelay is how long an interrupted subprocess may take to exit
+// before it is killed.
+const interruptWaitDelay = 5 * time.Second
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 type BashCommandsWorker interface {
-	ExecCommands(*ReqCreateNewCommandBody) (*[]models.CommandsWithoutID, error)
-	RunSubprocess(*sync.WaitGroup, *string, chan<- models.CommandsWithoutID, chan<- struct{}) 
+	ExecCommands(*ReqCreateNewCommandBody, context.Context) (*[]models.CommandsWithoutID, error)
+	RunSubprocess(*sync.WaitGroup, *string, chan<- models.CommandsWithoutID, chan<- struct{}, context.Context) 
 }
 
-type BashCommands struct{}
+type BashCommands struct {
+	// output of a stream kept by default, DEFAULT_MAX_OUTPUT_BYTES if 0
+	MaxOutputBytes int64
+	// whole output of a truncated stream stored in chunks,
+	// DEFAULT_MAX_STORED_OUTPUT_BYTES if 0
+	MaxStoredOutputBytes int64
+	// stores the artifacts of commands, commands can't declare artifacts
+	// without it
+	Artifacts *artifacts.Store
+	// the named workspaces commands can run in
+	Workspaces *workspaces.Manager
+	// the running requests, they can't be cancelled or streamed by id
+	// without it
+	Runs *Runs
+}
+
+// subprocess is how a script runs besides the script itself.
+type subprocess struct {
+	// variables added to the environment of the server
+	env []string
+	maxOutputBytes int64
+	maxStoredOutputBytes int64
+	// working directory, the one of the server if empty
+	dir string
+	// the run the output is published to with the index of the command,
+	// nil if it isn't streamed
+	run *Run
+	index int
+}
+
+func (sh BashCommands) maxOutputBytes() int64 {
+	if sh.MaxOutputBytes > 0 {
+		return sh.MaxOutputBytes
+	}
+	return DEFAULT_MAX_OUTPUT_BYTES
+}
+
+func (sh BashCommands) maxStoredOutputBytes() int64 {
+	if sh.MaxStoredOutputBytes > 0 {
+		return sh.MaxStoredOutputBytes
+	}
+	return DEFAULT_MAX_STORED_OUTPUT_BYTES
+}
 
 type ReqCreateNewCommandBody struct {
 	BashStrings []string `json:"bash_strings"`
+	// Commands are bash strings with options, they run together with
+	// BashStrings and come after them in the result
+	Commands []CommandOptions `json:"commands,omitempty"`
+	// Templates are expanded into a command per combination of their
+	// parameters, they come after Commands in the result
+	Templates []CommandTemplate `json:"templates,omitempty"`
+	// Uploads are copied into the working directory of every command, the
+	// commands get temporary ones
+	Uploads *Uploads `json:"-"`
+	// the api key that submitted the commands, only it can cancel and
+	// stream them unless it sees all commands
+	ApiKeyId *uint `json:"-"`
+	// the key whose workspaces the commands may use, nil for all of them,
+	// see auth.CommandsOwner
+	WorkspaceOwner *uint `json:"-"`
+	// Output gets the events of the run while the commands run, the output
+	// of commands with secrets can't be streamed
+	Output func(models.RunEvents) `json:"-"`
+}
+
+type CommandOptions struct {
+	BashString string `json:"bash_string"`
+	Assertions *models.Assertions `json:"assertions,omitempty"`
+	// Env is exported to the command, it is stored only with the options
+	// replayed by reruns
+	Env map[string]string `json:"env,omitempty"`
+	// Secrets maps env variables to the names of the secrets whose values
+	// they get, the values are masked in the output
+	Secrets map[string]string `json:"secrets,omitempty"`
+	// SecretEnv holds the values of Secrets once they are read
+	SecretEnv map[string]string `json:"-"`
+	// ProcessEnv is exported to the process of the command like SecretEnv,
+	// so large values don't go through the arguments of the process. It
+	// isn't masked or stored, such as the results of workflow steps
+	ProcessEnv map[string]string `json:"-"`
+	Retry *models.RetryPolicy `json:"retry,omitempty"`
+	// the command is interrupted after the timeout, it covers all attempts
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+	// bytes of each stream that are kept, the default of the server if 0
+	MaxOutputBytes int64 `json:"max_output_bytes,omitempty"`
+	// the command runs in a new temporary directory that is removed after it
+	TempDir bool `json:"temp_dir,omitempty"`
+	// globs of the files of the working directory that are collected after
+	// the command exits, declaring them implies TempDir without a workspace
+	Artifacts []string `json:"artifacts,omitempty"`
+	// the command runs in the named workspace, its files are kept for the
+	// next commands
+	Workspace string `json:"workspace,omitempty"`
+	// the template the command was expanded from and its parameters
+	Template string `json:"-"`
+	Parameters map[string]string `json:"-"`
+}
+
+// CommandOptions returns every command of the request, bash strings first and
+// expanded templates last.
+func (inputStruct *ReqCreateNewCommandBody) CommandOptions() ([]CommandOptions, error) {
+	options := make([]CommandOptions, 0, len(inputStruct.BashStrings)+len(inputStruct.Commands))
+	for _, bashString := range inputStruct.BashStrings {
+		options = append(options, CommandOptions{BashString: bashString})
+	}
+	options = append(options, inputStruct.Commands...)
+	for i := range inputStruct.Templates {
+		expanded, err := inputStruct.Templates[i].Expand()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, expanded...)
+	}
+	return options, nil
+}
+
+// ValidateOptions checks the retry policy and the assertions of a command,
+// the options a client can get wrong without breaking the request format.
+func ValidateOptions(option CommandOptions) error {
+	if option.Retry != nil {
+		if err := ValidateRetryPolicy(option.Retry); err != nil {
+			return err
+		}
+	}
+	if option.Assertions != nil {
+		if err := ValidateAssertions(option.Assertions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SecretNames returns the names of the secrets the commands refer to.
+func (inputStruct *ReqCreateNewCommandBody) SecretNames() []string {
+	names := []string{}
+	for _, option := range inputStruct.Commands {
+		for _, name := range option.Secrets {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// SecretReader reads the values of secrets by their names, it is implemented
+// by the database.
+type SecretReader interface {
+	GettingSecretValuesQuery(names []string, ctx context.Context) (map[string]string, error)
+}
+
+// ReadSecrets reads the secrets the commands refer to and sets their env
+// variables.
+func (inputStruct *ReqCreateNewCommandBody) ReadSecrets(reader SecretReader, ctx context.Context) error {
+	names := inputStruct.SecretNames()
+	if len(names) == 0 {
+		return nil
+	}
+	values, err := reader.GettingSecretValuesQuery(names, ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read secrets: %w", err)
+	}
+	return inputStruct.SetSecretValues(values)
+}
+
+// SetSecretValues sets the env variables of the secrets of the commands from
+// the values of the secrets by their names.
+func (inputStruct *ReqCreateNewCommandBody) SetSecretValues(values map[string]string) error {
+	for i, option := range inputStruct.Commands {
+		if len(option.Secrets) == 0 {
+			continue
+		}
+		inputStruct.Commands[i].SecretEnv = make(map[string]string, len(option.Secrets))
+		for variable, name := range option.Secrets {
+			value, ok := values[name]
+			if !ok {
+				return fmt.Errorf("secret %q isn't found", name)
+			}
+			inputStruct.Commands[i].SecretEnv[variable] = value
+		}
+	}
+	return nil
+}
+
+// processEnv returns the variables of the secrets and ProcessEnv of the
+// command for the environment of its process. Unlike Env they aren't part of
+// the script, so they don't show up in the arguments of the process.
+func processEnv(option CommandOptions) ([]string, error) {
+	if len(option.Secrets) != len(option.SecretEnv) {
+		return nil, fmt.Errorf("secrets of command %q aren't read", option.BashString)
+	}
+	env := make([]string, 0, len(option.SecretEnv)+len(option.ProcessEnv))
+	for variable, value := range option.SecretEnv {
+		if !envNameRegexp.MatchString(variable) {
+			return nil, fmt.Errorf("invalid env variable name %q", variable)
+		}
+		if _, ok := option.Env[variable]; ok {
+			return nil, fmt.Errorf("env variable %q is both in env and in secrets", variable)
+		}
+		env = append(env, variable+"="+value)
+	}
+	for variable, value := range option.ProcessEnv {
+		if !envNameRegexp.MatchString(variable) {
+			return nil, fmt.Errorf("invalid env variable name %q", variable)
+		}
+		_, inEnv := option.Env[variable]
+		_, inSecrets := option.SecretEnv[variable]
+		if inEnv || inSecrets {
+			return nil, fmt.Errorf("env variable %q is set twice", variable)
+		}
+		env = append(env, variable+"="+value)
+	}
+	return env, nil
 }
 
-func (sh BashCommands) ExecCommands(inputStruct *ReqCreateNewCommandBody) (*[]models.CommandsWithoutID, error) {
+// ExecCommands runs every command in parallel and returns the results in the
+// order of the request. Cancelling ctx or the run interrupts the subprocesses
+// that are still running.
+func (sh BashCommands) ExecCommands(inputStruct *ReqCreateNewCommandBody, ctx context.Context) (*[]models.CommandsWithoutID, error) {
 	if inputStruct == nil {
 		return nil, fmt.Errorf("func parameter error: the function parameter is nil")
 	}
 
+	options, err := inputStruct.CommandOptions()
+	if err != nil {
+		return nil, err
+	}
+	scripts := make([]string, len(options))
+	subprocesses := make([]subprocess, len(options))
+	secretValues := []string{}
+	for i, option := range options {
+		script, err := ScriptWithEnv(option.BashString, option.Env)
+		if err != nil {
+			return nil, err
+		}
+		scripts[i] = script
+		if option.MaxOutputBytes < 0 || option.MaxOutputBytes > MAX_OUTPUT_BYTES_LIMIT {
+			return nil, fmt.Errorf("max_output_bytes must be between 0 and %v", MAX_OUTPUT_BYTES_LIMIT)
+		}
+		subprocesses[i].maxOutputBytes, subprocesses[i].maxStoredOutputBytes = sh.maxOutputBytes(), sh.maxStoredOutputBytes()
+		if option.MaxOutputBytes > 0 {
+			subprocesses[i].maxOutputBytes = option.MaxOutputBytes
+		}
+		if subprocesses[i].env, err = processEnv(option); err != nil {
+			return nil, err
+		}
+		for _, value := range option.SecretEnv {
+			secretValues = append(secretValues, value)
+		}
+		if err := ValidateOptions(option); err != nil {
+			return nil, err
+		}
+		if option.TimeoutMs < 0 {
+			return nil, fmt.Errorf("timeout_ms can't be negative")
+		}
+		if len(option.Artifacts) != 0 {
+			if sh.Artifacts == nil {
+				return nil, fmt.Errorf("artifact store isn't configured")
+			}
+			if err := artifacts.ValidatePatterns(option.Artifacts); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i, option := range options {
+		if option.Workspace != "" {
+			if sh.Workspaces == nil {
+				return nil, fmt.Errorf("workspaces aren't configured")
+			}
+			if option.TempDir || inputStruct.Uploads != nil {
+				return nil, fmt.Errorf("a command in workspace %q can't have a temporary directory", option.Workspace)
+			}
+			if subprocesses[i].dir, err = sh.Workspaces.Prepare(option.Workspace, inputStruct.ApiKeyId, inputStruct.WorkspaceOwner); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !option.TempDir && len(option.Artifacts) == 0 && inputStruct.Uploads == nil {
+			continue
+		}
+		if subprocesses[i].dir, err = os.MkdirTemp("", "command-"); err != nil {
+			return nil, fmt.Errorf("unable to create working directory: %w", err)
+		}
+		defer os.RemoveAll(subprocesses[i].dir)
+		if inputStruct.Uploads != nil {
+			if err := inputStruct.Uploads.copyTo(subprocesses[i].dir); err != nil {
+				return nil, fmt.Errorf("unable to copy uploads: %w", err)
+			}
+		}
+	}
+
+	// the output of commands with secrets is only masked once they exit
+	streamed := len(secretValues) == 0
+	if !streamed && inputStruct.Output != nil {
+		return nil, fmt.Errorf("output of commands with secrets can't be streamed")
+	}
+	bashStrings := make([]string, len(options))
+	for i, option := range options {
+		bashStrings[i] = option.BashString
+	}
+	run := newRun(bashStrings, inputStruct.ApiKeyId, streamed, inputStruct.Output)
+	if sh.Runs != nil {
+		ctx = sh.Runs.start(run, ctx)
+		defer sh.Runs.finish(run)
+	}
+	run.publish(models.RunEvents{Event: models.RUN_EVENT_STARTED, RunId: run.Id})
+	if streamed {
+		for i := range subprocesses {
+			subprocesses[i].run, subprocesses[i].index = run, i
+		}
+	}
+
 	var wg sync.WaitGroup
-	outputCommand := make(chan models.CommandsWithoutID, len(inputStruct.BashStrings))
-	errorChan := make(chan struct{})
-	for _, commandString := range inputStruct.BashStrings {
+	outputCommands := make([]chan models.CommandsWithoutID, len(options))
+	errorChans := make([]chan struct{}, len(options))
+	for i := range options {
+		outputCommands[i] = make(chan models.CommandsWithoutID, 1)
+		errorChans[i] = make(chan struct{}, 1)
+		commandCtx := ctx
+		if options[i].TimeoutMs > 0 {
+			var cancel context.CancelFunc
+			commandCtx, cancel = context.WithTimeout(ctx, time.Duration(options[i].TimeoutMs)*time.Millisecond)
+			defer cancel()
+		}
 		wg.Add(1)
-		go sh.RunSubprocess(&wg, &commandString, outputCommand, errorChan)
+		if options[i].Retry != nil {
+			go sh.runWithRetry(&wg, &scripts[i], subprocesses[i], options[i].Retry, outputCommands[i], errorChans[i], commandCtx)
+		} else {
+			go sh.runSubprocess(&wg, &scripts[i], subprocesses[i], outputCommands[i], errorChans[i], commandCtx)
+		}
 	}
 	wg.Wait()
-	close(outputCommand)
+
+	// secret values are masked before anything looks at the output
+	masker := secrets.NewMasker(secretValues)
 
 	isErrorOnChannel := false
-	sliceCommands := make([]models.CommandsWithoutID, 0)
-	for range 2 {
+	sliceCommands := make([]models.CommandsWithoutID, 0, len(options))
+	for i, option := range options {
 		select {
-		case <-errorChan:
+		case <-errorChans[i]:
 			isErrorOnChannel = true
-		default:
-			for elem := range outputCommand {
-				sliceCommands = append(sliceCommands, elem)
+		case elem := <-outputCommands[i]:
+			maskOutput(masker, &elem)
+			if len(option.Artifacts) != 0 {
+				if elem.Artifacts, err = sh.Artifacts.Collect(subprocesses[i].dir, option.Artifacts); err != nil {
+					log.Println(err)
+					isErrorOnChannel = true
+				}
+			}
+			elem.Command = option.BashString
+			elem.Template, elem.Parameters = option.Template, option.Parameters
+			// the values of the secrets aren't part of the options
+			if elem.Options, err = json.Marshal(option); err != nil {
+				log.Println(err)
+				isErrorOnChannel = true
+			}
+			if option.Assertions != nil {
+				elem.Assertions = EvaluateAssertions(option.Assertions, &elem)
+				passed := models.AssertionsPassed(elem.Assertions)
+				elem.AssertionsPassed = &passed
 			}
+			sliceCommands = append(sliceCommands, elem)
 		}
 	}
 	if isErrorOnChannel {
@@ -58,44 +411,133 @@ func (sh BashCommands) ExecCommands(inputStruct *ReqCreateNewCommandBody) (*[]mo
 	}
 }
 
-func (bash BashCommands) RunSubprocess(wg *sync.WaitGroup, input *string, output chan<- models.CommandsWithoutID, errorChan chan<- struct{}) {
-	grepCmd := exec.Command("sh", "-c", *input)
-	grepOut, err := grepCmd.StdoutPipe()
-	if err != nil {
-		log.Printf("stdout error: %v", err)
-		errorChan <- struct{}{}
-		wg.Done()
-		return
+// ScriptWithEnv prepends the export of every variable of env to the bash
+// string.
+func ScriptWithEnv(bashString string, env map[string]string) (string, error) {
+	if len(env) == 0 {
+		return bashString, nil
 	}
-	grepErr, err := grepCmd.StderrPipe()
-	if err != nil {
-		log.Printf("stderr error: %v", err)
-		errorChan <- struct{}{}
-		wg.Done()
-		return
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if !envNameRegexp.MatchString(name) {
+			return "", fmt.Errorf("invalid env variable name %q", name)
+		}
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	grepCmd.Start()
-	grepOutBytes, err := io.ReadAll(grepOut)
+	var script strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&script, "export %v=%v\n", name, ShellQuote(env[name]))
+	}
+	script.WriteString(bashString)
+	return script.String(), nil
+}
+
+// maskOutput replaces the secret values in the output of the command and of
+// its attempts. A stored stream that can't be masked is dropped.
+func maskOutput(masker *secrets.Masker, command *models.CommandsWithoutID) {
+	command.Log, command.Stdout, command.Stderr = masker.Mask(command.Log), masker.Mask(command.Stdout), masker.Mask(command.Stderr)
+	for i := range command.AttemptHistory {
+		command.AttemptHistory[i].Log = masker.Mask(command.AttemptHistory[i].Log)
+	}
+	if command.OutputFile != "" {
+		if err := maskOutputFile(masker, command.OutputFile); err != nil {
+			log.Printf("unable to mask output: %v\n", err)
+			os.Remove(command.OutputFile)
+			command.OutputFile = ""
+		}
+	}
+}
+
+// maskOutputFile masks the stream of a truncated log in place.
+func maskOutputFile(masker *secrets.Masker, path string) error {
+	src, err := os.Open(path)
 	if err != nil {
-		log.Printf("stdout read error: %v", err)
-		errorChan <- struct{}{}
-		wg.Done()
-		return
+		return err
 	}
-	grepErrBytes, err := io.ReadAll(grepErr)
+	defer src.Close()
+	dst, err := os.CreateTemp(filepath.Dir(path), "command-output-")
 	if err != nil {
-		log.Printf("stderr read error: %v", err)
+		return err
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+	if err := masker.MaskStream(dst, src); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(dst.Name(), path)
+}
+
+func (bash BashCommands) RunSubprocess(wg *sync.WaitGroup, input *string, output chan<- models.CommandsWithoutID, errorChan chan<- struct{}, ctx context.Context) {
+	bash.runSubprocess(wg, input, subprocess{maxOutputBytes: bash.maxOutputBytes(), maxStoredOutputBytes: bash.maxStoredOutputBytes()},
+		output, errorChan, ctx)
+}
+
+// runSubprocess runs the script as the subprocess says. Only the head and the
+// tail of the streams are kept in memory, so the memory a command takes is
+// bounded however much it writes. The whole stream of a truncated log is
+// left in the output file of the result.
+func (bash BashCommands) runSubprocess(wg *sync.WaitGroup, input *string, process subprocess, output chan<- models.CommandsWithoutID, errorChan chan<- struct{}, ctx context.Context) {
+	grepCmd := exec.CommandContext(ctx, "sh", "-c", *input)
+	grepCmd.Dir = process.dir
+	if len(process.env) != 0 {
+		grepCmd.Env = append(os.Environ(), process.env...)
+	}
+	// run in a separate process group and forward cancellation as SIGINT to
+	// the whole group, the same way Ctrl-C would in a terminal
+	grepCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	grepCmd.Cancel = func() error {
+		return syscall.Kill(-grepCmd.Process.Pid, syscall.SIGINT)
+	}
+	grepCmd.WaitDelay = interruptWaitDelay
+	// both streams are copied while the command runs, a command that fills
+	// one of them doesn't block
+	grepOut, grepErr := newCapture(process.maxOutputBytes, process.maxStoredOutputBytes), newCapture(process.maxOutputBytes, process.maxStoredOutputBytes)
+	grepCmd.Stdout, grepCmd.Stderr = grepOut, grepErr
+	if process.run != nil {
+		grepCmd.Stdout = io.MultiWriter(grepOut, streamWriter{process.run, process.index, "stdout"})
+		grepCmd.Stderr = io.MultiWriter(grepErr, streamWriter{process.run, process.index, "stderr"})
+	}
+
+	startedAt := time.Now()
+	if err := grepCmd.Start(); err != nil {
+		log.Printf("start error: %v", err)
+		grepOut.discardSpool()
+		grepErr.discardSpool()
 		errorChan <- struct{}{}
 		wg.Done()
 		return
 	}
 	grepCmd.Wait()
+	exitCode := grepCmd.ProcessState.ExitCode()
+	durationMs := time.Since(startedAt).Milliseconds()
+	// the usage of the shell includes the programs it waited for
+	cpuMs := (grepCmd.ProcessState.UserTime() + grepCmd.ProcessState.SystemTime()).Milliseconds()
 
-	if len(grepOutBytes) != 0 {
-		output <- models.CommandsWithoutID{Command: *input, IsError: false, Log: string(grepOutBytes)}
+	result := models.CommandsWithoutID{
+		Command: *input,
+		ExitCode: exitCode,
+		DurationMs: durationMs,
+		CpuMs: cpuMs,
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+		Stdout: grepOut.String(),
+		Stderr: grepErr.String(),
+	}
+	if grepOut.total != 0 {
+		result.IsError, result.Log = false, result.Stdout
+		result.Truncated, result.OutputBytes = grepOut.truncated(), grepOut.total
+		result.OutputFile = grepOut.spoolFile()
+		grepErr.discardSpool()
 	} else {
-		output <- models.CommandsWithoutID{Command: *input, IsError: true, Log: string(grepErrBytes)}
+		result.IsError, result.Log = true, result.Stderr
+		result.Truncated, result.OutputBytes = grepErr.truncated(), grepErr.total
+		result.OutputFile = grepErr.spoolFile()
 	}
+	result.ContentType = models.DetectContentType(result.Log)
+	output <- result
 	wg.Done()
 }
\ No newline at end of file
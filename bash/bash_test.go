@@ -1,11 +1,18 @@
 package bash
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/Vy4cheSlave/test-task-postgres/artifacts"
 	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/workspaces"
 	// mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
 )
 
@@ -28,7 +35,7 @@ func TestRunSubprocess(t *testing.T) {
         testname := fmt.Sprintf("%v", tt.inputString)
         t.Run(testname, func(t *testing.T) {
 			wg.Add(1)
-            bash.RunSubprocess(&wg, &tt.inputString, outputCommand, errorChan)
+            bash.RunSubprocess(&wg, &tt.inputString, outputCommand, errorChan, context.Background())
 			select {
 			case <- errorChan:
 				t.Errorf("Subprocess execution error")
@@ -68,7 +75,7 @@ func TestExecCommands(t *testing.T) {
 	for _, tt := range tests {
         testname := fmt.Sprintf("%v", tt.testName)
         t.Run(testname, func(t *testing.T) {
-            result, _ := bash.ExecCommands(tt.inputStruct)
+            result, _ := bash.ExecCommands(tt.inputStruct, context.Background())
 			if tt.inputStruct != nil {
 				if (*result)[0].Command != tt.wantResult.Command || (*result)[0].IsError != tt.wantResult.IsError || (*result)[0].Log != tt.wantResult.Log {
 					t.Errorf("Subprocess error: the behavior of the function does not meet expectations\ngot %v, want %v", result, tt.wantResult)
@@ -80,4 +87,236 @@ func TestExecCommands(t *testing.T) {
 			}
         })
     }
-}
\ No newline at end of file
+}
+
+func TestRunSubprocessExitCode(t *testing.T) {
+	var wg sync.WaitGroup
+	outputCommand := make(chan models.CommandsWithoutID, 1)
+	errorChan := make(chan struct{}, 1)
+
+	input := "echo failing >&2; exit 3"
+	wg.Add(1)
+	bash.RunSubprocess(&wg, &input, outputCommand, errorChan, context.Background())
+	select {
+	case <- errorChan:
+		t.Errorf("Subprocess execution error")
+	case resultValue := <- outputCommand:
+		if resultValue.ExitCode != 3 {
+			t.Errorf("Subprocess error: unexpected exit code\ngot %v, want %v", resultValue.ExitCode, 3)
+		}
+	}
+}
+
+func TestRunSubprocessInterrupt(t *testing.T) {
+	var wg sync.WaitGroup
+	outputCommand := make(chan models.CommandsWithoutID, 1)
+	errorChan := make(chan struct{}, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	input := "sleep 10"
+	start := time.Now()
+	wg.Add(1)
+	bash.RunSubprocess(&wg, &input, outputCommand, errorChan, ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Subprocess error: the subprocess was not interrupted, took %v", elapsed)
+	}
+	select {
+	case <- errorChan:
+		t.Errorf("Subprocess execution error")
+	case resultValue := <- outputCommand:
+		if resultValue.ExitCode == 0 {
+			t.Errorf("Subprocess error: interrupted subprocess reported exit code 0")
+		}
+	}
+}
+
+func TestExecCommandsEnv(t *testing.T) {
+	inputStruct := &ReqCreateNewCommandBody{
+		Commands: []CommandOptions{
+			{BashString: `echo "$GREETING"`, Env: map[string]string{"GREETING": "it's me"}},
+		},
+	}
+	result, err := bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	if (*result)[0].Log != "it's me\n" || (*result)[0].Command != `echo "$GREETING"` {
+		t.Errorf("Subprocess error: unexpected result\ngot %q, %q", (*result)[0].Command, (*result)[0].Log)
+	}
+	// the options are kept for reruns
+	options := CommandOptions{}
+	if err := json.Unmarshal((*result)[0].Options, &options); err != nil || options.Env["GREETING"] != "it's me" {
+		t.Errorf("Subprocess error: unexpected options %s", (*result)[0].Options)
+	}
+
+	inputStruct.Commands[0].Env = map[string]string{"NOT-A-NAME": ""}
+	if _, err := bash.ExecCommands(inputStruct, context.Background()); err == nil {
+		t.Errorf("Subprocess error: invalid env variable name was accepted")
+	}
+}
+
+func TestExecCommandsTimeout(t *testing.T) {
+	inputStruct := &ReqCreateNewCommandBody{
+		Commands: []CommandOptions{
+			{BashString: "sleep 5", TimeoutMs: 100},
+			{BashString: "echo fast", TimeoutMs: 5000},
+		},
+	}
+	result, err := bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	if !(*result)[0].TimedOut || (*result)[0].DurationMs >= 5000 {
+		t.Errorf("Subprocess error: the command wasn't timed out\ngot %+v", (*result)[0])
+	}
+	if (*result)[1].TimedOut || (*result)[1].Log != "fast\n" {
+		t.Errorf("Subprocess error: unexpected result\ngot %+v", (*result)[1])
+	}
+}
+
+func TestExecCommandsCpuTime(t *testing.T) {
+	inputStruct := &ReqCreateNewCommandBody{
+		BashStrings: []string{"i=0; while [ $i -lt 300000 ]; do i=$((i+1)); done; echo $i", "sleep 0.2; echo idle"},
+	}
+	result, err := bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	if (*result)[0].CpuMs <= 0 || (*result)[0].CpuMs > (*result)[0].DurationMs+10 {
+		t.Errorf("Subprocess error: unexpected cpu time of a busy command\ngot %+v", (*result)[0])
+	}
+	if (*result)[1].CpuMs >= (*result)[1].DurationMs {
+		t.Errorf("Subprocess error: unexpected cpu time of an idle command\ngot %+v", (*result)[1])
+	}
+}
+
+func TestExecCommandsSecrets(t *testing.T) {
+	inputStruct := &ReqCreateNewCommandBody{
+		Commands: []CommandOptions{
+			{
+				BashString: `echo "token $TOKEN"; printf %s "$TOKEN" | base64; echo "$TOKEN" >&2`,
+				Secrets:    map[string]string{"TOKEN": "api-token"},
+			},
+			{BashString: `echo "token ${TOKEN:-unset}"`},
+		},
+	}
+	if names := inputStruct.SecretNames(); len(names) != 1 || names[0] != "api-token" {
+		t.Fatalf("unexpected secret names %v", names)
+	}
+	if err := inputStruct.SetSecretValues(map[string]string{}); err == nil {
+		t.Fatalf("a missing secret isn't reported")
+	}
+	if err := inputStruct.SetSecretValues(map[string]string{"api-token": "s3cr3t value"}); err != nil {
+		t.Fatalf("SetSecretValues error: %v", err)
+	}
+
+	result, err := bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	if (*result)[0].Stdout != "token ***\n***\n" || (*result)[0].Stderr != "***\n" {
+		t.Errorf("Subprocess error: the secret isn't masked\ngot %q %q", (*result)[0].Stdout, (*result)[0].Stderr)
+	}
+	if (*result)[1].Stdout != "token unset\n" {
+		t.Errorf("Subprocess error: the secret is exported to another command\ngot %q", (*result)[1].Stdout)
+	}
+	if (*result)[0].Command != inputStruct.Commands[0].BashString {
+		t.Errorf("Subprocess error: the stored command has the secret\ngot %q", (*result)[0].Command)
+	}
+	if options := string((*result)[0].Options); strings.Contains(options, "s3cr3t") || !strings.Contains(options, "api-token") {
+		t.Errorf("Subprocess error: the options aren't stored without the secret values\ngot %s", options)
+	}
+
+	// the variable of a secret can't be set by env as well
+	inputStruct.Commands[0].Env = map[string]string{"TOKEN": "plain"}
+	if _, err := bash.ExecCommands(inputStruct, context.Background()); err == nil {
+		t.Errorf("a variable in env and in secrets isn't reported")
+	}
+}
+
+func TestExecCommandsBinaryOutput(t *testing.T) {
+	inputStruct := &ReqCreateNewCommandBody{
+		BashStrings: []string{`printf 'a\000b\377'`, `printf '\211PNG\r\n\032\n'`, "echo text"},
+	}
+	result, err := bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	if (*result)[0].Log != "a\x00b\xff" || (*result)[0].ContentType != "application/octet-stream" {
+		t.Errorf("Subprocess error: unexpected binary output %q %q", (*result)[0].Log, (*result)[0].ContentType)
+	}
+	if (*result)[1].ContentType != "image/png" {
+		t.Errorf("Subprocess error: unexpected content type %q", (*result)[1].ContentType)
+	}
+	if (*result)[2].ContentType != models.TEXT_CONTENT_TYPE {
+		t.Errorf("Subprocess error: unexpected content type of text %q", (*result)[2].ContentType)
+	}
+}
+
+func TestExecCommandsArtifacts(t *testing.T) {
+	sh := BashCommands{Artifacts: &artifacts.Store{Dir: t.TempDir(), MaxFileBytes: 1024, MaxCommandBytes: 1024}}
+	inputStruct := &ReqCreateNewCommandBody{
+		Commands: []CommandOptions{
+			{BashString: "mkdir out && echo report > out/report.txt && echo tmp > tmp.txt && pwd", Artifacts: []string{"out/*.txt"}},
+			{BashString: "pwd", TempDir: true},
+		},
+	}
+	result, err := sh.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	collected := (*result)[0].Artifacts
+	if len(collected) != 1 || collected[0].Path != "out/report.txt" || collected[0].SizeBytes != 7 || collected[0].Sha256 == "" {
+		t.Errorf("Subprocess error: unexpected artifacts %+v", collected)
+	}
+	// the temporary directories are removed after the commands
+	for _, command := range *result {
+		dir := strings.TrimSpace(command.Log)
+		if wd, _ := os.Getwd(); dir == wd {
+			t.Errorf("Subprocess error: the command ran in the working directory of the server")
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Subprocess error: the directory %v isn't removed", dir)
+		}
+	}
+
+	// artifacts need a store and patterns inside the working directory
+	outside := &ReqCreateNewCommandBody{Commands: []CommandOptions{{BashString: "true", Artifacts: []string{"../*"}}}}
+	if _, err := sh.ExecCommands(outside, context.Background()); err == nil {
+		t.Errorf("Subprocess error: expected an error for a pattern outside the working directory")
+	}
+	if _, err := bash.ExecCommands(inputStruct, context.Background()); err == nil {
+		t.Errorf("Subprocess error: expected an error without an artifact store")
+	}
+}
+
+func TestExecCommandsWorkspace(t *testing.T) {
+	sh := BashCommands{Workspaces: &workspaces.Manager{Dir: t.TempDir(), MaxBytes: 1024}}
+	first := &ReqCreateNewCommandBody{Commands: []CommandOptions{{BashString: "echo 1.2.3 > version", Workspace: "release"}}}
+	if _, err := sh.ExecCommands(first, context.Background()); err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	// the files are kept for the next commands
+	second := &ReqCreateNewCommandBody{Commands: []CommandOptions{{BashString: "cat version", Workspace: "release"}}}
+	result, err := sh.ExecCommands(second, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	if (*result)[0].Log != "1.2.3\n" {
+		t.Errorf("Subprocess error: unexpected output %q", (*result)[0].Log)
+	}
+
+	for _, option := range []CommandOptions{
+		{BashString: "true", Workspace: "../release"},
+		{BashString: "true", Workspace: "release", TempDir: true},
+	} {
+		if _, err := sh.ExecCommands(&ReqCreateNewCommandBody{Commands: []CommandOptions{option}}, context.Background()); err == nil {
+			t.Errorf("Subprocess error: expected an error for %+v", option)
+		}
+	}
+	if _, err := bash.ExecCommands(second, context.Background()); err == nil {
+		t.Errorf("Subprocess error: expected an error without workspaces")
+	}
+}
@@ -0,0 +1,178 @@
+package bash
+
+import (
+	// std
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var assignmentRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// restrictedEnvRegexp matches the variables that change which programs run
+// or what bash runs besides the bash string: the search path, the dynamic
+// loader, the startup files, word splitting and the shell options.
+var restrictedEnvRegexp = regexp.MustCompile(`^(PATH|LD_[A-Za-z0-9_]*|BASH_ENV|ENV|IFS|SHELLOPTS|BASHOPTS|PS4)$`)
+
+// IsRestrictedEnvName reports whether setting the variable may run programs
+// that CommandBinaries doesn't return.
+func IsRestrictedEnvName(name string) bool {
+	return restrictedEnvRegexp.MatchString(name)
+}
+
+// reserved words that may start a command and are followed by another
+// command
+var commandPrefixWords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "do": true, "while": true, "until": true,
+	"!": true, "{": true, "time": true,
+}
+
+// reserved words that end a compound command
+var compoundEndWords = map[string]bool{"fi": true, "done": true, "}": true}
+
+// compound commands whose words aren't all commands
+var unsupportedWords = map[string]bool{"for": true, "case": true, "select": true, "function": true, "coproc": true}
+
+// binariesLexer splits a bash string into the first words of its simple
+// commands.
+type binariesLexer struct {
+	binaries []string
+	word     strings.Builder
+	// the word has an unquoted $
+	isDynamic      bool
+	inWord         bool
+	atCommandStart bool
+	// the word is the target of a redirection
+	isRedirectTarget bool
+}
+
+// CommandBinaries returns the programs a bash string runs, the first word of
+// every simple command, in order and without repeats. Bash strings that may
+// run programs not visible in the text, such as command substitutions or a
+// program name taken from a variable, are rejected.
+func CommandBinaries(bashString string) ([]string, error) {
+	lexer := &binariesLexer{atCommandStart: true}
+	runes := []rune(bashString)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				if runes[i] != '\n' {
+					lexer.add(runes[i])
+				}
+			}
+		case r == '\'':
+			end := slices.Index(runes[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			lexer.inWord = true
+			lexer.word.WriteString(string(runes[i+1 : i+1+end]))
+			i += end + 1
+		case r == '"':
+			end, err := doubleQuoteEnd(runes, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if slices.Contains(runes[i+1:end], '$') {
+				lexer.isDynamic = true
+			}
+			lexer.inWord = true
+			lexer.word.WriteString(string(runes[i+1 : end]))
+			i = end
+		case r == '`':
+			return nil, fmt.Errorf("command substitution isn't supported")
+		case r == '$':
+			if i+1 < len(runes) && runes[i+1] == '(' {
+				return nil, fmt.Errorf("command substitution isn't supported")
+			}
+			lexer.isDynamic = true
+			lexer.add(r)
+		case (r == '<' || r == '>') && i+1 < len(runes) && runes[i+1] == '(':
+			return nil, fmt.Errorf("process substitution isn't supported")
+		case r == '<' || r == '>':
+			if err := lexer.endWord(); err != nil {
+				return nil, err
+			}
+			for i+1 < len(runes) && strings.ContainsRune("<>&|", runes[i+1]) {
+				i++
+			}
+			lexer.isRedirectTarget = true
+		case r == '#' && !lexer.inWord:
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		case r == ' ' || r == '\t':
+			if err := lexer.endWord(); err != nil {
+				return nil, err
+			}
+		case strings.ContainsRune(";&|\n()", r):
+			if err := lexer.endWord(); err != nil {
+				return nil, err
+			}
+			lexer.atCommandStart = true
+		default:
+			lexer.add(r)
+		}
+	}
+	if err := lexer.endWord(); err != nil {
+		return nil, err
+	}
+	return lexer.binaries, nil
+}
+
+func (lexer *binariesLexer) add(r rune) {
+	lexer.inWord = true
+	lexer.word.WriteRune(r)
+}
+
+func (lexer *binariesLexer) endWord() error {
+	if !lexer.inWord {
+		return nil
+	}
+	word, isDynamic := lexer.word.String(), lexer.isDynamic
+	lexer.word.Reset()
+	lexer.inWord, lexer.isDynamic = false, false
+
+	switch {
+	case lexer.isRedirectTarget:
+		lexer.isRedirectTarget = false
+	case !lexer.atCommandStart:
+	case assignmentRegexp.MatchString(word):
+		if name, _, _ := strings.Cut(word, "="); IsRestrictedEnvName(name) {
+			return fmt.Errorf("setting %v isn't supported", name)
+		}
+	case commandPrefixWords[word]:
+	case compoundEndWords[word]:
+		lexer.atCommandStart = false
+	case unsupportedWords[word]:
+		return fmt.Errorf("%q isn't supported", word)
+	case isDynamic:
+		return fmt.Errorf("the program name %q isn't known before running", word)
+	default:
+		if !slices.Contains(lexer.binaries, word) {
+			lexer.binaries = append(lexer.binaries, word)
+		}
+		lexer.atCommandStart = false
+	}
+	return nil
+}
+
+// doubleQuoteEnd returns the index of the quote that closes a double quoted
+// string starting at start.
+func doubleQuoteEnd(runes []rune, start int) (int, error) {
+	for i := start; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\':
+			i++
+		case runes[i] == '`' || (runes[i] == '$' && i+1 < len(runes) && runes[i+1] == '('):
+			return 0, fmt.Errorf("command substitution isn't supported")
+		case runes[i] == '"':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated double quote")
+}
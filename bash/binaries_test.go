@@ -0,0 +1,46 @@
+package bash
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandBinaries(t *testing.T) {
+	var tests = []struct {
+		bashString string
+		want       []string
+		wantErr    bool
+	}{
+		{"ls -la", []string{"ls"}, false},
+		{"ls | grep go; echo done && cat file || true", []string{"ls", "grep", "echo", "cat", "true"}, false},
+		{"NAME=value LANG=C sort file", []string{"sort"}, false},
+		{"ls 2>/dev/null >out.txt < in.txt", []string{"ls"}, false},
+		{"ls &>/dev/null & echo started", []string{"ls", "echo"}, false},
+		{"echo 'rm -rf /' \"$HOME\" # rm -rf /", []string{"echo"}, false},
+		{"if test -f go.mod; then cat go.mod; else echo none; fi", []string{"test", "cat", "echo"}, false},
+		{"while read line; do echo $line; done < file", []string{"read", "echo"}, false},
+		{"(cd /tmp && ls) | wc -l", []string{"cd", "ls", "wc"}, false},
+		{"'/bin/ls' /", []string{"/bin/ls"}, false},
+		{"echo $(rm -rf /)", nil, true},
+		{"echo \"`rm -rf /`\"", nil, true},
+		{"diff <(ls a) <(ls b)", nil, true},
+		{"$CMD arg", nil, true},
+		{"for f in *; do rm $f; done", nil, true},
+		{"echo 'unterminated", nil, true},
+		{"LD_PRELOAD=./evil.so ls", nil, true},
+		{"PATH=. ls", nil, true},
+		{"ls; BASH_ENV=./evil bash", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bashString, func(t *testing.T) {
+			got, err := CommandBinaries(tt.bashString)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CommandBinaries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("CommandBinaries() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
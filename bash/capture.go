@@ -0,0 +1,176 @@
+package bash
+
+import (
+	// std
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+const (
+	// output of a stream kept by default, the rest is dropped from the middle
+	DEFAULT_MAX_OUTPUT_BYTES int64 = 1 << 20
+	// the most a command may ask to keep
+	MAX_OUTPUT_BYTES_LIMIT int64 = 64 << 20
+	// environment variable with the default of the server
+	MAX_OUTPUT_BYTES_ENV string = "OUTPUT_MAX_BYTES"
+)
+
+const (
+	// whole output of a truncated stream stored by default, the log keeps
+	// only its head and tail
+	DEFAULT_MAX_STORED_OUTPUT_BYTES int64 = 1 << 30
+	// environment variable with the limit of the server
+	MAX_STORED_OUTPUT_BYTES_ENV string = "OUTPUT_MAX_STORED_BYTES"
+)
+
+// MaxOutputBytesFromEnv returns the limit of OUTPUT_MAX_BYTES, 0 if it isn't
+// set.
+func MaxOutputBytesFromEnv() (int64, error) {
+	value := os.Getenv(MAX_OUTPUT_BYTES_ENV)
+	if value == "" {
+		return 0, nil
+	}
+	maxOutputBytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || maxOutputBytes <= 0 || maxOutputBytes > MAX_OUTPUT_BYTES_LIMIT {
+		return 0, fmt.Errorf("invalid %v %q, it must be between 1 and %v", MAX_OUTPUT_BYTES_ENV, value, MAX_OUTPUT_BYTES_LIMIT)
+	}
+	return maxOutputBytes, nil
+}
+
+// MaxStoredOutputBytesFromEnv returns the limit of OUTPUT_MAX_STORED_BYTES, 0
+// if it isn't set.
+func MaxStoredOutputBytesFromEnv() (int64, error) {
+	value := os.Getenv(MAX_STORED_OUTPUT_BYTES_ENV)
+	if value == "" {
+		return 0, nil
+	}
+	maxStoredOutputBytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || maxStoredOutputBytes <= 0 {
+		return 0, fmt.Errorf("invalid %v %q, it must be a positive number of bytes", MAX_STORED_OUTPUT_BYTES_ENV, value)
+	}
+	return maxStoredOutputBytes, nil
+}
+
+// capture keeps the head and the tail of a stream written to it, up to limit
+// bytes together, and counts all of its bytes. The memory it takes doesn't
+// depend on how much is written. Once the stream outgrows the limit, the
+// whole stream is spooled to a temporary file up to spoolLimit bytes, so it
+// can be stored in chunks.
+type capture struct {
+	headLimit int
+	tailLimit int
+	head      []byte
+	// tail holds at least the last tailLimit bytes after the head, it is
+	// trimmed when it gets twice as long
+	tail  []byte
+	total int64
+
+	// no spool if 0
+	spoolLimit int64
+	spool      *os.File
+	spooled    int64
+	// a stream that can't be spooled keeps only its head and tail
+	spoolFailed bool
+}
+
+func newCapture(limit int64, spoolLimit int64) *capture {
+	headLimit := int(limit / 2)
+	return &capture{headLimit: headLimit, tailLimit: int(limit) - headLimit, spoolLimit: spoolLimit}
+}
+
+func (c *capture) Write(buf []byte) (int, error) {
+	n := len(buf)
+	if c.spool == nil && !c.spoolFailed && c.spoolLimit > 0 && c.total+int64(n) > int64(c.headLimit+c.tailLimit) {
+		// the stream isn't truncated yet, the head and the tail are all of it
+		c.startSpool()
+	}
+	if c.spool != nil {
+		c.writeSpool(buf)
+	}
+	c.total += int64(n)
+	if room := c.headLimit - len(c.head); room > 0 {
+		if room > len(buf) {
+			room = len(buf)
+		}
+		c.head = append(c.head, buf[:room]...)
+		buf = buf[room:]
+	}
+	if len(buf) >= c.tailLimit {
+		c.tail = append(c.tail[:0], buf[len(buf)-c.tailLimit:]...)
+		return n, nil
+	}
+	c.tail = append(c.tail, buf...)
+	if len(c.tail) > 2*c.tailLimit {
+		c.tail = append(c.tail[:0], c.tail[len(c.tail)-c.tailLimit:]...)
+	}
+	return n, nil
+}
+
+func (c *capture) truncated() bool {
+	return c.total > int64(c.headLimit+c.tailLimit)
+}
+
+// String returns the stream, a truncated stream is its head and tail with a
+// line about the dropped bytes between them.
+func (c *capture) String() string {
+	if !c.truncated() {
+		return string(c.head) + string(c.tail)
+	}
+	tail := c.tail[len(c.tail)-c.tailLimit:]
+	dropped := c.total - int64(len(c.head)+len(tail))
+	return string(c.head) + fmt.Sprintf("\n[... %v bytes truncated ...]\n", dropped) + string(tail)
+}
+
+func (c *capture) startSpool() {
+	spool, err := os.CreateTemp("", "command-output-")
+	if err != nil {
+		log.Printf("unable to spool output: %v\n", err)
+		c.spoolFailed = true
+		return
+	}
+	c.spool = spool
+	c.writeSpool(c.head)
+	c.writeSpool(c.tail)
+}
+
+func (c *capture) writeSpool(buf []byte) {
+	if room := c.spoolLimit - c.spooled; int64(len(buf)) > room {
+		buf = buf[:room]
+	}
+	if len(buf) == 0 {
+		return
+	}
+	n, err := c.spool.Write(buf)
+	c.spooled += int64(n)
+	if err != nil {
+		log.Printf("unable to spool output: %v\n", err)
+		c.discardSpool()
+		c.spoolFailed = true
+	}
+}
+
+// spoolFile closes the spool and returns its path, empty if the stream isn't
+// spooled.
+func (c *capture) spoolFile() string {
+	if c.spool == nil {
+		return ""
+	}
+	if err := c.spool.Close(); err != nil {
+		log.Printf("unable to spool output: %v\n", err)
+		os.Remove(c.spool.Name())
+		c.spool, c.spoolFailed = nil, true
+		return ""
+	}
+	return c.spool.Name()
+}
+
+// discardSpool removes the spool.
+func (c *capture) discardSpool() {
+	if c.spool != nil {
+		c.spool.Close()
+		os.Remove(c.spool.Name())
+		c.spool = nil
+	}
+}
@@ -0,0 +1,140 @@
+package bash
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	var tests = []struct {
+		testName      string
+		limit         int64
+		writes        []string
+		want          string
+		wantTruncated bool
+	}{
+		{"under the limit", 10, []string{"abc", "def"}, "abcdef", false},
+		{"at the limit", 6, []string{"abc", "def"}, "abcdef", false},
+		{"small writes", 4, []string{"a", "b", "c", "d", "e", "f", "g"}, "ab\n[... 3 bytes truncated ...]\nfg", true},
+		{"one big write", 4, []string{"abcdefg"}, "ab\n[... 3 bytes truncated ...]\nfg", true},
+		{"tail is trimmed", 4, []string{"abc", "d", "e", "f", "g", "h", "i", "j", "k"}, "ab\n[... 7 bytes truncated ...]\njk", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			c := newCapture(tt.limit, 0)
+			total := 0
+			for _, write := range tt.writes {
+				total += len(write)
+				if n, err := c.Write([]byte(write)); n != len(write) || err != nil {
+					t.Fatalf("Write() = %v, %v", n, err)
+				}
+				if len(c.tail) > 2*c.tailLimit {
+					t.Fatalf("the tail isn't trimmed, %v bytes", len(c.tail))
+				}
+			}
+			if got := c.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if c.truncated() != tt.wantTruncated || c.total != int64(total) {
+				t.Errorf("truncated() = %v with %v bytes, want %v with %v bytes", c.truncated(), c.total, tt.wantTruncated, total)
+			}
+		})
+	}
+}
+
+func TestCaptureSpool(t *testing.T) {
+	var tests = []struct {
+		testName   string
+		spoolLimit int64
+		writes     []string
+		want       string
+	}{
+		{"not truncated", 100, []string{"abc"}, ""},
+		{"small writes", 100, []string{"a", "b", "c", "d", "e", "f", "g"}, "abcdefg"},
+		{"one big write", 100, []string{"ab", "cdefg", "hi"}, "abcdefghi"},
+		{"over the spool limit", 5, []string{"abc", "defg"}, "abcde"},
+		{"no spool", 0, []string{"abcdefg"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			c := newCapture(4, tt.spoolLimit)
+			for _, write := range tt.writes {
+				c.Write([]byte(write))
+			}
+			path := c.spoolFile()
+			if tt.want == "" {
+				if path != "" {
+					os.Remove(path)
+					t.Errorf("the stream is spooled")
+				}
+				return
+			}
+			defer os.Remove(path)
+			if spooled, err := os.ReadFile(path); err != nil || string(spooled) != tt.want {
+				t.Errorf("spooled %q, %v, want %q", spooled, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecCommandsStoredOutputMasked(t *testing.T) {
+	sh := BashCommands{MaxOutputBytes: 100}
+	inputStruct := &ReqCreateNewCommandBody{
+		Commands: []CommandOptions{{
+			BashString: "for i in $(seq 1 20000); do echo \"token $TOKEN\"; done",
+			Secrets:    map[string]string{"TOKEN": "token"},
+			SecretEnv:  map[string]string{"TOKEN": "s3cr3t-value"},
+		}},
+	}
+	result, err := sh.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	command := (*result)[0]
+	defer os.Remove(command.OutputFile)
+	whole, err := os.ReadFile(command.OutputFile)
+	if err != nil {
+		t.Fatalf("the stream isn't stored: %v", err)
+	}
+	if strings.Contains(string(whole), "s3cr3t-value") || strings.Count(string(whole), "token ***\n") != 20000 {
+		t.Errorf("the stored stream isn't masked, %v bytes", len(whole))
+	}
+}
+
+func TestExecCommandsMaxOutputBytes(t *testing.T) {
+	sh := BashCommands{MaxOutputBytes: 100}
+	inputStruct := &ReqCreateNewCommandBody{
+		BashStrings: []string{"head -c 3000000 /dev/zero | tr '\\0' x; echo end"},
+		Commands: []CommandOptions{
+			{BashString: "seq 1 100", MaxOutputBytes: 1000},
+		},
+	}
+	result, err := sh.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+
+	big := (*result)[0]
+	if !big.Truncated || big.OutputBytes != 3000004 || !strings.HasPrefix(big.Log, strings.Repeat("x", 50)) ||
+		!strings.HasSuffix(big.Log, "xend\n") || len(big.Log) > 200 {
+		t.Errorf("Subprocess error: unexpected truncated output, %v of %v bytes: %q", len(big.Log), big.OutputBytes, big.Log)
+	}
+	// the whole stream is left for the database
+	if whole, err := os.ReadFile(big.OutputFile); err != nil || len(whole) != 3000004 || !strings.HasSuffix(string(whole), "xxend\n") {
+		t.Errorf("Subprocess error: unexpected stored output, %v bytes: %v", len(whole), err)
+	}
+	os.Remove(big.OutputFile)
+	// the command raises the limit of the server
+	if small := (*result)[1]; small.Truncated || small.OutputBytes != int64(len(small.Log)) || len(small.Log) != 292 {
+		t.Errorf("Subprocess error: unexpected output, truncated %v, %v bytes", small.Truncated, small.OutputBytes)
+	}
+
+	inputStruct = &ReqCreateNewCommandBody{Commands: []CommandOptions{{BashString: "ls", MaxOutputBytes: MAX_OUTPUT_BYTES_LIMIT + 1}}}
+	if _, err := sh.ExecCommands(inputStruct, context.Background()); err == nil {
+		t.Errorf("a limit over MAX_OUTPUT_BYTES_LIMIT isn't reported")
+	}
+}
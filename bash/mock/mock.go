@@ -5,6 +5,7 @@
 package mock_bash
 
 import (
+	context "context"
 	reflect "reflect"
 	sync "sync"
 
@@ -37,28 +38,66 @@ func (m *MockBashCommandsWorker) EXPECT() *MockBashCommandsWorkerMockRecorder {
 }
 
 // ExecCommands mocks base method.
-func (m *MockBashCommandsWorker) ExecCommands(arg0 *bash.ReqCreateNewCommandBody) (*[]models.CommandsWithoutID, error) {
+func (m *MockBashCommandsWorker) ExecCommands(arg0 *bash.ReqCreateNewCommandBody, arg1 context.Context) (*[]models.CommandsWithoutID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecCommands", arg0)
+	ret := m.ctrl.Call(m, "ExecCommands", arg0, arg1)
 	ret0, _ := ret[0].(*[]models.CommandsWithoutID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ExecCommands indicates an expected call of ExecCommands.
-func (mr *MockBashCommandsWorkerMockRecorder) ExecCommands(arg0 interface{}) *gomock.Call {
+func (mr *MockBashCommandsWorkerMockRecorder) ExecCommands(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCommands", reflect.TypeOf((*MockBashCommandsWorker)(nil).ExecCommands), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecCommands", reflect.TypeOf((*MockBashCommandsWorker)(nil).ExecCommands), arg0, arg1)
 }
 
 // RunSubprocess mocks base method.
-func (m *MockBashCommandsWorker) RunSubprocess(arg0 *sync.WaitGroup, arg1 *string, arg2 chan<- models.CommandsWithoutID, arg3 chan<- struct{}) {
+func (m *MockBashCommandsWorker) RunSubprocess(arg0 *sync.WaitGroup, arg1 *string, arg2 chan<- models.CommandsWithoutID, arg3 chan<- struct{}, arg4 context.Context) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "RunSubprocess", arg0, arg1, arg2, arg3)
+	m.ctrl.Call(m, "RunSubprocess", arg0, arg1, arg2, arg3, arg4)
 }
 
 // RunSubprocess indicates an expected call of RunSubprocess.
-func (mr *MockBashCommandsWorkerMockRecorder) RunSubprocess(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockBashCommandsWorkerMockRecorder) RunSubprocess(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunSubprocess", reflect.TypeOf((*MockBashCommandsWorker)(nil).RunSubprocess), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunSubprocess", reflect.TypeOf((*MockBashCommandsWorker)(nil).RunSubprocess), arg0, arg1, arg2, arg3, arg4)
+}
+
+// MockSecretReader is a mock of SecretReader interface.
+type MockSecretReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecretReaderMockRecorder
+}
+
+// MockSecretReaderMockRecorder is the mock recorder for MockSecretReader.
+type MockSecretReaderMockRecorder struct {
+	mock *MockSecretReader
+}
+
+// NewMockSecretReader creates a new mock instance.
+func NewMockSecretReader(ctrl *gomock.Controller) *MockSecretReader {
+	mock := &MockSecretReader{ctrl: ctrl}
+	mock.recorder = &MockSecretReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecretReader) EXPECT() *MockSecretReaderMockRecorder {
+	return m.recorder
+}
+
+// GettingSecretValuesQuery mocks base method.
+func (m *MockSecretReader) GettingSecretValuesQuery(names []string, ctx context.Context) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingSecretValuesQuery", names, ctx)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingSecretValuesQuery indicates an expected call of GettingSecretValuesQuery.
+func (mr *MockSecretReaderMockRecorder) GettingSecretValuesQuery(names, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSecretValuesQuery", reflect.TypeOf((*MockSecretReader)(nil).GettingSecretValuesQuery), names, ctx)
 }
@@ -0,0 +1,149 @@
+package bash
+
+import (
+	// std
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	// MAX_RETRY_ATTEMPTS limits the attempts of a retry policy.
+	MAX_RETRY_ATTEMPTS int = 10
+	// MAX_RETRY_DELAY_MS limits the delays of a retry policy and the delay
+	// an exponential backoff grows to.
+	MAX_RETRY_DELAY_MS int64 = 60 * 60 * 1000
+)
+
+// ValidateRetryPolicy checks the options of a retry policy.
+func ValidateRetryPolicy(policy *models.RetryPolicy) error {
+	if policy.MaxAttempts < 1 || policy.MaxAttempts > MAX_RETRY_ATTEMPTS {
+		return fmt.Errorf("retry max_attempts must be between 1 and %v", MAX_RETRY_ATTEMPTS)
+	}
+	switch policy.Backoff {
+	case "", models.BACKOFF_FIXED, models.BACKOFF_EXPONENTIAL:
+	default:
+		return fmt.Errorf("unknown retry backoff %q", policy.Backoff)
+	}
+	if policy.DelayMs < 0 || policy.MaxDelayMs < 0 {
+		return fmt.Errorf("retry delays can't be negative")
+	}
+	if policy.DelayMs > MAX_RETRY_DELAY_MS || policy.MaxDelayMs > MAX_RETRY_DELAY_MS {
+		return fmt.Errorf("retry delays can't be over %v ms", MAX_RETRY_DELAY_MS)
+	}
+	if _, err := regexp.Compile(policy.OnStderrRegex); err != nil {
+		return fmt.Errorf("invalid retry on_stderr_regex: %v", err)
+	}
+	return nil
+}
+
+// isRetryable reports whether a failed attempt may be retried by the policy.
+func isRetryable(policy *models.RetryPolicy, result *models.CommandsWithoutID) bool {
+	if len(policy.OnExitCodes) == 0 && policy.OnStderrRegex == "" {
+		return true
+	}
+	if slices.Contains(policy.OnExitCodes, result.ExitCode) {
+		return true
+	}
+	// the policy was validated, the regex compiles
+	return policy.OnStderrRegex != "" && regexp.MustCompile(policy.OnStderrRegex).MatchString(result.Stderr)
+}
+
+// retryDelay returns how long to wait after the given attempt.
+func retryDelay(policy *models.RetryPolicy, attempt int) time.Duration {
+	delay := time.Duration(policy.DelayMs) * time.Millisecond
+	limit := time.Duration(MAX_RETRY_DELAY_MS) * time.Millisecond
+	if policy.Backoff == models.BACKOFF_EXPONENTIAL && attempt > 1 {
+		// the shift is clamped before it can overflow
+		if shift := attempt - 1; shift >= 63 || delay > limit>>shift {
+			delay = limit
+		} else {
+			delay <<= shift
+		}
+	}
+	if delay > limit {
+		delay = limit
+	}
+	if maxDelay := time.Duration(policy.MaxDelayMs) * time.Millisecond; maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	if policy.Jitter && delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+	return delay
+}
+
+// runWithRetry runs the script until it succeeds or the policy gives up. The
+// result is the last attempt with the history of all of them, its duration
+// covers all attempts and delays.
+func (sh BashCommands) runWithRetry(wg *sync.WaitGroup, input *string, process subprocess, policy *models.RetryPolicy, output chan<- models.CommandsWithoutID, errorChan chan<- struct{}, ctx context.Context) {
+	defer wg.Done()
+	startedAt := time.Now()
+	history := []models.CommandAttempts{}
+	var delay time.Duration
+	var cpuMs int64
+	for attempt := 1; ; attempt++ {
+		var attemptWg sync.WaitGroup
+		attemptOutput := make(chan models.CommandsWithoutID, 1)
+		attemptError := make(chan struct{}, 1)
+		attemptWg.Add(1)
+		sh.runSubprocess(&attemptWg, input, process, attemptOutput, attemptError, ctx)
+
+		var result models.CommandsWithoutID
+		select {
+		case <-attemptError:
+			errorChan <- struct{}{}
+			return
+		case result = <-attemptOutput:
+		}
+		cpuMs += result.CpuMs
+		history = append(history, models.CommandAttempts{
+			Attempt: attempt,
+			IsError: result.IsError,
+			ExitCode: result.ExitCode,
+			DurationMs: result.DurationMs,
+			DelayMs: delay.Milliseconds(),
+			Log: result.Log,
+		})
+
+		summary := ""
+		switch {
+		case result.ExitCode == 0:
+			summary = fmt.Sprintf("succeeded on attempt %v of %v", attempt, policy.MaxAttempts)
+		case attempt == policy.MaxAttempts:
+			summary = fmt.Sprintf("failed after %v attempts", attempt)
+		case !isRetryable(policy, &result):
+			summary = fmt.Sprintf("failed on attempt %v, exit code %v isn't retried", attempt, result.ExitCode)
+		case ctx.Err() != nil:
+			summary = fmt.Sprintf("interrupted after %v attempts", attempt)
+		default:
+			delay = retryDelay(policy, attempt)
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				summary = fmt.Sprintf("interrupted after %v attempts", attempt)
+			case <-timer.C:
+			}
+		}
+		if summary == "" {
+			// only the stream of the last attempt is stored
+			if result.OutputFile != "" {
+				os.Remove(result.OutputFile)
+			}
+			continue
+		}
+
+		result.DurationMs, result.CpuMs = time.Since(startedAt).Milliseconds(), cpuMs
+		result.Attempts, result.RetrySummary, result.AttemptHistory = attempt, summary, history
+		output <- result
+		return
+	}
+}
@@ -0,0 +1,121 @@
+package bash
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+func TestRetryDelay(t *testing.T) {
+	var tests = []struct {
+		testName string
+		policy models.RetryPolicy
+		attempt int
+		want time.Duration
+	}{
+		{"fixed", models.RetryPolicy{DelayMs: 100}, 3, 100 * time.Millisecond},
+		{"exponential", models.RetryPolicy{Backoff: models.BACKOFF_EXPONENTIAL, DelayMs: 100}, 3, 400 * time.Millisecond},
+		{"capped", models.RetryPolicy{Backoff: models.BACKOFF_EXPONENTIAL, DelayMs: 100, MaxDelayMs: 250}, 3, 250 * time.Millisecond},
+		{"clamped", models.RetryPolicy{Backoff: models.BACKOFF_EXPONENTIAL, DelayMs: MAX_RETRY_DELAY_MS}, 100, time.Duration(MAX_RETRY_DELAY_MS) * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			if got := retryDelay(&tt.policy, tt.attempt); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	jittered := models.RetryPolicy{DelayMs: 100, Jitter: true}
+	for range 20 {
+		if got := retryDelay(&jittered, 1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Errorf("jitter: got %v, want between 50ms and 100ms", got)
+		}
+	}
+}
+
+func TestExecCommandsRetry(t *testing.T) {
+	// the command fails until it has run three times
+	counter := filepath.Join(t.TempDir(), "counter")
+	flaky := fmt.Sprintf(`echo x >> %v; test $(wc -l < %v) -ge 3 && echo ok`, counter, counter)
+
+	var tests = []struct {
+		testName string
+		bashString string
+		retry models.RetryPolicy
+		wantAttempts int
+		wantExitCode int
+		wantSummary string
+	}{
+		{
+			"succeeds on third attempt",
+			flaky,
+			models.RetryPolicy{MaxAttempts: 5, DelayMs: 1},
+			3, 0, "succeeded on attempt 3 of 5",
+		},
+		{
+			"gives up",
+			"exit 1",
+			models.RetryPolicy{MaxAttempts: 2, Backoff: models.BACKOFF_EXPONENTIAL, DelayMs: 1},
+			2, 1, "failed after 2 attempts",
+		},
+		{
+			"exit code isn't retried",
+			"exit 2",
+			models.RetryPolicy{MaxAttempts: 5, OnExitCodes: []int{1}},
+			1, 2, "failed on attempt 1, exit code 2 isn't retried",
+		},
+		{
+			"stderr matches",
+			"echo 'connection refused' >&2; exit 7",
+			models.RetryPolicy{MaxAttempts: 2, OnExitCodes: []int{1}, OnStderrRegex: "refused"},
+			2, 7, "failed after 2 attempts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			os.Remove(counter)
+			inputStruct := &ReqCreateNewCommandBody{
+				Commands: []CommandOptions{{BashString: tt.bashString, Retry: &tt.retry}},
+			}
+			result, err := bash.ExecCommands(inputStruct, context.Background())
+			if err != nil {
+				t.Fatalf("Subprocess execution error: %v", err)
+			}
+			command := (*result)[0]
+			if command.Attempts != tt.wantAttempts || len(command.AttemptHistory) != tt.wantAttempts {
+				t.Errorf("attempts: got %v with history of %v, want %v", command.Attempts, len(command.AttemptHistory), tt.wantAttempts)
+			}
+			if command.ExitCode != tt.wantExitCode {
+				t.Errorf("exit code: got %v, want %v", command.ExitCode, tt.wantExitCode)
+			}
+			if command.RetrySummary != tt.wantSummary {
+				t.Errorf("summary: got %q, want %q", command.RetrySummary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestValidateRetryPolicy(t *testing.T) {
+	invalid := []models.RetryPolicy{
+		{MaxAttempts: 0},
+		{MaxAttempts: MAX_RETRY_ATTEMPTS + 1},
+		{MaxAttempts: 2, Backoff: "linear"},
+		{MaxAttempts: 2, DelayMs: -1},
+		{MaxAttempts: 2, DelayMs: MAX_RETRY_DELAY_MS + 1},
+		{MaxAttempts: 2, MaxDelayMs: MAX_RETRY_DELAY_MS + 1},
+		{MaxAttempts: 2, OnStderrRegex: "("},
+	}
+	for _, policy := range invalid {
+		if err := ValidateRetryPolicy(&policy); err == nil {
+			t.Errorf("policy %+v was accepted", policy)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package bash
+
+import (
+	// std
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	// output of a run kept for the streams attached to it while it runs
+	RUN_BACKLOG_BYTES int = 64 << 10
+	// events an attached stream may fall behind before it is closed
+	RUN_SUBSCRIBER_EVENTS int = 256
+)
+
+var ErrRunNotFound = errors.New("run isn't found")
+
+// Runs keeps the requests whose commands are running, so that they can be
+// listed, cancelled and streamed by their ids. The ids are given by the
+// server process and aren't the ids of the stored commands.
+type Runs struct {
+	mutex  sync.Mutex
+	lastId uint
+	runs   map[uint]*Run
+}
+
+func NewRuns() *Runs {
+	return &Runs{runs: map[uint]*Run{}}
+}
+
+// Run is a running request. Its events go to the output of the request and
+// to the streams attached to it.
+type Run struct {
+	models.Runs
+	cancel context.CancelFunc
+	output func(models.RunEvents)
+
+	mutex        sync.Mutex
+	backlog      []models.RunEvents
+	backlogBytes int
+	subscribers  map[chan models.RunEvents]struct{}
+	finished     bool
+}
+
+func newRun(commands []string, apiKeyId *uint, streamed bool, output func(models.RunEvents)) *Run {
+	return &Run{
+		Runs:        models.Runs{Commands: commands, ApiKeyId: apiKeyId, StartedAt: time.Now(), Streamed: streamed},
+		output:      output,
+		subscribers: map[chan models.RunEvents]struct{}{},
+	}
+}
+
+// start gives the run an id and returns the context cancelling it.
+func (runs *Runs) start(run *Run, ctx context.Context) context.Context {
+	ctx, run.cancel = context.WithCancel(ctx)
+	runs.mutex.Lock()
+	defer runs.mutex.Unlock()
+	runs.lastId++
+	run.Id = runs.lastId
+	runs.runs[run.Id] = run
+	return ctx
+}
+
+// finish forgets the run and ends the streams attached to it.
+func (runs *Runs) finish(run *Run) {
+	runs.mutex.Lock()
+	delete(runs.runs, run.Id)
+	runs.mutex.Unlock()
+	run.cancel()
+
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.finished = true
+	for subscriber := range run.subscribers {
+		// publish keeps a place for it, the send doesn't block
+		subscriber <- models.RunEvents{Event: models.RUN_EVENT_FINISHED, RunId: run.Id}
+		close(subscriber)
+	}
+	run.subscribers = nil
+}
+
+// get returns a run the owner may see, a nil owner sees every run.
+func (runs *Runs) get(id uint, owner *uint) (*Run, error) {
+	runs.mutex.Lock()
+	defer runs.mutex.Unlock()
+	run, ok := runs.runs[id]
+	if !ok || (owner != nil && (run.ApiKeyId == nil || *run.ApiKeyId != *owner)) {
+		return nil, ErrRunNotFound
+	}
+	return run, nil
+}
+
+// List returns the runs the owner may see by id, a nil owner sees every run.
+func (runs *Runs) List(owner *uint) []models.Runs {
+	runs.mutex.Lock()
+	defer runs.mutex.Unlock()
+	list := []models.Runs{}
+	for _, run := range runs.runs {
+		if owner == nil || (run.ApiKeyId != nil && *run.ApiKeyId == *owner) {
+			list = append(list, run.Runs)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list
+}
+
+// Cancel interrupts the commands of a run the owner may see, the same way
+// a client that goes away does.
+func (runs *Runs) Cancel(id uint, owner *uint) error {
+	run, err := runs.get(id, owner)
+	if err != nil {
+		return err
+	}
+	run.cancel()
+	return nil
+}
+
+// Subscribe attaches a stream to a run the owner may see. The stream starts
+// with the recent output of the run and ends with a finished event once the
+// run is over, or without it if the stream falls behind. The returned func
+// detaches the stream.
+func (runs *Runs) Subscribe(id uint, owner *uint) (<-chan models.RunEvents, func(), error) {
+	run, err := runs.get(id, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	subscriber := make(chan models.RunEvents, RUN_SUBSCRIBER_EVENTS+len(run.backlog)+2)
+	subscriber <- models.RunEvents{Event: models.RUN_EVENT_STARTED, RunId: run.Id}
+	for _, event := range run.backlog {
+		subscriber <- event
+	}
+	if run.finished {
+		subscriber <- models.RunEvents{Event: models.RUN_EVENT_FINISHED, RunId: run.Id}
+		close(subscriber)
+		return subscriber, func() {}, nil
+	}
+	run.subscribers[subscriber] = struct{}{}
+	unsubscribe := func() {
+		run.mutex.Lock()
+		defer run.mutex.Unlock()
+		if _, ok := run.subscribers[subscriber]; ok {
+			delete(run.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return subscriber, unsubscribe, nil
+}
+
+// publish passes an event to the output of the request and to the attached
+// streams. The output of the request is written before the command can write
+// more, an attached stream that falls behind is closed instead. The last place
+// of a stream is kept for the finished event.
+func (run *Run) publish(event models.RunEvents) {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	if run.output != nil {
+		run.output(event)
+	}
+	if event.Event != models.RUN_EVENT_OUTPUT {
+		return
+	}
+	run.backlog = append(run.backlog, event)
+	run.backlogBytes += len(event.Data)
+	for len(run.backlog) > 1 && run.backlogBytes > RUN_BACKLOG_BYTES {
+		run.backlogBytes -= len(run.backlog[0].Data)
+		run.backlog = run.backlog[1:]
+	}
+	for subscriber := range run.subscribers {
+		if len(subscriber) < cap(subscriber)-1 {
+			subscriber <- event
+			continue
+		}
+		delete(run.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// streamWriter publishes what a stream of a command of the run writes.
+type streamWriter struct {
+	run    *Run
+	index  int
+	stream string
+}
+
+func (writer streamWriter) Write(buf []byte) (int, error) {
+	writer.run.publish(models.RunEvents{
+		Event:  models.RUN_EVENT_OUTPUT,
+		RunId:  writer.run.Id,
+		Index:  writer.index,
+		Stream: writer.stream,
+		Data:   bytes.Clone(buf),
+	})
+	return len(buf), nil
+}
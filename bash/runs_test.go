@@ -0,0 +1,142 @@
+package bash
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+func TestExecCommandsOutput(t *testing.T) {
+	var mutex sync.Mutex
+	events := []models.RunEvents{}
+	inputStruct := &ReqCreateNewCommandBody{
+		BashStrings: []string{"echo out", "echo err >&2"},
+		Output: func(event models.RunEvents) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			events = append(events, event)
+		},
+	}
+	sh := BashCommands{Runs: NewRuns()}
+	if _, err := sh.ExecCommands(inputStruct, context.Background()); err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+
+	if len(events) == 0 || events[0].Event != models.RUN_EVENT_STARTED || events[0].RunId == 0 {
+		t.Fatalf("expected a started event with a run id but got %+v", events)
+	}
+	output := map[string]string{}
+	for _, event := range events[1:] {
+		if event.Event != models.RUN_EVENT_OUTPUT || event.RunId != events[0].RunId {
+			t.Errorf("unexpected event %+v", event)
+		}
+		output[event.Stream] += string(event.Data)
+	}
+	if output["stdout"] != "out\n" || output["stderr"] != "err\n" {
+		t.Errorf("unexpected output %q", output)
+	}
+	if runs := sh.Runs.List(nil); len(runs) != 0 {
+		t.Errorf("finished runs are still listed: %+v", runs)
+	}
+
+	// secrets are masked once the commands exit, their output isn't streamed
+	inputStruct = &ReqCreateNewCommandBody{
+		Commands: []CommandOptions{{BashString: "echo $TOKEN", Secrets: map[string]string{"TOKEN": "token"}, SecretEnv: map[string]string{"TOKEN": "secret"}}},
+		Output:   func(models.RunEvents) {},
+	}
+	if _, err := sh.ExecCommands(inputStruct, context.Background()); err == nil {
+		t.Errorf("streaming the output of a command with secrets isn't an error")
+	}
+}
+
+func TestRuns(t *testing.T) {
+	owner, other := uint(1), uint(2)
+	sh := BashCommands{Runs: NewRuns()}
+	result := make(chan []models.CommandsWithoutID, 1)
+	go func() {
+		inputStruct := &ReqCreateNewCommandBody{BashStrings: []string{"echo ready; sleep 10"}, ApiKeyId: &owner}
+		commands, _ := sh.ExecCommands(inputStruct, context.Background())
+		result <- *commands
+	}()
+
+	var runs []models.Runs
+	for deadline := time.Now().Add(5 * time.Second); len(runs) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		runs = sh.Runs.List(&owner)
+	}
+	if len(runs) != 1 || runs[0].Commands[0] != "echo ready; sleep 10" || !runs[0].Streamed {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+	if list := sh.Runs.List(&other); len(list) != 0 {
+		t.Errorf("runs of another key are listed: %+v", list)
+	}
+	if err := sh.Runs.Cancel(runs[0].Id, &other); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("expected another key not to find the run but got %v", err)
+	}
+
+	events, unsubscribe, err := sh.Runs.Subscribe(runs[0].Id, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer unsubscribe()
+	// the output written before the stream was attached comes first
+	output := ""
+	for !strings.Contains(output, "ready") {
+		event, ok := <-events
+		if !ok {
+			t.Fatalf("the stream ended before the output")
+		}
+		output += string(event.Data)
+	}
+
+	if err := sh.Runs.Cancel(runs[0].Id, &owner); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	select {
+	case commands := <-result:
+		if commands[0].ExitCode == 0 {
+			t.Errorf("a cancelled command exited with 0")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the cancelled command is still running")
+	}
+	last := models.RunEvents{}
+	for event := range events {
+		last = event
+	}
+	if last.Event != models.RUN_EVENT_FINISHED {
+		t.Errorf("expected the stream to end with a finished event but got %+v", last)
+	}
+}
+
+func TestRuns_FinishFullStream(t *testing.T) {
+	runs := NewRuns()
+	run := newRun([]string{"yes"}, nil, true, nil)
+	runs.start(run, context.Background())
+	events, unsubscribe, err := runs.Subscribe(run.Id, nil)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer unsubscribe()
+
+	// a stream that takes every event it can hold without falling behind
+	// still gets the finished event
+	received := 0
+	for i := 0; i < cap(events)-2; i++ {
+		streamWriter{run: run, stream: "stdout"}.Write([]byte("y\n"))
+	}
+	runs.finish(run)
+	last := models.RunEvents{}
+	for event := range events {
+		last = event
+		received++
+	}
+	if last.Event != models.RUN_EVENT_FINISHED || received != cap(events) {
+		t.Errorf("expected %v events ending with a finished event but got %v ending with %+v", cap(events), received, last)
+	}
+}
@@ -0,0 +1,165 @@
+package bash
+
+import (
+	// std
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// MAX_TEMPLATE_COMMANDS limits the number of commands a template expands to.
+const MAX_TEMPLATE_COMMANDS int = 1000
+
+var (
+	// {{host}} or {{ host }}
+	placeholderRegexp = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	// values made only of these characters need no quoting
+	shellSafeRegexp = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+)
+
+// CommandTemplate is a bash string with {{name}} placeholders that runs once
+// per combination of the values in Matrix, for example ping -c1 {{host}}
+// with {"host": ["a.example", "b.example"]}.
+type CommandTemplate struct {
+	Template string `json:"template"`
+	Matrix map[string][]string `json:"matrix"`
+	Assertions *models.Assertions `json:"assertions,omitempty"`
+	Env map[string]string `json:"env,omitempty"`
+	Retry *models.RetryPolicy `json:"retry,omitempty"`
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+}
+
+// Expand returns a command per combination of the matrix values, the values
+// are shell quoted when they are substituted. The last parameter in
+// alphabetical order changes first.
+func (commandTemplate *CommandTemplate) Expand() ([]CommandOptions, error) {
+	if err := ValidatePlaceholders(commandTemplate.Template); err != nil {
+		return nil, err
+	}
+	used := map[string]bool{}
+	for _, match := range placeholderRegexp.FindAllStringSubmatch(commandTemplate.Template, -1) {
+		if _, ok := commandTemplate.Matrix[match[1]]; !ok {
+			return nil, fmt.Errorf("template %q: parameter %q has no values", commandTemplate.Template, match[1])
+		}
+		used[match[1]] = true
+	}
+
+	names := make([]string, 0, len(commandTemplate.Matrix))
+	total := 1
+	for name, values := range commandTemplate.Matrix {
+		if !used[name] {
+			return nil, fmt.Errorf("template %q doesn't use parameter %q", commandTemplate.Template, name)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("template %q: parameter %q has no values", commandTemplate.Template, name)
+		}
+		names = append(names, name)
+		total *= len(values)
+		if total > MAX_TEMPLATE_COMMANDS {
+			return nil, fmt.Errorf("template %q expands to more than %v commands", commandTemplate.Template, MAX_TEMPLATE_COMMANDS)
+		}
+	}
+	sort.Strings(names)
+
+	options := make([]CommandOptions, 0, total)
+	indexes := make([]int, len(names))
+	for range total {
+		parameters := make(map[string]string, len(names))
+		for i, name := range names {
+			parameters[name] = commandTemplate.Matrix[name][indexes[i]]
+		}
+		bashString := placeholderRegexp.ReplaceAllStringFunc(commandTemplate.Template, func(placeholder string) string {
+			return ShellQuote(parameters[placeholderRegexp.FindStringSubmatch(placeholder)[1]])
+		})
+		options = append(options, CommandOptions{
+			BashString: bashString,
+			Assertions: commandTemplate.Assertions,
+			Env: commandTemplate.Env,
+			Retry: commandTemplate.Retry,
+			TimeoutMs: commandTemplate.TimeoutMs,
+			Template: commandTemplate.Template,
+			Parameters: parameters,
+		})
+
+		// next combination
+		for i := len(indexes) - 1; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(commandTemplate.Matrix[names[i]]) {
+				break
+			}
+			indexes[i] = 0
+		}
+	}
+	return options, nil
+}
+
+// Placeholders returns the names of the parameters used by a template, each
+// name once.
+func Placeholders(template string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, match := range placeholderRegexp.FindAllStringSubmatch(template, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// ValidatePlaceholders checks that no placeholder of a template is inside
+// quotes. A substituted value is quoted as a word of its own, inside quotes
+// its quotes would end the quoted string instead.
+func ValidatePlaceholders(template string) error {
+	starts := map[int]int{}
+	for _, match := range placeholderRegexp.FindAllStringIndex(template, -1) {
+		starts[match[0]] = match[1]
+	}
+
+	// the quote the position is in, 0 outside quotes and '$' in $'...'
+	var quote byte
+	for i := 0; i < len(template); i++ {
+		if end, ok := starts[i]; ok {
+			if quote != 0 {
+				return fmt.Errorf("template %q: placeholder %v is inside quotes, the values are quoted when they are substituted",
+					template, template[i:end])
+			}
+			i = end - 1
+			continue
+		}
+		c := template[i]
+		switch {
+		case c == '\\' && quote != '\'':
+			// the next character is escaped, a placeholder is substituted
+			// all the same
+			if _, ok := starts[i+1]; !ok {
+				i++
+			}
+		case quote == 0 && c == '#' && (i == 0 || strings.IndexByte(" \t\n;&|(", template[i-1]) >= 0):
+			// a comment lasts to the end of the line
+			for i < len(template) && template[i] != '\n' {
+				i++
+			}
+		case quote == 0 && c == '$' && i+1 < len(template) && template[i+1] == '\'':
+			quote = '$'
+			i++
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case (quote == '\'' || quote == '$') && c == '\'', quote == '"' && c == '"':
+			quote = 0
+		}
+	}
+	return nil
+}
+
+// ShellQuote quotes a value so that the shell reads it as a single word
+// without expanding anything in it.
+func ShellQuote(value string) string {
+	if shellSafeRegexp.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,163 @@
+package bash
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandTemplateExpand(t *testing.T) {
+	var tests = []struct {
+		testName string
+		template CommandTemplate
+		wantBashStrings []string
+		wantErr string
+	}{
+		{
+			"matrix",
+			CommandTemplate{
+				Template: "deploy {{ version }} to {{host}}",
+				Matrix: map[string][]string{"host": {"a.example", "b.example"}, "version": {"1.0", "2.0"}},
+			},
+			[]string{"deploy 1.0 to a.example", "deploy 2.0 to a.example", "deploy 1.0 to b.example", "deploy 2.0 to b.example"},
+			"",
+		},
+		{
+			"quoting",
+			CommandTemplate{
+				Template: "echo {{value}}",
+				Matrix: map[string][]string{"value": {"$(whoami)", "it's", ""}},
+			},
+			[]string{"echo '$(whoami)'", `echo 'it'\''s'`, "echo ''"},
+			"",
+		},
+		{
+			"placeholder in single quotes",
+			CommandTemplate{
+				Template: "echo '{{value}}'",
+				Matrix: map[string][]string{"value": {"two words", `it's "quoted"`}},
+			},
+			nil,
+			"inside quotes",
+		},
+		{
+			"placeholder in double quotes",
+			CommandTemplate{
+				Template: `echo "value: {{value}}"`,
+				Matrix: map[string][]string{"value": {"two words", `it's "quoted"`}},
+			},
+			nil,
+			"inside quotes",
+		},
+		{
+			"missing parameter",
+			CommandTemplate{Template: "ping {{host}}"},
+			nil,
+			"has no values",
+		},
+		{
+			"unused parameter",
+			CommandTemplate{Template: "ping {{host}}", Matrix: map[string][]string{"host": {"a"}, "port": {"1"}}},
+			nil,
+			"doesn't use",
+		},
+		{
+			"too many commands",
+			CommandTemplate{Template: "{{a}} {{b}}", Matrix: map[string][]string{
+				"a": make([]string, MAX_TEMPLATE_COMMANDS), "b": {"1", "2"},
+			}},
+			nil,
+			"more than",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			options, err := tt.template.Expand()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			bashStrings := []string{}
+			for _, option := range options {
+				bashStrings = append(bashStrings, option.BashString)
+				if option.Template != tt.template.Template {
+					t.Errorf("template: got %q, want %q", option.Template, tt.template.Template)
+				}
+			}
+			if !reflect.DeepEqual(bashStrings, tt.wantBashStrings) {
+				t.Errorf("got %q, want %q", bashStrings, tt.wantBashStrings)
+			}
+		})
+	}
+}
+
+func TestValidatePlaceholders(t *testing.T) {
+	var tests = []struct {
+		testName string
+		template string
+		wantErr bool
+	}{
+		{"unquoted", "echo {{a}} {{ b }}", false},
+		{"between quoted strings", `echo 'x' {{a}} "y"`, false},
+		{"single quotes", "echo '{{a}}'", true},
+		{"double quotes", `echo "{{a}}"`, true},
+		{"ansi-c quotes", "echo $'\\n{{a}}'", true},
+		{"escaped quote", `echo \' {{a}} \"`, false},
+		{"escaped quote in double quotes", `echo "\"" {{a}}`, false},
+		{"double quote in single quotes", `echo '"' {{a}}`, false},
+		{"escaped placeholder in double quotes", `echo "\{{a}}"`, true},
+		{"quote in a comment", "# don't\necho {{a}}", false},
+		{"unclosed quote", "echo '{{a}}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			if err := ValidatePlaceholders(tt.template); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlaceholders(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecCommandsTemplate(t *testing.T) {
+	inputStruct := &ReqCreateNewCommandBody{
+		Templates: []CommandTemplate{
+			{Template: "echo {{value}}", Matrix: map[string][]string{"value": {"$(echo injected)"}}},
+		},
+	}
+	result, err := bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	command := (*result)[0]
+	if command.Log != "$(echo injected)\n" {
+		t.Errorf("Subprocess error: substituted value was expanded by the shell, got %q", command.Log)
+	}
+	if command.Template != "echo {{value}}" || command.Parameters["value"] != "$(echo injected)" {
+		t.Errorf("Subprocess error: template isn't recorded, got %q %v", command.Template, command.Parameters)
+	}
+
+	// the values are single words with their spaces and quotes
+	values := []string{"two  words", `it's "quoted"`}
+	inputStruct = &ReqCreateNewCommandBody{
+		Templates: []CommandTemplate{
+			{Template: `printf '[%s]' {{value}}`, Matrix: map[string][]string{"value": values}},
+		},
+	}
+	result, err = bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	for i, value := range values {
+		if (*result)[i].Log != "["+value+"]" {
+			t.Errorf("Subprocess error: got %q for value %q", (*result)[i].Log, value)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package bash
+
+import (
+	// std
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// limits of the files uploaded with a request
+	UPLOAD_MAX_FILE_BYTES  int64 = 32 << 20
+	UPLOAD_MAX_TOTAL_BYTES int64 = 128 << 20
+	UPLOAD_MAX_FILES       int   = 100
+)
+
+// Uploads is a directory with the files uploaded with a request, they are
+// copied into the working directory of every command before it starts.
+type Uploads struct {
+	Dir   string
+	files int
+	bytes int64
+}
+
+func NewUploads() (*Uploads, error) {
+	dir, err := os.MkdirTemp("", "uploads-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create upload directory: %w", err)
+	}
+	return &Uploads{Dir: dir}, nil
+}
+
+// ValidateUploadPath returns an error if the path of an uploaded file isn't a
+// relative path inside the working directory.
+func ValidateUploadPath(name string) error {
+	if name == "" || strings.Contains(name, `\`) || !filepath.IsLocal(name) {
+		return fmt.Errorf("invalid upload path %q, it must be relative to the working directory", name)
+	}
+	return nil
+}
+
+// Add stores an uploaded file at its path, a file can't be uploaded twice.
+func (uploads *Uploads) Add(name string, reader io.Reader) error {
+	if err := ValidateUploadPath(name); err != nil {
+		return err
+	}
+	if uploads.files >= UPLOAD_MAX_FILES {
+		return fmt.Errorf("more than %v files are uploaded", UPLOAD_MAX_FILES)
+	}
+	file := filepath.Join(uploads.Dir, filepath.Clean(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("unable to upload %q: %w", name, err)
+	}
+	writer, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to upload %q: %w", name, err)
+	}
+	defer writer.Close()
+
+	limit := min(UPLOAD_MAX_FILE_BYTES, UPLOAD_MAX_TOTAL_BYTES-uploads.bytes)
+	size, err := io.Copy(writer, io.LimitReader(reader, limit+1))
+	if err != nil {
+		return fmt.Errorf("unable to upload %q: %w", name, err)
+	}
+	if size > limit {
+		return fmt.Errorf("upload %q is over the limit of %v bytes per file and %v bytes per request",
+			name, UPLOAD_MAX_FILE_BYTES, UPLOAD_MAX_TOTAL_BYTES)
+	}
+	uploads.files++
+	uploads.bytes += size
+	return writer.Close()
+}
+
+// Remove removes the uploaded files.
+func (uploads *Uploads) Remove() error {
+	return os.RemoveAll(uploads.Dir)
+}
+
+// copyTo copies the uploaded files into a working directory.
+func (uploads *Uploads) copyTo(dir string) error {
+	return filepath.WalkDir(uploads.Dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(uploads.Dir, file)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		reader, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		writer, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, reader); err != nil {
+			writer.Close()
+			return err
+		}
+		return writer.Close()
+	})
+}
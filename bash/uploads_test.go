@@ -0,0 +1,78 @@
+package bash
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidateUploadPath(t *testing.T) {
+	var tests = []struct {
+		name    string
+		wantErr bool
+	}{
+		{"input.csv", false},
+		{"data/input.csv", false},
+		{"", true},
+		{"/etc/passwd", true},
+		{"../input.csv", true},
+		{"data/../../input.csv", true},
+		{`..\input.csv`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateUploadPath(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUploadPath(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUploads_Add(t *testing.T) {
+	uploads, err := NewUploads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer uploads.Remove()
+
+	if err := uploads.Add("data/input.csv", strings.NewReader("a,b\n")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := uploads.Add("data/input.csv", strings.NewReader("again")); err == nil {
+		t.Errorf("Add() of a file uploaded twice isn't an error")
+	}
+	if err := uploads.Add("../escape.csv", strings.NewReader("a,b\n")); err == nil {
+		t.Errorf("Add() of a path outside the directory isn't an error")
+	}
+	large := strings.NewReader(strings.Repeat("x", int(UPLOAD_MAX_FILE_BYTES)+1))
+	if err := uploads.Add("large.bin", large); err == nil {
+		t.Errorf("Add() of a file over the limit isn't an error")
+	}
+}
+
+func TestExecCommandsUploads(t *testing.T) {
+	uploads, err := NewUploads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer uploads.Remove()
+	if err := uploads.Add("data/input.csv", strings.NewReader("a,b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// every command gets its own copy of the files
+	inputStruct := &ReqCreateNewCommandBody{
+		BashStrings: []string{"cat data/input.csv && rm data/input.csv", "cat data/input.csv"},
+		Uploads:     uploads,
+	}
+	result, err := bash.ExecCommands(inputStruct, context.Background())
+	if err != nil {
+		t.Fatalf("Subprocess execution error: %v", err)
+	}
+	for _, command := range *result {
+		if command.IsError || command.Log != "a,b\n" {
+			t.Errorf("Subprocess error: unexpected output %q of %q", command.Log, command.Command)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// openarchive writes the NDJSON of a sealed retention archive to stdout. The
+// master keys are read like by the server, from OUTPUT_KEY_FILE and
+// OUTPUT_PREVIOUS_KEY_FILES.
+package main
+
+import (
+	// std
+	"fmt"
+	"io"
+	"os"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/retention"
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: openarchive <commands-...ndjson.gz.sealed>")
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "openarchive: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path string, out io.Writer) error {
+	master, previous, err := secrets.MasterKeysFromEnv()
+	if err != nil {
+		return err
+	}
+	if master == nil {
+		return fmt.Errorf("%v isn't set", secrets.OUTPUT_KEY_FILE_ENV)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	archive, err := retention.OpenArchive(file, append([]*secrets.MasterKey{master}, previous...)...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, archive)
+	return err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	// std
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ANSI_RESET string = "\x1b[0m"
+	TAB_WIDTH  int    = 8
+)
+
+// sanitizeLine prepares a line of captured output for drawing inside a pane.
+// SGR sequences (colors, bold, ...) are kept, every other escape sequence and
+// control character is dropped so it cannot move the cursor or clear the
+// screen, tabs are expanded and a carriage return rewrites the line the same
+// way it would in a terminal.
+func sanitizeLine(line string) string {
+	if i := strings.LastIndexByte(strings.TrimRight(line, "\r"), '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+
+	var b strings.Builder
+	column := 0
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '\x1b':
+			seq, n := escapeSequence(line[i:])
+			if strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m") {
+				b.WriteString(seq)
+			}
+			i += n
+		case c == '\t':
+			spaces := TAB_WIDTH - column%TAB_WIDTH
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			i++
+		case c < 0x20 || c == 0x7f:
+			i++
+		default:
+			r, size := utf8.DecodeRuneInString(line[i:])
+			if r == utf8.RuneError && size == 1 {
+				b.WriteRune(utf8.RuneError)
+			} else {
+				b.WriteString(line[i : i+size])
+			}
+			column++
+			i += size
+		}
+	}
+	return b.String()
+}
+
+// escapeSequence returns the escape sequence at the start of s and its length.
+func escapeSequence(s string) (string, int) {
+	if len(s) < 2 {
+		return s, len(s)
+	}
+	switch s[1] {
+	case '[':
+		// CSI: parameters and intermediates up to a final byte in 0x40-0x7e
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return s[:i+1], i + 1
+			}
+		}
+		return s, len(s)
+	case ']':
+		// OSC: terminated by BEL or ST
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return s[:i+1], i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return s[:i+2], i + 2
+			}
+		}
+		return s, len(s)
+	default:
+		return s[:2], 2
+	}
+}
+
+// truncateVisible cuts a sanitized line to width visible characters, escape
+// sequences do not count towards the width.
+func truncateVisible(line string, width int) string {
+	var b strings.Builder
+	visible := 0
+	hasSgr := false
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			seq, n := escapeSequence(line[i:])
+			b.WriteString(seq)
+			hasSgr = true
+			i += n
+			continue
+		}
+		if visible == width {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		b.WriteString(line[i : i+size])
+		visible++
+		i += size
+	}
+	if hasSgr {
+		b.WriteString(ANSI_RESET)
+	}
+	return b.String()
+}
+
+// visibleLen returns the number of visible characters of a sanitized line.
+func visibleLen(line string) int {
+	n := 0
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			_, size := escapeSequence(line[i:])
+			i += size
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(line[i:])
+		n++
+		i += size
+	}
+	return n
+}
+
+// stripAnsi removes the escape sequences of a sanitized line.
+func stripAnsi(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			_, size := escapeSequence(line[i:])
+			i += size
+			continue
+		}
+		b.WriteByte(line[i])
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSanitizeLine(t *testing.T) {
+	var tests = []struct {
+		testName string
+		input string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"sgr kept", "\x1b[31mred\x1b[0m", "\x1b[31mred\x1b[0m"},
+		{"cursor movement dropped", "\x1b[2J\x1b[Hcleared", "cleared"},
+		{"osc title dropped", "\x1b]0;title\atext", "text"},
+		{"carriage return rewrites", "10%\r50%\r100%", "100%"},
+		{"trailing carriage return", "done\r", "done"},
+		{"tab expanded", "a\tb", "a       b"},
+		{"control chars dropped", "a\x07b\x00c", "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			if got := sanitizeLine(tt.input); got != tt.want {
+				t.Errorf("sanitizeLine(%q)\ngot %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateVisible(t *testing.T) {
+	var tests = []struct {
+		testName string
+		input string
+		width int
+		want string
+	}{
+		{"short", "abc", 5, "abc"},
+		{"long", "abcdef", 3, "abc"},
+		{"escape sequences are not counted", "\x1b[1mabcdef", 3, "\x1b[1mabc" + ANSI_RESET},
+		{"multibyte", "привет", 3, "при"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			if got := truncateVisible(tt.input, tt.width); got != tt.want {
+				t.Errorf("truncateVisible(%q, %v)\ngot %q, want %q", tt.input, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	events := parseKeys([]byte("j\x1b[A\x1b[6~\r/\x7f\x03"))
+	want := []tuiKey{keyRune, keyUp, keyPageDown, keyEnter, keyRune, keyBackspace, keyQuit}
+	if len(events) != len(want) {
+		t.Fatalf("parseKeys: got %v events, want %v", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.key != want[i] {
+			t.Errorf("parseKeys: event %v got key %v, want %v", i, event.key, want[i])
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	// std
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// Client is a thin wrapper over the bash REST API.
+type Client struct {
+	baseUrl string
+	// sent as a bearer token when not empty
+	apiKey string
+	http   *http.Client
+}
+
+func NewClient(baseUrl string, apiKey string) *Client {
+	return &Client{
+		baseUrl: strings.TrimRight(baseUrl, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{},
+	}
+}
+
+// Run executes the commands on the server. Cancelling ctx drops the request,
+// which makes the server interrupt the commands that are still running.
+func (c *Client) Run(ctx context.Context, bashStrings ...string) ([]models.CommandsWithoutID, error) {
+	body, err := json.Marshal(bash.ReqCreateNewCommandBody{BashStrings: bashStrings})
+	if err != nil {
+		return nil, fmt.Errorf("json marshal error: %w", err)
+	}
+	// the server answers 500 together with the results when some command
+	// could not be started, so the body is decoded regardless of the status
+	commands := []models.CommandsWithoutID{}
+	status, err := c.do(ctx, http.MethodPost, "/bash/create-command", bytes.NewReader(body), &commands)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK && len(commands) == 0 {
+		return nil, fmt.Errorf("server responded with %v", status)
+	}
+	return commands, nil
+}
+
+// Rerun executes a command from the history again, the new execution is
+// linked to the original one.
+func (c *Client) Rerun(ctx context.Context, id uint) ([]models.CommandsWithoutID, error) {
+	commands := []models.CommandsWithoutID{}
+	status, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/bash/commands/%v/rerun", id), nil, &commands)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK && len(commands) == 0 {
+		return nil, fmt.Errorf("server responded with %v", status)
+	}
+	return commands, nil
+}
+
+// RunStream executes the commands on the server and passes their output to
+// onEvent while they run. It returns the stored commands of the result event.
+func (c *Client) RunStream(ctx context.Context, onEvent func(models.RunEvents), bashStrings ...string) ([]models.CommandsWithoutID, error) {
+	body, err := json.Marshal(bash.ReqCreateNewCommandBody{BashStrings: bashStrings})
+	if err != nil {
+		return nil, fmt.Errorf("json marshal error: %w", err)
+	}
+	var result *models.RunEvents
+	err = c.stream(ctx, http.MethodPost, "/bash/create-command?stream=true", bytes.NewReader(body), func(event models.RunEvents) {
+		if event.Event == models.RUN_EVENT_RESULT {
+			result = &event
+			return
+		}
+		onEvent(event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("server closed the stream before the result")
+	}
+	if len(result.Commands) == 0 && result.Error != "" {
+		return nil, fmt.Errorf("server error: %v", result.Error)
+	}
+	return result.Commands, nil
+}
+
+// Runs returns the requests whose commands are running on the server.
+func (c *Client) Runs(ctx context.Context) ([]models.Runs, error) {
+	runs := []models.Runs{}
+	status, err := c.do(ctx, http.MethodGet, "/bash/runs", nil, &runs)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("server responded with %v", status)
+	}
+	return runs, nil
+}
+
+// Cancel interrupts the commands of a running request.
+func (c *Client) Cancel(ctx context.Context, runId uint) error {
+	status, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/bash/runs/%v/cancel", runId), nil, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		return fmt.Errorf("run %v isn't running", runId)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("server responded with %v", status)
+	}
+	return nil
+}
+
+// Tail passes the output of a running request to onEvent until it finishes.
+func (c *Client) Tail(ctx context.Context, runId uint, onEvent func(models.RunEvents)) error {
+	return c.stream(ctx, http.MethodGet, fmt.Sprintf("/bash/runs/%v/stream", runId), nil, onEvent)
+}
+
+// RerunBatch executes the commands of a batch again, only the failed ones
+// with failedOnly.
+func (c *Client) RerunBatch(ctx context.Context, id uint, failedOnly bool) ([]models.CommandsWithoutID, error) {
+	commands := []models.CommandsWithoutID{}
+	status, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/bash/batches/%v/rerun?failed_only=%v", id, failedOnly), nil, &commands)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK && len(commands) == 0 {
+		return nil, fmt.Errorf("server responded with %v", status)
+	}
+	return commands, nil
+}
+
+func (c *Client) List(ctx context.Context) ([]models.Commands, error) {
+	commands := []models.Commands{}
+	status, err := c.do(ctx, http.MethodGet, "/bash/get-commands", nil, &commands)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("server responded with %v", status)
+	}
+	return commands, nil
+}
+
+func (c *Client) Get(ctx context.Context, id uint) (*models.Commands, error) {
+	command := models.Commands{}
+	status, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/bash/get-commands/%v", id), nil, &command)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("server responded with %v", status)
+	}
+	return &command, nil
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request error: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request error: %w", err)
+	}
+	return resp, nil
+}
+
+// stream passes every NDJSON event of the response to onEvent. Errors are
+// answered before the stream starts, with the reason as the body.
+func (c *Client) stream(ctx context.Context, method string, path string, body io.Reader, onEvent func(models.RunEvents)) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		reason, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		if len(bytes.TrimSpace(reason)) != 0 {
+			return fmt.Errorf("server responded with %v: %v", resp.StatusCode, strings.TrimSpace(string(reason)))
+		}
+		return fmt.Errorf("server responded with %v", resp.StatusCode)
+	}
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event models.RunEvents
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("read stream error: %w", err)
+		}
+		onEvent(event)
+	}
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body io.Reader, out any) (int, error) {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("read response body error: %w", err)
+	}
+	if len(bytes.TrimSpace(buf)) == 0 || out == nil {
+		return resp.StatusCode, nil
+	}
+	if err := json.Unmarshal(buf, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("json unmarshal error: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	// std
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	DEFAULT_SERVER_URL string = "http://localhost:8080"
+	// exit code used by shells for a process stopped with Ctrl-C
+	EXIT_CODE_INTERRUPTED int = 130
+)
+
+const usage = `usage: termctl [-server url] [-api-key key] <command> [args]
+
+commands:
+  run <bash string>   run a command on the server, stream its output and exit
+                      with its exit code
+  list                list executed commands
+  get <id>            show a single command with its output
+  rerun <id>          run a command from the history again
+  runs                list the commands that are running with their run ids
+  tail <run id>       stream the output of running commands until they exit
+  cancel <run id>     interrupt running commands
+  shell               open an interactive shell against the server
+  tui                 open a dashboard with the command history
+
+The server url and the api key may also be set with the TERMCTL_SERVER and
+TERMCTL_API_KEY environment variables.
+`
+
+func main() {
+	serverUrl := os.Getenv("TERMCTL_SERVER")
+	if serverUrl == "" {
+		serverUrl = DEFAULT_SERVER_URL
+	}
+	flag.StringVar(&serverUrl, "server", serverUrl, "server url")
+	apiKey := flag.String("api-key", os.Getenv("TERMCTL_API_KEY"), "api key")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	client := NewClient(serverUrl, *apiKey)
+	args := flag.Args()[1:]
+
+	var code int
+	switch flag.Arg(0) {
+	case "run":
+		code = runCommand(client, args)
+	case "list":
+		code = listCommands(client)
+	case "get":
+		code = getCommand(client, args)
+	case "rerun":
+		code = rerunCommand(client, args)
+	case "runs":
+		code = listRuns(client)
+	case "tail":
+		code = tailRun(client, args)
+	case "cancel":
+		code = cancelRun(client, args)
+	case "shell":
+		code = NewShell(client, os.Stdin, os.Stdout).Run()
+	case "tui":
+		code = NewTui(client, os.Stdin, os.Stdout).Run()
+	default:
+		fmt.Fprintf(os.Stderr, "termctl: unknown command %q\n", flag.Arg(0))
+		flag.Usage()
+		code = 2
+	}
+	os.Exit(code)
+}
+
+func runCommand(client *Client, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "termctl: run needs a bash string")
+		return 2
+	}
+	// Ctrl-C cancels the request and the server interrupts the command
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	commands, err := streamCommand(ctx, client, strings.Join(args, " "))
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "termctl: interrupted")
+		return EXIT_CODE_INTERRUPTED
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	code := 0
+	for _, command := range commands {
+		code = remoteExitCode(command.ExitCode)
+	}
+	return code
+}
+
+// streamCommand runs a bash string on the server and prints its output while
+// it runs.
+func streamCommand(ctx context.Context, client *Client, bashString string) ([]models.CommandsWithoutID, error) {
+	printer := newOutputPrinter(os.Stdout, os.Stderr)
+	defer printer.finish()
+	return client.RunStream(ctx, printer.print, bashString)
+}
+
+func rerunCommand(client *Client, args []string) int {
+	id, code := parseId(args, "command")
+	if code != 0 {
+		return code
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	commands, err := client.Rerun(ctx, id)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "termctl: interrupted")
+		return EXIT_CODE_INTERRUPTED
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	for _, command := range commands {
+		printLog(command.IsError, command.Log)
+		code = remoteExitCode(command.ExitCode)
+	}
+	return code
+}
+
+func listCommands(client *Client) int {
+	commands, err := client.List(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEXIT\tERROR\tCOMMAND")
+	for _, command := range commands {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", command.Id, command.ExitCode, command.IsError, firstLine(command.Command))
+	}
+	w.Flush()
+	return 0
+}
+
+func getCommand(client *Client, args []string) int {
+	id, code := parseId(args, "command")
+	if code != 0 {
+		return code
+	}
+	command, err := client.Get(context.Background(), id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	fmt.Printf("id:        %v\ncommand:   %v\nexit code: %v\nis error:  %v\n\n", command.Id, command.Command, command.ExitCode, command.IsError)
+	printLog(false, command.Log)
+	return 0
+}
+
+func listRuns(client *Client) int {
+	runs, err := client.Runs(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tSTARTED\tSTREAMED\tCOMMANDS")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", run.Id, run.StartedAt.Local().Format(time.TimeOnly), run.Streamed, firstLine(strings.Join(run.Commands, "; ")))
+	}
+	w.Flush()
+	return 0
+}
+
+func tailRun(client *Client, args []string) int {
+	id, code := parseId(args, "run")
+	if code != 0 {
+		return code
+	}
+	// Ctrl-C only stops following the output, the commands keep running
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	printer := newOutputPrinter(os.Stdout, os.Stderr)
+	err := client.Tail(ctx, id, printer.print)
+	printer.finish()
+	if ctx.Err() != nil {
+		return EXIT_CODE_INTERRUPTED
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func cancelRun(client *Client, args []string) int {
+	id, code := parseId(args, "run")
+	if code != 0 {
+		return code
+	}
+	if err := client.Cancel(context.Background(), id); err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseId parses the single id argument of a command or a run, a non-zero
+// code means the arguments are invalid.
+func parseId(args []string, what string) (uint, int) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "termctl: a %v id is required\n", what)
+		return 0, 2
+	}
+	id, err := strconv.ParseUint(args[0], 10, 0)
+	if err != nil || id == 0 {
+		fmt.Fprintf(os.Stderr, "termctl: invalid %v id %q\n", what, args[0])
+		return 0, 2
+	}
+	return uint(id), 0
+}
+
+// outputPrinter writes the streamed output of commands, stderr output goes to
+// stderr.
+type outputPrinter struct {
+	stdout io.Writer
+	stderr io.Writer
+	// the last byte written to each of them
+	last map[io.Writer]byte
+}
+
+func newOutputPrinter(stdout io.Writer, stderr io.Writer) *outputPrinter {
+	return &outputPrinter{stdout: stdout, stderr: stderr, last: map[io.Writer]byte{}}
+}
+
+func (printer *outputPrinter) print(event models.RunEvents) {
+	if event.Event != models.RUN_EVENT_OUTPUT || len(event.Data) == 0 {
+		return
+	}
+	out := printer.stdout
+	if event.Stream == "stderr" {
+		out = printer.stderr
+	}
+	out.Write(event.Data)
+	printer.last[out] = event.Data[len(event.Data)-1]
+}
+
+// finish ends the output with a newline the same way printLog does.
+func (printer *outputPrinter) finish() {
+	for out, last := range printer.last {
+		if last != '\n' {
+			fmt.Fprintln(out)
+		}
+	}
+	clear(printer.last)
+}
+
+// printLog writes the captured output, stderr output goes to stderr.
+func printLog(isError bool, log string) {
+	out := os.Stdout
+	if isError {
+		out = os.Stderr
+	}
+	fmt.Fprint(out, log)
+	if log != "" && !strings.HasSuffix(log, "\n") {
+		fmt.Fprintln(out)
+	}
+}
+
+// remoteExitCode maps the exit code reported by the server to a local one;
+// the server reports -1 for commands that were terminated by a signal.
+func remoteExitCode(exitCode int) int {
+	if exitCode < 0 {
+		return EXIT_CODE_INTERRUPTED
+	}
+	return exitCode
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	// std
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	// terminal
+	"golang.org/x/term"
+)
+
+const SHELL_PROMPT string = "termctl> "
+
+// Shell is an interactive REPL which runs every entered line on the server.
+// On a terminal it provides line editing and history; while a command is
+// running Ctrl-C interrupts it on the server instead of exiting the shell.
+type Shell struct {
+	client   *Client
+	in       *os.File
+	out      io.Writer
+	history  []string
+	exitCode int
+}
+
+func NewShell(client *Client, in *os.File, out io.Writer) *Shell {
+	return &Shell{client: client, in: in, out: out}
+}
+
+// Run reads lines until exit or end of input and returns the exit code of
+// the last executed command.
+func (sh *Shell) Run() int {
+	fd := int(sh.in.Fd())
+	if !term.IsTerminal(fd) {
+		scanner := bufio.NewScanner(sh.in)
+		for scanner.Scan() {
+			if !sh.handleLine(scanner.Text()) {
+				break
+			}
+		}
+		return sh.exitCode
+	}
+
+	fmt.Fprintln(sh.out, "connected to", sh.client.baseUrl, "- type exit or press Ctrl-D to quit")
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{sh.in, sh.out}, SHELL_PROMPT)
+	for {
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+			return 1
+		}
+		line, err := terminal.ReadLine()
+		// commands run in cooked mode so Ctrl-C is delivered as a signal
+		term.Restore(fd, oldState)
+		if err != nil {
+			return sh.exitCode
+		}
+		if !sh.handleLine(line) {
+			return sh.exitCode
+		}
+	}
+}
+
+// handleLine executes a single line and reports whether the shell should
+// keep reading.
+func (sh *Shell) handleLine(line string) bool {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "":
+		return true
+	case line == "exit":
+		return false
+	case line == "history":
+		for i, entry := range sh.history {
+			fmt.Fprintf(sh.out, "%5d  %v\n", i+1, entry)
+		}
+		return true
+	}
+	sh.history = append(sh.history, line)
+	sh.exitCode = sh.execute(line)
+	return true
+}
+
+func (sh *Shell) execute(line string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	commands, err := streamCommand(ctx, sh.client, line)
+	if ctx.Err() != nil {
+		fmt.Fprintln(sh.out, "^C")
+		return EXIT_CODE_INTERRUPTED
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	code := 0
+	for _, command := range commands {
+		code = remoteExitCode(command.ExitCode)
+	}
+	return code
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	// std
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// terminal
+	"golang.org/x/term"
+)
+
+const TUI_REFRESH_INTERVAL time.Duration = 2 * time.Second
+
+type tuiKey int
+
+const (
+	keyRune tuiKey = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyTab
+	keyQuit
+)
+
+type tuiKeyEvent struct {
+	key tuiKey
+	r   rune
+}
+
+type tuiListEvent struct {
+	commands []models.Commands
+	runs     []models.Runs
+	err      error
+}
+
+// tuiTailEvent is output of the followed run, finished is set once its
+// stream ends.
+type tuiTailEvent struct {
+	runId    uint
+	data     []byte
+	finished bool
+	err      error
+}
+
+type tuiView int
+
+const (
+	viewCommands tuiView = iota
+	viewBatches
+	viewRuns
+)
+
+var tuiViewNames = [...]string{"commands", "batches", "runs"}
+
+// output of the followed run kept for the detail pane
+const TUI_TAIL_BYTES int = 256 << 10
+
+// tuiRow is a row of the table of a view with its detail pane.
+type tuiRow struct {
+	id     uint
+	text   string
+	title  string
+	detail []string
+}
+
+// Tui is a full screen dashboard with a table of executed commands, of their
+// batches or of the running commands and a detail pane with the output of
+// the selected row.
+type Tui struct {
+	client *Client
+	in     *os.File
+	out    io.Writer
+
+	view         tuiView
+	commands     []models.Commands
+	runs         []models.Runs
+	filter       string
+	filterActive bool
+	detailFocus  bool
+	selected     int
+	tableOffset  int
+	detailOffset int
+	status       string
+
+	// the run whose output is followed
+	tailRunId  uint
+	tailOutput []byte
+	tailCancel context.CancelFunc
+}
+
+func NewTui(client *Client, in *os.File, out io.Writer) *Tui {
+	return &Tui{client: client, in: in, out: out}
+}
+
+func (t *Tui) Run() int {
+	fd := int(t.in.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "termctl: tui needs a terminal")
+		return 1
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "termctl: %v\n", err)
+		return 1
+	}
+	defer term.Restore(fd, oldState)
+	// alternate screen, hidden cursor
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+	defer t.stopTail()
+
+	keys := make(chan tuiKeyEvent)
+	go t.readKeys(keys)
+	lists := make(chan tuiListEvent, 1)
+	actions := make(chan string, 1)
+	tails := make(chan tuiTailEvent, 64)
+	ticker := time.NewTicker(TUI_REFRESH_INTERVAL)
+	defer ticker.Stop()
+
+	go t.fetch(lists)
+	t.status = "loading..."
+	for {
+		t.draw(fd)
+		select {
+		case event, ok := <-keys:
+			if !ok || !t.handleKey(event, actions, tails) {
+				return 0
+			}
+		case event := <-lists:
+			if event.err != nil {
+				t.status = event.err.Error()
+				continue
+			}
+			t.setLists(event.commands, event.runs)
+			if t.status == "loading..." {
+				t.status = ""
+			}
+		case status := <-actions:
+			t.status = status
+			go t.fetch(lists)
+		case event := <-tails:
+			t.handleTail(event)
+		case <-ticker.C:
+			go t.fetch(lists)
+		}
+	}
+}
+
+func (t *Tui) fetch(lists chan<- tuiListEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), TUI_REFRESH_INTERVAL)
+	defer cancel()
+	event := tuiListEvent{}
+	event.commands, event.err = t.client.List(ctx)
+	if event.err == nil {
+		event.runs, event.err = t.client.Runs(ctx)
+	}
+	select {
+	case lists <- event:
+	default:
+		// a newer refresh is already waiting
+	}
+}
+
+// startTail follows the output of a run, the output of the run followed
+// before is dropped.
+func (t *Tui) startTail(runId uint, tails chan<- tuiTailEvent) {
+	t.stopTail()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.tailRunId, t.tailOutput, t.tailCancel = runId, []byte{}, cancel
+	t.detailFocus, t.detailOffset = false, 0
+	send := func(event tuiTailEvent) {
+		select {
+		case tails <- event:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		err := t.client.Tail(ctx, runId, func(event models.RunEvents) {
+			if event.Event == models.RUN_EVENT_OUTPUT {
+				send(tuiTailEvent{runId: runId, data: event.Data})
+			}
+		})
+		send(tuiTailEvent{runId: runId, finished: true, err: err})
+	}()
+}
+
+func (t *Tui) stopTail() {
+	if t.tailCancel != nil {
+		t.tailCancel()
+		t.tailCancel = nil
+	}
+}
+
+func (t *Tui) handleTail(event tuiTailEvent) {
+	if event.runId != t.tailRunId || t.tailCancel == nil {
+		return
+	}
+	if event.finished {
+		t.stopTail()
+		if event.err != nil {
+			t.status = fmt.Sprintf("following run %v failed: %v", event.runId, event.err)
+		} else {
+			t.status = fmt.Sprintf("run %v finished", event.runId)
+		}
+		return
+	}
+	t.tailOutput = append(t.tailOutput, event.data...)
+	if extra := len(t.tailOutput) - TUI_TAIL_BYTES; extra > 0 {
+		t.tailOutput = t.tailOutput[extra:]
+	}
+}
+
+func (t *Tui) readKeys(keys chan<- tuiKeyEvent) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := t.in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, event := range parseKeys(buf[:n]) {
+			keys <- event
+		}
+	}
+}
+
+// parseKeys converts raw terminal input into key events.
+func parseKeys(b []byte) []tuiKeyEvent {
+	events := []tuiKeyEvent{}
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) == 1:
+			events = append(events, tuiKeyEvent{key: keyEscape})
+			b = b[1:]
+		case b[0] == 0x1b:
+			seq, n := escapeSequence(string(b))
+			switch seq {
+			case "\x1b[A", "\x1bOA":
+				events = append(events, tuiKeyEvent{key: keyUp})
+			case "\x1b[B", "\x1bOB":
+				events = append(events, tuiKeyEvent{key: keyDown})
+			case "\x1b[5~":
+				events = append(events, tuiKeyEvent{key: keyPageUp})
+			case "\x1b[6~":
+				events = append(events, tuiKeyEvent{key: keyPageDown})
+			}
+			b = b[n:]
+		case b[0] == 0x03:
+			events = append(events, tuiKeyEvent{key: keyQuit})
+			b = b[1:]
+		case b[0] == '\r' || b[0] == '\n':
+			events = append(events, tuiKeyEvent{key: keyEnter})
+			b = b[1:]
+		case b[0] == 0x7f || b[0] == 0x08:
+			events = append(events, tuiKeyEvent{key: keyBackspace})
+			b = b[1:]
+		case b[0] == '\t':
+			events = append(events, tuiKeyEvent{key: keyTab})
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			events = append(events, tuiKeyEvent{key: keyRune, r: r})
+			b = b[size:]
+		}
+	}
+	return events
+}
+
+// handleKey updates the state and reports whether the dashboard keeps running.
+func (t *Tui) handleKey(event tuiKeyEvent, actions chan<- string, tails chan<- tuiTailEvent) bool {
+	if event.key == keyQuit {
+		return false
+	}
+	if t.filterActive {
+		switch event.key {
+		case keyEnter:
+			t.filterActive = false
+		case keyEscape:
+			t.filterActive = false
+			t.filter = ""
+		case keyBackspace:
+			if runes := []rune(t.filter); len(runes) > 0 {
+				t.filter = string(runes[:len(runes)-1])
+			}
+		case keyRune:
+			t.filter += string(event.r)
+		}
+		t.selected, t.tableOffset, t.detailOffset = 0, 0, 0
+		return true
+	}
+
+	rows := t.rows()
+	var selected *tuiRow
+	if t.selected < len(rows) {
+		selected = &rows[t.selected]
+	}
+	switch {
+	case event.key == keyRune && event.r == 'q':
+		return false
+	case event.key == keyRune && event.r == '/':
+		t.filterActive = true
+	case event.key == keyRune && event.r >= '1' && event.r <= '3':
+		t.view = tuiView(event.r - '1')
+		t.selected, t.tableOffset, t.detailOffset, t.detailFocus = 0, 0, 0, false
+	case event.key == keyEscape:
+		switch {
+		case t.detailFocus:
+			t.detailFocus = false
+		case t.tailCancel != nil:
+			t.stopTail()
+			t.status = fmt.Sprintf("stopped following run %v", t.tailRunId)
+		default:
+			t.filter = ""
+		}
+	case event.key == keyTab || event.key == keyEnter:
+		t.detailFocus = !t.detailFocus
+	case selected == nil:
+	case event.key == keyRune && event.r == 'r' && t.view == viewCommands:
+		id := selected.id
+		t.status = fmt.Sprintf("rerunning command %v...", id)
+		go func() {
+			results, err := t.client.Rerun(context.Background(), id)
+			actions <- rerunStatus(fmt.Sprintf("command %v", id), results, err)
+		}()
+	case event.key == keyRune && (event.r == 'r' || event.r == 'f') && t.view == viewBatches:
+		id, failedOnly := selected.id, event.r == 'f'
+		t.status = fmt.Sprintf("rerunning batch %v...", id)
+		go func() {
+			results, err := t.client.RerunBatch(context.Background(), id, failedOnly)
+			actions <- rerunStatus(fmt.Sprintf("batch %v", id), results, err)
+		}()
+	case event.key == keyRune && event.r == 'c' && t.view == viewRuns:
+		id := selected.id
+		t.status = fmt.Sprintf("cancelling run %v...", id)
+		go func() {
+			if err := t.client.Cancel(context.Background(), id); err != nil {
+				actions <- fmt.Sprintf("cancel of run %v failed: %v", id, err)
+				return
+			}
+			actions <- fmt.Sprintf("run %v is cancelled", id)
+		}()
+	case event.key == keyRune && event.r == 't' && t.view == viewRuns:
+		t.startTail(selected.id, tails)
+		t.status = fmt.Sprintf("following run %v, esc stops", selected.id)
+	case event.key == keyUp || (event.key == keyRune && event.r == 'k'):
+		t.move(-1, len(rows))
+	case event.key == keyDown || (event.key == keyRune && event.r == 'j'):
+		t.move(1, len(rows))
+	case event.key == keyPageUp:
+		t.move(-10, len(rows))
+	case event.key == keyPageDown:
+		t.move(10, len(rows))
+	}
+	return true
+}
+
+// rerunStatus describes the result of a rerun for the status line.
+func rerunStatus(what string, results []models.CommandsWithoutID, err error) string {
+	if err != nil {
+		return fmt.Sprintf("rerun of %v failed: %v", what, err)
+	}
+	codes := make([]string, 0, len(results))
+	for _, result := range results {
+		codes = append(codes, fmt.Sprint(result.ExitCode))
+	}
+	return fmt.Sprintf("rerun of %v finished, exit code %v", what, strings.Join(codes, ", "))
+}
+
+func (t *Tui) move(delta int, total int) {
+	if t.detailFocus {
+		t.detailOffset = max(0, t.detailOffset+delta)
+		return
+	}
+	t.selected = min(max(0, t.selected+delta), max(0, total-1))
+	t.detailOffset = 0
+}
+
+// setLists replaces the commands and the runs keeping the same row selected.
+func (t *Tui) setLists(commands []models.Commands, runs []models.Runs) {
+	var selectedId uint
+	if rows := t.rows(); t.selected < len(rows) {
+		selectedId = rows[t.selected].id
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Id > commands[j].Id })
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Id > runs[j].Id })
+	t.commands, t.runs = commands, runs
+	for i, row := range t.rows() {
+		if row.id == selectedId {
+			t.selected = i
+			return
+		}
+	}
+	t.selected = 0
+}
+
+// matches reports whether a command matches the filter. A filter matches the
+// command text, "error"/"ok" and "exit=N" match the outcome.
+func (t *Tui) matches(command models.Commands) bool {
+	filter := strings.ToLower(t.filter)
+	return filter == "" ||
+		strings.Contains(strings.ToLower(command.Command), filter) ||
+		filter == "error" && command.IsError ||
+		filter == "ok" && !command.IsError ||
+		filter == fmt.Sprintf("exit=%v", command.ExitCode)
+}
+
+// rows returns the rows of the view matching the filter.
+func (t *Tui) rows() []tuiRow {
+	rows := []tuiRow{}
+	switch t.view {
+	case viewCommands:
+		for _, command := range t.commands {
+			if !t.matches(command) {
+				continue
+			}
+			rows = append(rows, tuiRow{
+				id:     command.Id,
+				text:   fmt.Sprintf(" %-8v %-5v %-6v %v", command.Id, command.ExitCode, command.IsError, firstLine(command.Command)),
+				title:  fmt.Sprintf(" output of #%v ", command.Id),
+				detail: strings.Split(strings.TrimRight(command.Log, "\n"), "\n"),
+			})
+		}
+	case viewBatches:
+		// the commands are sorted by id, so are their batches
+		batches := map[uint][]models.Commands{}
+		ids := []uint{}
+		for _, command := range t.commands {
+			if command.BatchId == nil {
+				continue
+			}
+			if _, ok := batches[*command.BatchId]; !ok {
+				ids = append(ids, *command.BatchId)
+			}
+			batches[*command.BatchId] = append(batches[*command.BatchId], command)
+		}
+		for _, id := range ids {
+			commands := batches[id]
+			if !slices.ContainsFunc(commands, t.matches) {
+				continue
+			}
+			slices.Reverse(commands)
+			failed := 0
+			detail := []string{}
+			for _, command := range commands {
+				if command.IsFailed() {
+					failed++
+				}
+				detail = append(detail, fmt.Sprintf("\x1b[1m#%v exit %v: %v\x1b[0m", command.Id, command.ExitCode, firstLine(command.Command)))
+				if log := strings.TrimRight(command.Log, "\n"); log != "" {
+					detail = append(detail, strings.Split(log, "\n")...)
+				}
+			}
+			rows = append(rows, tuiRow{
+				id:     id,
+				text:   fmt.Sprintf(" %-8v %-8v %-6v %v", id, len(commands), failed, firstLine(commands[0].Command)),
+				title:  fmt.Sprintf(" commands of batch #%v ", id),
+				detail: detail,
+			})
+		}
+	case viewRuns:
+		for _, run := range t.runs {
+			commands := strings.Join(run.Commands, "; ")
+			if t.filter != "" && !strings.Contains(strings.ToLower(commands), strings.ToLower(t.filter)) {
+				continue
+			}
+			row := tuiRow{
+				id:    run.Id,
+				text:  fmt.Sprintf(" %-8v %-9v %-8v %v", run.Id, run.StartedAt.Local().Format(time.TimeOnly), run.Streamed, firstLine(commands)),
+				title: fmt.Sprintf(" commands of run %v ", run.Id),
+			}
+			for _, command := range run.Commands {
+				row.detail = append(row.detail, strings.Split(command, "\n")...)
+			}
+			row.detail = append(row.detail, "", "t follows the output, c cancels the commands")
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (t *Tui) draw(fd int) {
+	width, height, err := term.GetSize(fd)
+	if err != nil || width < 20 || height < 8 {
+		width, height = 80, 24
+	}
+	lines := t.render(width, height)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString("\x1b[J")
+	fmt.Fprint(t.out, b.String())
+}
+
+var tuiTableHeaders = [...]string{
+	fmt.Sprintf(" %-8v %-5v %-6v %v", "ID", "EXIT", "ERROR", "COMMAND"),
+	fmt.Sprintf(" %-8v %-8v %-6v %v", "BATCH", "COMMANDS", "FAILED", "FIRST COMMAND"),
+	fmt.Sprintf(" %-8v %-9v %-8v %v", "RUN", "STARTED", "STREAMED", "COMMANDS"),
+}
+
+var tuiHelp = [...]string{
+	" 1/2/3 view  j/k move  tab focus output  / filter  r rerun  q quit",
+	" 1/2/3 view  j/k move  tab focus output  / filter  r rerun  f rerun failed  q quit",
+	" 1/2/3 view  j/k move  tab focus output  / filter  t follow  c cancel  q quit",
+}
+
+// render returns exactly height lines of the screen.
+func (t *Tui) render(width int, height int) []string {
+	rows := t.rows()
+	tableHeight := max(3, (height-4)/2)
+	detailHeight := height - tableHeight - 4
+
+	lines := make([]string, 0, height)
+	header := fmt.Sprintf(" termctl - %v - %v %v", t.client.baseUrl, len(rows), tuiViewNames[t.view])
+	if t.filter != "" || t.filterActive {
+		header += fmt.Sprintf(" - filter: %v", t.filter)
+		if t.filterActive {
+			header += "_"
+		}
+	}
+	lines = append(lines, "\x1b[7m"+padRight(truncateVisible(header, width), width)+ANSI_RESET)
+	lines = append(lines, "\x1b[1m"+truncateVisible(tuiTableHeaders[t.view], width)+ANSI_RESET)
+
+	if t.selected < t.tableOffset {
+		t.tableOffset = t.selected
+	}
+	if t.selected >= t.tableOffset+tableHeight {
+		t.tableOffset = t.selected - tableHeight + 1
+	}
+	for row := 0; row < tableHeight; row++ {
+		i := t.tableOffset + row
+		if i >= len(rows) {
+			lines = append(lines, "")
+			continue
+		}
+		line := truncateVisible(stripAnsi(sanitizeLine(rows[i].text)), width)
+		if i == t.selected {
+			marker := "\x1b[7m"
+			if t.detailFocus {
+				marker = "\x1b[4m"
+			}
+			line = marker + padRight(line, width) + ANSI_RESET
+		}
+		lines = append(lines, line)
+	}
+
+	title := " output "
+	var output []string
+	if t.selected < len(rows) {
+		title, output = rows[t.selected].title, rows[t.selected].detail
+		if t.view == viewRuns && rows[t.selected].id == t.tailRunId && t.tailOutput != nil {
+			title = fmt.Sprintf(" output of run %v ", t.tailRunId)
+			output = strings.Split(strings.TrimRight(string(t.tailOutput), "\n"), "\n")
+			if !t.detailFocus {
+				// the output is followed until the pane is scrolled
+				t.detailOffset = len(output)
+			}
+		}
+	}
+	lines = append(lines, strings.Repeat("─", 2)+title+strings.Repeat("─", max(0, width-2-len([]rune(title)))))
+	t.detailOffset = min(t.detailOffset, max(0, len(output)-detailHeight))
+	for row := 0; row < detailHeight; row++ {
+		i := t.detailOffset + row
+		if i >= len(output) {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, truncateVisible(sanitizeLine(output[i]), width))
+	}
+
+	help := tuiHelp[t.view]
+	if t.status != "" {
+		help = " " + t.status
+	}
+	lines = append(lines, "\x1b[7m"+padRight(truncateVisible(help, width), width)+ANSI_RESET)
+	return lines
+}
+
+func padRight(line string, width int) string {
+	if n := visibleLen(line); n < width {
+		return line + strings.Repeat(" ", width-n)
+	}
+	return line
+}
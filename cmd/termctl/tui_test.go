@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+func TestTuiRows(t *testing.T) {
+	first, second := uint(1), uint(2)
+	tui := NewTui(NewClient(DEFAULT_SERVER_URL, ""), nil, nil)
+	tui.setLists([]models.Commands{
+		{Id: 1, BatchId: &first, Command: "echo a", Log: "a\n"},
+		{Id: 2, BatchId: &first, Command: "false", ExitCode: 1, IsError: true},
+		{Id: 3, BatchId: &second, Command: "echo b", Log: "b\n"},
+		{Id: 4, Command: "echo imported"},
+	}, []models.Runs{{Id: 7, Commands: []string{"sleep 60"}, Streamed: true}})
+
+	var tests = []struct {
+		testName string
+		view     tuiView
+		filter   string
+		wantIds  []uint
+	}{
+		{"commands newest first", viewCommands, "", []uint{4, 3, 2, 1}},
+		{"commands filter", viewCommands, "error", []uint{2}},
+		{"batches newest first", viewBatches, "", []uint{2, 1}},
+		{"batches with a matching command", viewBatches, "exit=1", []uint{1}},
+		{"runs", viewRuns, "", []uint{7}},
+		{"runs filter", viewRuns, "echo", []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			tui.view, tui.filter = tt.view, tt.filter
+			ids := []uint{}
+			for _, row := range tui.rows() {
+				ids = append(ids, row.id)
+			}
+			if len(ids) != len(tt.wantIds) {
+				t.Fatalf("rows() ids = %v, want %v", ids, tt.wantIds)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIds[i] {
+					t.Fatalf("rows() ids = %v, want %v", ids, tt.wantIds)
+				}
+			}
+		})
+	}
+
+	// the batch lists its commands in the order they ran
+	tui.view, tui.filter = viewBatches, ""
+	detail := tui.rows()[1].detail
+	if len(detail) != 3 || detail[0] != "\x1b[1m#1 exit 0: echo a\x1b[0m" || detail[1] != "a" {
+		t.Errorf("unexpected batch detail %q", detail)
+	}
+}
@@ -0,0 +1,223 @@
+package cron
+
+import (
+	// std
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	second bits
+	minute bits
+	hour   bits
+	dom    bits
+	month  bits
+	dow    bits
+	// a restricted day of month or day of week field, with both restricted a
+	// day matches when either of them matches as in Vixie cron
+	domStar bool
+	dowStar bool
+}
+
+// bits is a set of allowed values of a field.
+type bits uint64
+
+func (b bits) has(value int) bool {
+	return b&(1<<uint(value)) != 0
+}
+
+type field struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	secondField = field{min: 0, max: 59}
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is sunday as well as 0
+	dowField = field{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression with five fields (minute hour day-of-month
+// month day-of-week) or six fields with seconds in front. Fields support *,
+// lists, ranges, steps and month and weekday names, the usual @daily style
+// macros are accepted too.
+func Parse(expression string) (*Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := macros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron expression %q must have 5 or 6 fields", expression)
+	}
+
+	schedule := &Schedule{
+		domStar: fields[3] == "*" || fields[3] == "?",
+		dowStar: fields[5] == "*" || fields[5] == "?",
+	}
+	var err error
+	for i, target := range []struct {
+		bits  *bits
+		field field
+	}{
+		{&schedule.second, secondField},
+		{&schedule.minute, minuteField},
+		{&schedule.hour, hourField},
+		{&schedule.dom, domField},
+		{&schedule.month, monthField},
+		{&schedule.dow, dowField},
+	} {
+		if *target.bits, err = parseField(fields[i], target.field); err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expression, err)
+		}
+	}
+	if schedule.dow.has(7) {
+		schedule.dow |= 1
+	}
+	return schedule, nil
+}
+
+func parseField(expression string, f field) (bits, error) {
+	var result bits
+	for _, part := range strings.Split(expression, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		var low, high int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			low, high = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = f.value(lowPart); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(highPart); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if low, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+			high = low
+			// 5/15 means starting at 5 every 15
+			if hasStep {
+				high = f.max
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+		for value := low; value <= high; value += step {
+			result |= 1 << uint(value)
+		}
+	}
+	return result, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if value, ok := f.names[strings.ToLower(s)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("value %v is out of range %v-%v", value, f.min, f.max)
+	}
+	return value, nil
+}
+
+// Next returns the first activation time strictly after t, in the location
+// of t. A zero time is returned when there is none within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for !s.month.has(int(t.Month())) {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for !s.hour.has(t.Hour()) {
+		next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		// a missing hour on a DST change normalizes back to the same hour
+		if !next.After(t) {
+			next = t.Truncate(time.Hour).Add(time.Hour)
+		}
+		t = next
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for !s.minute.has(t.Minute()) {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for !s.second.has(t.Second()) {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom.has(t.Day())
+	dowMatch := s.dow.has(int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
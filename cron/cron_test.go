@@ -0,0 +1,70 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	var tests = []string{
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+	}
+	for _, expression := range tests {
+		t.Run(expression, func(t *testing.T) {
+			if _, err := Parse(expression); err == nil {
+				t.Errorf("expected an error for %q", expression)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	var tests = []struct {
+		expression string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 9, 10, 38, 18, 0, time.UTC), time.Date(2024, 5, 9, 10, 39, 0, 0, time.UTC)},
+		{"*/15 * * * * *", time.Date(2024, 5, 9, 10, 38, 18, 0, time.UTC), time.Date(2024, 5, 9, 10, 38, 30, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC), time.Date(2024, 5, 13, 9, 0, 0, 0, time.UTC)},
+		{"30 2 1 jan,jul *", time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 2, 30, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{"0 0 13 * 5", time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)},
+		// sunday as 7
+		{"0 12 * * 7", time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 12, 12, 0, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, 5, 9, 10, 0, 0, 0, moscow), time.Date(2024, 5, 10, 9, 0, 0, 0, moscow)},
+		// 02:30 doesn't exist in Berlin on 2024-03-31
+		{"30 2 * * *", time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), time.Date(2024, 4, 1, 2, 30, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			schedule, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v)\ngot %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	// std
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyColumns are the columns read by scanApiKey, in the same order.
+const apiKeyColumns = "id, name, role, prefix, subject, scopes, created_at, last_used_at, revoked_at"
+
+func scanApiKey(row pgx.Row, key *models.ApiKeys) error {
+	return row.Scan(&key.Id, &key.Name, &key.Role, &key.Prefix, &key.Subject, &key.Scopes, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+}
+
+// CreateNewApiKeyQuery stores a key by the hash of its secret part.
+func (db DB) CreateNewApiKeyQuery(key *models.ApiKeys, keyHash string, ctx context.Context) (uint, error) {
+	query := "insert into api_keys (name, role, prefix, key_hash, scopes) values ($1, $2, $3, $4, $5) returning id, created_at;"
+
+	err := db.pool.QueryRow(ctx, query, key.Name, key.Role, key.Prefix, keyHash, key.Scopes).Scan(&key.Id, &key.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert row: %w", err)
+	}
+
+	return key.Id, nil
+}
+
+// GettingListApiKeysQuery returns the keys, revoked ones included, without
+// their hashes.
+func (db DB) GettingListApiKeysQuery(ctx context.Context) (*[]models.ApiKeys, error) {
+	rows, err := db.pool.Query(ctx, "select "+apiKeyColumns+" from api_keys order by id;")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.ApiKeys{}
+	for rows.Next() {
+		key := models.ApiKeys{}
+		if err := scanApiKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return &keys, rows.Err()
+}
+
+func (db DB) RevokeApiKeyQuery(requestId uint, ctx context.Context) error {
+	tag, err := db.pool.Exec(ctx, "update api_keys set revoked_at = now() where id = $1 and revoked_at is null;", requestId)
+	if err != nil {
+		return fmt.Errorf("unable to update row: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("api key %v: %w", requestId, pgx.ErrNoRows)
+	}
+	return nil
+}
+
+// GettingApiKeyQuery returns a key by its id, revoked or not.
+func (db DB) GettingApiKeyQuery(requestId uint, ctx context.Context) (*models.ApiKeys, error) {
+	key := models.ApiKeys{}
+	if err := scanApiKey(db.pool.QueryRow(ctx, "select "+apiKeyColumns+" from api_keys where id = $1;", requestId), &key); err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	return &key, nil
+}
+
+// AuthenticateApiKeyQuery returns the key that isn't revoked with the given
+// hash and records its use. It returns nil without an error when there is no
+// such key.
+func (db DB) AuthenticateApiKeyQuery(keyHash string, ctx context.Context) (*models.ApiKeys, error) {
+	query := "update api_keys set last_used_at = now() where key_hash = $1 and revoked_at is null returning " + apiKeyColumns + ";"
+
+	key := models.ApiKeys{}
+	if err := scanApiKey(db.pool.QueryRow(ctx, query, keyHash), &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	return &key, nil
+}
+
+// UpsertTokenUserQuery stores the user of a verified token by its subject,
+// taking the name, role and scopes of the token, and records its use. The
+// stored user keeps its id, so its commands stay its own across sign ins, and
+// a revoked user stays revoked.
+func (db DB) UpsertTokenUserQuery(user *models.ApiKeys, ctx context.Context) error {
+	query := `insert into api_keys (name, role, prefix, subject, scopes, last_used_at) values ($1, $2, $3, $4, $5, now())
+		on conflict (subject) do update set name = excluded.name, role = excluded.role, scopes = excluded.scopes, last_used_at = now()
+		returning ` + apiKeyColumns + ";"
+
+	err := scanApiKey(db.pool.QueryRow(ctx, query, user.Name, user.Role, user.Prefix, user.Subject, user.Scopes), user)
+	if err != nil {
+		return fmt.Errorf("unable to upsert row: %w", err)
+	}
+	return nil
+}
+
+// GettingUsageQuery returns the number of commands of a key stored since the
+// given time and their CPU time.
+func (db DB) GettingUsageQuery(apiKeyId uint, since time.Time, ctx context.Context) (*models.Usage, error) {
+	query := "select count(*), coalesce(sum(cpu_ms), 0) from commands where api_key_id = $1 and created_at >= $2;"
+
+	usage := models.Usage{ApiKeyId: apiKeyId, Day: since}
+	if err := db.pool.QueryRow(ctx, query, apiKeyId, since).Scan(&usage.Commands, &usage.CpuMs); err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	return &usage, nil
+}
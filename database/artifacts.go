@@ -0,0 +1,77 @@
+package database
+
+import (
+	// std
+	"context"
+	"fmt"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// insertArtifacts stores the artifacts collected from the working directory
+// of a command.
+func insertArtifacts(tx pgx.Tx, commandId uint, artifacts []models.Artifacts, ctx context.Context) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	query := `insert into artifacts (command_id, path, size_bytes, sha256, skipped)
+		values ($1, $2, $3, nullif($4, ''), nullif($5, ''));`
+
+	batch := &pgx.Batch{}
+	for _, artifact := range artifacts {
+		batch.Queue(query, commandId, artifact.Path, artifact.SizeBytes, artifact.Sha256, artifact.Skipped)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("unable to insert artifacts: %w", err)
+	}
+	return nil
+}
+
+// GettingCommandArtifactsQuery returns the artifacts of a command by path.
+func (db DB) GettingCommandArtifactsQuery(commandId uint, apiKeyId *uint, ctx context.Context) (*[]models.Artifacts, error) {
+	query := `select a.id, a.command_id, a.path, a.size_bytes, coalesce(a.sha256, ''), coalesce(a.skipped, ''), a.created_at
+		from artifacts a join commands c on c.id = a.command_id
+		where a.command_id = $1 and ` + visibleToKey("c.api_key_id", "$2") + ` order by a.path;`
+
+	rows, err := db.pool.Query(ctx, query, commandId, apiKeyId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	artifacts := []models.Artifacts{}
+	for rows.Next() {
+		artifact := models.Artifacts{}
+		err := rows.Scan(&artifact.Id, &artifact.CommandId, &artifact.Path, &artifact.SizeBytes, &artifact.Sha256,
+			&artifact.Skipped, &artifact.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	return &artifacts, rows.Err()
+}
+
+// GettingArtifactChecksumsQuery returns the checksums of the stored files
+// some artifact refers to.
+func (db DB) GettingArtifactChecksumsQuery(ctx context.Context) (map[string]bool, error) {
+	rows, err := db.pool.Query(ctx, "select distinct sha256 from artifacts where sha256 is not null;")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	checksums := map[string]bool{}
+	for rows.Next() {
+		var checksum string
+		if err := rows.Scan(&checksum); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		checksums[checksum] = true
+	}
+
+	return checksums, rows.Err()
+}
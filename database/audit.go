@@ -0,0 +1,121 @@
+package database
+
+import (
+	// std
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// AUDIT_LOG_LOCK is the key of the advisory lock that orders the appends to
+// the hash chain of the audit log.
+const AUDIT_LOG_LOCK int64 = 0x61756469
+
+// auditEntryColumns are the columns read by scanAuditEntry, in the same order.
+const auditEntryColumns = "id, created_at, api_key_id, actor, source_ip, action, target, status, request_hash, prev_hash, hash"
+
+func scanAuditEntry(row pgx.Row, entry *models.AuditEntries) error {
+	return row.Scan(&entry.Id, &entry.CreatedAt, &entry.ApiKeyId, &entry.Actor, &entry.SourceIp, &entry.Action,
+		&entry.Target, &entry.Status, &entry.RequestHash, &entry.PrevHash, &entry.Hash)
+}
+
+// AppendAuditEntryQuery links the entry to the newest one and stores it, its
+// id, previous hash and hash are set.
+func (db DB) AppendAuditEntryQuery(entry *models.AuditEntries, ctx context.Context) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "select pg_advisory_xact_lock($1);", AUDIT_LOG_LOCK); err != nil {
+		return fmt.Errorf("unable to lock audit log: %w", err)
+	}
+	err = tx.QueryRow(ctx, "select hash from audit_log order by id desc limit 1;").Scan(&entry.PrevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("unable to query: %w", err)
+	}
+	entry.Hash = entry.ChainHash()
+
+	query := `insert into audit_log (created_at, api_key_id, actor, source_ip, action, target, status, request_hash, prev_hash, hash)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) returning id;`
+	err = tx.QueryRow(ctx, query, entry.CreatedAt, entry.ApiKeyId, entry.Actor, entry.SourceIp, entry.Action, entry.Target,
+		entry.Status, entry.RequestHash, entry.PrevHash, entry.Hash).Scan(&entry.Id)
+	if err != nil {
+		return fmt.Errorf("unable to insert row: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GettingAuditLogQuery returns the entries of the filter, the newest first.
+func (db DB) GettingAuditLogQuery(filter models.AuditFilter, ctx context.Context) (*[]models.AuditEntries, error) {
+	conditions := []string{"true"}
+	parameters := []any{}
+	addCondition := func(condition string, parameter any) {
+		parameters = append(parameters, parameter)
+		conditions = append(conditions, fmt.Sprintf(condition, len(parameters)))
+	}
+	if filter.ApiKeyId != nil {
+		addCondition("api_key_id = $%v", *filter.ApiKeyId)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%v", filter.Action)
+	}
+	if filter.Since != nil {
+		addCondition("created_at >= $%v", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("created_at < $%v", *filter.Until)
+	}
+	if filter.BeforeId != nil {
+		addCondition("id < $%v", *filter.BeforeId)
+	}
+	parameters = append(parameters, filter.Limit)
+	query := fmt.Sprintf("select %v from audit_log where %v order by id desc limit $%v;",
+		auditEntryColumns, strings.Join(conditions, " and "), len(parameters))
+
+	rows, err := db.pool.Query(ctx, query, parameters...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntries{}
+	for rows.Next() {
+		entry := models.AuditEntries{}
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return &entries, rows.Err()
+}
+
+// WalkAuditLogQuery calls walk with every entry, the oldest first, and stops
+// at the first error of walk.
+func (db DB) WalkAuditLogQuery(walk func(*models.AuditEntries) error, ctx context.Context) error {
+	rows, err := db.pool.Query(ctx, "select "+auditEntryColumns+" from audit_log order by id;")
+	if err != nil {
+		return fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := models.AuditEntries{}
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return fmt.Errorf("unable to scan row: %w", err)
+		}
+		if err := walk(&entry); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
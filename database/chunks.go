@@ -0,0 +1,94 @@
+package database
+
+import (
+	// std
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// OUTPUT_CHUNK_BYTES is the size of the chunks the stream of a truncated log
+// is stored in, only the last chunk may be shorter.
+const OUTPUT_CHUNK_BYTES int = 64 << 10
+
+// chunks sent to the database at once
+const outputChunksPerBatch int = 16
+
+// insertOutputChunks stores the stream of the output file of a command in
+// chunks.
+func (db DB) insertOutputChunks(tx pgx.Tx, commandId uint, path string, ctx context.Context) (int64, error) {
+	if path == "" {
+		return 0, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read output: %w", err)
+	}
+	defer file.Close()
+
+	query := `insert into command_output_chunks (command_id, seq, data, data_key_id, sealed_data) values ($1, $2, $3, $4, $5);`
+	buf := make([]byte, OUTPUT_CHUNK_BYTES)
+	batch := &pgx.Batch{}
+	var stored int64
+	for seq := 0; ; seq++ {
+		n, readErr := io.ReadFull(file, buf)
+		if n > 0 {
+			storedChunk, err := db.sealLog(string(buf[:n]), outputChunkAdditionalData)
+			if err != nil {
+				return 0, err
+			}
+			batch.Queue(query, commandId, seq, storedChunk.log, storedChunk.keyId, storedChunk.sealed)
+			stored += int64(n)
+		}
+		done := errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF)
+		if readErr != nil && !done {
+			return 0, fmt.Errorf("unable to read output: %w", readErr)
+		}
+		if batch.Len() == outputChunksPerBatch || (done && batch.Len() != 0) {
+			if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+				return 0, fmt.Errorf("unable to insert output chunks: %w", err)
+			}
+			batch = &pgx.Batch{}
+		}
+		if done {
+			break
+		}
+	}
+
+	if _, err := tx.Exec(ctx, "update commands set stored_output_bytes = $2 where id = $1;", commandId, stored); err != nil {
+		return 0, fmt.Errorf("unable to update command: %w", err)
+	}
+	return stored, nil
+}
+
+// removeOutputFiles removes the output files of the commands once they are
+// stored, or failed to be.
+func removeOutputFiles(commands []models.CommandsWithoutID) {
+	for _, command := range commands {
+		if command.OutputFile != "" {
+			os.Remove(command.OutputFile)
+		}
+	}
+}
+
+// GettingOutputChunkQuery returns a chunk of the stored stream of a command,
+// the chunks are numbered from 0. The caller checks the command is visible.
+func (db DB) GettingOutputChunkQuery(commandId uint, seq int, ctx context.Context) ([]byte, error) {
+	stored := sealedLog{}
+	err := db.pool.QueryRow(ctx, "select data, data_key_id, sealed_data from command_output_chunks where command_id = $1 and seq = $2;",
+		commandId, seq).Scan(&stored.log, &stored.keyId, &stored.sealed)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query output chunk %v: %w", seq, err)
+	}
+	chunk, err := db.openLog(stored, outputChunkAdditionalData, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(chunk), nil
+}
@@ -5,10 +5,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 	"path/filepath"
 	// local
 	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
 	// web
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -21,16 +23,125 @@ import (
 type DBWorker interface {
 	Ping(context.Context) error
 	Close()
-	CreateNewCommandsQuery([]models.CommandsWithoutID, context.Context) error
-	GettingListCommandsQuery(context.Context) (*[]models.Commands, error)
-	GettingSingleCommandQuery(uint, context.Context) (*models.Commands, error)
+	CreateNewCommandsQuery([]models.CommandsWithoutID, models.NewBatch, context.Context) (uint, error)
+	// the *uint of the command, batch, schedule and workflow queries is the
+	// api key whose rows are visible, nil for all rows
+	GettingListCommandsQuery(models.CommandFilter, context.Context) (*[]models.Commands, error)
+	GettingSingleCommandQuery(uint, *uint, context.Context) (*models.Commands, error)
+	GettingOutputChunkQuery(uint, int, context.Context) ([]byte, error)
+	GettingCommandAttemptsQuery(uint, *uint, context.Context) (*[]models.CommandAttempts, error)
+	GettingBatchQuery(uint, *uint, context.Context) (*models.Batches, error)
+	CreateNewScheduleQuery(*models.Schedules, context.Context) (uint, error)
+	GettingListSchedulesQuery(*uint, context.Context) (*[]models.Schedules, error)
+	GettingSingleScheduleQuery(uint, *uint, context.Context) (*models.Schedules, error)
+	GettingScheduleBatchesQuery(uint, *uint, context.Context) (*[]models.Batches, error)
+	SetSchedulePausedQuery(uint, *uint, bool, time.Time, context.Context) error
+	DeleteScheduleQuery(uint, *uint, context.Context) error
+	GettingDueSchedulesQuery(time.Time, context.Context) (*[]models.Schedules, error)
+	ClaimScheduleRunQuery(uint, time.Time, time.Time, context.Context) (bool, error)
+	CreateNewWorkflowQuery(*models.Workflows, context.Context) (uint, error)
+	UpdateWorkflowStepQuery(*models.WorkflowSteps, *models.CommandsWithoutID, context.Context) error
+	FinishWorkflowQuery(uint, string, context.Context) error
+	GettingListWorkflowsQuery(*uint, context.Context) (*[]models.Workflows, error)
+	GettingSingleWorkflowQuery(uint, *uint, context.Context) (*models.Workflows, error)
+	CreateNewScriptVersionQuery(*models.Scripts, context.Context) (uint, error)
+	GettingListScriptsQuery(context.Context) (*[]models.Scripts, error)
+	GettingScriptQuery(string, int, context.Context) (*models.Scripts, error)
+	GettingScriptVersionsQuery(string, context.Context) (*[]models.Scripts, error)
+	CreateNewWebhookQuery(*models.Webhooks, context.Context) (uint, error)
+	GettingListWebhooksQuery(context.Context) (*[]models.Webhooks, error)
+	DeleteWebhookQuery(uint, context.Context) error
+	GettingWebhookDeliveriesQuery(uint, context.Context) (*[]models.WebhookDeliveries, error)
+	ClaimWebhookDeliveriesQuery(time.Time, int, context.Context) (*[]models.WebhookDeliveries, error)
+	UpdateWebhookDeliveryQuery(*models.WebhookDeliveries, context.Context) error
+	CreateNewApiKeyQuery(*models.ApiKeys, string, context.Context) (uint, error)
+	GettingListApiKeysQuery(context.Context) (*[]models.ApiKeys, error)
+	GettingApiKeyQuery(uint, context.Context) (*models.ApiKeys, error)
+	RevokeApiKeyQuery(uint, context.Context) error
+	AuthenticateApiKeyQuery(string, context.Context) (*models.ApiKeys, error)
+	UpsertTokenUserQuery(*models.ApiKeys, context.Context) error
+	GettingListRolePoliciesQuery(context.Context) (*[]models.RolePolicies, error)
+	GettingRolePolicyQuery(string, context.Context) (*models.RolePolicies, error)
+	UpdateRolePolicyQuery(*models.RolePolicies, context.Context) error
+	GettingUsageQuery(uint, time.Time, context.Context) (*models.Usage, error)
+	AppendAuditEntryQuery(*models.AuditEntries, context.Context) error
+	GettingAuditLogQuery(models.AuditFilter, context.Context) (*[]models.AuditEntries, error)
+	WalkAuditLogQuery(func(*models.AuditEntries) error, context.Context) error
+	SaveSecretQuery(*models.Secrets, string, context.Context) error
+	GettingListSecretsQuery(context.Context) (*[]models.Secrets, error)
+	DeleteSecretQuery(string, context.Context) error
+	GettingSecretValuesQuery([]string, context.Context) (map[string]string, error)
+	RotateOutputKeyQuery(time.Duration, context.Context) (bool, error)
+	ReencryptOutputQuery(int, context.Context) (int, error)
+	GettingExpiredCommandsQuery(models.Retention, int, context.Context) (*[]models.Commands, error)
+	DeleteCommandsQuery([]uint, context.Context) (int, error)
+	DeleteCommandQuery(uint, *uint, context.Context) error
+	WalkCommandsQuery(models.CommandFilter, func(*models.ExportedCommands) error, context.Context) error
+	ImportCommandsQuery(func() (*models.ExportedCommands, error), *uint, context.Context) (models.ImportSummary, error)
+	GettingCommandArtifactsQuery(uint, *uint, context.Context) (*[]models.Artifacts, error)
+	GettingArtifactChecksumsQuery(context.Context) (map[string]bool, error)
 }
 
 type DB struct {
 	pool *pgxpool.Pool
+	// seals the values of secrets, secrets can't be used without it
+	secretsBox *secrets.Box
+	// seals the logs of commands, nil when they are stored in clear
+	output *outputKeys
 }
 
-func ConnectToDB(databaseUrl string, numerAttemptToConnect uint) (DBWorker, error) {
+// Option configures the DB returned by ConnectToDB.
+type Option func(*DB)
+
+// WithSecretsBox sets the box the values of secrets are sealed with.
+func WithSecretsBox(box *secrets.Box) Option {
+	return func(db *DB) {
+		db.secretsBox = box
+	}
+}
+
+// commandColumns are the columns read by scanCommand, in the same order.
+const commandColumns = "id, batch_id, rerun_of, command, is_error, exit_code, duration_ms, cpu_ms, log, assertions, assertions_passed, " +
+	"coalesce(template, ''), parameters, coalesce(attempts, 0), coalesce(retry_summary, ''), timed_out, api_key_id, truncated, output_bytes, content_type, created_at, log_key_id, sealed_log, " +
+	"options, options_key_id, sealed_options, stored_output_bytes"
+
+// batchColumns are the columns read by scanBatch, in the same order.
+const batchColumns = "id, rerun_of, schedule_id, scheduled_for, script_id, created_at, coalesce(verdict, ''), coalesce(skipped, '')"
+
+func scanBatch(row pgx.Row, batch *models.Batches) error {
+	return row.Scan(&batch.Id, &batch.RerunOf, &batch.ScheduleId, &batch.ScheduledFor, &batch.ScriptId, &batch.CreatedAt, &batch.Verdict,
+		&batch.Skipped)
+}
+
+// scanCommand scans a command and opens its log if it is sealed, the extra
+// destinations are scanned from the columns after commandColumns.
+func (db DB) scanCommand(row pgx.Row, command *models.Commands, ctx context.Context, extra ...any) error {
+	stored, storedOptions := sealedLog{}, sealedLog{}
+	dest := []any{&command.Id, &command.BatchId, &command.RerunOf, &command.Command, &command.IsError,
+		&command.ExitCode, &command.DurationMs, &command.CpuMs, &stored.log, &command.Assertions, &command.AssertionsPassed,
+		&command.Template, &command.Parameters, &command.Attempts, &command.RetrySummary, &command.TimedOut, &command.ApiKeyId,
+		&command.Truncated, &command.OutputBytes, &command.ContentType, &command.CreatedAt, &stored.keyId, &stored.sealed,
+		&storedOptions.log, &storedOptions.keyId, &storedOptions.sealed, &command.StoredOutputBytes}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
+	}
+	command.Log, err = db.openLog(stored, commandLogAdditionalData, ctx)
+	if err != nil {
+		return err
+	}
+	// the commands stored before the options were kept have none
+	if storedOptions.log != nil || storedOptions.keyId != nil {
+		options, err := db.openLog(storedOptions, commandOptionsAdditionalData, ctx)
+		if err != nil {
+			return fmt.Errorf("unable to open options: %w", err)
+		}
+		command.Options = []byte(options)
+	}
+	return nil
+}
+
+func ConnectToDB(databaseUrl string, numerAttemptToConnect uint, options ...Option) (DBWorker, error) {
 	var err error
 	var pool *pgxpool.Pool
 	for range numerAttemptToConnect {
@@ -50,7 +161,16 @@ func ConnectToDB(databaseUrl string, numerAttemptToConnect uint) (DBWorker, erro
 			return nil, fmt.Errorf("Couldn't up migrations")
 		}
 
-		return DB{pool: pool}, nil
+		db := DB{pool: pool}
+		for _, option := range options {
+			option(&db)
+		}
+		if db.output != nil {
+			if err := db.loadOutputKeys(context.Background()); err != nil {
+				return nil, fmt.Errorf("unable to load output keys: %w", err)
+			}
+		}
+		return db, nil
 	}
 	return nil, fmt.Errorf("Unable to create connection pool %v", err)
 }
@@ -63,48 +183,188 @@ func (db DB) Close() {
 	db.pool.Close()
 }
 
-func (db DB) CreateNewCommandsQuery(commands []models.CommandsWithoutID, ctx context.Context) error {
-	query := "insert into commands (command, is_error, log) values ($1, $2, $3);"
+// CreateNewCommandsQuery stores the commands as a new batch and returns its id.
+func (db DB) CreateNewCommandsQuery(commands []models.CommandsWithoutID, newBatch models.NewBatch, ctx context.Context) (uint, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	defer removeOutputFiles(commands)
 
-	// if _, err := db.pool.Exec(ctx, query, command.Command, command.Log); err != nil {
-	// 	return fmt.Errorf("unable to insert row: %w", err)
-	// }
+	stored := models.Batches{
+		RerunOf: newBatch.RerunOf,
+		ScheduleId: newBatch.ScheduleId,
+		ScheduledFor: newBatch.ScheduledFor,
+		ScriptId: newBatch.ScriptId,
+		Verdict: models.BatchVerdict(commands),
+		Skipped: newBatch.Skipped,
+		Commands: make([]models.Commands, len(commands)),
+	}
+	err = tx.QueryRow(ctx, `insert into batches (rerun_of, schedule_id, scheduled_for, script_id, verdict, skipped)
+		values ($1, $2, $3, $4, nullif($5, ''), nullif($6, '')) returning id, created_at;`,
+		newBatch.RerunOf, newBatch.ScheduleId, newBatch.ScheduledFor, newBatch.ScriptId, stored.Verdict, stored.Skipped).Scan(&stored.Id, &stored.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert batch: %w", err)
+	}
 
 	batch := &pgx.Batch{}
 	for _, command := range commands {
-		batch.Queue(query, command.Command, command.IsError, command.Log)
-	}
-
-	results := db.pool.SendBatch(ctx, batch)
-  	return results.Close()
-
-	// rows, err := db.pool.SendBatch(ctx, batch).Query()
-	// if err != nil {
-	// 	return fmt.Errorf("unable to query: %w", err)
-	// }
-	// defer rows.Close()
-
-	// indexCommands := 0
-	// for rows.Next() {
-	// 	err := rows.Scan(
-	// 		&(*commands)[indexCommands].Id, 
-	// 		&(*commands)[indexCommands].Command, 
-	// 		&(*commands)[indexCommands].IsError,
-	// 		&(*commands)[indexCommands].Log,
-	// 	)
-	// 	if err != nil {
-	// 		return fmt.Errorf("unable to values row: %w", err)
-	// 	}
-	// 	indexCommands++
-	// }
-
-	// return nil
+		storedLog, err := db.sealLog(command.Log, commandLogAdditionalData)
+		if err != nil {
+			return 0, err
+		}
+		storedOptions, err := db.sealOptions(command.Options)
+		if err != nil {
+			return 0, err
+		}
+		batch.Queue(insertCommandQuery, stored.Id, command.RerunOf, command.Command, command.IsError, command.ExitCode,
+			command.DurationMs, storedLog.log, command.Assertions, command.AssertionsPassed, command.Template, command.Parameters,
+			command.Attempts, command.RetrySummary, command.TimedOut, newBatch.ApiKeyId, command.CpuMs, storedLog.keyId, storedLog.sealed,
+			command.Truncated, command.OutputBytes, command.ContentType, storedOptions.log, storedOptions.keyId, storedOptions.sealed)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	events := make([]models.WebhookEvent, 0, len(commands)+1)
+	for i, command := range commands {
+		var commandId uint
+		if err := results.QueryRow().Scan(&commandId); err != nil {
+			results.Close()
+			return 0, fmt.Errorf("unable to insert commands: %w", err)
+		}
+		command.BatchId = stored.Id
+		stored.Commands[i] = command.WithId(commandId)
+		stored.Commands[i].ApiKeyId = newBatch.ApiKeyId
+		events = append(events, models.WebhookEvent{
+			Event: models.CommandEvent(stored.Commands[i]),
+			OccurredAt: stored.CreatedAt,
+			Command: &stored.Commands[i],
+		})
+	}
+	if err := results.Close(); err != nil {
+		return 0, fmt.Errorf("unable to insert commands: %w", err)
+	}
+	for i, command := range commands {
+		if err := db.insertCommandAttempts(tx, stored.Commands[i].Id, command.AttemptHistory, ctx); err != nil {
+			return 0, err
+		}
+		if err := insertArtifacts(tx, stored.Commands[i].Id, command.Artifacts, ctx); err != nil {
+			return 0, err
+		}
+		if stored.Commands[i].StoredOutputBytes, err = db.insertOutputChunks(tx, stored.Commands[i].Id, command.OutputFile, ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	events = append(events, models.WebhookEvent{Event: models.BatchEvent(stored), OccurredAt: stored.CreatedAt, Batch: &stored})
+	if err := enqueueWebhookEvents(tx, events, ctx); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return stored.Id, nil
+}
+
+// insertCommandQuery stores a command and returns its id.
+const insertCommandQuery = `insert into commands (batch_id, rerun_of, command, is_error, exit_code, duration_ms, log, assertions,
+	assertions_passed, template, parameters, attempts, retry_summary, timed_out, api_key_id, cpu_ms, log_key_id, sealed_log,
+	truncated, output_bytes, content_type, options, options_key_id, sealed_options)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9, nullif($10, ''), $11, nullif($12, 0), nullif($13, ''), $14, $15, $16, $17, $18, $19, $20,
+		coalesce(nullif($21, ''), 'text/plain; charset=utf-8'), $22, $23, $24)
+	returning id;`
+
+// insertCommandAttempts stores the attempts of a command with a retry policy.
+func (db DB) insertCommandAttempts(tx pgx.Tx, commandId uint, attempts []models.CommandAttempts, ctx context.Context) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+	query := `insert into command_attempts (command_id, attempt, is_error, exit_code, duration_ms, delay_ms, log, log_key_id, sealed_log)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+
+	batch := &pgx.Batch{}
+	for _, attempt := range attempts {
+		storedLog, err := db.sealLog(attempt.Log, attemptLogAdditionalData)
+		if err != nil {
+			return err
+		}
+		batch.Queue(query, commandId, attempt.Attempt, attempt.IsError, attempt.ExitCode, attempt.DurationMs, attempt.DelayMs,
+			storedLog.log, storedLog.keyId, storedLog.sealed)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("unable to insert command attempts: %w", err)
+	}
+	return nil
+}
+
+// GettingCommandAttemptsQuery returns the attempts of a command in order.
+func (db DB) GettingCommandAttemptsQuery(requestId uint, apiKeyId *uint, ctx context.Context) (*[]models.CommandAttempts, error) {
+	query := `select a.id, a.command_id, a.attempt, a.is_error, a.exit_code, a.duration_ms, a.delay_ms, a.log, a.log_key_id, a.sealed_log
+		from command_attempts a join commands c on c.id = a.command_id
+		where a.command_id = $1 and ` + visibleToKey("c.api_key_id", "$2") + ` order by a.attempt;`
+
+	rows, err := db.pool.Query(ctx, query, requestId, apiKeyId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []models.CommandAttempts{}
+	for rows.Next() {
+		attempt := models.CommandAttempts{}
+		stored := sealedLog{}
+		err := rows.Scan(&attempt.Id, &attempt.CommandId, &attempt.Attempt, &attempt.IsError, &attempt.ExitCode,
+			&attempt.DurationMs, &attempt.DelayMs, &stored.log, &stored.keyId, &stored.sealed)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		if attempt.Log, err = db.openLog(stored, attemptLogAdditionalData, ctx); err != nil {
+			return nil, fmt.Errorf("unable to open log: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return &attempts, rows.Err()
+}
+
+// visibleToKey returns the condition that the column holds the api key of the
+// parameter, true when the parameter is null.
+func visibleToKey(column string, parameter string) string {
+	return "(" + parameter + "::integer is null or " + column + " = " + parameter + ")"
+}
+
+// commandConditions returns the where clause of the columns of the commands
+// table that the filter selects, with its parameters.
+func commandConditions(filter models.CommandFilter) (string, []any) {
+	conditions := []string{"true"}
+	parameters := []any{}
+	addCondition := func(condition string, parameter any) {
+		parameters = append(parameters, parameter)
+		conditions = append(conditions, fmt.Sprintf(condition, len(parameters)))
+	}
+	if filter.ApiKeyId != nil {
+		addCondition("api_key_id = $%v", *filter.ApiKeyId)
+	}
+	if filter.Since != nil {
+		addCondition("created_at >= $%v", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("created_at < $%v", *filter.Until)
+	}
+	if filter.BatchId != nil {
+		addCondition("batch_id = $%v", *filter.BatchId)
+	}
+	if filter.IsError != nil {
+		addCondition("is_error = $%v", *filter.IsError)
+	}
+	return strings.Join(conditions, " and "), parameters
 }
 
-func (db DB) GettingListCommandsQuery(ctx context.Context) (*[]models.Commands, error) {
-	query := "select id, command, is_error, log from commands;"
+func (db DB) GettingListCommandsQuery(filter models.CommandFilter, ctx context.Context) (*[]models.Commands, error) {
+	conditions, parameters := commandConditions(filter)
+	query := "select " + commandColumns + " from commands where " + conditions + " order by id;"
 	
-	rows, err := db.pool.Query(ctx, query)
+	rows, err := db.pool.Query(ctx, query, parameters...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to query: %w", err)
 	}
@@ -113,7 +373,7 @@ func (db DB) GettingListCommandsQuery(ctx context.Context) (*[]models.Commands,
 	commands := []models.Commands{}
 	for rows.Next() {
 		command := models.Commands{}
-		err := rows.Scan(&command.Id, &command.Command, &command.IsError, &command.Log)
+		err := db.scanCommand(rows, &command, ctx)
 		if err != nil {
 		return nil, fmt.Errorf("unable to scan row: %w", err)
 		}
@@ -123,14 +383,46 @@ func (db DB) GettingListCommandsQuery(ctx context.Context) (*[]models.Commands,
 	return &commands, err
 }
 
-func (db DB) GettingSingleCommandQuery(requestId uint, ctx context.Context) (*models.Commands, error) {
-	query := "select command, log from commands where id = $1;"
+func (db DB) GettingSingleCommandQuery(requestId uint, apiKeyId *uint, ctx context.Context) (*models.Commands, error) {
+	query := "select " + commandColumns + " from commands where id = $1 and " + visibleToKey("api_key_id", "$2") + ";"
 
-	command := models.Commands{Id: requestId}
-	err := db.pool.QueryRow(ctx, query, command.Id).Scan(&command.Command, &command.Log)
+	command := models.Commands{}
+	err := db.scanCommand(db.pool.QueryRow(ctx, query, requestId, apiKeyId), &command, ctx)
 	if err != nil {
 		return nil, fmt.Errorf("unable to query: %w", err)
 	}
 
 	return &command, nil
+}
+
+// GettingBatchQuery returns a batch with its commands. A batch is submitted
+// by one key, so a batch with commands of another key isn't found.
+func (db DB) GettingBatchQuery(requestId uint, apiKeyId *uint, ctx context.Context) (*models.Batches, error) {
+	query := "select " + batchColumns + " from batches where id = $1 and ($2::integer is null or exists " +
+		"(select 1 from commands where commands.batch_id = batches.id and commands.api_key_id = $2));"
+
+	batch := models.Batches{}
+	err := scanBatch(db.pool.QueryRow(ctx, query, requestId, apiKeyId), &batch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+
+	query = "select " + commandColumns + " from commands where batch_id = $1 order by id;"
+	rows, err := db.pool.Query(ctx, query, batch.Id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	batch.Commands = []models.Commands{}
+	for rows.Next() {
+		command := models.Commands{}
+		err := db.scanCommand(rows, &command, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		batch.Commands = append(batch.Commands, command)
+	}
+
+	return &batch, rows.Err()
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package database
+
+import (
+	// std
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// WalkCommandsQuery calls walk with every command the filter selects and its
+// batch, the oldest first, and stops at the first error of walk. Only one
+// command is held in memory at a time.
+func (db DB) WalkCommandsQuery(filter models.CommandFilter, walk func(*models.ExportedCommands) error, ctx context.Context) error {
+	conditions, parameters := commandConditions(filter)
+	query := "select " + commandColumns + `, b.batch_id, b.batch_rerun_of, b.batch_created_at, coalesce(b.batch_verdict, '')
+		from commands left join (select id as batch_id, rerun_of as batch_rerun_of, created_at as batch_created_at,
+			verdict as batch_verdict from batches) b using (batch_id)
+		where ` + conditions + " order by id;"
+
+	rows, err := db.pool.Query(ctx, query, parameters...)
+	if err != nil {
+		return fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		command := models.ExportedCommands{}
+		var batchId *uint
+		batch := models.ExportedBatch{}
+		var batchCreatedAt *time.Time
+		err := db.scanCommand(rows, &command.Commands, ctx, &batchId, &batch.RerunOf, &batchCreatedAt, &batch.Verdict)
+		if err != nil {
+			return fmt.Errorf("unable to scan row: %w", err)
+		}
+		if batchId != nil {
+			batch.Id, batch.CreatedAt = *batchId, *batchCreatedAt
+			command.Batch = &batch
+		}
+		if err := walk(&command); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ImportCommandsQuery stores the commands returned by next until it returns
+// io.EOF, in one transaction. The commands and batches get new ids, the
+// commands of an exported batch are stored in one new batch and the reruns
+// refer to the new ids of commands and batches imported before them. The
+// timestamps are kept, the commands belong to the api key.
+func (db DB) ImportCommandsQuery(next func() (*models.ExportedCommands, error), apiKeyId *uint, ctx context.Context) (models.ImportSummary, error) {
+	summary := models.ImportSummary{}
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// new ids by the exported ones
+	batchIds := map[uint]uint{}
+	commandIds := map[uint]uint{}
+	for {
+		command, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return summary, err
+		}
+
+		var batchId *uint
+		if command.BatchId != nil {
+			id, ok := batchIds[*command.BatchId]
+			if !ok {
+				batch := models.ExportedBatch{Id: *command.BatchId}
+				if command.Batch != nil {
+					batch = *command.Batch
+				}
+				err := tx.QueryRow(ctx, `insert into batches (rerun_of, created_at, verdict)
+					values ($1, coalesce($2, now()), nullif($3, '')) returning id;`,
+					importedId(batchIds, batch.RerunOf), nullTime(batch.CreatedAt), batch.Verdict).Scan(&id)
+				if err != nil {
+					return summary, fmt.Errorf("unable to insert batch: %w", err)
+				}
+				batchIds[*command.BatchId] = id
+				summary.Batches++
+			}
+			batchId = &id
+		}
+
+		storedLog, err := db.sealLog(command.Log, commandLogAdditionalData)
+		if err != nil {
+			return summary, err
+		}
+		var id uint
+		err = tx.QueryRow(ctx, `insert into commands (batch_id, rerun_of, command, is_error, exit_code, duration_ms, log, assertions,
+			assertions_passed, template, parameters, attempts, retry_summary, timed_out, api_key_id, cpu_ms, log_key_id, sealed_log,
+			truncated, output_bytes, content_type, created_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, nullif($10, ''), $11, nullif($12, 0), nullif($13, ''), $14, $15, $16, $17, $18,
+				$19, $20, coalesce(nullif($21, ''), 'text/plain; charset=utf-8'), coalesce($22, now()))
+			returning id;`,
+			batchId, importedId(commandIds, command.RerunOf), command.Command, command.IsError, command.ExitCode, command.DurationMs,
+			storedLog.log, command.Assertions, command.AssertionsPassed, command.Template, command.Parameters, command.Attempts,
+			command.RetrySummary, command.TimedOut, apiKeyId, command.CpuMs, storedLog.keyId, storedLog.sealed,
+			command.Truncated, command.OutputBytes, command.ContentType, command.CreatedAt).Scan(&id)
+		if err != nil {
+			return summary, fmt.Errorf("unable to insert command %v: %w", command.Id, err)
+		}
+		commandIds[command.Id] = id
+		summary.Commands++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return summary, fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return summary, nil
+}
+
+// importedId returns the new id of an exported id, nil if it isn't imported.
+func importedId(ids map[uint]uint, exported *uint) *uint {
+	if exported == nil {
+		return nil
+	}
+	id, ok := ids[*exported]
+	if !ok {
+		return nil
+	}
+	return &id
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists exit_code integer not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table commands drop column if exists exit_code;
+-- +goose StatementEnd
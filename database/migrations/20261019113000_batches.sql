@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists batches (id serial primary key, rerun_of integer references batches (id) on delete set null, created_at timestamptz not null default now());
+alter table commands add column if not exists batch_id integer references batches (id) on delete set null;
+alter table commands add column if not exists rerun_of integer references commands (id) on delete set null;
+create index if not exists commands_batch_id_idx on commands (batch_id);
+create index if not exists commands_rerun_of_idx on commands (rerun_of);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists commands_rerun_of_idx;
+drop index if exists commands_batch_id_idx;
+alter table commands drop column if exists rerun_of;
+alter table commands drop column if exists batch_id;
+drop table if exists batches;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists duration_ms bigint not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table commands drop column if exists duration_ms;
+-- +goose StatementEnd
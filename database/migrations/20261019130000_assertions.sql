@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists assertions jsonb;
+alter table commands add column if not exists assertions_passed boolean;
+alter table batches add column if not exists verdict text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table batches drop column if exists verdict;
+alter table commands drop column if exists assertions_passed;
+alter table commands drop column if exists assertions;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists schedules (
+	id serial primary key,
+	name text not null,
+	cron_expression text not null,
+	timezone text not null default 'UTC',
+	commands jsonb not null,
+	misfire_policy text not null default 'skip',
+	paused boolean not null default false,
+	next_run_at timestamptz not null,
+	last_run_at timestamptz,
+	created_at timestamptz not null default now()
+);
+create index if not exists schedules_next_run_at_idx on schedules (next_run_at) where not paused;
+alter table batches add column if not exists schedule_id integer references schedules (id) on delete set null;
+alter table batches add column if not exists scheduled_for timestamptz;
+create index if not exists batches_schedule_id_idx on batches (schedule_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists batches_schedule_id_idx;
+alter table batches drop column if exists scheduled_for;
+alter table batches drop column if exists schedule_id;
+drop table if exists schedules;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists workflows (
+	id serial primary key,
+	name text not null,
+	status text not null,
+	definition jsonb not null,
+	created_at timestamptz not null default now(),
+	finished_at timestamptz
+);
+create table if not exists workflow_steps (
+	id serial primary key,
+	workflow_id integer not null references workflows (id) on delete cascade,
+	name text not null,
+	needs text[] not null default '{}',
+	status text not null,
+	command_id integer references commands (id) on delete set null,
+	outputs jsonb,
+	started_at timestamptz,
+	finished_at timestamptz,
+	unique (workflow_id, name)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists workflow_steps;
+drop table if exists workflows;
+-- +goose StatementEnd
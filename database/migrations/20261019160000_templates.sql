@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists template text;
+alter table commands add column if not exists parameters jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table commands drop column if exists parameters;
+alter table commands drop column if exists template;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists scripts (
+	id serial primary key,
+	name text not null,
+	version integer not null,
+	description text not null default '',
+	body text not null,
+	parameters jsonb not null default '[]',
+	created_at timestamptz not null default now(),
+	unique (name, version)
+);
+alter table batches add column if not exists script_id integer references scripts (id) on delete set null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table batches drop column if exists script_id;
+drop table if exists scripts;
+-- +goose StatementEnd
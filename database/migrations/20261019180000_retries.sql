@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists attempts integer;
+alter table commands add column if not exists retry_summary text;
+create table if not exists command_attempts (
+	id serial primary key,
+	command_id integer not null references commands (id) on delete cascade,
+	attempt integer not null,
+	is_error boolean not null,
+	exit_code integer not null,
+	duration_ms bigint not null,
+	delay_ms bigint not null,
+	log text not null,
+	unique (command_id, attempt)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists command_attempts;
+alter table commands drop column if exists retry_summary;
+alter table commands drop column if exists attempts;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists timed_out boolean not null default false;
+create table if not exists webhooks (
+	id serial primary key,
+	url text not null,
+	secret text not null,
+	events text[] not null,
+	created_at timestamptz not null default now()
+);
+create table if not exists webhook_deliveries (
+	id serial primary key,
+	webhook_id integer not null references webhooks (id) on delete cascade,
+	event text not null,
+	payload jsonb not null,
+	status text not null default 'pending',
+	attempts integer not null default 0,
+	next_attempt_at timestamptz not null default now(),
+	last_status_code integer,
+	last_error text,
+	created_at timestamptz not null default now(),
+	delivered_at timestamptz
+);
+create index if not exists webhook_deliveries_pending_idx on webhook_deliveries (next_attempt_at) where status = 'pending';
+create index if not exists webhook_deliveries_webhook_id_idx on webhook_deliveries (webhook_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists webhook_deliveries;
+drop table if exists webhooks;
+alter table commands drop column if exists timed_out;
+-- +goose StatementEnd
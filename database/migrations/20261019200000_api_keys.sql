@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists api_keys (
+	id serial primary key,
+	name text not null,
+	prefix text not null,
+	key_hash text not null unique,
+	scopes text[] not null,
+	created_at timestamptz not null default now(),
+	last_used_at timestamptz,
+	revoked_at timestamptz
+);
+alter table commands add column if not exists api_key_id integer references api_keys (id);
+alter table schedules add column if not exists api_key_id integer references api_keys (id);
+alter table workflows add column if not exists api_key_id integer references api_keys (id);
+create index if not exists commands_api_key_id_idx on commands (api_key_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table workflows drop column if exists api_key_id;
+alter table schedules drop column if exists api_key_id;
+alter table commands drop column if exists api_key_id;
+drop table if exists api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table api_keys add column if not exists role text not null default 'viewer';
+update api_keys set role = case
+	when 'admin' = any(scopes) then 'admin'
+	when 'commands:execute' = any(scopes) then 'operator'
+	else 'viewer'
+end;
+create table if not exists role_policies (
+	role text primary key,
+	allowed_binaries text[] not null default '{}',
+	own_commands_only boolean not null default false,
+	updated_at timestamptz not null default now()
+);
+insert into role_policies (role, allowed_binaries, own_commands_only) values
+	('viewer', '{}', false),
+	('operator', '{cat,date,df,du,echo,grep,head,hostname,ls,ps,pwd,sort,tail,uname,uptime,wc}', true),
+	('admin', '{}', false)
+on conflict (role) do nothing;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists role_policies;
+alter table api_keys drop column if exists role;
+-- +goose StatementEnd
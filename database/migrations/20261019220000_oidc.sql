@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table api_keys alter column key_hash drop not null;
+alter table api_keys add column if not exists subject text unique;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+delete from api_keys where key_hash is null and id not in (
+	select api_key_id from commands where api_key_id is not null
+	union select api_key_id from schedules where api_key_id is not null
+	union select api_key_id from workflows where api_key_id is not null
+);
+update api_keys set key_hash = 'revoked:' || id, revoked_at = coalesce(revoked_at, now()) where key_hash is null;
+alter table api_keys drop column if exists subject;
+alter table api_keys alter column key_hash set not null;
+-- +goose StatementEnd
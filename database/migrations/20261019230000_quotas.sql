@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists cpu_ms bigint not null default 0;
+alter table commands add column if not exists created_at timestamptz;
+update commands c set created_at = b.created_at from batches b where b.id = c.batch_id and c.created_at is null;
+alter table commands alter column created_at set default now();
+create index if not exists commands_api_key_id_created_at_idx on commands (api_key_id, created_at);
+alter table role_policies add column if not exists commands_per_day integer not null default 0;
+alter table role_policies add column if not exists cpu_seconds_per_day integer not null default 0;
+alter table role_policies add column if not exists max_concurrent_commands integer not null default 0;
+update role_policies set commands_per_day = 1000, cpu_seconds_per_day = 3600, max_concurrent_commands = 10
+	where role = 'operator';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table role_policies drop column if exists max_concurrent_commands;
+alter table role_policies drop column if exists cpu_seconds_per_day;
+alter table role_policies drop column if exists commands_per_day;
+drop index if exists commands_api_key_id_created_at_idx;
+alter table commands drop column if exists created_at;
+alter table commands drop column if exists cpu_ms;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists audit_log (
+	id bigserial primary key,
+	created_at timestamptz not null,
+	api_key_id integer references api_keys (id),
+	actor text not null,
+	source_ip text not null,
+	action text not null,
+	target text not null,
+	status integer not null,
+	request_hash text not null,
+	prev_hash text not null,
+	hash text not null unique
+);
+create index if not exists audit_log_api_key_id_idx on audit_log (api_key_id);
+create index if not exists audit_log_created_at_idx on audit_log (created_at);
+create or replace function audit_log_append_only() returns trigger as $$
+begin
+	raise exception 'audit_log is append-only';
+end;
+$$ language plpgsql;
+create trigger audit_log_append_only before update or delete or truncate on audit_log
+	for each statement execute function audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists audit_log;
+drop function if exists audit_log_append_only();
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists secrets (
+	name text primary key,
+	sealed_value bytea not null,
+	api_key_id integer references api_keys (id),
+	created_at timestamptz not null default now(),
+	updated_at timestamptz not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists secrets;
+-- +goose StatementEnd
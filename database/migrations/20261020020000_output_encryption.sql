@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists data_keys (
+	id serial primary key,
+	master_key_id text not null,
+	wrapped_key bytea not null,
+	created_at timestamptz not null default now()
+);
+
+-- a sealed log is stored in sealed_log with the id of its data key, log is
+-- empty then
+alter table commands add column if not exists log_key_id integer references data_keys (id),
+	add column if not exists sealed_log bytea;
+alter table command_attempts add column if not exists log_key_id integer references data_keys (id),
+	add column if not exists sealed_log bytea;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table command_attempts drop column if exists log_key_id, drop column if exists sealed_log;
+alter table commands drop column if exists log_key_id, drop column if exists sealed_log;
+drop table if exists data_keys;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table commands add column if not exists truncated boolean not null default false,
+	add column if not exists output_bytes bigint;
+-- the logs stored so far are whole, a sealed log is the nonce and the tag of
+-- AES-GCM longer than the log
+update commands set output_bytes = case when sealed_log is null then octet_length(log) else octet_length(sealed_log) - 28 end
+	where output_bytes is null;
+alter table commands alter column output_bytes set not null, alter column output_bytes set default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table commands drop column if exists truncated, drop column if exists output_bytes;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- output may have NUL bytes and invalid UTF-8, it is stored as it is
+alter table commands alter column log type bytea using convert_to(log, 'UTF8');
+alter table command_attempts alter column log type bytea using convert_to(log, 'UTF8');
+alter table commands add column if not exists content_type text not null default 'text/plain; charset=utf-8';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- fails on logs that aren't valid UTF-8
+alter table commands drop column if exists content_type;
+alter table command_attempts alter column log type text using convert_from(log, 'UTF8');
+alter table commands alter column log type text using convert_from(log, 'UTF8');
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists artifacts (
+	id serial primary key,
+	command_id integer not null references commands (id) on delete cascade,
+	path text not null,
+	size_bytes bigint not null,
+	-- the file in the artifact store, null when it is skipped
+	sha256 text,
+	skipped text,
+	created_at timestamptz not null default now(),
+	unique (command_id, path)
+);
+create index if not exists artifacts_sha256 on artifacts (sha256);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists artifacts;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the options a command was submitted with, replayed when it is rerun. They
+-- hold the env of the command, so they are sealed like the log.
+alter table commands add column if not exists options bytea,
+	add column if not exists options_key_id integer references data_keys (id),
+	add column if not exists sealed_options bytea;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table commands drop column if exists options, drop column if exists options_key_id, drop column if exists sealed_options;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- why a scheduled run started no commands or a workflow step didn't run its
+-- command, such as an exceeded quota of the key
+alter table batches add column if not exists skipped text;
+alter table workflow_steps add column if not exists error text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table workflow_steps drop column if exists error;
+alter table batches drop column if exists skipped;
+-- +goose StatementEnd
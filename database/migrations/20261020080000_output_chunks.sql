@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the whole stream of a truncated log, the log keeps only its head and tail.
+-- The chunks are sealed like the log.
+create table if not exists command_output_chunks (
+	id bigserial primary key,
+	command_id integer not null references commands (id) on delete cascade,
+	seq integer not null,
+	data bytea not null,
+	data_key_id integer references data_keys (id),
+	sealed_data bytea,
+	unique (command_id, seq)
+);
+alter table commands add column if not exists stored_output_bytes bigint not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists command_output_chunks;
+alter table commands drop column if exists stored_output_bytes;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the bodies of webhook requests no longer carry the logs of commands, the
+-- logs queued so far are dropped from the outbox
+update webhook_deliveries set payload = payload #- '{command,log}' where payload -> 'command' ? 'log';
+update webhook_deliveries set payload = jsonb_set(payload, '{batch,commands}',
+	(select coalesce(jsonb_agg(c - 'log' order by n), '[]'::jsonb)
+		from jsonb_array_elements(payload -> 'batch' -> 'commands') with ordinality as e(c, n)))
+	where jsonb_typeof(payload -> 'batch' -> 'commands') = 'array';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the dropped logs can't be restored
+select 1;
+-- +goose StatementEnd
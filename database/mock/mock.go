@@ -7,6 +7,7 @@ package mock_database
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Vy4cheSlave/test-task-postgres/models"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,65 @@ func (m *MockDBWorker) EXPECT() *MockDBWorkerMockRecorder {
 	return m.recorder
 }
 
+// AppendAuditEntryQuery mocks base method.
+func (m *MockDBWorker) AppendAuditEntryQuery(arg0 *models.AuditEntries, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEntryQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendAuditEntryQuery indicates an expected call of AppendAuditEntryQuery.
+func (mr *MockDBWorkerMockRecorder) AppendAuditEntryQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEntryQuery", reflect.TypeOf((*MockDBWorker)(nil).AppendAuditEntryQuery), arg0, arg1)
+}
+
+// AuthenticateApiKeyQuery mocks base method.
+func (m *MockDBWorker) AuthenticateApiKeyQuery(arg0 string, arg1 context.Context) (*models.ApiKeys, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateApiKeyQuery", arg0, arg1)
+	ret0, _ := ret[0].(*models.ApiKeys)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateApiKeyQuery indicates an expected call of AuthenticateApiKeyQuery.
+func (mr *MockDBWorkerMockRecorder) AuthenticateApiKeyQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateApiKeyQuery", reflect.TypeOf((*MockDBWorker)(nil).AuthenticateApiKeyQuery), arg0, arg1)
+}
+
+// ClaimScheduleRunQuery mocks base method.
+func (m *MockDBWorker) ClaimScheduleRunQuery(arg0 uint, arg1, arg2 time.Time, arg3 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimScheduleRunQuery", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimScheduleRunQuery indicates an expected call of ClaimScheduleRunQuery.
+func (mr *MockDBWorkerMockRecorder) ClaimScheduleRunQuery(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimScheduleRunQuery", reflect.TypeOf((*MockDBWorker)(nil).ClaimScheduleRunQuery), arg0, arg1, arg2, arg3)
+}
+
+// ClaimWebhookDeliveriesQuery mocks base method.
+func (m *MockDBWorker) ClaimWebhookDeliveriesQuery(arg0 time.Time, arg1 int, arg2 context.Context) (*[]models.WebhookDeliveries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveriesQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]models.WebhookDeliveries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveriesQuery indicates an expected call of ClaimWebhookDeliveriesQuery.
+func (mr *MockDBWorkerMockRecorder) ClaimWebhookDeliveriesQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveriesQuery", reflect.TypeOf((*MockDBWorker)(nil).ClaimWebhookDeliveriesQuery), arg0, arg1, arg2)
+}
+
 // Close mocks base method.
 func (m *MockDBWorker) Close() {
 	m.ctrl.T.Helper()
@@ -47,48 +107,599 @@ func (mr *MockDBWorkerMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDBWorker)(nil).Close))
 }
 
+// CreateNewApiKeyQuery mocks base method.
+func (m *MockDBWorker) CreateNewApiKeyQuery(arg0 *models.ApiKeys, arg1 string, arg2 context.Context) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewApiKeyQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewApiKeyQuery indicates an expected call of CreateNewApiKeyQuery.
+func (mr *MockDBWorkerMockRecorder) CreateNewApiKeyQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewApiKeyQuery", reflect.TypeOf((*MockDBWorker)(nil).CreateNewApiKeyQuery), arg0, arg1, arg2)
+}
+
 // CreateNewCommandsQuery mocks base method.
-func (m *MockDBWorker) CreateNewCommandsQuery(arg0 []models.CommandsWithoutID, arg1 context.Context) error {
+func (m *MockDBWorker) CreateNewCommandsQuery(arg0 []models.CommandsWithoutID, arg1 models.NewBatch, arg2 context.Context) (uint, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewCommandsQuery", arg0, arg1)
+	ret := m.ctrl.Call(m, "CreateNewCommandsQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewCommandsQuery indicates an expected call of CreateNewCommandsQuery.
+func (mr *MockDBWorkerMockRecorder) CreateNewCommandsQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).CreateNewCommandsQuery), arg0, arg1, arg2)
+}
+
+// CreateNewScheduleQuery mocks base method.
+func (m *MockDBWorker) CreateNewScheduleQuery(arg0 *models.Schedules, arg1 context.Context) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewScheduleQuery", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewScheduleQuery indicates an expected call of CreateNewScheduleQuery.
+func (mr *MockDBWorkerMockRecorder) CreateNewScheduleQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewScheduleQuery", reflect.TypeOf((*MockDBWorker)(nil).CreateNewScheduleQuery), arg0, arg1)
+}
+
+// CreateNewScriptVersionQuery mocks base method.
+func (m *MockDBWorker) CreateNewScriptVersionQuery(arg0 *models.Scripts, arg1 context.Context) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewScriptVersionQuery", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewScriptVersionQuery indicates an expected call of CreateNewScriptVersionQuery.
+func (mr *MockDBWorkerMockRecorder) CreateNewScriptVersionQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewScriptVersionQuery", reflect.TypeOf((*MockDBWorker)(nil).CreateNewScriptVersionQuery), arg0, arg1)
+}
+
+// CreateNewWebhookQuery mocks base method.
+func (m *MockDBWorker) CreateNewWebhookQuery(arg0 *models.Webhooks, arg1 context.Context) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewWebhookQuery", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewWebhookQuery indicates an expected call of CreateNewWebhookQuery.
+func (mr *MockDBWorkerMockRecorder) CreateNewWebhookQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewWebhookQuery", reflect.TypeOf((*MockDBWorker)(nil).CreateNewWebhookQuery), arg0, arg1)
+}
+
+// CreateNewWorkflowQuery mocks base method.
+func (m *MockDBWorker) CreateNewWorkflowQuery(arg0 *models.Workflows, arg1 context.Context) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewWorkflowQuery", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewWorkflowQuery indicates an expected call of CreateNewWorkflowQuery.
+func (mr *MockDBWorkerMockRecorder) CreateNewWorkflowQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewWorkflowQuery", reflect.TypeOf((*MockDBWorker)(nil).CreateNewWorkflowQuery), arg0, arg1)
+}
+
+// DeleteCommandQuery mocks base method.
+func (m *MockDBWorker) DeleteCommandQuery(arg0 uint, arg1 *uint, arg2 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCommandQuery", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// CreateNewCommandsQuery indicates an expected call of CreateNewCommandsQuery.
-func (mr *MockDBWorkerMockRecorder) CreateNewCommandsQuery(arg0, arg1 interface{}) *gomock.Call {
+// DeleteCommandQuery indicates an expected call of DeleteCommandQuery.
+func (mr *MockDBWorkerMockRecorder) DeleteCommandQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommandQuery", reflect.TypeOf((*MockDBWorker)(nil).DeleteCommandQuery), arg0, arg1, arg2)
+}
+
+// DeleteCommandsQuery mocks base method.
+func (m *MockDBWorker) DeleteCommandsQuery(arg0 []uint, arg1 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCommandsQuery", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCommandsQuery indicates an expected call of DeleteCommandsQuery.
+func (mr *MockDBWorkerMockRecorder) DeleteCommandsQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).DeleteCommandsQuery), arg0, arg1)
+}
+
+// DeleteScheduleQuery mocks base method.
+func (m *MockDBWorker) DeleteScheduleQuery(arg0 uint, arg1 *uint, arg2 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScheduleQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteScheduleQuery indicates an expected call of DeleteScheduleQuery.
+func (mr *MockDBWorkerMockRecorder) DeleteScheduleQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduleQuery", reflect.TypeOf((*MockDBWorker)(nil).DeleteScheduleQuery), arg0, arg1, arg2)
+}
+
+// DeleteSecretQuery mocks base method.
+func (m *MockDBWorker) DeleteSecretQuery(arg0 string, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecretQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecretQuery indicates an expected call of DeleteSecretQuery.
+func (mr *MockDBWorkerMockRecorder) DeleteSecretQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecretQuery", reflect.TypeOf((*MockDBWorker)(nil).DeleteSecretQuery), arg0, arg1)
+}
+
+// DeleteWebhookQuery mocks base method.
+func (m *MockDBWorker) DeleteWebhookQuery(arg0 uint, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookQuery indicates an expected call of DeleteWebhookQuery.
+func (mr *MockDBWorkerMockRecorder) DeleteWebhookQuery(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).CreateNewCommandsQuery), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookQuery", reflect.TypeOf((*MockDBWorker)(nil).DeleteWebhookQuery), arg0, arg1)
+}
+
+// FinishWorkflowQuery mocks base method.
+func (m *MockDBWorker) FinishWorkflowQuery(arg0 uint, arg1 string, arg2 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishWorkflowQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishWorkflowQuery indicates an expected call of FinishWorkflowQuery.
+func (mr *MockDBWorkerMockRecorder) FinishWorkflowQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishWorkflowQuery", reflect.TypeOf((*MockDBWorker)(nil).FinishWorkflowQuery), arg0, arg1, arg2)
+}
+
+// GettingApiKeyQuery mocks base method.
+func (m *MockDBWorker) GettingApiKeyQuery(arg0 uint, arg1 context.Context) (*models.ApiKeys, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingApiKeyQuery", arg0, arg1)
+	ret0, _ := ret[0].(*models.ApiKeys)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingApiKeyQuery indicates an expected call of GettingApiKeyQuery.
+func (mr *MockDBWorkerMockRecorder) GettingApiKeyQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingApiKeyQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingApiKeyQuery), arg0, arg1)
+}
+
+// GettingArtifactChecksumsQuery mocks base method.
+func (m *MockDBWorker) GettingArtifactChecksumsQuery(arg0 context.Context) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingArtifactChecksumsQuery", arg0)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingArtifactChecksumsQuery indicates an expected call of GettingArtifactChecksumsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingArtifactChecksumsQuery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingArtifactChecksumsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingArtifactChecksumsQuery), arg0)
+}
+
+// GettingAuditLogQuery mocks base method.
+func (m *MockDBWorker) GettingAuditLogQuery(arg0 models.AuditFilter, arg1 context.Context) (*[]models.AuditEntries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingAuditLogQuery", arg0, arg1)
+	ret0, _ := ret[0].(*[]models.AuditEntries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingAuditLogQuery indicates an expected call of GettingAuditLogQuery.
+func (mr *MockDBWorkerMockRecorder) GettingAuditLogQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingAuditLogQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingAuditLogQuery), arg0, arg1)
+}
+
+// GettingBatchQuery mocks base method.
+func (m *MockDBWorker) GettingBatchQuery(arg0 uint, arg1 *uint, arg2 context.Context) (*models.Batches, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingBatchQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Batches)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingBatchQuery indicates an expected call of GettingBatchQuery.
+func (mr *MockDBWorkerMockRecorder) GettingBatchQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingBatchQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingBatchQuery), arg0, arg1, arg2)
+}
+
+// GettingCommandArtifactsQuery mocks base method.
+func (m *MockDBWorker) GettingCommandArtifactsQuery(arg0 uint, arg1 *uint, arg2 context.Context) (*[]models.Artifacts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingCommandArtifactsQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]models.Artifacts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingCommandArtifactsQuery indicates an expected call of GettingCommandArtifactsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingCommandArtifactsQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingCommandArtifactsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingCommandArtifactsQuery), arg0, arg1, arg2)
+}
+
+// GettingCommandAttemptsQuery mocks base method.
+func (m *MockDBWorker) GettingCommandAttemptsQuery(arg0 uint, arg1 *uint, arg2 context.Context) (*[]models.CommandAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingCommandAttemptsQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]models.CommandAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingCommandAttemptsQuery indicates an expected call of GettingCommandAttemptsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingCommandAttemptsQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingCommandAttemptsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingCommandAttemptsQuery), arg0, arg1, arg2)
+}
+
+// GettingDueSchedulesQuery mocks base method.
+func (m *MockDBWorker) GettingDueSchedulesQuery(arg0 time.Time, arg1 context.Context) (*[]models.Schedules, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingDueSchedulesQuery", arg0, arg1)
+	ret0, _ := ret[0].(*[]models.Schedules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingDueSchedulesQuery indicates an expected call of GettingDueSchedulesQuery.
+func (mr *MockDBWorkerMockRecorder) GettingDueSchedulesQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingDueSchedulesQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingDueSchedulesQuery), arg0, arg1)
+}
+
+// GettingExpiredCommandsQuery mocks base method.
+func (m *MockDBWorker) GettingExpiredCommandsQuery(arg0 models.Retention, arg1 int, arg2 context.Context) (*[]models.Commands, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingExpiredCommandsQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]models.Commands)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingExpiredCommandsQuery indicates an expected call of GettingExpiredCommandsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingExpiredCommandsQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingExpiredCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingExpiredCommandsQuery), arg0, arg1, arg2)
+}
+
+// GettingListApiKeysQuery mocks base method.
+func (m *MockDBWorker) GettingListApiKeysQuery(arg0 context.Context) (*[]models.ApiKeys, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListApiKeysQuery", arg0)
+	ret0, _ := ret[0].(*[]models.ApiKeys)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingListApiKeysQuery indicates an expected call of GettingListApiKeysQuery.
+func (mr *MockDBWorkerMockRecorder) GettingListApiKeysQuery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListApiKeysQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListApiKeysQuery), arg0)
 }
 
 // GettingListCommandsQuery mocks base method.
-func (m *MockDBWorker) GettingListCommandsQuery(arg0 context.Context) (*[]models.Commands, error) {
+func (m *MockDBWorker) GettingListCommandsQuery(arg0 models.CommandFilter, arg1 context.Context) (*[]models.Commands, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GettingListCommandsQuery", arg0)
+	ret := m.ctrl.Call(m, "GettingListCommandsQuery", arg0, arg1)
 	ret0, _ := ret[0].(*[]models.Commands)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GettingListCommandsQuery indicates an expected call of GettingListCommandsQuery.
-func (mr *MockDBWorkerMockRecorder) GettingListCommandsQuery(arg0 interface{}) *gomock.Call {
+func (mr *MockDBWorkerMockRecorder) GettingListCommandsQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListCommandsQuery), arg0, arg1)
+}
+
+// GettingListRolePoliciesQuery mocks base method.
+func (m *MockDBWorker) GettingListRolePoliciesQuery(arg0 context.Context) (*[]models.RolePolicies, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListRolePoliciesQuery", arg0)
+	ret0, _ := ret[0].(*[]models.RolePolicies)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingListRolePoliciesQuery indicates an expected call of GettingListRolePoliciesQuery.
+func (mr *MockDBWorkerMockRecorder) GettingListRolePoliciesQuery(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListCommandsQuery), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListRolePoliciesQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListRolePoliciesQuery), arg0)
+}
+
+// GettingListSchedulesQuery mocks base method.
+func (m *MockDBWorker) GettingListSchedulesQuery(arg0 *uint, arg1 context.Context) (*[]models.Schedules, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListSchedulesQuery", arg0, arg1)
+	ret0, _ := ret[0].(*[]models.Schedules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingListSchedulesQuery indicates an expected call of GettingListSchedulesQuery.
+func (mr *MockDBWorkerMockRecorder) GettingListSchedulesQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListSchedulesQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListSchedulesQuery), arg0, arg1)
+}
+
+// GettingListScriptsQuery mocks base method.
+func (m *MockDBWorker) GettingListScriptsQuery(arg0 context.Context) (*[]models.Scripts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListScriptsQuery", arg0)
+	ret0, _ := ret[0].(*[]models.Scripts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingListScriptsQuery indicates an expected call of GettingListScriptsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingListScriptsQuery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListScriptsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListScriptsQuery), arg0)
+}
+
+// GettingListSecretsQuery mocks base method.
+func (m *MockDBWorker) GettingListSecretsQuery(arg0 context.Context) (*[]models.Secrets, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListSecretsQuery", arg0)
+	ret0, _ := ret[0].(*[]models.Secrets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingListSecretsQuery indicates an expected call of GettingListSecretsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingListSecretsQuery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListSecretsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListSecretsQuery), arg0)
+}
+
+// GettingListWebhooksQuery mocks base method.
+func (m *MockDBWorker) GettingListWebhooksQuery(arg0 context.Context) (*[]models.Webhooks, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListWebhooksQuery", arg0)
+	ret0, _ := ret[0].(*[]models.Webhooks)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingListWebhooksQuery indicates an expected call of GettingListWebhooksQuery.
+func (mr *MockDBWorkerMockRecorder) GettingListWebhooksQuery(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListWebhooksQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListWebhooksQuery), arg0)
+}
+
+// GettingListWorkflowsQuery mocks base method.
+func (m *MockDBWorker) GettingListWorkflowsQuery(arg0 *uint, arg1 context.Context) (*[]models.Workflows, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListWorkflowsQuery", arg0, arg1)
+	ret0, _ := ret[0].(*[]models.Workflows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingListWorkflowsQuery indicates an expected call of GettingListWorkflowsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingListWorkflowsQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListWorkflowsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingListWorkflowsQuery), arg0, arg1)
+}
+
+// GettingOutputChunkQuery mocks base method.
+func (m *MockDBWorker) GettingOutputChunkQuery(arg0 uint, arg1 int, arg2 context.Context) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingOutputChunkQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingOutputChunkQuery indicates an expected call of GettingOutputChunkQuery.
+func (mr *MockDBWorkerMockRecorder) GettingOutputChunkQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingOutputChunkQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingOutputChunkQuery), arg0, arg1, arg2)
+}
+
+// GettingRolePolicyQuery mocks base method.
+func (m *MockDBWorker) GettingRolePolicyQuery(arg0 string, arg1 context.Context) (*models.RolePolicies, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingRolePolicyQuery", arg0, arg1)
+	ret0, _ := ret[0].(*models.RolePolicies)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingRolePolicyQuery indicates an expected call of GettingRolePolicyQuery.
+func (mr *MockDBWorkerMockRecorder) GettingRolePolicyQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingRolePolicyQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingRolePolicyQuery), arg0, arg1)
+}
+
+// GettingScheduleBatchesQuery mocks base method.
+func (m *MockDBWorker) GettingScheduleBatchesQuery(arg0 uint, arg1 *uint, arg2 context.Context) (*[]models.Batches, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingScheduleBatchesQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*[]models.Batches)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingScheduleBatchesQuery indicates an expected call of GettingScheduleBatchesQuery.
+func (mr *MockDBWorkerMockRecorder) GettingScheduleBatchesQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingScheduleBatchesQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingScheduleBatchesQuery), arg0, arg1, arg2)
+}
+
+// GettingScriptQuery mocks base method.
+func (m *MockDBWorker) GettingScriptQuery(arg0 string, arg1 int, arg2 context.Context) (*models.Scripts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingScriptQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Scripts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingScriptQuery indicates an expected call of GettingScriptQuery.
+func (mr *MockDBWorkerMockRecorder) GettingScriptQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingScriptQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingScriptQuery), arg0, arg1, arg2)
+}
+
+// GettingScriptVersionsQuery mocks base method.
+func (m *MockDBWorker) GettingScriptVersionsQuery(arg0 string, arg1 context.Context) (*[]models.Scripts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingScriptVersionsQuery", arg0, arg1)
+	ret0, _ := ret[0].(*[]models.Scripts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingScriptVersionsQuery indicates an expected call of GettingScriptVersionsQuery.
+func (mr *MockDBWorkerMockRecorder) GettingScriptVersionsQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingScriptVersionsQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingScriptVersionsQuery), arg0, arg1)
+}
+
+// GettingSecretValuesQuery mocks base method.
+func (m *MockDBWorker) GettingSecretValuesQuery(arg0 []string, arg1 context.Context) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingSecretValuesQuery", arg0, arg1)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingSecretValuesQuery indicates an expected call of GettingSecretValuesQuery.
+func (mr *MockDBWorkerMockRecorder) GettingSecretValuesQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSecretValuesQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingSecretValuesQuery), arg0, arg1)
 }
 
 // GettingSingleCommandQuery mocks base method.
-func (m *MockDBWorker) GettingSingleCommandQuery(arg0 uint, arg1 context.Context) (*models.Commands, error) {
+func (m *MockDBWorker) GettingSingleCommandQuery(arg0 uint, arg1 *uint, arg2 context.Context) (*models.Commands, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GettingSingleCommandQuery", arg0, arg1)
+	ret := m.ctrl.Call(m, "GettingSingleCommandQuery", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*models.Commands)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GettingSingleCommandQuery indicates an expected call of GettingSingleCommandQuery.
-func (mr *MockDBWorkerMockRecorder) GettingSingleCommandQuery(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockDBWorkerMockRecorder) GettingSingleCommandQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSingleCommandQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingSingleCommandQuery), arg0, arg1, arg2)
+}
+
+// GettingSingleScheduleQuery mocks base method.
+func (m *MockDBWorker) GettingSingleScheduleQuery(arg0 uint, arg1 *uint, arg2 context.Context) (*models.Schedules, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingSingleScheduleQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Schedules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingSingleScheduleQuery indicates an expected call of GettingSingleScheduleQuery.
+func (mr *MockDBWorkerMockRecorder) GettingSingleScheduleQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSingleScheduleQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingSingleScheduleQuery), arg0, arg1, arg2)
+}
+
+// GettingSingleWorkflowQuery mocks base method.
+func (m *MockDBWorker) GettingSingleWorkflowQuery(arg0 uint, arg1 *uint, arg2 context.Context) (*models.Workflows, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingSingleWorkflowQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Workflows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingSingleWorkflowQuery indicates an expected call of GettingSingleWorkflowQuery.
+func (mr *MockDBWorkerMockRecorder) GettingSingleWorkflowQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSingleWorkflowQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingSingleWorkflowQuery), arg0, arg1, arg2)
+}
+
+// GettingUsageQuery mocks base method.
+func (m *MockDBWorker) GettingUsageQuery(arg0 uint, arg1 time.Time, arg2 context.Context) (*models.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingUsageQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingUsageQuery indicates an expected call of GettingUsageQuery.
+func (mr *MockDBWorkerMockRecorder) GettingUsageQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSingleCommandQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingSingleCommandQuery), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingUsageQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingUsageQuery), arg0, arg1, arg2)
+}
+
+// GettingWebhookDeliveriesQuery mocks base method.
+func (m *MockDBWorker) GettingWebhookDeliveriesQuery(arg0 uint, arg1 context.Context) (*[]models.WebhookDeliveries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingWebhookDeliveriesQuery", arg0, arg1)
+	ret0, _ := ret[0].(*[]models.WebhookDeliveries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GettingWebhookDeliveriesQuery indicates an expected call of GettingWebhookDeliveriesQuery.
+func (mr *MockDBWorkerMockRecorder) GettingWebhookDeliveriesQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingWebhookDeliveriesQuery", reflect.TypeOf((*MockDBWorker)(nil).GettingWebhookDeliveriesQuery), arg0, arg1)
+}
+
+// ImportCommandsQuery mocks base method.
+func (m *MockDBWorker) ImportCommandsQuery(arg0 func() (*models.ExportedCommands, error), arg1 *uint, arg2 context.Context) (models.ImportSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportCommandsQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.ImportSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportCommandsQuery indicates an expected call of ImportCommandsQuery.
+func (mr *MockDBWorkerMockRecorder) ImportCommandsQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).ImportCommandsQuery), arg0, arg1, arg2)
 }
 
 // Ping mocks base method.
@@ -104,3 +715,159 @@ func (mr *MockDBWorkerMockRecorder) Ping(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockDBWorker)(nil).Ping), arg0)
 }
+
+// ReencryptOutputQuery mocks base method.
+func (m *MockDBWorker) ReencryptOutputQuery(arg0 int, arg1 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptOutputQuery", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptOutputQuery indicates an expected call of ReencryptOutputQuery.
+func (mr *MockDBWorkerMockRecorder) ReencryptOutputQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptOutputQuery", reflect.TypeOf((*MockDBWorker)(nil).ReencryptOutputQuery), arg0, arg1)
+}
+
+// RevokeApiKeyQuery mocks base method.
+func (m *MockDBWorker) RevokeApiKeyQuery(arg0 uint, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKeyQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeApiKeyQuery indicates an expected call of RevokeApiKeyQuery.
+func (mr *MockDBWorkerMockRecorder) RevokeApiKeyQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKeyQuery", reflect.TypeOf((*MockDBWorker)(nil).RevokeApiKeyQuery), arg0, arg1)
+}
+
+// RotateOutputKeyQuery mocks base method.
+func (m *MockDBWorker) RotateOutputKeyQuery(arg0 time.Duration, arg1 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateOutputKeyQuery", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateOutputKeyQuery indicates an expected call of RotateOutputKeyQuery.
+func (mr *MockDBWorkerMockRecorder) RotateOutputKeyQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateOutputKeyQuery", reflect.TypeOf((*MockDBWorker)(nil).RotateOutputKeyQuery), arg0, arg1)
+}
+
+// SaveSecretQuery mocks base method.
+func (m *MockDBWorker) SaveSecretQuery(arg0 *models.Secrets, arg1 string, arg2 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSecretQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSecretQuery indicates an expected call of SaveSecretQuery.
+func (mr *MockDBWorkerMockRecorder) SaveSecretQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSecretQuery", reflect.TypeOf((*MockDBWorker)(nil).SaveSecretQuery), arg0, arg1, arg2)
+}
+
+// SetSchedulePausedQuery mocks base method.
+func (m *MockDBWorker) SetSchedulePausedQuery(arg0 uint, arg1 *uint, arg2 bool, arg3 time.Time, arg4 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedulePausedQuery", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSchedulePausedQuery indicates an expected call of SetSchedulePausedQuery.
+func (mr *MockDBWorkerMockRecorder) SetSchedulePausedQuery(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedulePausedQuery", reflect.TypeOf((*MockDBWorker)(nil).SetSchedulePausedQuery), arg0, arg1, arg2, arg3, arg4)
+}
+
+// UpdateRolePolicyQuery mocks base method.
+func (m *MockDBWorker) UpdateRolePolicyQuery(arg0 *models.RolePolicies, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRolePolicyQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRolePolicyQuery indicates an expected call of UpdateRolePolicyQuery.
+func (mr *MockDBWorkerMockRecorder) UpdateRolePolicyQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRolePolicyQuery", reflect.TypeOf((*MockDBWorker)(nil).UpdateRolePolicyQuery), arg0, arg1)
+}
+
+// UpdateWebhookDeliveryQuery mocks base method.
+func (m *MockDBWorker) UpdateWebhookDeliveryQuery(arg0 *models.WebhookDeliveries, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDeliveryQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDeliveryQuery indicates an expected call of UpdateWebhookDeliveryQuery.
+func (mr *MockDBWorkerMockRecorder) UpdateWebhookDeliveryQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDeliveryQuery", reflect.TypeOf((*MockDBWorker)(nil).UpdateWebhookDeliveryQuery), arg0, arg1)
+}
+
+// UpdateWorkflowStepQuery mocks base method.
+func (m *MockDBWorker) UpdateWorkflowStepQuery(arg0 *models.WorkflowSteps, arg1 *models.CommandsWithoutID, arg2 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWorkflowStepQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWorkflowStepQuery indicates an expected call of UpdateWorkflowStepQuery.
+func (mr *MockDBWorkerMockRecorder) UpdateWorkflowStepQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWorkflowStepQuery", reflect.TypeOf((*MockDBWorker)(nil).UpdateWorkflowStepQuery), arg0, arg1, arg2)
+}
+
+// UpsertTokenUserQuery mocks base method.
+func (m *MockDBWorker) UpsertTokenUserQuery(arg0 *models.ApiKeys, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTokenUserQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertTokenUserQuery indicates an expected call of UpsertTokenUserQuery.
+func (mr *MockDBWorkerMockRecorder) UpsertTokenUserQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTokenUserQuery", reflect.TypeOf((*MockDBWorker)(nil).UpsertTokenUserQuery), arg0, arg1)
+}
+
+// WalkAuditLogQuery mocks base method.
+func (m *MockDBWorker) WalkAuditLogQuery(arg0 func(*models.AuditEntries) error, arg1 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalkAuditLogQuery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalkAuditLogQuery indicates an expected call of WalkAuditLogQuery.
+func (mr *MockDBWorkerMockRecorder) WalkAuditLogQuery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalkAuditLogQuery", reflect.TypeOf((*MockDBWorker)(nil).WalkAuditLogQuery), arg0, arg1)
+}
+
+// WalkCommandsQuery mocks base method.
+func (m *MockDBWorker) WalkCommandsQuery(arg0 models.CommandFilter, arg1 func(*models.ExportedCommands) error, arg2 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WalkCommandsQuery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WalkCommandsQuery indicates an expected call of WalkCommandsQuery.
+func (mr *MockDBWorkerMockRecorder) WalkCommandsQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalkCommandsQuery", reflect.TypeOf((*MockDBWorker)(nil).WalkCommandsQuery), arg0, arg1, arg2)
+}
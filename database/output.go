@@ -0,0 +1,331 @@
+package database
+
+import (
+	// std
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// the logs are sealed with the table as additional data, a sealed log can't
+// be moved from an attempt to a command
+var (
+	commandLogAdditionalData     = []byte("commands.log")
+	attemptLogAdditionalData     = []byte("command_attempts.log")
+	commandOptionsAdditionalData = []byte("commands.options")
+	outputChunkAdditionalData    = []byte("command_output_chunks.data")
+)
+
+// outputKeys envelope-encrypts the logs of commands: a log is sealed with a
+// data key, the data keys are stored wrapped by the master key. It is shared
+// by the copies of DB.
+type outputKeys struct {
+	master   *secrets.MasterKey
+	previous []*secrets.MasterKey
+
+	mutex sync.RWMutex
+	// id of the data key new logs are sealed with
+	currentId int
+	// unwrapped data keys by their ids
+	boxes map[int]*secrets.Box
+}
+
+// WithOutputKeys enables the encryption of the logs of commands with data
+// keys wrapped by master. Data keys wrapped by the previous master keys are
+// wrapped by master on connect.
+func WithOutputKeys(master *secrets.MasterKey, previous ...*secrets.MasterKey) Option {
+	return func(db *DB) {
+		if master != nil {
+			db.output = &outputKeys{master: master, previous: previous, boxes: map[int]*secrets.Box{}}
+		}
+	}
+}
+
+// sealedLog is a log as it is stored, either in clear or sealed with a data
+// key. The log column is bytea, a log may be any bytes.
+type sealedLog struct {
+	log    []byte
+	keyId  *int
+	sealed []byte
+}
+
+func (db DB) checkOutputKeys() error {
+	if db.output == nil {
+		return fmt.Errorf("output key isn't configured")
+	}
+	return nil
+}
+
+// sealLog returns the log as it is stored, in clear when the output isn't
+// encrypted.
+func (db DB) sealLog(log string, additionalData []byte) (sealedLog, error) {
+	if db.output == nil {
+		return sealedLog{log: []byte(log)}, nil
+	}
+	db.output.mutex.RLock()
+	keyId := db.output.currentId
+	box := db.output.boxes[keyId]
+	db.output.mutex.RUnlock()
+
+	sealed, err := box.Seal([]byte(log), additionalData)
+	if err != nil {
+		return sealedLog{}, err
+	}
+	// the log column isn't null
+	return sealedLog{log: []byte{}, keyId: &keyId, sealed: sealed}, nil
+}
+
+// sealOptions returns the options of a command as they are stored like a
+// log, null when the command has none.
+func (db DB) sealOptions(options []byte) (sealedLog, error) {
+	if len(options) == 0 {
+		return sealedLog{}, nil
+	}
+	return db.sealLog(string(options), commandOptionsAdditionalData)
+}
+
+// openLog returns the log in clear.
+func (db DB) openLog(stored sealedLog, additionalData []byte, ctx context.Context) (string, error) {
+	if stored.keyId == nil {
+		return string(stored.log), nil
+	}
+	box, err := db.dataKey(*stored.keyId, ctx)
+	if err != nil {
+		return "", err
+	}
+	log, err := box.Open(stored.sealed, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("data key %v: %w", *stored.keyId, err)
+	}
+	return string(log), nil
+}
+
+// dataKey returns the box of a data key, the keys added by other instances
+// are read on demand.
+func (db DB) dataKey(id int, ctx context.Context) (*secrets.Box, error) {
+	if err := db.checkOutputKeys(); err != nil {
+		return nil, err
+	}
+	db.output.mutex.RLock()
+	box, ok := db.output.boxes[id]
+	db.output.mutex.RUnlock()
+	if ok {
+		return box, nil
+	}
+
+	var masterKeyId string
+	var wrapped []byte
+	err := db.pool.QueryRow(ctx, "select master_key_id, wrapped_key from data_keys where id = $1;", id).Scan(&masterKeyId, &wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("data key %v: %w", id, err)
+	}
+	if box, err = db.output.unwrap(masterKeyId, wrapped); err != nil {
+		return nil, fmt.Errorf("data key %v: %w", id, err)
+	}
+	db.output.mutex.Lock()
+	db.output.boxes[id] = box
+	db.output.mutex.Unlock()
+	return box, nil
+}
+
+// unwrap opens a data key wrapped by the current or a previous master key.
+func (keys *outputKeys) unwrap(masterKeyId string, wrapped []byte) (*secrets.Box, error) {
+	for _, master := range append([]*secrets.MasterKey{keys.master}, keys.previous...) {
+		if master.Id != masterKeyId {
+			continue
+		}
+		key, err := master.Unwrap(wrapped)
+		if err != nil {
+			return nil, err
+		}
+		return secrets.NewBox(key)
+	}
+	return nil, fmt.Errorf("master key %v isn't configured", masterKeyId)
+}
+
+// loadOutputKeys wraps the data keys of the previous master keys by the
+// current one and reads the newest data key, a data key is created if there
+// is none.
+func (db DB) loadOutputKeys(ctx context.Context) error {
+	for _, previous := range db.output.previous {
+		rows, err := db.pool.Query(ctx, "select id, wrapped_key from data_keys where master_key_id = $1;", previous.Id)
+		if err != nil {
+			return fmt.Errorf("unable to query: %w", err)
+		}
+		rewrapped := map[int][]byte{}
+		for rows.Next() {
+			var id int
+			var wrapped []byte
+			if err := rows.Scan(&id, &wrapped); err != nil {
+				rows.Close()
+				return fmt.Errorf("unable to scan row: %w", err)
+			}
+			key, err := previous.Unwrap(wrapped)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("data key %v: %w", id, err)
+			}
+			if rewrapped[id], err = db.output.master.Wrap(key); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for id, wrapped := range rewrapped {
+			_, err := db.pool.Exec(ctx, "update data_keys set master_key_id = $2, wrapped_key = $3 where id = $1;",
+				id, db.output.master.Id, wrapped)
+			if err != nil {
+				return fmt.Errorf("unable to update data key %v: %w", id, err)
+			}
+		}
+	}
+
+	_, err := db.RotateOutputKeyQuery(0, ctx)
+	return err
+}
+
+// RotateOutputKeyQuery reads the newest data key and creates a new one when
+// there is none or the newest one is older than maxAge, 0 means never. It
+// returns whether a data key is created.
+func (db DB) RotateOutputKeyQuery(maxAge time.Duration, ctx context.Context) (bool, error) {
+	if err := db.checkOutputKeys(); err != nil {
+		return false, err
+	}
+	var id int
+	var createdAt time.Time
+	err := db.pool.QueryRow(ctx, "select id, created_at from data_keys order by id desc limit 1;").Scan(&id, &createdAt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("unable to query: %w", err)
+	}
+	if err == nil && (maxAge == 0 || time.Since(createdAt) < maxAge) {
+		if _, err := db.dataKey(id, ctx); err != nil {
+			return false, err
+		}
+		db.setCurrentDataKey(id)
+		return false, nil
+	}
+
+	key, err := secrets.NewDataKey()
+	if err != nil {
+		return false, err
+	}
+	box, err := secrets.NewBox(key)
+	if err != nil {
+		return false, err
+	}
+	wrapped, err := db.output.master.Wrap(key)
+	if err != nil {
+		return false, err
+	}
+	err = db.pool.QueryRow(ctx, "insert into data_keys (master_key_id, wrapped_key) values ($1, $2) returning id;",
+		db.output.master.Id, wrapped).Scan(&id)
+	if err != nil {
+		return false, fmt.Errorf("unable to insert data key: %w", err)
+	}
+	db.output.mutex.Lock()
+	db.output.boxes[id] = box
+	db.output.mutex.Unlock()
+	db.setCurrentDataKey(id)
+	return true, nil
+}
+
+func (db DB) setCurrentDataKey(id int) {
+	db.output.mutex.Lock()
+	db.output.currentId = id
+	db.output.mutex.Unlock()
+}
+
+// ReencryptOutputQuery seals up to limit logs, options and output chunks of
+// commands that
+// are in clear or sealed with an older data key with the current one, and
+// returns how many are sealed again.
+func (db DB) ReencryptOutputQuery(limit int, ctx context.Context) (int, error) {
+	if err := db.checkOutputKeys(); err != nil {
+		return 0, err
+	}
+	reencrypted := 0
+	for _, table := range []struct {
+		name           string
+		column         string
+		additionalData []byte
+	}{
+		{"commands", "log", commandLogAdditionalData},
+		{"command_attempts", "log", attemptLogAdditionalData},
+		{"commands", "options", commandOptionsAdditionalData},
+		{"command_output_chunks", "data", outputChunkAdditionalData},
+	} {
+		if reencrypted >= limit {
+			break
+		}
+		n, err := db.reencryptColumn(table.name, table.column, table.additionalData, limit-reencrypted, ctx)
+		if err != nil {
+			return reencrypted, err
+		}
+		reencrypted += n
+	}
+	return reencrypted, nil
+}
+
+// reencryptColumn seals the values of column, stored with the column_key_id
+// and sealed_column columns, null values are skipped.
+func (db DB) reencryptColumn(table string, column string, additionalData []byte, limit int, ctx context.Context) (int, error) {
+	db.output.mutex.RLock()
+	currentId := db.output.currentId
+	db.output.mutex.RUnlock()
+
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf(`select id, %[2]v, %[2]v_key_id, sealed_%[2]v from %[1]v
+		where %[2]v is not null and %[2]v_key_id is distinct from $1 order by id limit $2 for update skip locked;`, table, column)
+	rows, err := tx.Query(ctx, query, currentId, limit)
+	if err != nil {
+		return 0, fmt.Errorf("unable to query: %w", err)
+	}
+	logs := map[uint]string{}
+	for rows.Next() {
+		var id uint
+		stored := sealedLog{}
+		if err := rows.Scan(&id, &stored.log, &stored.keyId, &stored.sealed); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("unable to scan row: %w", err)
+		}
+		if logs[id], err = db.openLog(stored, additionalData, ctx); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%v %v: %w", table, id, err)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	query = fmt.Sprintf("update %[1]v set %[2]v = $2, %[2]v_key_id = $3, sealed_%[2]v = $4 where id = $1;", table, column)
+	batch := &pgx.Batch{}
+	for id, log := range logs {
+		stored, err := db.sealLog(log, additionalData)
+		if err != nil {
+			return 0, err
+		}
+		batch.Queue(query, id, stored.log, stored.keyId, stored.sealed)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, fmt.Errorf("unable to update %v: %w", table, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return len(logs), nil
+}
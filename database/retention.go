@@ -0,0 +1,117 @@
+package database
+
+import (
+	// std
+	"context"
+	"fmt"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// GettingExpiredCommandsQuery returns up to limit commands the retention
+// prunes, the oldest first. Commands stored before they had a creation time
+// count as the oldest.
+func (db DB) GettingExpiredCommandsQuery(retention models.Retention, limit int, ctx context.Context) (*[]models.Commands, error) {
+	query := `with ranked as (
+			select id, is_error, coalesce(created_at, '-infinity') as created_at,
+				row_number() over newest as position,
+				sum(octet_length(log) + coalesce(octet_length(sealed_log), 0) + stored_output_bytes) over newest as output_bytes
+			from commands window newest as (order by is_error desc, id desc)
+		)
+		select ` + commandColumns + ` from commands where id in (select id from ranked where
+			(not is_error and created_at < $1::timestamptz) or (is_error and created_at < $2::timestamptz)
+			or ($3::integer > 0 and position > $3) or ($4::bigint > 0 and output_bytes > $4))
+		order by id limit $5;`
+
+	rows, err := db.pool.Query(ctx, query, retention.SucceededBefore, retention.FailedBefore,
+		retention.MaxCommands, retention.MaxOutputBytes, limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	commands := []models.Commands{}
+	for rows.Next() {
+		command := models.Commands{}
+		if err := db.scanCommand(rows, &command, ctx); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		commands = append(commands, command)
+	}
+
+	return &commands, rows.Err()
+}
+
+// DeleteCommandsQuery deletes the commands with their attempts and the
+// batches left without commands, and returns how many commands are deleted.
+func (db DB) DeleteCommandsQuery(ids []uint, ctx context.Context) (int, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	deleted, err := deleteCommands(tx, "id = any($1)", []any{ids}, ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return deleted, nil
+}
+
+// DeleteCommandQuery deletes a command visible to the api key, see
+// DeleteCommandsQuery.
+func (db DB) DeleteCommandQuery(requestId uint, apiKeyId *uint, ctx context.Context) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	deleted, err := deleteCommands(tx, "id = $1 and "+visibleToKey("api_key_id", "$2"), []any{requestId, apiKeyId}, ctx)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return fmt.Errorf("command %v: %w", requestId, pgx.ErrNoRows)
+	}
+	return tx.Commit(ctx)
+}
+
+// deleteCommands deletes the commands of the condition and the batches they
+// leave empty. The attempts are deleted and the reruns and workflow steps of
+// the commands are unlinked by their foreign keys.
+func deleteCommands(tx pgx.Tx, condition string, parameters []any, ctx context.Context) (int, error) {
+	rows, err := tx.Query(ctx, "delete from commands where "+condition+" returning batch_id;", parameters...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete rows: %w", err)
+	}
+	batchIds := []uint{}
+	deleted := 0
+	for rows.Next() {
+		var batchId *uint
+		if err := rows.Scan(&batchId); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("unable to scan row: %w", err)
+		}
+		deleted++
+		if batchId != nil {
+			batchIds = append(batchIds, *batchId)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("unable to delete rows: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `delete from batches b where b.id = any($1)
+		and not exists (select 1 from commands c where c.batch_id = b.id);`, batchIds)
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete empty batches: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	// std
+	"context"
+	"fmt"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// rolePolicyColumns are the columns read by scanRolePolicy, in the same order.
+const rolePolicyColumns = "role, allowed_binaries, own_commands_only, commands_per_day, cpu_seconds_per_day, " +
+	"max_concurrent_commands, updated_at"
+
+func scanRolePolicy(row pgx.Row, policy *models.RolePolicies) error {
+	return row.Scan(&policy.Role, &policy.AllowedBinaries, &policy.OwnCommandsOnly, &policy.CommandsPerDay,
+		&policy.CpuSecondsPerDay, &policy.MaxConcurrentCommands, &policy.UpdatedAt)
+}
+
+func (db DB) GettingListRolePoliciesQuery(ctx context.Context) (*[]models.RolePolicies, error) {
+	rows, err := db.pool.Query(ctx, "select "+rolePolicyColumns+" from role_policies order by role;")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []models.RolePolicies{}
+	for rows.Next() {
+		policy := models.RolePolicies{}
+		if err := scanRolePolicy(rows, &policy); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return &policies, rows.Err()
+}
+
+func (db DB) GettingRolePolicyQuery(role string, ctx context.Context) (*models.RolePolicies, error) {
+	query := "select " + rolePolicyColumns + " from role_policies where role = $1;"
+
+	policy := models.RolePolicies{}
+	if err := scanRolePolicy(db.pool.QueryRow(ctx, query, role), &policy); err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	return &policy, nil
+}
+
+// UpdateRolePolicyQuery replaces the policy of a role and sets its update
+// time.
+func (db DB) UpdateRolePolicyQuery(policy *models.RolePolicies, ctx context.Context) error {
+	query := `update role_policies set allowed_binaries = $2, own_commands_only = $3, commands_per_day = $4,
+		cpu_seconds_per_day = $5, max_concurrent_commands = $6, updated_at = now()
+		where role = $1 returning updated_at;`
+
+	err := db.pool.QueryRow(ctx, query, policy.Role, policy.AllowedBinaries, policy.OwnCommandsOnly, policy.CommandsPerDay,
+		policy.CpuSecondsPerDay, policy.MaxConcurrentCommands).Scan(&policy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("unable to update row: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	// std
+	"context"
+	"fmt"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// scheduleColumns are the columns read by scanSchedule, in the same order.
+const scheduleColumns = "id, name, cron_expression, timezone, commands, misfire_policy, paused, next_run_at, last_run_at, created_at, api_key_id"
+
+func scanSchedule(row pgx.Row, schedule *models.Schedules) error {
+	return row.Scan(&schedule.Id, &schedule.Name, &schedule.CronExpression, &schedule.Timezone, &schedule.Commands,
+		&schedule.MisfirePolicy, &schedule.Paused, &schedule.NextRunAt, &schedule.LastRunAt, &schedule.CreatedAt, &schedule.ApiKeyId)
+}
+
+func (db DB) querySchedules(ctx context.Context, query string, args ...any) (*[]models.Schedules, error) {
+	rows, err := db.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedules{}
+	for rows.Next() {
+		schedule := models.Schedules{}
+		if err := scanSchedule(rows, &schedule); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return &schedules, rows.Err()
+}
+
+func (db DB) CreateNewScheduleQuery(schedule *models.Schedules, ctx context.Context) (uint, error) {
+	query := `insert into schedules (name, cron_expression, timezone, commands, misfire_policy, paused, next_run_at, api_key_id)
+		values ($1, $2, $3, $4, $5, $6, $7, $8) returning id;`
+
+	var id uint
+	err := db.pool.QueryRow(ctx, query, schedule.Name, schedule.CronExpression, schedule.Timezone, schedule.Commands,
+		schedule.MisfirePolicy, schedule.Paused, schedule.NextRunAt, schedule.ApiKeyId).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert row: %w", err)
+	}
+
+	return id, nil
+}
+
+func (db DB) GettingListSchedulesQuery(apiKeyId *uint, ctx context.Context) (*[]models.Schedules, error) {
+	return db.querySchedules(ctx, "select "+scheduleColumns+" from schedules where "+visibleToKey("api_key_id", "$1")+" order by id;", apiKeyId)
+}
+
+func (db DB) GettingSingleScheduleQuery(requestId uint, apiKeyId *uint, ctx context.Context) (*models.Schedules, error) {
+	query := "select " + scheduleColumns + " from schedules where id = $1 and " + visibleToKey("api_key_id", "$2") + ";"
+
+	schedule := models.Schedules{}
+	if err := scanSchedule(db.pool.QueryRow(ctx, query, requestId, apiKeyId), &schedule); err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+// GettingScheduleBatchesQuery returns the runs of a schedule without their
+// commands, newest first.
+func (db DB) GettingScheduleBatchesQuery(requestId uint, apiKeyId *uint, ctx context.Context) (*[]models.Batches, error) {
+	query := "select " + batchColumns + " from batches where schedule_id = (select id from schedules where id = $1 and " +
+		visibleToKey("api_key_id", "$2") + ") order by id desc;"
+
+	rows, err := db.pool.Query(ctx, query, requestId, apiKeyId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	batches := []models.Batches{}
+	for rows.Next() {
+		batch := models.Batches{}
+		if err := scanBatch(rows, &batch); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	return &batches, rows.Err()
+}
+
+// SetSchedulePausedQuery pauses or resumes a schedule, nextRunAt is the next
+// run time of a resumed schedule.
+func (db DB) SetSchedulePausedQuery(requestId uint, apiKeyId *uint, paused bool, nextRunAt time.Time, ctx context.Context) error {
+	query := "update schedules set paused = $2, next_run_at = case when $2 then next_run_at else $3 end where id = $1 and " +
+		visibleToKey("api_key_id", "$4") + ";"
+
+	tag, err := db.pool.Exec(ctx, query, requestId, paused, nextRunAt, apiKeyId)
+	if err != nil {
+		return fmt.Errorf("unable to update row: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("schedule %v: %w", requestId, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (db DB) DeleteScheduleQuery(requestId uint, apiKeyId *uint, ctx context.Context) error {
+	tag, err := db.pool.Exec(ctx, "delete from schedules where id = $1 and "+visibleToKey("api_key_id", "$2")+";", requestId, apiKeyId)
+	if err != nil {
+		return fmt.Errorf("unable to delete row: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("schedule %v: %w", requestId, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GettingDueSchedulesQuery returns the active schedules whose next run is not
+// after now.
+func (db DB) GettingDueSchedulesQuery(now time.Time, ctx context.Context) (*[]models.Schedules, error) {
+	return db.querySchedules(ctx, "select "+scheduleColumns+" from schedules where not paused and next_run_at <= $1 order by next_run_at;", now)
+}
+
+// ClaimScheduleRunQuery moves the next run of a schedule from nextRunAt to
+// newNextRunAt. It reports false when another scheduler has already claimed
+// the run, so every run is started once even with several servers.
+func (db DB) ClaimScheduleRunQuery(requestId uint, nextRunAt time.Time, newNextRunAt time.Time, ctx context.Context) (bool, error) {
+	query := "update schedules set next_run_at = $3, last_run_at = now() where id = $1 and next_run_at = $2 and not paused;"
+
+	tag, err := db.pool.Exec(ctx, query, requestId, nextRunAt, newNextRunAt)
+	if err != nil {
+		return false, fmt.Errorf("unable to update row: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
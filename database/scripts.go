@@ -0,0 +1,77 @@
+package database
+
+import (
+	// std
+	"context"
+	"fmt"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// scriptColumns are the columns read by scanScript, in the same order.
+const scriptColumns = "id, name, version, description, body, parameters, created_at"
+
+func scanScript(row pgx.Row, script *models.Scripts) error {
+	return row.Scan(&script.Id, &script.Name, &script.Version, &script.Description, &script.Body, &script.Parameters,
+		&script.CreatedAt)
+}
+
+func (db DB) queryScripts(ctx context.Context, query string, args ...any) (*[]models.Scripts, error) {
+	rows, err := db.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	scripts := []models.Scripts{}
+	for rows.Next() {
+		script := models.Scripts{}
+		if err := scanScript(rows, &script); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		scripts = append(scripts, script)
+	}
+
+	return &scripts, rows.Err()
+}
+
+// CreateNewScriptVersionQuery stores the script as the next version of the
+// script with its name and sets the id, version and creation time.
+func (db DB) CreateNewScriptVersionQuery(script *models.Scripts, ctx context.Context) (uint, error) {
+	query := `insert into scripts (name, version, description, body, parameters)
+		select $1, coalesce(max(version), 0) + 1, $2, $3, $4 from scripts where name = $1
+		returning id, version, created_at;`
+
+	err := db.pool.QueryRow(ctx, query, script.Name, script.Description, script.Body, script.Parameters).
+		Scan(&script.Id, &script.Version, &script.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert row: %w", err)
+	}
+
+	return script.Id, nil
+}
+
+// GettingListScriptsQuery returns the latest version of every script.
+func (db DB) GettingListScriptsQuery(ctx context.Context) (*[]models.Scripts, error) {
+	return db.queryScripts(ctx, "select distinct on (name) "+scriptColumns+" from scripts order by name, version desc;")
+}
+
+// GettingScriptQuery returns a version of a script, the latest one when the
+// version is 0.
+func (db DB) GettingScriptQuery(name string, version int, ctx context.Context) (*models.Scripts, error) {
+	query := "select " + scriptColumns + " from scripts where name = $1 and ($2 = 0 or version = $2) order by version desc limit 1;"
+
+	script := models.Scripts{}
+	if err := scanScript(db.pool.QueryRow(ctx, query, name, version), &script); err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+
+	return &script, nil
+}
+
+// GettingScriptVersionsQuery returns every version of a script, newest first.
+func (db DB) GettingScriptVersionsQuery(name string, ctx context.Context) (*[]models.Scripts, error) {
+	return db.queryScripts(ctx, "select "+scriptColumns+" from scripts where name = $1 order by version desc;", name)
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	// std
+	"context"
+	"fmt"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// secretColumns are the columns read by scanSecret, in the same order.
+const secretColumns = "name, api_key_id, created_at, updated_at"
+
+func scanSecret(row pgx.Row, secret *models.Secrets) error {
+	return row.Scan(&secret.Name, &secret.ApiKeyId, &secret.CreatedAt, &secret.UpdatedAt)
+}
+
+func (db DB) checkSecretsBox() error {
+	if db.secretsBox == nil {
+		return fmt.Errorf("secrets key isn't configured")
+	}
+	return nil
+}
+
+// SaveSecretQuery seals the value and stores it, replacing the value of the
+// secret with the same name. The name is authenticated with the value, a
+// sealed value copied to another secret can't be opened.
+func (db DB) SaveSecretQuery(secret *models.Secrets, value string, ctx context.Context) error {
+	if err := db.checkSecretsBox(); err != nil {
+		return err
+	}
+	sealed, err := db.secretsBox.Seal([]byte(value), []byte(secret.Name))
+	if err != nil {
+		return err
+	}
+
+	query := `insert into secrets (name, sealed_value, api_key_id) values ($1, $2, $3)
+		on conflict (name) do update set sealed_value = excluded.sealed_value, api_key_id = excluded.api_key_id, updated_at = now()
+		returning ` + secretColumns + ";"
+	if err := scanSecret(db.pool.QueryRow(ctx, query, secret.Name, sealed, secret.ApiKeyId), secret); err != nil {
+		return fmt.Errorf("unable to upsert row: %w", err)
+	}
+	return nil
+}
+
+func (db DB) GettingListSecretsQuery(ctx context.Context) (*[]models.Secrets, error) {
+	rows, err := db.pool.Query(ctx, "select "+secretColumns+" from secrets order by name;")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []models.Secrets{}
+	for rows.Next() {
+		secret := models.Secrets{}
+		if err := scanSecret(rows, &secret); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return &secrets, rows.Err()
+}
+
+func (db DB) DeleteSecretQuery(name string, ctx context.Context) error {
+	tag, err := db.pool.Exec(ctx, "delete from secrets where name = $1;", name)
+	if err != nil {
+		return fmt.Errorf("unable to delete row: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("secret %v: %w", name, pgx.ErrNoRows)
+	}
+	return nil
+}
+
+// GettingSecretValuesQuery returns the opened values of the secrets by their
+// names, every secret has to exist.
+func (db DB) GettingSecretValuesQuery(names []string, ctx context.Context) (map[string]string, error) {
+	if err := db.checkSecretsBox(); err != nil {
+		return nil, err
+	}
+	rows, err := db.pool.Query(ctx, "select name, sealed_value from secrets where name = any($1);", names)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	values := map[string]string{}
+	for rows.Next() {
+		var name string
+		var sealed []byte
+		if err := rows.Scan(&name, &sealed); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		value, err := db.secretsBox.Open(sealed, []byte(name))
+		if err != nil {
+			return nil, fmt.Errorf("secret %v: %w", name, err)
+		}
+		values[name] = string(value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("secret %v: %w", name, pgx.ErrNoRows)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	// std
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// WEBHOOK_DELIVERY_LEASE is how long a claimed delivery is hidden from the
+// other claims, a delivery that isn't updated in time is claimed again.
+const WEBHOOK_DELIVERY_LEASE time.Duration = time.Minute
+
+// webhookDeliveryColumns are the columns read by scanWebhookDelivery, in the
+// same order.
+const webhookDeliveryColumns = "id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, " +
+	"coalesce(last_error, ''), created_at, delivered_at"
+
+func scanWebhookDelivery(row pgx.Row, delivery *models.WebhookDeliveries, extra ...any) error {
+	return row.Scan(append([]any{&delivery.Id, &delivery.WebhookId, &delivery.Event, &delivery.Payload, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt,
+		&delivery.DeliveredAt}, extra...)...)
+}
+
+// webhookCommand is a command in the body of a webhook request. Its log isn't
+// sent: it may be large, binary or have NUL bytes jsonb can't store, and it
+// would sit unsealed in the outbox. The receiver reads it by OutputUrl.
+type webhookCommand struct {
+	models.Commands
+	// shadows the log of the command, it is never set
+	Log       *string `json:"log,omitempty"`
+	OutputUrl string  `json:"output_url"`
+}
+
+type webhookBatch struct {
+	models.Batches
+	Commands []webhookCommand `json:"commands"`
+}
+
+type webhookPayload struct {
+	Event      string          `json:"event"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Command    *webhookCommand `json:"command,omitempty"`
+	Batch      *webhookBatch   `json:"batch,omitempty"`
+}
+
+func newWebhookCommand(command models.Commands) webhookCommand {
+	return webhookCommand{Commands: command, OutputUrl: fmt.Sprintf("/bash/commands/%v/output", command.Id)}
+}
+
+// marshalWebhookEvent returns the body of the deliveries of an event, the
+// commands go without their logs.
+func marshalWebhookEvent(event models.WebhookEvent) ([]byte, error) {
+	payload := webhookPayload{Event: event.Event, OccurredAt: event.OccurredAt}
+	if event.Command != nil {
+		command := newWebhookCommand(*event.Command)
+		payload.Command = &command
+	}
+	if event.Batch != nil {
+		payload.Batch = &webhookBatch{Batches: *event.Batch, Commands: make([]webhookCommand, len(event.Batch.Commands))}
+		for i, command := range event.Batch.Commands {
+			payload.Batch.Commands[i] = newWebhookCommand(command)
+		}
+	}
+	return json.Marshal(payload)
+}
+
+// enqueueWebhookEvents adds a delivery of every event to the outbox of each
+// webhook subscribed to it, in the transaction that stores what the event
+// is about.
+func enqueueWebhookEvents(tx pgx.Tx, events []models.WebhookEvent, ctx context.Context) error {
+	query := `insert into webhook_deliveries (webhook_id, event, payload)
+		select id, $1, $2 from webhooks where $1 = any(events) or '*' = any(events);`
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		payload, err := marshalWebhookEvent(event)
+		if err != nil {
+			return fmt.Errorf("json marshal error: %w", err)
+		}
+		batch.Queue(query, event.Event, payload)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("unable to insert webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (db DB) CreateNewWebhookQuery(webhook *models.Webhooks, ctx context.Context) (uint, error) {
+	query := "insert into webhooks (url, secret, events) values ($1, $2, $3) returning id, created_at;"
+
+	err := db.pool.QueryRow(ctx, query, webhook.Url, webhook.Secret, webhook.Events).Scan(&webhook.Id, &webhook.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert row: %w", err)
+	}
+
+	return webhook.Id, nil
+}
+
+// GettingListWebhooksQuery returns the webhooks without their secrets.
+func (db DB) GettingListWebhooksQuery(ctx context.Context) (*[]models.Webhooks, error) {
+	rows, err := db.pool.Query(ctx, "select id, url, events, created_at from webhooks order by id;")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhooks{}
+	for rows.Next() {
+		webhook := models.Webhooks{}
+		if err := rows.Scan(&webhook.Id, &webhook.Url, &webhook.Events, &webhook.CreatedAt); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return &webhooks, rows.Err()
+}
+
+func (db DB) DeleteWebhookQuery(requestId uint, ctx context.Context) error {
+	tag, err := db.pool.Exec(ctx, "delete from webhooks where id = $1;", requestId)
+	if err != nil {
+		return fmt.Errorf("unable to delete row: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("webhook %v not found", requestId)
+	}
+	return nil
+}
+
+// GettingWebhookDeliveriesQuery returns the deliveries of a webhook, newest
+// first.
+func (db DB) GettingWebhookDeliveriesQuery(requestId uint, ctx context.Context) (*[]models.WebhookDeliveries, error) {
+	query := "select " + webhookDeliveryColumns + " from webhook_deliveries where webhook_id = $1 order by id desc;"
+
+	rows, err := db.pool.Query(ctx, query, requestId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDeliveries{}
+	for rows.Next() {
+		delivery := models.WebhookDeliveries{}
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return &deliveries, rows.Err()
+}
+
+// ClaimWebhookDeliveriesQuery returns up to limit pending deliveries that are
+// due and leases them for WEBHOOK_DELIVERY_LEASE.
+func (db DB) ClaimWebhookDeliveriesQuery(now time.Time, limit int, ctx context.Context) (*[]models.WebhookDeliveries, error) {
+	query := `update webhook_deliveries d set next_attempt_at = $3
+		from webhooks w
+		where w.id = d.webhook_id and d.id in (
+			select id from webhook_deliveries where status = 'pending' and next_attempt_at <= $1
+			order by next_attempt_at limit $2 for update skip locked
+		)
+		returning d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_status_code,
+			coalesce(d.last_error, ''), d.created_at, d.delivered_at, w.url, w.secret;`
+
+	rows, err := db.pool.Query(ctx, query, now, limit, now.Add(WEBHOOK_DELIVERY_LEASE))
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDeliveries{}
+	for rows.Next() {
+		delivery := models.WebhookDeliveries{}
+		if err := scanWebhookDelivery(rows, &delivery, &delivery.Url, &delivery.Secret); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return &deliveries, rows.Err()
+}
+
+// UpdateWebhookDeliveryQuery stores the outcome of a delivery attempt.
+func (db DB) UpdateWebhookDeliveryQuery(delivery *models.WebhookDeliveries, ctx context.Context) error {
+	query := `update webhook_deliveries set status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5,
+		last_error = nullif($6, ''), delivered_at = $7 where id = $1;`
+
+	_, err := db.pool.Exec(ctx, query, delivery.Id, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("unable to update row: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+func TestMarshalWebhookEvent(t *testing.T) {
+	command := models.Commands{Id: 7, Command: "printf 'a\\0b'", Log: "a\x00b", ContentType: "application/octet-stream"}
+	events := []models.WebhookEvent{
+		{Event: models.EVENT_COMMAND_SUCCEEDED, OccurredAt: time.Now(), Command: &command},
+		{Event: models.EVENT_BATCH_SUCCEEDED, OccurredAt: time.Now(), Batch: &models.Batches{Id: 3, Commands: []models.Commands{command}}},
+	}
+
+	for _, event := range events {
+		t.Run(event.Event, func(t *testing.T) {
+			payload, err := marshalWebhookEvent(event)
+			if err != nil {
+				t.Fatalf("marshalWebhookEvent error: %v", err)
+			}
+			// jsonb can't store NUL
+			if strings.Contains(string(payload), `\u0000`) {
+				t.Errorf("the payload has a NUL byte: %s", payload)
+			}
+
+			var decoded struct {
+				Command *map[string]any `json:"command"`
+				Batch   *struct {
+					Id       uint             `json:"id"`
+					Commands []map[string]any `json:"commands"`
+				} `json:"batch"`
+			}
+			if err := json.Unmarshal(payload, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			commands := []map[string]any{}
+			if decoded.Command != nil {
+				commands = append(commands, *decoded.Command)
+			}
+			if decoded.Batch != nil {
+				if decoded.Batch.Id != 3 {
+					t.Errorf("unexpected batch %s", payload)
+				}
+				commands = append(commands, decoded.Batch.Commands...)
+			}
+			if len(commands) != 1 {
+				t.Fatalf("expected a command in the payload %s", payload)
+			}
+			if _, ok := commands[0]["log"]; ok || commands[0]["id"] != float64(7) || commands[0]["output_url"] != "/bash/commands/7/output" {
+				t.Errorf("unexpected command %v", commands[0])
+			}
+		})
+	}
+}
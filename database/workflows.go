@@ -0,0 +1,176 @@
+package database
+
+import (
+	// std
+	"context"
+	"fmt"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"github.com/jackc/pgx/v5"
+)
+
+// workflowColumns are the columns read by scanWorkflow, in the same order.
+const workflowColumns = "id, name, status, definition, created_at, finished_at, api_key_id"
+
+// workflowStepColumns are the columns read by scanWorkflowStep, in the same
+// order.
+const workflowStepColumns = "id, workflow_id, name, needs, status, command_id, outputs, started_at, finished_at, coalesce(error, '')"
+
+func scanWorkflow(row pgx.Row, workflow *models.Workflows) error {
+	return row.Scan(&workflow.Id, &workflow.Name, &workflow.Status, &workflow.Definition, &workflow.CreatedAt, &workflow.FinishedAt, &workflow.ApiKeyId)
+}
+
+func scanWorkflowStep(row pgx.Row, step *models.WorkflowSteps) error {
+	return row.Scan(&step.Id, &step.WorkflowId, &step.Name, &step.Needs, &step.Status, &step.CommandId, &step.Outputs,
+		&step.StartedAt, &step.FinishedAt, &step.Error)
+}
+
+// CreateNewWorkflowQuery stores the workflow with its steps and sets their ids.
+func (db DB) CreateNewWorkflowQuery(workflow *models.Workflows, ctx context.Context) (uint, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, "insert into workflows (name, status, definition, api_key_id) values ($1, $2, $3, $4) returning id, created_at;",
+		workflow.Name, workflow.Status, workflow.Definition, workflow.ApiKeyId).Scan(&workflow.Id, &workflow.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert workflow: %w", err)
+	}
+
+	query := "insert into workflow_steps (workflow_id, name, needs, status) values ($1, $2, $3, $4) returning id;"
+	for i := range workflow.Steps {
+		step := &workflow.Steps[i]
+		step.WorkflowId = workflow.Id
+		if err := tx.QueryRow(ctx, query, step.WorkflowId, step.Name, step.Needs, step.Status).Scan(&step.Id); err != nil {
+			return 0, fmt.Errorf("unable to insert workflow step: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return workflow.Id, nil
+}
+
+// UpdateWorkflowStepQuery stores the state of a step. A non nil command is
+// stored as the command of the step and its id is set on the step.
+func (db DB) UpdateWorkflowStepQuery(step *models.WorkflowSteps, command *models.CommandsWithoutID, ctx context.Context) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if command != nil {
+		defer removeOutputFiles([]models.CommandsWithoutID{*command})
+		// the command is recorded with the key that submitted the workflow
+		var apiKeyId *uint
+		if err := tx.QueryRow(ctx, "select api_key_id from workflows where id = $1;", step.WorkflowId).Scan(&apiKeyId); err != nil {
+			return fmt.Errorf("unable to query: %w", err)
+		}
+		storedLog, err := db.sealLog(command.Log, commandLogAdditionalData)
+		if err != nil {
+			return err
+		}
+		storedOptions, err := db.sealOptions(command.Options)
+		if err != nil {
+			return err
+		}
+		var commandId uint
+		err = tx.QueryRow(ctx, insertCommandQuery, nil, command.RerunOf, command.Command, command.IsError, command.ExitCode,
+			command.DurationMs, storedLog.log, command.Assertions, command.AssertionsPassed, command.Template, command.Parameters,
+			command.Attempts, command.RetrySummary, command.TimedOut, apiKeyId, command.CpuMs, storedLog.keyId, storedLog.sealed,
+			command.Truncated, command.OutputBytes, command.ContentType, storedOptions.log, storedOptions.keyId, storedOptions.sealed).Scan(&commandId)
+		if err != nil {
+			return fmt.Errorf("unable to insert command: %w", err)
+		}
+		if err := db.insertCommandAttempts(tx, commandId, command.AttemptHistory, ctx); err != nil {
+			return err
+		}
+		if err := insertArtifacts(tx, commandId, command.Artifacts, ctx); err != nil {
+			return err
+		}
+		storedOutputBytes, err := db.insertOutputChunks(tx, commandId, command.OutputFile, ctx)
+		if err != nil {
+			return err
+		}
+		stored := command.WithId(commandId)
+		stored.StoredOutputBytes = storedOutputBytes
+		stored.ApiKeyId = apiKeyId
+		event := models.WebhookEvent{Event: models.CommandEvent(stored), OccurredAt: time.Now(), Command: &stored}
+		if err := enqueueWebhookEvents(tx, []models.WebhookEvent{event}, ctx); err != nil {
+			return err
+		}
+		step.CommandId = &commandId
+	}
+
+	_, err = tx.Exec(ctx, `update workflow_steps set status = $2, command_id = $3, outputs = $4, started_at = $5, finished_at = $6,
+		error = nullif($7, '') where id = $1;`, step.Id, step.Status, step.CommandId, step.Outputs, step.StartedAt, step.FinishedAt, step.Error)
+	if err != nil {
+		return fmt.Errorf("unable to update workflow step: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (db DB) FinishWorkflowQuery(requestId uint, status string, ctx context.Context) error {
+	_, err := db.pool.Exec(ctx, "update workflows set status = $2, finished_at = now() where id = $1;", requestId, status)
+	if err != nil {
+		return fmt.Errorf("unable to update row: %w", err)
+	}
+	return nil
+}
+
+// GettingListWorkflowsQuery returns the workflows without their steps.
+func (db DB) GettingListWorkflowsQuery(apiKeyId *uint, ctx context.Context) (*[]models.Workflows, error) {
+	rows, err := db.pool.Query(ctx, "select "+workflowColumns+" from workflows where "+visibleToKey("api_key_id", "$1")+" order by id;", apiKeyId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	workflows := []models.Workflows{}
+	for rows.Next() {
+		workflow := models.Workflows{}
+		if err := scanWorkflow(rows, &workflow); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		workflows = append(workflows, workflow)
+	}
+
+	return &workflows, rows.Err()
+}
+
+func (db DB) GettingSingleWorkflowQuery(requestId uint, apiKeyId *uint, ctx context.Context) (*models.Workflows, error) {
+	query := "select " + workflowColumns + " from workflows where id = $1 and " + visibleToKey("api_key_id", "$2") + ";"
+
+	workflow := models.Workflows{}
+	if err := scanWorkflow(db.pool.QueryRow(ctx, query, requestId, apiKeyId), &workflow); err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+
+	query = "select " + workflowStepColumns + " from workflow_steps where workflow_id = $1 order by id;"
+	rows, err := db.pool.Query(ctx, query, workflow.Id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query: %w", err)
+	}
+	defer rows.Close()
+
+	workflow.Steps = []models.WorkflowSteps{}
+	for rows.Next() {
+		step := models.WorkflowSteps{}
+		if err := scanWorkflowStep(rows, &step); err != nil {
+			return nil, fmt.Errorf("unable to scan row: %w", err)
+		}
+		workflow.Steps = append(workflow.Steps, step)
+	}
+
+	return &workflow, rows.Err()
+}
@@ -0,0 +1,262 @@
+package diff
+
+import (
+	// std
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a single line of the edit script, aIndex and bIndex are the line
+// numbers in the old and new text.
+type op struct {
+	kind   opKind
+	aIndex int
+	bIndex int
+	line   string
+}
+
+var (
+	ansiRegexp = regexp.MustCompile(`\x1b(\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-Z\\-_])`)
+	timestampRegexps = []*regexp.Regexp{
+		// 2024-05-09T10:38:18.123+03:00, 2024-05-09 10:38:18
+		regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?`),
+		// output of date: Sat May  9 10:38:18 UTC 2024
+		regexp.MustCompile(`(Mon|Tue|Wed|Thu|Fri|Sat|Sun) (Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) +\d{1,2} \d{2}:\d{2}:\d{2}( [A-Z]{2,5})? \d{4}`),
+		// 10:38:18, 10:38:18.123
+		regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`),
+	}
+)
+
+const TIMESTAMP_PLACEHOLDER string = "<timestamp>"
+
+// MAX_EDIT_DISTANCE bounds the changed lines the shortest edit script is
+// searched for, the search keeps O(d²) state. Texts that differ more are
+// diffed as their common head and tail around one replaced block.
+const MAX_EDIT_DISTANCE int = 2000
+
+// StripAnsi removes terminal escape sequences.
+func StripAnsi(s string) string {
+	return ansiRegexp.ReplaceAllString(s, "")
+}
+
+// MaskTimestamps replaces dates and times with a placeholder so that they
+// don't show up as differences.
+func MaskTimestamps(s string) string {
+	for _, re := range timestampRegexps {
+		s = re.ReplaceAllString(s, TIMESTAMP_PLACEHOLDER)
+	}
+	return s
+}
+
+// Lines splits text into lines without the trailing newlines.
+func Lines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Unified returns the unified diff of two texts with the given number of
+// context lines, or an empty string when they are equal.
+func Unified(aName string, bName string, a string, b string, context int) string {
+	ops := editScript(Lines(a), Lines(b))
+	hunks := groupHunks(ops, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %v\n+++ %v\n", aName, bName)
+	for _, hunk := range hunks {
+		writeHunk(&out, hunk)
+	}
+	return out.String()
+}
+
+// editScript computes the edit script of two texts. The lines in common at
+// their start and end are equal, the shortest edit script of the rest is
+// computed unless it is longer than MAX_EDIT_DISTANCE, then the rest is
+// deleted and inserted as a whole.
+func editScript(a []string, b []string) []op {
+	head := 0
+	for head < len(a) && head < len(b) && a[head] == b[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(a)-head && tail < len(b)-head && a[len(a)-1-tail] == b[len(b)-1-tail] {
+		tail++
+	}
+
+	ops := make([]op, 0, len(a)+len(b)-head-tail)
+	for i := range head {
+		ops = append(ops, op{kind: opEqual, aIndex: i, bIndex: i, line: a[i]})
+	}
+	aRest, bRest := a[head:len(a)-tail], b[head:len(b)-tail]
+	rest, ok := shortestEditScript(aRest, bRest, MAX_EDIT_DISTANCE)
+	if !ok {
+		rest = rest[:0]
+		for i, line := range aRest {
+			rest = append(rest, op{kind: opDelete, aIndex: i, bIndex: 0, line: line})
+		}
+		for i, line := range bRest {
+			rest = append(rest, op{kind: opInsert, aIndex: len(aRest), bIndex: i, line: line})
+		}
+	}
+	for _, o := range rest {
+		o.aIndex += head
+		o.bIndex += head
+		ops = append(ops, o)
+	}
+	for i := range tail {
+		ops = append(ops, op{kind: opEqual, aIndex: len(a) - tail + i, bIndex: len(b) - tail + i, line: a[len(a)-tail+i]})
+	}
+	return ops
+}
+
+// shortestEditScript computes the shortest edit script with the Myers
+// algorithm, it returns false if the script is longer than maxD.
+func shortestEditScript(a []string, b []string, maxD int) ([]op, bool) {
+	n, m := len(a), len(b)
+	maxD = min(maxD, n+m)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] holds v[-d..d] after step d
+	trace := [][]int{}
+
+	done := false
+	for d := 0; d <= maxD && !done; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+	}
+	if !done {
+		return nil, false
+	}
+
+	ops := []op{}
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		get := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{kind: opEqual, aIndex: x, bIndex: y, line: a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{kind: opInsert, aIndex: x, bIndex: y, line: b[y]})
+		} else {
+			x--
+			ops = append(ops, op{kind: opDelete, aIndex: x, bIndex: y, line: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{kind: opEqual, aIndex: x, bIndex: y, line: a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, true
+}
+
+// groupHunks splits the edit script into hunks of changes surrounded by at
+// most context equal lines.
+func groupHunks(ops []op, context int) [][]op {
+	hunks := [][]op{}
+	start, end := -1, -1
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		if start >= 0 && i-end-1 > 2*context {
+			hunks = append(hunks, ops[start:min(len(ops), end+context+1)])
+			start = -1
+		}
+		if start < 0 {
+			start = max(0, i-context)
+		}
+		end = i
+	}
+	if start >= 0 {
+		hunks = append(hunks, ops[start:min(len(ops), end+context+1)])
+	}
+	return hunks
+}
+
+func writeHunk(out *strings.Builder, hunk []op) {
+	aStart, bStart := hunk[0].aIndex, hunk[0].bIndex
+	aCount, bCount := 0, 0
+	for _, o := range hunk {
+		if o.kind != opInsert {
+			aCount++
+		}
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%v +%v @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+	for _, o := range hunk {
+		switch o.kind {
+		case opEqual:
+			out.WriteString(" ")
+		case opDelete:
+			out.WriteString("-")
+		case opInsert:
+			out.WriteString("+")
+		}
+		out.WriteString(o.line)
+		out.WriteString("\n")
+	}
+}
+
+// hunkRange formats a range of a hunk header, an empty range points at the
+// line before it as diff -u does.
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%v,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%v,%v", start+1, count)
+}
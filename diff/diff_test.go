@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	var tests = []struct {
+		testName string
+		a string
+		b string
+		want string
+	}{
+		{
+			"equal",
+			"a\nb\n",
+			"a\nb\n",
+			"",
+		},
+		{
+			"changed line",
+			"a\nb\nc\n",
+			"a\nx\nc\n",
+			"--- a\n+++ b\n@@ -2 +2 @@\n-b\n+x\n",
+		},
+		{
+			"from empty",
+			"",
+			"a\nb\n",
+			"--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"to empty",
+			"a\n",
+			"",
+			"--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"--- a\n+++ b\n@@ -0,0 +1 @@\n+0\n@@ -10 +10,0 @@\n-10\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			if got := Unified("a", "b", tt.a, tt.b, 0); got != tt.want {
+				t.Errorf("Unified error\ngot %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedContext(t *testing.T) {
+	a := []string{}
+	for i := range 20 {
+		a = append(a, fmt.Sprint(i))
+	}
+	b := append([]string{}, a...)
+	b[10] = "ten"
+
+	got := Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 3)
+	want := "--- a\n+++ b\n@@ -8,7 +8,7 @@\n 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n"
+	if got != want {
+		t.Errorf("Unified error\ngot %q\nwant %q", got, want)
+	}
+}
+
+func TestUnifiedMaxEditDistance(t *testing.T) {
+	a, b := []string{"head"}, []string{"head"}
+	for i := range MAX_EDIT_DISTANCE {
+		a = append(a, fmt.Sprint("a", i))
+		b = append(b, fmt.Sprint("b", i))
+	}
+	a, b = append(a, "tail"), append(b, "tail")
+
+	// the texts differ by twice the limit, the lines between the head and
+	// the tail are replaced as a whole
+	got := Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 1)
+	var want strings.Builder
+	fmt.Fprintf(&want, "--- a\n+++ b\n@@ -1,%[1]v +1,%[1]v @@\n head\n", MAX_EDIT_DISTANCE+2)
+	for _, line := range a[1 : len(a)-1] {
+		want.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[1 : len(b)-1] {
+		want.WriteString("+" + line + "\n")
+	}
+	want.WriteString(" tail\n")
+	if got != want.String() {
+		t.Errorf("Unified error\ngot %.200q\nwant %.200q", got, want.String())
+	}
+
+	// under the limit the edit script is the shortest one
+	b = append([]string{}, a...)
+	b[1], b[len(b)-2] = "first", "last"
+	got = Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 0)
+	want.Reset()
+	fmt.Fprintf(&want, "--- a\n+++ b\n@@ -2 +2 @@\n-a0\n+first\n@@ -%[1]v +%[1]v @@\n-a%[2]v\n+last\n", MAX_EDIT_DISTANCE+1, MAX_EDIT_DISTANCE-1)
+	if got != want.String() {
+		t.Errorf("Unified error\ngot %q\nwant %q", got, want.String())
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	if got := StripAnsi("\x1b[1;31mred\x1b[0m \x1b]0;title\x07text"); got != "red text" {
+		t.Errorf("StripAnsi error: got %q", got)
+	}
+	var tests = []string{
+		"started at 2024-05-09T10:38:18.123+03:00",
+		"started at 2024-05-09 10:38:18",
+		"started at Sat May  9 10:38:18 UTC 2024",
+		"started at 10:38:18",
+	}
+	for _, input := range tests {
+		if got := MaskTimestamps(input); got != "started at " + TIMESTAMP_PLACEHOLDER {
+			t.Errorf("MaskTimestamps(%q) error: got %q", input, got)
+		}
+	}
+}
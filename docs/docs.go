@@ -18,7 +18,16 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/bash/create-command": {
+        "/bash/api-keys": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/api-keys/"
+                ],
+                "responses": {}
+            },
             "post": {
                 "consumes": [
                     "application/json"
@@ -27,23 +36,101 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "/bash/"
+                    "/bash/api-keys/"
                 ],
                 "parameters": [
                     {
-                        "description": "input bash string",
-                        "name": "new_command",
+                        "description": "name and scopes",
+                        "name": "new_api_key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_Vy4cheSlave_test-task-postgres_bash.ReqCreateNewCommandBody"
+                            "$ref": "#/definitions/handlers.ReqCreateApiKeyBody"
                         }
                     }
                 ],
                 "responses": {}
             }
         },
-        "/bash/get-commands": {
+        "/bash/api-keys/{id}": {
+            "delete": {
+                "tags": [
+                    "/bash/api-keys/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/audit": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/audit/"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "entries of the key",
+                        "name": "api_key_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "route, like POST /bash/create-command",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "entries older than this one",
+                        "name": "before_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "100 by default, 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/audit/verify": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/audit/"
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/batches/{id}": {
             "get": {
                 "produces": [
                     "application/json"
@@ -51,10 +138,30 @@ const docTemplate = `{
                 "tags": [
                     "/bash/"
                 ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "text",
+                            "base64"
+                        ],
+                        "type": "string",
+                        "description": "text or base64 of the raw bytes of the logs",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
                 "responses": {}
             }
         },
-        "/bash/get-commands/{id}": {
+        "/bash/batches/{id}/groups": {
             "get": {
                 "produces": [
                     "application/json"
@@ -70,21 +177,1829 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "name of the template parameter",
+                        "name": "by",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {}
             }
-        }
-    },
-    "definitions": {
-        "github_com_Vy4cheSlave_test-task-postgres_bash.ReqCreateNewCommandBody": {
-            "type": "object",
-            "properties": {
-                "bash_strings": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        },
+        "/bash/batches/{id}/rerun": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "rerun only the failed commands",
+                        "name": "failed_only",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "stream the output as NDJSON events",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/export": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "text/plain",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "ndjson",
+                            "csv",
+                            "tar.gz"
+                        ],
+                        "type": "string",
+                        "description": "export format, ndjson by default",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "commands created at or after this RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "commands created before this RFC 3339 time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "commands of the batch",
+                        "name": "batch_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "failed or succeeded commands only",
+                        "name": "is_error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/import": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "description": "ndjson export, one command per line",
+                        "name": "commands",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportedCommands"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportSummary"
+                        }
+                    }
+                }
+            }
+        },
+        "/bash/commands/{id}": {
+            "delete": {
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/{id}/artifacts": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Artifacts"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bash/commands/{id}/artifacts.zip": {
+            "get": {
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/{id}/artifacts/{artifactId}": {
+            "get": {
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "artifactId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/{id}/attempts": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/{id}/diff/{otherId}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "otherId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "strip terminal escape sequences before comparing",
+                        "name": "ignore_ansi",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "mask dates and times before comparing",
+                        "name": "ignore_timestamps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of context lines, 3 by default",
+                        "name": "context",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/{id}/output": {
+            "get": {
+                "produces": [
+                    "text/plain",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "first byte",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of bytes",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "bytes=start-end",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/commands/{id}/rerun": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "stream the output as NDJSON events",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/create-command": {
+            "post": {
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "description": "input bash string",
+                        "name": "new_command",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/bash.ReqCreateNewCommandBody"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "stream the output as NDJSON events",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/get-commands": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "text",
+                            "base64"
+                        ],
+                        "type": "string",
+                        "description": "text or base64 of the raw bytes of the logs",
+                        "name": "encoding",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "commands created at or after this RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "commands created before this RFC 3339 time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "commands of the batch",
+                        "name": "batch_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "failed or succeeded commands only",
+                        "name": "is_error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/get-commands/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "text",
+                            "base64"
+                        ],
+                        "type": "string",
+                        "description": "text or base64 of the raw bytes of the log",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/me/usage": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/me/"
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/roles": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/roles/"
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/roles/{role}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/roles/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "viewer, operator or admin",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "command policy",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReqUpdateRolePolicyBody"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/runs": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/runs/"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Runs"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bash/runs/{id}/cancel": {
+            "post": {
+                "tags": [
+                    "/bash/runs/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/runs/{id}/stream": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/runs/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/schedules": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/schedules/"
+                ],
+                "responses": {}
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/schedules/"
+                ],
+                "parameters": [
+                    {
+                        "description": "cron expression and commands",
+                        "name": "new_schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReqCreateScheduleBody"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/schedules/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/schedules/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "tags": [
+                    "/bash/schedules/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/schedules/{id}/batches": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/schedules/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/schedules/{id}/pause": {
+            "post": {
+                "tags": [
+                    "/bash/schedules/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/schedules/{id}/resume": {
+            "post": {
+                "tags": [
+                    "/bash/schedules/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/scripts": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/scripts/"
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/scripts/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/scripts/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "script version, the latest by default",
+                        "name": "version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/scripts/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "script body and parameters",
+                        "name": "script",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReqSaveScriptBody"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/scripts/{name}/run": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/scripts/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "version and parameters",
+                        "name": "run",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReqRunScriptBody"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "stream the output as NDJSON events",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/scripts/{name}/versions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/scripts/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "script name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/secrets": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/secrets/"
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/secrets/{name}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/secrets/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "secret name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "secret value",
+                        "name": "secret",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReqSaveSecretBody"
+                        }
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "tags": [
+                    "/bash/secrets/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "secret name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/webhooks/"
+                ],
+                "responses": {}
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/webhooks/"
+                ],
+                "parameters": [
+                    {
+                        "description": "url and events",
+                        "name": "new_webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReqCreateWebhookBody"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/webhooks/{id}": {
+            "delete": {
+                "tags": [
+                    "/bash/webhooks/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/webhooks/{id}/deliveries": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/webhooks/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/workflows": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/workflows/"
+                ],
+                "responses": {}
+            },
+            "post": {
+                "consumes": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/workflows/"
+                ],
+                "parameters": [
+                    {
+                        "description": "workflow definition in JSON or YAML",
+                        "name": "definition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workflow.Definition"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/workflows/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/workflows/"
+                ],
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "uint without 0",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/workspaces": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/workspaces/"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Workspaces"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bash/workspaces/{name}": {
+            "delete": {
+                "tags": [
+                    "/bash/workspaces/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workspace name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/workspaces/{name}/files": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/workspaces/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workspace name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WorkspaceFiles"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/bash/workspaces/{name}/files/{path}": {
+            "get": {
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "/bash/workspaces/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workspace name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "path of the file in the workspace",
+                        "name": "path",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "put": {
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "/bash/workspaces/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workspace name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "path of the file in the workspace",
+                        "name": "path",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResWorkspaceFile"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "/bash/workspaces/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workspace name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "path of the file in the workspace",
+                        "name": "path",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/bash/workspaces/{name}/snapshot": {
+            "get": {
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "/bash/workspaces/"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workspace name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        }
+    },
+    "definitions": {
+        "bash.CommandOptions": {
+            "type": "object",
+            "properties": {
+                "artifacts": {
+                    "description": "globs of the files of the working directory that are collected after\nthe command exits, declaring them implies TempDir without a workspace",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "assertions": {
+                    "$ref": "#/definitions/models.Assertions"
+                },
+                "bash_string": {
+                    "type": "string"
+                },
+                "env": {
+                    "description": "Env is exported to the command, it is stored only with the options\nreplayed by reruns",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "max_output_bytes": {
+                    "description": "bytes of each stream that are kept, the default of the server if 0",
+                    "type": "integer"
+                },
+                "retry": {
+                    "$ref": "#/definitions/models.RetryPolicy"
+                },
+                "secrets": {
+                    "description": "Secrets maps env variables to the names of the secrets whose values\nthey get, the values are masked in the output",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "temp_dir": {
+                    "description": "the command runs in a new temporary directory that is removed after it",
+                    "type": "boolean"
+                },
+                "timeout_ms": {
+                    "description": "the command is interrupted after the timeout, it covers all attempts",
+                    "type": "integer"
+                },
+                "workspace": {
+                    "description": "the command runs in the named workspace, its files are kept for the\nnext commands",
+                    "type": "string"
+                }
+            }
+        },
+        "bash.CommandTemplate": {
+            "type": "object",
+            "properties": {
+                "assertions": {
+                    "$ref": "#/definitions/models.Assertions"
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "matrix": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "retry": {
+                    "$ref": "#/definitions/models.RetryPolicy"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "bash.ReqCreateNewCommandBody": {
+            "type": "object",
+            "properties": {
+                "bash_strings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "commands": {
+                    "description": "Commands are bash strings with options, they run together with\nBashStrings and come after them in the result",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bash.CommandOptions"
+                    }
+                },
+                "templates": {
+                    "description": "Templates are expanded into a command per combination of their\nparameters, they come after Commands in the result",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bash.CommandTemplate"
+                    }
+                }
+            }
+        },
+        "handlers.ReqCreateApiKeyBody": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "viewer (default), operator or admin",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "commands:read, commands:execute or admin, all scopes of the role by\ndefault",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.ReqCreateScheduleBody": {
+            "type": "object",
+            "properties": {
+                "commands": {
+                    "$ref": "#/definitions/bash.ReqCreateNewCommandBody"
+                },
+                "cron_expression": {
+                    "description": "five fields, or six with seconds in front",
+                    "type": "string"
+                },
+                "misfire_policy": {
+                    "description": "skip (default), run_once or catch_up",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "description": "IANA time zone name, UTC by default",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ReqCreateWebhookBody": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "command.succeeded, command.failed, command.timed_out, batch.succeeded,\nbatch.failed or * for all of them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "key of the X-Webhook-Signature HMAC, generated when empty",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "http or https url that receives the events",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ReqRunScriptBody": {
+            "type": "object",
+            "properties": {
+                "assertions": {
+                    "$ref": "#/definitions/models.Assertions"
+                },
+                "parameters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "description": "0 or no version runs the latest one",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ReqSaveScriptBody": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "bash string with {{name}} placeholders of the parameters",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "parameters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScriptParameter"
+                    }
+                }
+            }
+        },
+        "handlers.ReqSaveSecretBody": {
+            "type": "object",
+            "properties": {
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ReqUpdateRolePolicyBody": {
+            "type": "object",
+            "properties": {
+                "allowed_binaries": {
+                    "description": "programs the role may run, any program when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "commands_per_day": {
+                    "description": "quotas of every key of the role, 0 is no limit",
+                    "type": "integer"
+                },
+                "cpu_seconds_per_day": {
+                    "type": "integer"
+                },
+                "max_concurrent_commands": {
+                    "type": "integer"
+                },
+                "own_commands_only": {
+                    "description": "the keys of the role see only the commands they submitted",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.ResWorkspaceFile": {
+            "type": "object",
+            "properties": {
+                "path": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Artifacts": {
+            "type": "object",
+            "properties": {
+                "command_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "path relative to the working directory, with slashes",
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "why the file isn't stored, for example a size limit",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AssertionResult": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Assertions": {
+            "type": "object",
+            "properties": {
+                "exit_code": {
+                    "type": "integer"
+                },
+                "json_path": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JsonPathAssertion"
+                    }
+                },
+                "max_duration_ms": {
+                    "type": "integer"
+                },
+                "stderr_empty": {
+                    "type": "boolean"
+                },
+                "stdout_contains": {
+                    "type": "string"
+                },
+                "stdout_regex": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportedBatch": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rerun_of": {
+                    "type": "integer"
+                },
+                "verdict": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportedCommands": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "description": "the api key that submitted the command",
+                    "type": "integer"
+                },
+                "assertions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AssertionResult"
+                    }
+                },
+                "assertions_passed": {
+                    "type": "boolean"
+                },
+                "attempts": {
+                    "description": "number of attempts of a command with a retry policy and their outcome,\nthe other fields hold the last attempt",
+                    "type": "integer"
+                },
+                "batch": {
+                    "$ref": "#/definitions/models.ExportedBatch"
+                },
+                "batch_id": {
+                    "type": "integer"
+                },
+                "command": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "description": "detected content type of the log, the log may be any bytes",
+                    "type": "string"
+                },
+                "cpu_ms": {
+                    "description": "user and system CPU time of the command and the processes it waited for",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_error": {
+                    "type": "boolean"
+                },
+                "log": {
+                    "type": "string"
+                },
+                "log_encoding": {
+                    "description": "LOG_ENCODING_BASE64 when the log is the base64 of its bytes, the log\nis UTF-8 text otherwise",
+                    "type": "string"
+                },
+                "output_bytes": {
+                    "type": "integer"
+                },
+                "parameters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "rerun_of": {
+                    "type": "integer"
+                },
+                "retry_summary": {
+                    "type": "string"
+                },
+                "stored_output_bytes": {
+                    "description": "bytes of the stream of a truncated log stored in chunks, they are\nserved by /bash/commands/{id}/output. 0 when only the log is stored.",
+                    "type": "integer"
+                },
+                "template": {
+                    "description": "the template the command was expanded from and its parameters",
+                    "type": "string"
+                },
+                "timed_out": {
+                    "description": "the command was interrupted by its timeout",
+                    "type": "boolean"
+                },
+                "truncated": {
+                    "description": "the log is the head and the tail of the stream, OutputBytes is the\nsize of the whole stream",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ImportSummary": {
+            "type": "object",
+            "properties": {
+                "batches": {
+                    "type": "integer"
+                },
+                "commands": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.JsonPathAssertion": {
+            "type": "object",
+            "properties": {
+                "equals": {},
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RetryPolicy": {
+            "type": "object",
+            "properties": {
+                "backoff": {
+                    "description": "fixed (default) or exponential",
+                    "type": "string"
+                },
+                "delay_ms": {
+                    "description": "delay before the second attempt, exponential backoff doubles it for\neach next one up to MaxDelayMs",
+                    "type": "integer"
+                },
+                "jitter": {
+                    "description": "randomizes every delay between half and all of it",
+                    "type": "boolean"
+                },
+                "max_attempts": {
+                    "description": "attempts including the first one",
+                    "type": "integer"
+                },
+                "max_delay_ms": {
+                    "type": "integer"
+                },
+                "on_exit_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "on_stderr_regex": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Runs": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "description": "the api key that submitted the commands",
+                    "type": "integer"
+                },
+                "commands": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "streamed": {
+                    "description": "the output of commands with secrets isn't streamed, it is only masked\nonce they exit",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ScriptParameter": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "used when the run doesn't set the parameter",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.WorkspaceFiles": {
+            "type": "object",
+            "properties": {
+                "link_target": {
+                    "description": "the target of a symbolic link",
+                    "type": "string"
+                },
+                "modified_at": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "path relative to the workspace, with slashes",
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Workspaces": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "description": "the key that created the workspace",
+                    "type": "integer"
+                },
+                "files": {
+                    "type": "integer"
+                },
+                "modified_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "workflow.Definition": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/workflow.Step"
+                    }
+                }
+            }
+        },
+        "workflow.Step": {
+            "type": "object",
+            "properties": {
+                "assertions": {
+                    "$ref": "#/definitions/models.Assertions"
+                },
+                "continue_on_error": {
+                    "description": "a failure of the step doesn't fail the workflow and counts as success\nfor the conditions of the next steps",
+                    "type": "boolean"
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "if": {
+                    "description": "condition on the needed steps, success() by default",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "needs": {
+                    "description": "names of the steps that have to finish first",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "retry": {
+                    "description": "the timeout covers all attempts",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetryPolicy"
+                        }
+                    ]
+                },
+                "run": {
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "type": "integer"
                 }
             }
         }
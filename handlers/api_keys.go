@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+var apiKeyScopes = map[string]bool{
+	models.SCOPE_COMMANDS_READ: true,
+	models.SCOPE_COMMANDS_EXECUTE: true,
+	models.SCOPE_ADMIN: true,
+}
+
+type ReqCreateApiKeyBody struct {
+	Name string `json:"name"`
+	// viewer (default), operator or admin
+	Role string `json:"role"`
+	// commands:read, commands:execute or admin, all scopes of the role by
+	// default
+	Scopes []string `json:"scopes"`
+}
+
+// CreateNewApiKeyHandler creates a key. The key itself is returned only in
+// this response, the server keeps its hash.
+//
+//	@Tags		/bash/api-keys/
+//	@Accept		json
+//	@Produce	json
+//	@Param		new_api_key	body	ReqCreateApiKeyBody	true	"name and scopes"
+//	@Router		/bash/api-keys [post]
+func (restApi RestApi) CreateNewApiKeyHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var inputStruct ReqCreateApiKeyBody
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		if err := json.Unmarshal(buf, &inputStruct); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("json unmarshal error: %v", err))
+			return
+		}
+
+		if inputStruct.Name == "" {
+			closeHandlerWithErr(w, fmt.Errorf("api key has no name"))
+			return
+		}
+		if inputStruct.Role == "" {
+			inputStruct.Role = models.ROLE_VIEWER
+		}
+		roleScopes, ok := models.RoleScopes[inputStruct.Role]
+		if !ok {
+			closeHandlerWithErr(w, fmt.Errorf("unknown role %q", inputStruct.Role))
+			return
+		}
+		if len(inputStruct.Scopes) == 0 {
+			inputStruct.Scopes = roleScopes
+		}
+		// a key may have fewer scopes than its role, but not more
+		role := models.ApiKeys{Scopes: roleScopes}
+		for _, scope := range inputStruct.Scopes {
+			if !apiKeyScopes[scope] {
+				closeHandlerWithErr(w, fmt.Errorf("unknown scope %q", scope))
+				return
+			}
+			if !role.HasScope(scope) {
+				closeHandlerWithErr(w, fmt.Errorf("scope %q isn't granted to role %v", scope, inputStruct.Role))
+				return
+			}
+		}
+		key, err := auth.GenerateKey()
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+
+		apiKey := models.ApiKeys{
+			Name: inputStruct.Name,
+			Role: inputStruct.Role,
+			Prefix: auth.DisplayPrefix(key),
+			Scopes: inputStruct.Scopes,
+		}
+		if _, err := db.CreateNewApiKeyQuery(&apiKey, auth.HashKey(key), context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		apiKey.Key = key
+
+		writeJsonResponse(w, http.StatusOK, apiKey)
+	}
+}
+
+//	@Tags		/bash/api-keys/
+//	@Produce	json
+//	@Router		/bash/api-keys [get]
+func (restApi RestApi) GettingListApiKeysHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := db.GettingListApiKeysQuery(context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, keys)
+	}
+}
+
+// RevokeApiKeyHandler revokes a key, the commands it submitted keep
+// referring to it.
+//
+//	@Tags		/bash/api-keys/
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/api-keys/{id} [delete]
+func (restApi RestApi) RevokeApiKeyHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := db.RevokeApiKeyQuery(pathVal, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_CreateNewApiKeyHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `new key`,
+			inputBody: `{"name": "ci", "role": "operator"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewApiKeyQuery(gomock.Any(), gomock.Any(), context.Background()).DoAndReturn(
+					func(key *models.ApiKeys, keyHash string, ctx context.Context) (uint, error) {
+						if key.Key != "" || !strings.HasPrefix(key.Prefix, auth.KEY_PREFIX) || len(keyHash) != 64 ||
+							!key.HasScope(models.SCOPE_COMMANDS_EXECUTE) {
+							t.Errorf("unexpected key %+v with hash %q", key, keyHash)
+						}
+						return 1, nil
+					},
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `fewer scopes than the role`,
+			inputBody: `{"name": "dashboard", "role": "admin", "scopes": ["commands:read"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewApiKeyQuery(gomock.Any(), gomock.Any(), context.Background()).Return(uint(2), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `scope of another role`,
+			inputBody: `{"name": "ci", "role": "viewer", "scopes": ["commands:execute"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `unknown role`,
+			inputBody: `{"name": "ci", "role": "root"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `unknown scope`,
+			inputBody: `{"name": "ci", "role": "admin", "scopes": ["commands:delete"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `no name`,
+			inputBody: `{"scopes": ["admin"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			inputBody: `{"name": "ci", "role": "admin"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewApiKeyQuery(gomock.Any(), gomock.Any(), context.Background()).Return(uint(0), fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/api-keys", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			handleFunc := restApi.CreateNewApiKeyHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			if testCase.expectedStatusCode == http.StatusOK && !strings.Contains(w.Body.String(), `"key":"tk_`) {
+				t.Errorf("the key isn't returned: %v", w.Body.String())
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_CreateNewCommandHandlerApiKey(t *testing.T) {
+	// init dependences
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mDatabase := mock_database.NewMockDBWorker(ctrl)
+	mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+
+	mBash.EXPECT().ExecCommands(gomock.Any(), gomock.Any()).Return(&[]models.CommandsWithoutID{{Command: "ls"}}, nil)
+	mDatabase.EXPECT().CreateNewCommandsQuery(gomock.Any(), gomock.Any(), context.Background()).DoAndReturn(
+		func(commands []models.CommandsWithoutID, newBatch models.NewBatch, ctx context.Context) (uint, error) {
+			if newBatch.ApiKeyId == nil || *newBatch.ApiKeyId != 7 {
+				t.Errorf("the commands aren't recorded with the key: %+v", newBatch)
+			}
+			return 1, nil
+		},
+	)
+
+	restApi := RestApi{}
+
+	// test request
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/bash/create-command", bytes.NewBufferString(`{"bash_strings": ["ls"]}`))
+	r = r.WithContext(auth.WithApiKey(r.Context(), &models.ApiKeys{Id: 7}))
+	handleFunc := restApi.CreateNewCommandHandler(mDatabase, mBash)
+	handleFunc(w, r)
+
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("expected status code %v but got %v", http.StatusOK, w.Result().StatusCode)
+	}
+	defer w.Result().Body.Close()
+}
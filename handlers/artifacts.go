@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	// std
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/artifacts"
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// GettingCommandArtifactsHandler returns the artifacts collected from the
+// working directory of a command, the skipped ones included.
+//
+//	@Tags		/bash/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Success	200	{array}	models.Artifacts
+//	@Router		/bash/commands/{id}/artifacts [get]
+func (restApi RestApi) GettingCommandArtifactsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		commandArtifacts, err := db.GettingCommandArtifactsQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		writeJsonResponse(w, http.StatusOK, commandArtifacts)
+	}
+}
+
+// GettingArtifactHandler returns the file of an artifact of a command. The
+// checksum is the ETag, so that Range and If-None-Match requests work.
+//
+//	@Tags		/bash/
+//	@Produce	octet-stream
+//	@Param		id			path	uint	true	"uint without 0"	minimum(1)
+//	@Param		artifactId	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/commands/{id}/artifacts/{artifactId} [get]
+func (restApi RestApi) GettingArtifactHandler(db database.DBWorker, store *artifacts.Store) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			closeHandlerWithErr(w, fmt.Errorf("artifact store isn't configured"))
+			return
+		}
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		artifactId, err := parsePathId(r, "artifactId")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		commandArtifacts, err := db.GettingCommandArtifactsQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		var artifact *models.Artifacts
+		for i := range *commandArtifacts {
+			if (*commandArtifacts)[i].Id == artifactId {
+				artifact = &(*commandArtifacts)[i]
+			}
+		}
+		if artifact == nil || artifact.Sha256 == "" {
+			closeHandlerWithErr(w, fmt.Errorf("command %v has no stored artifact %v", pathVal, artifactId))
+			return
+		}
+		file, err := store.Open(artifact.Sha256)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("artifact store error: %v", err))
+			return
+		}
+		defer file.Close()
+
+		name := path.Base(artifact.Path)
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		w.Header().Set("ETag", `"`+artifact.Sha256+`"`)
+		http.ServeContent(w, r, "", artifact.CreatedAt, file)
+	}
+}
+
+// GettingArtifactsZipHandler streams the stored artifacts of a command as a
+// zip archive with their paths.
+//
+//	@Tags		/bash/
+//	@Produce	octet-stream
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/commands/{id}/artifacts.zip [get]
+func (restApi RestApi) GettingArtifactsZipHandler(db database.DBWorker, store *artifacts.Store) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			closeHandlerWithErr(w, fmt.Errorf("artifact store isn't configured"))
+			return
+		}
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		commandArtifacts, err := db.GettingCommandArtifactsQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="command-%v-artifacts.zip"`, pathVal))
+		archive := zip.NewWriter(w)
+		for _, artifact := range *commandArtifacts {
+			if artifact.Sha256 == "" {
+				continue
+			}
+			if err := writeZipArtifact(archive, store, artifact); err != nil {
+				// the status is sent already, the archive is cut short
+				log.Printf("artifacts zip error: %v\n", err)
+				return
+			}
+		}
+		if err := archive.Close(); err != nil {
+			log.Printf("artifacts zip error: %v\n", err)
+		}
+	}
+}
+
+func writeZipArtifact(archive *zip.Writer, store *artifacts.Store, artifact models.Artifacts) error {
+	file, err := store.Open(artifact.Sha256)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: artifact.Path, Method: zip.Deflate, Modified: artifact.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, file)
+	return err
+}
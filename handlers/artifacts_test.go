@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/artifacts"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+// collectedArtifacts stores a report and returns the artifacts of a command
+// with it and a skipped file.
+func collectedArtifacts(t *testing.T, store *artifacts.Store) []models.Artifacts {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "report.html"), []byte("<p>ok</p>"), 0o644)
+	collected, err := store.Collect(dir, []string{"*.html"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collected[0].Id, collected[0].CommandId = 1, 5
+	return append(collected, models.Artifacts{Id: 2, CommandId: 5, Path: "core", SizeBytes: 1 << 30, Skipped: "larger than 1024 bytes"})
+}
+
+func TestRestApi_GettingArtifactHandler(t *testing.T) {
+	store := &artifacts.Store{Dir: t.TempDir(), MaxFileBytes: 1024, MaxCommandBytes: 1024}
+
+	testTable := []struct {
+		name string
+		artifactId string
+		store *artifacts.Store
+		queryErr error
+		expectedStatusCode int
+		expectedBody string
+	} {
+		{
+			name: `download`,
+			artifactId: "1",
+			store: store,
+			expectedStatusCode: http.StatusOK,
+			expectedBody: "<p>ok</p>",
+		},
+		{
+			name: `skipped artifact`,
+			artifactId: "2",
+			store: store,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `unknown artifact`,
+			artifactId: "3",
+			store: store,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `no store`,
+			artifactId: "1",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			artifactId: "1",
+			store: store,
+			queryErr: fmt.Errorf("some db error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			if testCase.store != nil {
+				collected := collectedArtifacts(t, store)
+				mDatabase.EXPECT().GettingCommandArtifactsQuery(uint(5), nil, context.Background()).Return(&collected, testCase.queryErr)
+			}
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/commands/5/artifacts/" + testCase.artifactId, nil)
+			r.SetPathValue("id", "5")
+			r.SetPathValue("artifactId", testCase.artifactId)
+			handleFunc := restApi.GettingArtifactHandler(mDatabase, testCase.store)
+			handleFunc(w, r)
+
+			if w.Code != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Code)
+			}
+			if w.Code == http.StatusOK {
+				if w.Body.String() != testCase.expectedBody || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+					t.Errorf("unexpected artifact %q %q", w.Header().Get("Content-Type"), w.Body.String())
+				}
+				if w.Header().Get("Content-Disposition") != `attachment; filename=report.html` {
+					t.Errorf("unexpected content disposition %q", w.Header().Get("Content-Disposition"))
+				}
+			}
+		})
+	}
+}
+
+func TestRestApi_GettingArtifactsZipHandler(t *testing.T) {
+	store := &artifacts.Store{Dir: t.TempDir(), MaxFileBytes: 1024, MaxCommandBytes: 1024}
+
+	// init dependences
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mDatabase := mock_database.NewMockDBWorker(ctrl)
+	collected := collectedArtifacts(t, store)
+	mDatabase.EXPECT().GettingCommandArtifactsQuery(uint(5), nil, context.Background()).Return(&collected, nil)
+
+	restApi := RestApi{}
+
+	// test request
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/bash/commands/5/artifacts.zip", nil)
+	r.SetPathValue("id", "5")
+	handleFunc := restApi.GettingArtifactsZipHandler(mDatabase, store)
+	handleFunc(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("zip error: %v", err)
+	}
+	// skipped artifacts aren't in the archive
+	if len(archive.File) != 1 || archive.File[0].Name != "report.html" {
+		t.Fatalf("unexpected files %+v", archive.File)
+	}
+	file, _ := archive.File[0].Open()
+	content, _ := io.ReadAll(file)
+	if string(content) != "<p>ok</p>" {
+		t.Errorf("unexpected content %q", content)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/audit"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	AUDIT_DEFAULT_LIMIT int = 100
+	AUDIT_MAX_LIMIT int = 1000
+)
+
+// parseAuditFilter returns the filter of the query parameters of the request.
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{Action: query.Get("action"), Limit: AUDIT_DEFAULT_LIMIT}
+	for name, id := range map[string]**uint{"api_key_id": &filter.ApiKeyId, "before_id": &filter.BeforeId} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 0)
+			if err != nil {
+				return filter, fmt.Errorf("%v is not a number: %v", name, err)
+			}
+			converted := uint(parsed)
+			*id = &converted
+		}
+	}
+	for name, at := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("%v is not an RFC 3339 time: %v", name, err)
+			}
+			*at = &parsed
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > AUDIT_MAX_LIMIT {
+			return filter, fmt.Errorf("limit must be from 1 to %v", AUDIT_MAX_LIMIT)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// GettingAuditLogHandler returns the entries of the audit log, the newest
+// first.
+//
+//	@Tags		/bash/audit/
+//	@Produce	json
+//	@Param		api_key_id	query	uint	false	"entries of the key"
+//	@Param		action		query	string	false	"route, like POST /bash/create-command"
+//	@Param		since		query	string	false	"RFC 3339 time"
+//	@Param		until		query	string	false	"RFC 3339 time"
+//	@Param		before_id	query	uint	false	"entries older than this one"
+//	@Param		limit		query	int		false	"100 by default, 1000 at most"
+//	@Router		/bash/audit [get]
+func (restApi RestApi) GettingAuditLogHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		entries, err := db.GettingAuditLogQuery(filter, context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, entries)
+	}
+}
+
+// VerifyAuditLogHandler walks the hash chain of the whole audit log.
+//
+//	@Tags		/bash/audit/
+//	@Produce	json
+//	@Router		/bash/audit/verify [get]
+func (restApi RestApi) VerifyAuditLogHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		verification, err := audit.Verify(db, context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, verification)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_GettingAuditLogHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		query string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `default filter`,
+			query: "",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingAuditLogQuery(models.AuditFilter{Limit: AUDIT_DEFAULT_LIMIT}, context.Background()).Return(&[]models.AuditEntries{}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `all filters`,
+			query: "?api_key_id=2&action=POST+/bash/create-command&since=2026-10-19T00:00:00Z&until=2026-10-20T00:00:00Z&before_id=50&limit=10",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingAuditLogQuery(gomock.Any(), context.Background()).DoAndReturn(
+					func(filter models.AuditFilter, ctx context.Context) (*[]models.AuditEntries, error) {
+						if *filter.ApiKeyId != 2 || filter.Action != "POST /bash/create-command" || filter.Since.Day() != 19 ||
+							filter.Until.Day() != 20 || *filter.BeforeId != 50 || filter.Limit != 10 {
+							t.Errorf("unexpected filter %+v", filter)
+						}
+						return &[]models.AuditEntries{}, nil
+					},
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `invalid time`,
+			query: "?since=yesterday",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `limit too big`,
+			query: "?limit=5000",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			query: "",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingAuditLogQuery(gomock.Any(), context.Background()).Return(nil, fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/audit"+testCase.query, nil)
+			handleFunc := restApi.GettingAuditLogHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	// std
+	"archive/tar"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	// formats of the export of commands
+	EXPORT_FORMAT_NDJSON string = "ndjson"
+	EXPORT_FORMAT_CSV    string = "csv"
+	EXPORT_FORMAT_TAR_GZ string = "tar.gz"
+)
+
+// exportColumns is the header of the csv export.
+var exportColumns = []string{"id", "batch_id", "batch_created_at", "rerun_of", "created_at", "command", "is_error", "exit_code",
+	"duration_ms", "cpu_ms", "timed_out", "truncated", "output_bytes", "content_type", "log_encoding", "log"}
+
+// commandsExporter writes the commands of an export one by one.
+type commandsExporter interface {
+	write(*models.ExportedCommands) error
+	close() error
+}
+
+// newCommandsExporter returns the exporter of the format with its content
+// type.
+func newCommandsExporter(format string, w io.Writer) (commandsExporter, string, error) {
+	switch format {
+	case "", EXPORT_FORMAT_NDJSON:
+		return ndjsonExporter{json.NewEncoder(w)}, "application/x-ndjson", nil
+	case EXPORT_FORMAT_CSV:
+		exporter := csvExporter{csv.NewWriter(w)}
+		return exporter, "text/csv; charset=utf-8", exporter.writer.Write(exportColumns)
+	case EXPORT_FORMAT_TAR_GZ:
+		gz := gzip.NewWriter(w)
+		return tarExporter{gz: gz, tar: tar.NewWriter(gz)}, "application/gzip", nil
+	default:
+		return nil, "", fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// encodeExportedLog encodes a log that isn't UTF-8 in base64, so that an
+// import gets the same bytes back.
+func encodeExportedLog(command *models.ExportedCommands) {
+	if utf8.ValidString(command.Log) {
+		command.EncodeLog(models.LOG_ENCODING_TEXT)
+		return
+	}
+	command.EncodeLog(models.LOG_ENCODING_BASE64)
+}
+
+type ndjsonExporter struct {
+	encoder *json.Encoder
+}
+
+func (exporter ndjsonExporter) write(command *models.ExportedCommands) error {
+	encodeExportedLog(command)
+	return exporter.encoder.Encode(command)
+}
+
+func (exporter ndjsonExporter) close() error {
+	return nil
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func (exporter csvExporter) write(command *models.ExportedCommands) error {
+	encodeExportedLog(command)
+	optional := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+	timestamp := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+	var batchCreatedAt *time.Time
+	if command.Batch != nil {
+		batchCreatedAt = &command.Batch.CreatedAt
+	}
+	return exporter.writer.Write([]string{
+		strconv.FormatUint(uint64(command.Id), 10),
+		optional(command.BatchId),
+		timestamp(batchCreatedAt),
+		optional(command.RerunOf),
+		timestamp(command.CreatedAt),
+		command.Command,
+		strconv.FormatBool(command.IsError),
+		strconv.Itoa(command.ExitCode),
+		strconv.FormatInt(command.DurationMs, 10),
+		strconv.FormatInt(command.CpuMs, 10),
+		strconv.FormatBool(command.TimedOut),
+		strconv.FormatBool(command.Truncated),
+		strconv.FormatInt(command.OutputBytes, 10),
+		command.ContentType,
+		command.LogEncoding,
+		command.Log,
+	})
+}
+
+func (exporter csvExporter) close() error {
+	exporter.writer.Flush()
+	return exporter.writer.Error()
+}
+
+// tarExporter writes every command as commands/<id>.json without its log and
+// the log as it is to commands/<id>.log.
+type tarExporter struct {
+	gz  *gzip.Writer
+	tar *tar.Writer
+}
+
+func (exporter tarExporter) write(command *models.ExportedCommands) error {
+	output := []byte(command.Log)
+	command.Log = ""
+	metadata, err := json.Marshal(command)
+	if err != nil {
+		return err
+	}
+	modTime := time.Now()
+	if command.CreatedAt != nil {
+		modTime = *command.CreatedAt
+	}
+	for _, file := range []struct {
+		name    string
+		content []byte
+	}{
+		{fmt.Sprintf("commands/%v.json", command.Id), metadata},
+		{fmt.Sprintf("commands/%v.log", command.Id), output},
+	} {
+		header := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content)), ModTime: modTime}
+		if err := exporter.tar.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := exporter.tar.Write(file.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (exporter tarExporter) close() error {
+	if err := exporter.tar.Close(); err != nil {
+		return err
+	}
+	return exporter.gz.Close()
+}
+
+// ExportCommandsHandler streams the commands visible to the request that the
+// filters of the list select, the oldest first, as ndjson, csv or a tar.gz
+// archive. The ndjson export can be imported by ImportCommandsHandler.
+//
+//	@Tags		/bash/
+//	@Produce	json,plain,octet-stream
+//	@Param		format		query	string	false	"export format, ndjson by default"	Enums(ndjson, csv, tar.gz)
+//	@Param		since		query	string	false	"commands created at or after this RFC 3339 time"
+//	@Param		until		query	string	false	"commands created before this RFC 3339 time"
+//	@Param		batch_id	query	uint	false	"commands of the batch"
+//	@Param		is_error	query	bool	false	"failed or succeeded commands only"
+//	@Router		/bash/commands/export [get]
+func (restApi RestApi) ExportCommandsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseCommandFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		exporter, contentType, err := newCommandsExporter(format, w)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if format == "" {
+			format = EXPORT_FORMAT_NDJSON
+		}
+
+		// the headers go with the first command, a query that fails before
+		// it is answered with 500, a later error can only cut the export
+		// short
+		started := false
+		start := func() {
+			started = true
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="commands.%v"`, format))
+			w.WriteHeader(http.StatusOK)
+		}
+		err = db.WalkCommandsQuery(filter, func(command *models.ExportedCommands) error {
+			if !started {
+				start()
+			}
+			return exporter.write(command)
+		}, r.Context())
+		if err != nil && !started {
+			closeHandlerWithErr(w, fmt.Errorf("export error: %v", err))
+			return
+		}
+		if !started {
+			start()
+		}
+		if err == nil {
+			err = exporter.close()
+		}
+		if err != nil {
+			log.Printf("export error: %v\n", err)
+		}
+	}
+}
+
+// ImportCommandsHandler stores the commands of an ndjson export in one
+// transaction. The commands and batches get new ids, the commands of an
+// exported batch stay in one batch and keep their timestamps.
+//
+//	@Tags		/bash/
+//	@Accept		json
+//	@Produce	json
+//	@Param		commands	body	models.ExportedCommands	true	"ndjson export, one command per line"
+//	@Success	200			{object}	models.ImportSummary
+//	@Router		/bash/commands/import [post]
+func (restApi RestApi) ImportCommandsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		next := func() (*models.ExportedCommands, error) {
+			command := models.ExportedCommands{}
+			if err := decoder.Decode(&command); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, err
+				}
+				return nil, fmt.Errorf("json decode error: %w", err)
+			}
+			if err := command.DecodeLog(); err != nil {
+				return nil, err
+			}
+			return &command, nil
+		}
+
+		summary, err := db.ImportCommandsQuery(next, auth.ApiKeyId(r.Context()), r.Context())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("import error: %v", err))
+			return
+		}
+		writeJsonResponse(w, http.StatusOK, summary)
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func exportedCommands() []models.ExportedCommands {
+	batchId, rerunOf := uint(4), uint(1)
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	batch := &models.ExportedBatch{Id: batchId, CreatedAt: createdAt, Verdict: models.VERDICT_PASSED}
+	return []models.ExportedCommands{
+		{Commands: models.Commands{Id: 7, BatchId: &batchId, Command: "echo hi", Log: "hi\n", OutputBytes: 3,
+			ContentType: models.TEXT_CONTENT_TYPE, CreatedAt: &createdAt}, Batch: batch},
+		{Commands: models.Commands{Id: 8, BatchId: &batchId, RerunOf: &rerunOf, Command: "cat image", Log: "\x89PNG\xff",
+			OutputBytes: 5, ContentType: "image/png", CreatedAt: &createdAt}, Batch: batch},
+	}
+}
+
+func TestRestApi_ExportCommandsHandler(t *testing.T) {
+	since, batchId, isError := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), uint(4), false
+	testTable := []struct {
+		name string
+		format string
+		query string
+		expectedFilter models.CommandFilter
+		walkErr error
+		expectedStatusCode int
+		expectedContentType string
+		checkBody func(*testing.T, []byte)
+	} {
+		{
+			name: `ndjson`,
+			format: "",
+			expectedStatusCode: http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			checkBody: func(t *testing.T, body []byte) {
+				decoder := json.NewDecoder(bytes.NewReader(body))
+				for _, want := range exportedCommands() {
+					got := models.ExportedCommands{}
+					if err := decoder.Decode(&got); err != nil {
+						t.Fatalf("json decode error: %v", err)
+					}
+					if err := got.DecodeLog(); err != nil {
+						t.Fatal(err)
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("expected %+v but got %+v", want, got)
+					}
+				}
+			},
+		},
+		{
+			name: `csv`,
+			format: "csv",
+			expectedStatusCode: http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			checkBody: func(t *testing.T, body []byte) {
+				records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+				if err != nil {
+					t.Fatalf("csv error: %v", err)
+				}
+				if len(records) != 3 || !reflect.DeepEqual(records[0], exportColumns) {
+					t.Fatalf("unexpected records %q", records)
+				}
+				if records[1][0] != "7" || records[1][15] != "hi\n" || records[2][14] != models.LOG_ENCODING_BASE64 {
+					t.Errorf("unexpected records %q", records[1:])
+				}
+			},
+		},
+		{
+			name: `tar.gz`,
+			format: "tar.gz",
+			expectedStatusCode: http.StatusOK,
+			expectedContentType: "application/gzip",
+			checkBody: func(t *testing.T, body []byte) {
+				gz, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				files := map[string]string{}
+				archive := tar.NewReader(gz)
+				for {
+					header, err := archive.Next()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					content, _ := io.ReadAll(archive)
+					files[header.Name] = string(content)
+				}
+				if len(files) != 4 || files["commands/8.log"] != "\x89PNG\xff" || !strings.Contains(files["commands/7.json"], `"echo hi"`) {
+					t.Errorf("unexpected files %q", files)
+				}
+			},
+		},
+		{
+			name: `unknown format`,
+			format: "parquet",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `filtered`,
+			format: "ndjson",
+			query: "&since=2026-10-01T00:00:00Z&batch_id=4&is_error=false",
+			expectedFilter: models.CommandFilter{Since: &since, BatchId: &batchId, IsError: &isError},
+			expectedStatusCode: http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			checkBody: func(t *testing.T, body []byte) {
+				if lines := bytes.Count(body, []byte("\n")); lines != 2 {
+					t.Errorf("expected 2 commands but got %v", lines)
+				}
+			},
+		},
+		{
+			name: `invalid filter`,
+			format: "ndjson",
+			query: "&is_error=maybe",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: `db querry error`,
+			format: "ndjson",
+			walkErr: fmt.Errorf("some db error"),
+			// nothing was streamed yet, so the error still gets its status
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			if testCase.checkBody != nil || testCase.walkErr != nil {
+				mDatabase.EXPECT().WalkCommandsQuery(testCase.expectedFilter, gomock.Any(), gomock.Any()).DoAndReturn(
+					func(filter models.CommandFilter, walk func(*models.ExportedCommands) error, ctx context.Context) error {
+						if testCase.walkErr != nil {
+							return testCase.walkErr
+						}
+						for _, command := range exportedCommands() {
+							if err := walk(&command); err != nil {
+								return err
+							}
+						}
+						return nil
+					},
+				)
+			}
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/commands/export?format=" + testCase.format + testCase.query, nil)
+			handleFunc := restApi.ExportCommandsHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Code != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Code)
+			}
+			if testCase.checkBody != nil {
+				if contentType := w.Header().Get("Content-Type"); contentType != testCase.expectedContentType {
+					t.Errorf("expected content type %q but got %q", testCase.expectedContentType, contentType)
+				}
+				testCase.checkBody(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestRestApi_ImportCommandsHandler(t *testing.T) {
+	export := &bytes.Buffer{}
+	exporter, _, _ := newCommandsExporter(EXPORT_FORMAT_NDJSON, export)
+	for _, command := range exportedCommands() {
+		exporter.write(&command)
+	}
+
+	testTable := []struct {
+		name string
+		inputBody string
+		importErr error
+		expectedStatusCode int
+		expectedCommands []models.ExportedCommands
+	} {
+		{
+			name: `import`,
+			inputBody: export.String(),
+			expectedStatusCode: http.StatusOK,
+			expectedCommands: exportedCommands(),
+		},
+		{
+			name: `invalid json`,
+			inputBody: `{"id": 1, "command": `,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `invalid base64 log`,
+			inputBody: `{"id": 1, "command": "true", "log": "%%%", "log_encoding": "base64"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			inputBody: export.String(),
+			importErr: fmt.Errorf("some db error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedCommands: exportedCommands(),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			var imported []models.ExportedCommands
+			mDatabase.EXPECT().ImportCommandsQuery(gomock.Any(), nil, gomock.Any()).DoAndReturn(
+				func(next func() (*models.ExportedCommands, error), apiKeyId *uint, ctx context.Context) (models.ImportSummary, error) {
+					for {
+						command, err := next()
+						if errors.Is(err, io.EOF) {
+							break
+						}
+						if err != nil {
+							return models.ImportSummary{}, err
+						}
+						imported = append(imported, *command)
+					}
+					return models.ImportSummary{Batches: 1, Commands: len(imported)}, testCase.importErr
+				},
+			)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/commands/import", bytes.NewBufferString(testCase.inputBody))
+			handleFunc := restApi.ImportCommandsHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Code != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Code)
+			}
+			if testCase.expectedCommands != nil && !reflect.DeepEqual(imported, testCase.expectedCommands) {
+				t.Errorf("expected commands %+v but got %+v", testCase.expectedCommands, imported)
+			}
+			if w.Code == http.StatusOK && strings.TrimSpace(w.Body.String()) != `{"batches":1,"commands":2}` {
+				t.Errorf("unexpected summary %q", w.Body.String())
+			}
+		})
+	}
+}
@@ -3,18 +3,27 @@ package handlers
 import (
 	// std
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"context"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"time"
 	// "sync"
 	// "os/exec"
 	// local
+	"github.com/Vy4cheSlave/test-task-postgres/artifacts"
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
 	"github.com/Vy4cheSlave/test-task-postgres/database"
 	_ "github.com/Vy4cheSlave/test-task-postgres/docs"
 	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/diff"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/workspaces"
 )
 
 //go:generate mockgen -source=handlers.go -destination=mock/mock.go
@@ -22,7 +31,60 @@ import (
 type RestApiWorker interface {
 	CreateNewCommandHandler(database.DBWorker, bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request)
 	GettingSingleCommandHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	DeleteCommandHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingCommandOutputHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	ExportCommandsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	ImportCommandsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingCommandArtifactsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingArtifactHandler(database.DBWorker, *artifacts.Store) func(http.ResponseWriter, *http.Request)
+	GettingArtifactsZipHandler(database.DBWorker, *artifacts.Store) func(http.ResponseWriter, *http.Request)
+	GettingListWorkspacesHandler(*workspaces.Manager) func(http.ResponseWriter, *http.Request)
+	GettingWorkspaceFilesHandler(*workspaces.Manager) func(http.ResponseWriter, *http.Request)
+	GettingWorkspaceFileHandler(*workspaces.Manager) func(http.ResponseWriter, *http.Request)
+	SaveWorkspaceFileHandler(*workspaces.Manager) func(http.ResponseWriter, *http.Request)
+	DeleteWorkspaceFileHandler(*workspaces.Manager) func(http.ResponseWriter, *http.Request)
+	GettingWorkspaceSnapshotHandler(*workspaces.Manager) func(http.ResponseWriter, *http.Request)
+	DeleteWorkspaceHandler(*workspaces.Manager) func(http.ResponseWriter, *http.Request)
+	GettingListRunsHandler(*bash.Runs) func(http.ResponseWriter, *http.Request)
+	CancelRunHandler(*bash.Runs) func(http.ResponseWriter, *http.Request)
+	GettingRunStreamHandler(*bash.Runs) func(http.ResponseWriter, *http.Request)
 	GettingListCommandsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingCommandAttemptsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingBatchHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingBatchGroupsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	RerunCommandHandler(database.DBWorker, bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request)
+	RerunBatchHandler(database.DBWorker, bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request)
+	CommandsDiffHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	CreateNewScheduleHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingListSchedulesHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingSingleScheduleHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingScheduleBatchesHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	PauseScheduleHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	ResumeScheduleHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	DeleteScheduleHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	CreateNewWorkflowHandler(database.DBWorker, bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request)
+	GettingListWorkflowsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingSingleWorkflowHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	SaveScriptHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingListScriptsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingScriptHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingScriptVersionsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	RunScriptHandler(database.DBWorker, bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request)
+	CreateNewWebhookHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingListWebhooksHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	DeleteWebhookHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingWebhookDeliveriesHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	CreateNewApiKeyHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingListApiKeysHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	RevokeApiKeyHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingListRolePoliciesHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	UpdateRolePolicyHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingUsageHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingAuditLogHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	VerifyAuditLogHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	SaveSecretHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	GettingListSecretsHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
+	DeleteSecretHandler(database.DBWorker) func(http.ResponseWriter, *http.Request)
 }
 
 type RestApi struct{}
@@ -32,15 +94,77 @@ func closeHandlerWithErr(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusInternalServerError)
 }
 
+// closeHandlerForbidden answers 403 with the reason, so that the client
+// knows which part of its request the command policy rejects.
+func closeHandlerForbidden(w http.ResponseWriter, err error) {
+	log.Println(err)
+	http.Error(w, err.Error(), http.StatusForbidden)
+}
+
+// closeHandlerOverQuota answers 429 with Retry-After if the request goes over
+// a quota and 400 if it has more commands than the role may run at once,
+// other errors are internal errors.
+func closeHandlerOverQuota(w http.ResponseWriter, err error) {
+	if errors.Is(err, limits.ErrTooManyCommands) {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var quotaErr *limits.QuotaError
+	if !errors.As(err, &quotaErr) {
+		closeHandlerWithErr(w, err)
+		return
+	}
+	log.Println(err)
+	limits.WriteTooManyRequests(w, quotaErr.RetryAfter, err.Error())
+}
+
+// validateCommandOptions answers 400 and returns false if the retry policy or
+// the assertions of a command are invalid, such as a regex that doesn't
+// compile, so that they are rejected before anything runs.
+func validateCommandOptions(w http.ResponseWriter, options []bash.CommandOptions) bool {
+	for _, option := range options {
+		if err := bash.ValidateOptions(option); err != nil {
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+// checkCommandPolicy returns an error if the command policy of the request
+// doesn't allow one of the commands.
+func checkCommandPolicy(r *http.Request, options []bash.CommandOptions) error {
+	return auth.CheckOptions(r.Context(), options)
+}
+
+// CreateNewCommandHandler runs the commands of the request. A multipart
+// request has the commands as JSON in the request field and files to copy
+// into the working directories in files fields, named by their paths.
+//
 //	@Tags		/bash/
-//	@Accept		json
+//	@Accept		json,mpfd
 //	@Produce	json
 //	@Param		new_command	body	bash.ReqCreateNewCommandBody	true	"input bash string"
+//	@Param		stream		query	bool						false	"stream the output as NDJSON events"
 //	@Router		/bash/create-command [post]
 func (restApi RestApi) CreateNewCommandHandler(db database.DBWorker, sh bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var inputStruct bash.ReqCreateNewCommandBody
 		defer r.Body.Close()
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			uploads, err := readMultipartCommand(w, r, &inputStruct)
+			if uploads != nil {
+				defer uploads.Remove()
+			}
+			if err != nil {
+				closeHandlerWithErr(w, err)
+				return
+			}
+			execAndStoreCommands(w, r, db, sh, &inputStruct, nil, models.NewBatch{})
+			return
+		}
 		buf, err := io.ReadAll(r.Body)
 		if err != nil {
 			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
@@ -51,45 +175,210 @@ func (restApi RestApi) CreateNewCommandHandler(db database.DBWorker, sh bash.Bas
 			return
 		}
 
-		isErrorOnChannel := false
-		sliceCommands, err := sh.ExecCommands(&inputStruct)
-		if err != nil {
-			log.Println(err)
-			isErrorOnChannel = true
-		} 
-		if sliceCommands == nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		execAndStoreCommands(w, r, db, sh, &inputStruct, nil, models.NewBatch{})
+	}
+}
+
+// execAndStoreCommands checks the bash strings against the command policy and
+// the quotas of the request, runs them, stores the results as a new
+// batch submitted by the api key of the request and writes them to the
+// response. rerunOf maps a bash string to the ids of the commands it reruns.
+// With the stream query the response is the NDJSON events of the run, the
+// last one has the stored commands.
+func execAndStoreCommands(w http.ResponseWriter, r *http.Request, db database.DBWorker, sh bash.BashCommandsWorker,
+	inputStruct *bash.ReqCreateNewCommandBody, rerunOf map[string][]uint, newBatch models.NewBatch) {
+	streamed, err := parseBoolQuery(r, "stream")
+	if err != nil {
+		closeHandlerWithErr(w, err)
+		return
+	}
+	// invalid options are reported by ExecCommands
+	if options, err := inputStruct.CommandOptions(); err == nil {
+		if !validateCommandOptions(w, options) {
+			return
+		}
+		if err := checkCommandPolicy(r, options); err != nil {
+			closeHandlerForbidden(w, err)
 			return
 		}
+		if quotas := limits.QuotasFromContext(r.Context()); quotas != nil {
+			release, err := quotas.Acquire(db, len(options), r.Context())
+			if err != nil {
+				closeHandlerOverQuota(w, err)
+				return
+			}
+			// the commands count as running until they are stored
+			defer release()
+		}
+	}
+	if err := inputStruct.ReadSecrets(db, r.Context()); err != nil {
+		closeHandlerWithErr(w, err)
+		return
+	}
 
-		if err = db.CreateNewCommandsQuery(*sliceCommands, context.Background()); err != nil {
-			log.Printf("database query error: %v\n", err)
+	var stream *runStream
+	if streamed {
+		stream = &runStream{w: w}
+		inputStruct.Output = stream.write
+	}
+	inputStruct.ApiKeyId = auth.ApiKeyId(r.Context())
+	inputStruct.WorkspaceOwner = auth.CommandsOwner(r.Context())
+
+	isErrorOnChannel := false
+	// the request context is cancelled when the client goes away, which
+	// interrupts the commands that are still running
+	sliceCommands, err := sh.ExecCommands(inputStruct, r.Context())
+	if err != nil {
+		log.Println(err)
+		isErrorOnChannel = true
+	} 
+	if sliceCommands == nil {
+		if stream != nil && stream.started {
+			stream.write(models.RunEvents{Event: models.RUN_EVENT_RESULT, RunId: stream.runId, Error: err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	for i, command := range *sliceCommands {
+		if ids := rerunOf[command.Command]; len(ids) != 0 {
+			(*sliceCommands)[i].RerunOf = &ids[0]
+			rerunOf[command.Command] = ids[1:]
+		}
+	}
+
+	newBatch.ApiKeyId = inputStruct.ApiKeyId
+	batchId, err := db.CreateNewCommandsQuery(*sliceCommands, newBatch, context.Background())
+	if err != nil {
+		log.Printf("database query error: %v\n", err)
+	} else {
+		for i := range *sliceCommands {
+			(*sliceCommands)[i].BatchId = batchId
 		}
+	}
 
+	if stream != nil && stream.started {
+		result := models.RunEvents{Event: models.RUN_EVENT_RESULT, RunId: stream.runId, Commands: *sliceCommands}
 		if isErrorOnChannel {
-			w.WriteHeader(http.StatusInternalServerError)
-		} else {
-			w.WriteHeader(http.StatusOK)
+			result.Error = "run subprocess error"
 		}
-		w.Header().Set("сontent-type", "application/json; charset=UTF-8")
-		if err := json.NewEncoder(w).Encode(sliceCommands); err != nil {
-			log.Printf("json encode error: %v\n", err)
+		stream.write(result)
+		return
+	}
+
+	if isErrorOnChannel {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.Header().Set("сontent-type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(sliceCommands); err != nil {
+		log.Printf("json encode error: %v\n", err)
+	}
+}
+
+// parsePathId returns the positive integer path value with the given name.
+func parsePathId(r *http.Request, name string) (uint, error) {
+	pathVal, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return 0, fmt.Errorf("pathValue is not a number: %v", err)
+	}
+	if pathVal <= 0 {
+		return 0, fmt.Errorf("pathValue isn't positive")
+	}
+	return uint(pathVal), nil
+}
+
+// parseBoolQuery returns the bool query parameter with the given name, false
+// if it is absent.
+func parseBoolQuery(r *http.Request, name string) (bool, error) {
+	query := r.URL.Query().Get(name)
+	if query == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(query)
+	if err != nil {
+		return false, fmt.Errorf("%v is not a bool: %v", name, err)
+	}
+	return value, nil
+}
+
+// parseLogEncoding returns the encoding query value of the logs, text if it
+// is absent.
+func parseLogEncoding(r *http.Request) (string, error) {
+	switch encoding := r.URL.Query().Get("encoding"); encoding {
+	case "", models.LOG_ENCODING_TEXT:
+		return models.LOG_ENCODING_TEXT, nil
+	case models.LOG_ENCODING_BASE64:
+		return encoding, nil
+	default:
+		return "", fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+// parseCommandFilter returns the filter of the query parameters of the list
+// and the export of commands, limited to the commands the request may see.
+func parseCommandFilter(r *http.Request) (models.CommandFilter, error) {
+	query := r.URL.Query()
+	filter := models.CommandFilter{ApiKeyId: auth.CommandsOwner(r.Context())}
+	for name, at := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("%v is not an RFC 3339 time: %v", name, err)
+			}
+			*at = &parsed
+		}
+	}
+	if value := query.Get("batch_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return filter, fmt.Errorf("batch_id is not a number: %v", err)
 		}
+		batchId := uint(parsed)
+		filter.BatchId = &batchId
 	}
+	if value := query.Get("is_error"); value != "" {
+		isError, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("is_error is not a boolean: %v", err)
+		}
+		filter.IsError = &isError
+	}
+	return filter, nil
 }
 
 //	@Tags		/bash/
 //	@Produce	json
+//	@Param		encoding	query	string	false	"text or base64 of the raw bytes of the logs"	Enums(text, base64)
+//	@Param		since		query	string	false	"commands created at or after this RFC 3339 time"
+//	@Param		until		query	string	false	"commands created before this RFC 3339 time"
+//	@Param		batch_id	query	uint	false	"commands of the batch"
+//	@Param		is_error	query	bool	false	"failed or succeeded commands only"
 //	@Router		/bash/get-commands [get]
 func (restApi RestApi) GettingListCommandsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		commands, err := db.GettingListCommandsQuery(context.Background()) 
+		encoding, err := parseLogEncoding(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		filter, err := parseCommandFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		commands, err := db.GettingListCommandsQuery(filter, context.Background()) 
 		if err != nil {
 			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
 			return
 		} else {
 			w.WriteHeader(http.StatusOK)
 		}
+		for i := range *commands {
+			(*commands)[i].EncodeLog(encoding)
+		}
 		w.Header().Set("сontent-type", "application/json; charset=UTF-8")
 		json.NewEncoder(w).Encode(commands)
 	}
@@ -97,27 +386,314 @@ func (restApi RestApi) GettingListCommandsHandler(db database.DBWorker) func(htt
 
 //	@Tags		/bash/
 //	@Produce	json
-//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Param		id			path	uint	true	"uint without 0"	minimum(1)
+//	@Param		encoding	query	string	false	"text or base64 of the raw bytes of the log"	Enums(text, base64)
 //	@Router		/bash/get-commands/{id} [get]
 func (restApi RestApi) GettingSingleCommandHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		pathVal, err := strconv.Atoi(r.PathValue("id"))
+		pathVal, err := parsePathId(r, "id")
 		if err != nil {
-			closeHandlerWithErr(w, fmt.Errorf("pathValue is not a number: %v", err))
+			closeHandlerWithErr(w, err)
 			return
 		}
-		if pathVal <= 0 {
-			closeHandlerWithErr(w, fmt.Errorf("pathValue isn't positive"))
+		encoding, err := parseLogEncoding(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
 			return
 		}
-		command, err := db.GettingSingleCommandQuery(uint(pathVal), context.Background())
+		command, err := db.GettingSingleCommandQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
 		if err != nil {
 			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
 			return
 		}
+		command.EncodeLog(encoding)
 
 		w.WriteHeader(http.StatusOK)
 		w.Header().Set("сontent-type", "application/json; charset=UTF-8")
 		json.NewEncoder(w).Encode(command)
 	}
+}
+
+// DeleteCommandHandler deletes a command with its attempts, the batch of the
+// command is deleted with its last command.
+//
+//	@Tags		/bash/
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/commands/{id} [delete]
+func (restApi RestApi) DeleteCommandHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := db.DeleteCommandQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// GettingCommandAttemptsHandler returns the attempts of a command that ran
+// with a retry policy.
+//
+//	@Tags		/bash/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/commands/{id}/attempts [get]
+func (restApi RestApi) GettingCommandAttemptsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		attempts, err := db.GettingCommandAttemptsQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, attempts)
+	}
+}
+
+//	@Tags		/bash/
+//	@Produce	json
+//	@Param		id			path	uint	true	"uint without 0"	minimum(1)
+//	@Param		encoding	query	string	false	"text or base64 of the raw bytes of the logs"	Enums(text, base64)
+//	@Router		/bash/batches/{id} [get]
+func (restApi RestApi) GettingBatchHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		encoding, err := parseLogEncoding(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		batch, err := db.GettingBatchQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		for i := range batch.Commands {
+			batch.Commands[i].EncodeLog(encoding)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("сontent-type", "application/json; charset=UTF-8")
+		json.NewEncoder(w).Encode(batch)
+	}
+}
+
+// GettingBatchGroupsHandler groups the commands of a batch by the value of a
+// template parameter.
+//
+//	@Tags		/bash/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Param		by	query	string	true	"name of the template parameter"
+//	@Router		/bash/batches/{id}/groups [get]
+func (restApi RestApi) GettingBatchGroupsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		parameter := r.URL.Query().Get("by")
+		if parameter == "" {
+			closeHandlerWithErr(w, fmt.Errorf("query parameter by is required"))
+			return
+		}
+		batch, err := db.GettingBatchQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, models.GroupByParameter(batch.Commands, parameter))
+	}
+}
+
+//	@Tags		/bash/
+//	@Produce	json
+//	@Param		id		path	uint	true	"uint without 0"	minimum(1)
+//	@Param		stream	query	bool	false	"stream the output as NDJSON events"
+//	@Router		/bash/commands/{id}/rerun [post]
+func (restApi RestApi) RerunCommandHandler(db database.DBWorker, sh bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		command, err := db.GettingSingleCommandQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		options, err := rerunOptions(command)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		inputStruct := bash.ReqCreateNewCommandBody{Commands: []bash.CommandOptions{options}}
+		rerunOf := map[string][]uint{command.Command: {pathVal}}
+		execAndStoreCommands(w, r, db, sh, &inputStruct, rerunOf, models.NewBatch{})
+	}
+}
+
+//	@Tags		/bash/
+//	@Produce	json
+//	@Param		id			path	uint	true	"uint without 0"	minimum(1)
+//	@Param		failed_only	query	bool	false	"rerun only the failed commands"
+//	@Param		stream		query	bool	false	"stream the output as NDJSON events"
+//	@Router		/bash/batches/{id}/rerun [post]
+func (restApi RestApi) RerunBatchHandler(db database.DBWorker, sh bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		failedOnly, err := parseBoolQuery(r, "failed_only")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		batch, err := db.GettingBatchQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		inputStruct := bash.ReqCreateNewCommandBody{Commands: []bash.CommandOptions{}}
+		rerunOf := map[string][]uint{}
+		for _, command := range batch.Commands {
+			if failedOnly && !command.IsFailed() {
+				continue
+			}
+			options, err := rerunOptions(&command)
+			if err != nil {
+				closeHandlerWithErr(w, err)
+				return
+			}
+			inputStruct.Commands = append(inputStruct.Commands, options)
+			rerunOf[command.Command] = append(rerunOf[command.Command], command.Id)
+		}
+		if len(inputStruct.Commands) == 0 {
+			w.WriteHeader(http.StatusOK)
+			w.Header().Set("сontent-type", "application/json; charset=UTF-8")
+			json.NewEncoder(w).Encode([]models.CommandsWithoutID{})
+			return
+		}
+		execAndStoreCommands(w, r, db, sh, &inputStruct, rerunOf, models.NewBatch{RerunOf: &batch.Id})
+	}
+}
+
+// rerunOptions returns the options a command was submitted with, the values
+// of its secrets are read again. A command stored without its options, such
+// as an imported one, is rerun with the default options.
+func rerunOptions(command *models.Commands) (bash.CommandOptions, error) {
+	options := bash.CommandOptions{BashString: command.Command}
+	if len(command.Options) != 0 {
+		if err := json.Unmarshal(command.Options, &options); err != nil {
+			return options, fmt.Errorf("options of command %v: %w", command.Id, err)
+		}
+	}
+	options.Template, options.Parameters = command.Template, command.Parameters
+	return options, nil
+}
+
+const DEFAULT_DIFF_CONTEXT int = 3
+
+//	@Tags		/bash/
+//	@Produce	json
+//	@Param		id					path	uint	true	"uint without 0"	minimum(1)
+//	@Param		otherId				path	uint	true	"uint without 0"	minimum(1)
+//	@Param		ignore_ansi			query	bool	false	"strip terminal escape sequences before comparing"
+//	@Param		ignore_timestamps	query	bool	false	"mask dates and times before comparing"
+//	@Param		context				query	uint	false	"number of context lines, 3 by default"
+//	@Router		/bash/commands/{id}/diff/{otherId} [get]
+func (restApi RestApi) CommandsDiffHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		otherId, err := parsePathId(r, "otherId")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		ignoreAnsi, err := parseBoolQuery(r, "ignore_ansi")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		ignoreTimestamps, err := parseBoolQuery(r, "ignore_timestamps")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		contextLines := DEFAULT_DIFF_CONTEXT
+		if query := r.URL.Query().Get("context"); query != "" {
+			if contextLines, err = strconv.Atoi(query); err != nil || contextLines < 0 {
+				closeHandlerWithErr(w, fmt.Errorf("context isn't a non-negative number: %v", query))
+				return
+			}
+		}
+
+		command, err := db.GettingSingleCommandQuery(id, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		other, err := db.GettingSingleCommandQuery(otherId, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		log, otherLog := command.Log, other.Log
+		if ignoreAnsi {
+			log, otherLog = diff.StripAnsi(log), diff.StripAnsi(otherLog)
+		}
+		if ignoreTimestamps {
+			log, otherLog = diff.MaskTimestamps(log), diff.MaskTimestamps(otherLog)
+		}
+		result := models.CommandsDiff{
+			Id: command.Id,
+			OtherId: other.Id,
+			Command: command.Command,
+			OtherCommand: other.Command,
+			Stream: command.Stream(),
+			OtherStream: other.Stream(),
+			LogDiff: diff.Unified(
+				fmt.Sprintf("%v (command %v)", command.Stream(), command.Id),
+				fmt.Sprintf("%v (command %v)", other.Stream(), other.Id),
+				log, otherLog, contextLines,
+			),
+			ExitCode: command.ExitCode,
+			OtherExitCode: other.ExitCode,
+			ExitCodeDelta: other.ExitCode - command.ExitCode,
+			DurationMs: command.DurationMs,
+			OtherDurationMs: other.DurationMs,
+			DurationDeltaMs: other.DurationMs - command.DurationMs,
+		}
+		result.IsLogEqual = result.LogDiff == ""
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("сontent-type", "application/json; charset=UTF-8")
+		json.NewEncoder(w).Encode(result)
+	}
 }
\ No newline at end of file
@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
 	"github.com/Vy4cheSlave/test-task-postgres/bash"
 	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
 	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
@@ -35,7 +36,7 @@ func TestRestApi_CreateNewCommandHandler(t *testing.T) {
 			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
 				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
 					BashStrings: []string{"test1"},
-				}).Return(
+				}, context.Background()).Return(
 					&[]models.CommandsWithoutID{
 						{
 							Command: "test1",
@@ -55,8 +56,9 @@ func TestRestApi_CreateNewCommandHandler(t *testing.T) {
 							Log: "test1 is successful",
 						},
 					},
+					models.NewBatch{},
 					context.Background(),
-				).Return(nil).AnyTimes()
+				).Return(uint(1), nil).AnyTimes()
 			},
 			expectedStatusCode: http.StatusOK,
 			expectedResponseIsError: false,
@@ -67,7 +69,7 @@ func TestRestApi_CreateNewCommandHandler(t *testing.T) {
 			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
 				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
 					BashStrings: []string{"test2"},
-				}).Return(
+				}, context.Background()).Return(
 					&[]models.CommandsWithoutID{
 						{
 							Command: "test2",
@@ -87,8 +89,9 @@ func TestRestApi_CreateNewCommandHandler(t *testing.T) {
 							Log: "test2 isn't successful",
 						},
 					},
+					models.NewBatch{},
 					context.Background(),
-				).Return(nil).AnyTimes()
+				).Return(uint(1), nil).AnyTimes()
 			},
 			expectedStatusCode: http.StatusOK,
 			expectedResponseIsError: true,
@@ -99,7 +102,7 @@ func TestRestApi_CreateNewCommandHandler(t *testing.T) {
 			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
 				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
 					BashStrings: []string{"test3"},
-				}).Return(
+				}, context.Background()).Return(
 					&[]models.CommandsWithoutID{
 						{
 							Command: "test3",
@@ -119,8 +122,9 @@ func TestRestApi_CreateNewCommandHandler(t *testing.T) {
 							Log: "test3 is successful",
 						},
 					},
+					models.NewBatch{},
 					context.Background(),
-				).Return(nil).AnyTimes()
+				).Return(uint(1), nil).AnyTimes()
 			},
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedResponseIsError: false,
@@ -170,6 +174,45 @@ func TestRestApi_CreateNewCommandHandler(t *testing.T) {
 
 }
 
+func TestRestApi_CreateNewCommandHandlerInvalidOptions(t *testing.T) {
+	testTable := []struct {
+		name string
+		inputBody string
+	} {
+		{
+			name: `invalid stdout regex`,
+			inputBody: `{"commands": [{"bash_string": "true", "assertions": {"stdout_regex": "("}}]}`,
+		},
+		{
+			name: `invalid retry regex`,
+			inputBody: `{"commands": [{"bash_string": "true", "retry": {"max_attempts": 2, "on_stderr_regex": "("}}]}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/create-command", bytes.NewBufferString(testCase.inputBody))
+			handleFunc := restApi.CreateNewCommandHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			// nothing runs, the mocks fail on any call
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status code %v but got %v", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
 func TestRestApi_GettingListCommandsQuery(t *testing.T) {
 	type mockDBBehavior func(*mock_database.MockDBWorker)
 
@@ -181,7 +224,7 @@ func TestRestApi_GettingListCommandsQuery(t *testing.T) {
 		{
 			name: `without db error`,
 			mockDBBehavior: func(m *mock_database.MockDBWorker) {
-				m.EXPECT().GettingListCommandsQuery(context.Background()).Return(
+				m.EXPECT().GettingListCommandsQuery(models.CommandFilter{}, context.Background()).Return(
 					&[]models.Commands{
 						{},
 					},
@@ -193,7 +236,7 @@ func TestRestApi_GettingListCommandsQuery(t *testing.T) {
 		{
 			name: `with db error`,
 			mockDBBehavior: func(m *mock_database.MockDBWorker) {
-				m.EXPECT().GettingListCommandsQuery(context.Background()).Return(
+				m.EXPECT().GettingListCommandsQuery(models.CommandFilter{}, context.Background()).Return(
 					nil,
 					fmt.Errorf("some db error"),
 				)
@@ -240,7 +283,7 @@ func TestRestApi_GettingSingleCommandHandler(t *testing.T) {
 			name: `default input`,
 			pathValue: "5",
 			mockDBBehavior: func(m *mock_database.MockDBWorker) {
-				m.EXPECT().GettingSingleCommandQuery(uint(5), context.Background()).Return(
+				m.EXPECT().GettingSingleCommandQuery(uint(5), nil, context.Background()).Return(
 					&models.Commands{
 						Id: 5,
 						Command: "some command id 5",
@@ -268,7 +311,7 @@ func TestRestApi_GettingSingleCommandHandler(t *testing.T) {
 			name: `db querry error`,
 			pathValue: "6",
 			mockDBBehavior: func(m *mock_database.MockDBWorker) {
-				m.EXPECT().GettingSingleCommandQuery(uint(6), context.Background()).Return(
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(
 					nil,
 					fmt.Errorf("some db error"),
 				)
@@ -301,4 +344,575 @@ func TestRestApi_GettingSingleCommandHandler(t *testing.T) {
 		}) 
 	}
 
-}
\ No newline at end of file
+}
+
+func TestRestApi_GettingSingleCommandHandlerEncoding(t *testing.T) {
+	testTable := []struct {
+		name string
+		query string
+		expectedStatusCode int
+		expectedLog string
+		expectedEncoding string
+	} {
+		{
+			name: `text`,
+			query: "",
+			expectedStatusCode: http.StatusOK,
+			expectedLog: "a\x00b\uFFFD",
+		},
+		{
+			name: `base64`,
+			query: "?encoding=base64",
+			expectedStatusCode: http.StatusOK,
+			expectedLog: "YQBi/w==",
+			expectedEncoding: models.LOG_ENCODING_BASE64,
+		},
+		{
+			name: `unknown encoding`,
+			query: "?encoding=hex",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			if testCase.expectedStatusCode == http.StatusOK {
+				mDatabase.EXPECT().GettingSingleCommandQuery(uint(5), nil, context.Background()).Return(
+					&models.Commands{Id: 5, Command: "binary", Log: "a\x00b\xff", ContentType: "application/octet-stream"}, nil)
+			}
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/get-commands/5" + testCase.query, nil)
+			r.SetPathValue("id", "5")
+			handleFunc := restApi.GettingSingleCommandHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			if w.Result().StatusCode == http.StatusOK {
+				var command models.Commands
+				if err := json.NewDecoder(w.Result().Body).Decode(&command); err != nil {
+					t.Fatalf("json decode error: %v", err)
+				}
+				if command.Log != testCase.expectedLog || command.LogEncoding != testCase.expectedEncoding {
+					t.Errorf("expected log %q %q but got %q %q", testCase.expectedLog, testCase.expectedEncoding, command.Log, command.LogEncoding)
+				}
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_DeleteCommandHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	ownKeyId := uint(3)
+	testTable := []struct {
+		name string
+		pathValue string
+		policy *models.RolePolicies
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `delete`,
+			pathValue: "6",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().DeleteCommandQuery(uint(6), nil, context.Background()).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `own commands only`,
+			pathValue: "6",
+			policy: &models.RolePolicies{Role: models.ROLE_VIEWER, OwnCommandsOnly: true},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().DeleteCommandQuery(uint(6), &ownKeyId, context.Background()).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `invalid id`,
+			pathValue: "0",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			pathValue: "6",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().DeleteCommandQuery(uint(6), nil, context.Background()).Return(fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/bash/commands/", nil)
+			r.SetPathValue("id", testCase.pathValue)
+			if testCase.policy != nil {
+				ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: ownKeyId, Role: testCase.policy.Role})
+				r = r.WithContext(auth.WithPolicy(ctx, testCase.policy))
+			}
+			handleFunc := restApi.DeleteCommandHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_RerunCommandHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+	type mockBashBehavior func(*mock_bash.MockBashCommandsWorker)
+
+	originalId := uint(7)
+	testTable := []struct {
+		name string
+		pathValue string
+		mockDBBehavior mockDBBehavior
+		mockBashBehavior mockBashBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `rerun is linked to the original`,
+			pathValue: "7",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
+					Commands: []bash.CommandOptions{{BashString: "flaky"}},
+				}, context.Background()).Return(
+					&[]models.CommandsWithoutID{
+						{Command: "flaky", Log: "ok"},
+					},
+					nil,
+				)
+			},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(originalId, nil, context.Background()).Return(
+					&models.Commands{Id: originalId, Command: "flaky", IsError: true},
+					nil,
+				)
+				m.EXPECT().CreateNewCommandsQuery(
+					[]models.CommandsWithoutID{
+						{RerunOf: &originalId, Command: "flaky", Log: "ok"},
+					},
+					models.NewBatch{},
+					context.Background(),
+				).Return(uint(2), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `rerun replays the stored options`,
+			pathValue: "7",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
+					Commands: []bash.CommandOptions{{
+						BashString: "deploy $STAGE",
+						Env: map[string]string{"STAGE": "prod"},
+						Retry: &models.RetryPolicy{MaxAttempts: 3},
+						TimeoutMs: 1000,
+						Workspace: "deploy",
+						Template: "deploy",
+						Parameters: map[string]string{"stage": "prod"},
+					}},
+				}, context.Background()).Return(
+					&[]models.CommandsWithoutID{
+						{Command: "deploy $STAGE", Log: "ok"},
+					},
+					nil,
+				)
+			},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(originalId, nil, context.Background()).Return(
+					&models.Commands{
+						Id: originalId,
+						Command: "deploy $STAGE",
+						Template: "deploy",
+						Parameters: map[string]string{"stage": "prod"},
+						Options: []byte(`{"bash_string": "deploy $STAGE", "env": {"STAGE": "prod"}, "retry": {"max_attempts": 3},
+							"timeout_ms": 1000, "workspace": "deploy"}`),
+					},
+					nil,
+				)
+				m.EXPECT().CreateNewCommandsQuery(
+					[]models.CommandsWithoutID{
+						{RerunOf: &originalId, Command: "deploy $STAGE", Log: "ok"},
+					},
+					models.NewBatch{},
+					context.Background(),
+				).Return(uint(2), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `command not found`,
+			pathValue: "8",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(8), nil, context.Background()).Return(
+					nil,
+					fmt.Errorf("no rows in result set"),
+				)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `pathValue is not number`,
+			pathValue: "not_number",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+			testCase.mockBashBehavior(mBash)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/commands/{id}/rerun", nil)
+			r.SetPathValue("id", testCase.pathValue)
+			handleFunc := restApi.RerunCommandHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		}) 
+	}
+}
+
+func TestRestApi_RerunBatchHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+	type mockBashBehavior func(*mock_bash.MockBashCommandsWorker)
+
+	batchId := uint(3)
+	failedId := uint(11)
+	batch := &models.Batches{
+		Id: batchId,
+		Commands: []models.Commands{
+			{Id: 10, BatchId: &batchId, Command: "succeeded", Log: "ok"},
+			{Id: failedId, BatchId: &batchId, Command: "failed", IsError: true, ExitCode: 1},
+		},
+	}
+
+	testTable := []struct {
+		name string
+		query string
+		mockDBBehavior mockDBBehavior
+		mockBashBehavior mockBashBehavior
+		expectedStatusCode int
+		expectedCommands int
+	} {
+		{
+			name: `rerun only failed`,
+			query: "?failed_only=true",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
+					Commands: []bash.CommandOptions{{BashString: "failed"}},
+				}, context.Background()).Return(
+					&[]models.CommandsWithoutID{
+						{Command: "failed", Log: "ok"},
+					},
+					nil,
+				)
+			},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingBatchQuery(batchId, nil, context.Background()).Return(batch, nil)
+				m.EXPECT().CreateNewCommandsQuery(
+					[]models.CommandsWithoutID{
+						{RerunOf: &failedId, Command: "failed", Log: "ok"},
+					},
+					models.NewBatch{RerunOf: &batchId},
+					context.Background(),
+				).Return(uint(4), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedCommands: 1,
+		},
+		{
+			name: `rerun whole batch`,
+			query: "",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
+					Commands: []bash.CommandOptions{{BashString: "succeeded"}, {BashString: "failed"}},
+				}, context.Background()).Return(
+					&[]models.CommandsWithoutID{
+						{Command: "failed", Log: "ok"},
+						{Command: "succeeded", Log: "ok"},
+					},
+					nil,
+				)
+			},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingBatchQuery(batchId, nil, context.Background()).Return(batch, nil)
+				m.EXPECT().CreateNewCommandsQuery(gomock.Any(), models.NewBatch{RerunOf: &batchId}, context.Background()).Return(uint(5), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedCommands: 2,
+		},
+		{
+			name: `failed_only is not a bool`,
+			query: "?failed_only=maybe",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			query: "",
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingBatchQuery(batchId, nil, context.Background()).Return(nil, fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+			testCase.mockBashBehavior(mBash)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/batches/3/rerun" + testCase.query, nil)
+			r.SetPathValue("id", "3")
+			handleFunc := restApi.RerunBatchHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+			if testCase.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			wBodyStruct := []models.CommandsWithoutID{}
+			if err := json.NewDecoder(w.Result().Body).Decode(&wBodyStruct); err != nil {
+				t.Error("writer body json unmarshall error")
+				return
+			}
+			if len(wBodyStruct) != testCase.expectedCommands {
+				t.Errorf("expected %v commands but got %v", testCase.expectedCommands, len(wBodyStruct))
+			}
+			for _, command := range wBodyStruct {
+				if command.RerunOf == nil {
+					t.Errorf("command %q is not linked to the original", command.Command)
+				}
+			}
+		}) 
+	}
+}
+
+func TestRestApi_CommandsDiffHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	original := &models.Commands{Id: 1, Command: "date; echo ok", ExitCode: 0, DurationMs: 100,
+		Log: "\x1b[32m2024-05-09 10:38:18\x1b[0m\nok\n"}
+	rerun := &models.Commands{Id: 2, Command: "date; echo ok", ExitCode: 1, DurationMs: 250,
+		Log: "2024-05-10 11:00:00\nok\n"}
+
+	testTable := []struct {
+		name string
+		query string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+		expectedIsLogEqual bool
+	} {
+		{
+			name: `logs differ`,
+			query: "",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(1), nil, context.Background()).Return(original, nil)
+				m.EXPECT().GettingSingleCommandQuery(uint(2), nil, context.Background()).Return(rerun, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedIsLogEqual: false,
+		},
+		{
+			name: `ignore ansi and timestamps`,
+			query: "?ignore_ansi=true&ignore_timestamps=true",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(1), nil, context.Background()).Return(original, nil)
+				m.EXPECT().GettingSingleCommandQuery(uint(2), nil, context.Background()).Return(rerun, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedIsLogEqual: true,
+		},
+		{
+			name: `context is not a number`,
+			query: "?context=many",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			query: "",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(1), nil, context.Background()).Return(original, nil)
+				m.EXPECT().GettingSingleCommandQuery(uint(2), nil, context.Background()).Return(nil, fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/commands/1/diff/2" + testCase.query, nil)
+			r.SetPathValue("id", "1")
+			r.SetPathValue("otherId", "2")
+			handleFunc := restApi.CommandsDiffHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+			if testCase.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			wBodyStruct := models.CommandsDiff{}
+			if err := json.NewDecoder(w.Result().Body).Decode(&wBodyStruct); err != nil {
+				t.Error("writer body json unmarshall error")
+				return
+			}
+			if wBodyStruct.IsLogEqual != testCase.expectedIsLogEqual {
+				t.Errorf("expected is_log_equal %v but got %v, diff:\n%v", testCase.expectedIsLogEqual, wBodyStruct.IsLogEqual, wBodyStruct.LogDiff)
+			}
+			if wBodyStruct.ExitCodeDelta != 1 || wBodyStruct.DurationDeltaMs != 150 {
+				t.Errorf("unexpected deltas: exit code %v, duration %v", wBodyStruct.ExitCodeDelta, wBodyStruct.DurationDeltaMs)
+			}
+		}) 
+	}
+}
+
+func TestRestApi_GettingBatchGroupsHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	batchId := uint(6)
+	batch := &models.Batches{
+		Id: batchId,
+		Commands: []models.Commands{
+			{Id: 1, Command: "ping -c1 a", Template: "ping -c1 {{host}}", Parameters: map[string]string{"host": "a"}},
+			{Id: 2, Command: "ping -c1 b", Template: "ping -c1 {{host}}", Parameters: map[string]string{"host": "b"}, ExitCode: 1},
+			{Id: 3, Command: "ping -c2 a", Template: "ping -c2 {{host}}", Parameters: map[string]string{"host": "a"}},
+			{Id: 4, Command: "uptime"},
+		},
+	}
+
+	testTable := []struct {
+		name string
+		query string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+		expectedGroups []models.CommandsGroup
+	} {
+		{
+			name: `group by host`,
+			query: "?by=host",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingBatchQuery(batchId, nil, context.Background()).Return(batch, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedGroups: []models.CommandsGroup{
+				{Parameter: "host", Value: "a", Commands: []models.Commands{batch.Commands[0], batch.Commands[2]}},
+				{Parameter: "host", Value: "b", FailedCount: 1, Commands: []models.Commands{batch.Commands[1]}},
+			},
+		},
+		{
+			name: `without parameter`,
+			query: "",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/batches/6/groups" + testCase.query, nil)
+			r.SetPathValue("id", "6")
+			handleFunc := restApi.GettingBatchGroupsHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+			if testCase.expectedGroups == nil {
+				return
+			}
+			var groups []models.CommandsGroup
+			if err := json.NewDecoder(w.Result().Body).Decode(&groups); err != nil {
+				t.Fatalf("json decode error: %v", err)
+			}
+			expected, _ := json.Marshal(testCase.expectedGroups)
+			got, _ := json.Marshal(groups)
+			if string(got) != string(expected) {
+				t.Errorf("expected groups %s but got %s", expected, got)
+			}
+		}) 
+	}
+}
@@ -8,7 +8,10 @@ import (
 	http "net/http"
 	reflect "reflect"
 
+	artifacts "github.com/Vy4cheSlave/test-task-postgres/artifacts"
+	bash "github.com/Vy4cheSlave/test-task-postgres/bash"
 	database "github.com/Vy4cheSlave/test-task-postgres/database"
+	workspaces "github.com/Vy4cheSlave/test-task-postgres/workspaces"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,18 +38,326 @@ func (m *MockRestApiWorker) EXPECT() *MockRestApiWorkerMockRecorder {
 	return m.recorder
 }
 
+// CancelRunHandler mocks base method.
+func (m *MockRestApiWorker) CancelRunHandler(arg0 *bash.Runs) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelRunHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// CancelRunHandler indicates an expected call of CancelRunHandler.
+func (mr *MockRestApiWorkerMockRecorder) CancelRunHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelRunHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CancelRunHandler), arg0)
+}
+
+// CommandsDiffHandler mocks base method.
+func (m *MockRestApiWorker) CommandsDiffHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommandsDiffHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// CommandsDiffHandler indicates an expected call of CommandsDiffHandler.
+func (mr *MockRestApiWorkerMockRecorder) CommandsDiffHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommandsDiffHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CommandsDiffHandler), arg0)
+}
+
+// CreateNewApiKeyHandler mocks base method.
+func (m *MockRestApiWorker) CreateNewApiKeyHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewApiKeyHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// CreateNewApiKeyHandler indicates an expected call of CreateNewApiKeyHandler.
+func (mr *MockRestApiWorkerMockRecorder) CreateNewApiKeyHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewApiKeyHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CreateNewApiKeyHandler), arg0)
+}
+
 // CreateNewCommandHandler mocks base method.
-func (m *MockRestApiWorker) CreateNewCommandHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+func (m *MockRestApiWorker) CreateNewCommandHandler(arg0 database.DBWorker, arg1 bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewCommandHandler", arg0)
+	ret := m.ctrl.Call(m, "CreateNewCommandHandler", arg0, arg1)
 	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
 	return ret0
 }
 
 // CreateNewCommandHandler indicates an expected call of CreateNewCommandHandler.
-func (mr *MockRestApiWorkerMockRecorder) CreateNewCommandHandler(arg0 interface{}) *gomock.Call {
+func (mr *MockRestApiWorkerMockRecorder) CreateNewCommandHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewCommandHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CreateNewCommandHandler), arg0, arg1)
+}
+
+// CreateNewScheduleHandler mocks base method.
+func (m *MockRestApiWorker) CreateNewScheduleHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewScheduleHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// CreateNewScheduleHandler indicates an expected call of CreateNewScheduleHandler.
+func (mr *MockRestApiWorkerMockRecorder) CreateNewScheduleHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewScheduleHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CreateNewScheduleHandler), arg0)
+}
+
+// CreateNewWebhookHandler mocks base method.
+func (m *MockRestApiWorker) CreateNewWebhookHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewWebhookHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// CreateNewWebhookHandler indicates an expected call of CreateNewWebhookHandler.
+func (mr *MockRestApiWorkerMockRecorder) CreateNewWebhookHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewWebhookHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CreateNewWebhookHandler), arg0)
+}
+
+// CreateNewWorkflowHandler mocks base method.
+func (m *MockRestApiWorker) CreateNewWorkflowHandler(arg0 database.DBWorker, arg1 bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewWorkflowHandler", arg0, arg1)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// CreateNewWorkflowHandler indicates an expected call of CreateNewWorkflowHandler.
+func (mr *MockRestApiWorkerMockRecorder) CreateNewWorkflowHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewWorkflowHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CreateNewWorkflowHandler), arg0, arg1)
+}
+
+// DeleteCommandHandler mocks base method.
+func (m *MockRestApiWorker) DeleteCommandHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCommandHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// DeleteCommandHandler indicates an expected call of DeleteCommandHandler.
+func (mr *MockRestApiWorkerMockRecorder) DeleteCommandHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommandHandler", reflect.TypeOf((*MockRestApiWorker)(nil).DeleteCommandHandler), arg0)
+}
+
+// DeleteScheduleHandler mocks base method.
+func (m *MockRestApiWorker) DeleteScheduleHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScheduleHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// DeleteScheduleHandler indicates an expected call of DeleteScheduleHandler.
+func (mr *MockRestApiWorkerMockRecorder) DeleteScheduleHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduleHandler", reflect.TypeOf((*MockRestApiWorker)(nil).DeleteScheduleHandler), arg0)
+}
+
+// DeleteSecretHandler mocks base method.
+func (m *MockRestApiWorker) DeleteSecretHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecretHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// DeleteSecretHandler indicates an expected call of DeleteSecretHandler.
+func (mr *MockRestApiWorkerMockRecorder) DeleteSecretHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecretHandler", reflect.TypeOf((*MockRestApiWorker)(nil).DeleteSecretHandler), arg0)
+}
+
+// DeleteWebhookHandler mocks base method.
+func (m *MockRestApiWorker) DeleteWebhookHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// DeleteWebhookHandler indicates an expected call of DeleteWebhookHandler.
+func (mr *MockRestApiWorkerMockRecorder) DeleteWebhookHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookHandler", reflect.TypeOf((*MockRestApiWorker)(nil).DeleteWebhookHandler), arg0)
+}
+
+// DeleteWorkspaceFileHandler mocks base method.
+func (m *MockRestApiWorker) DeleteWorkspaceFileHandler(arg0 *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWorkspaceFileHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// DeleteWorkspaceFileHandler indicates an expected call of DeleteWorkspaceFileHandler.
+func (mr *MockRestApiWorkerMockRecorder) DeleteWorkspaceFileHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWorkspaceFileHandler", reflect.TypeOf((*MockRestApiWorker)(nil).DeleteWorkspaceFileHandler), arg0)
+}
+
+// DeleteWorkspaceHandler mocks base method.
+func (m *MockRestApiWorker) DeleteWorkspaceHandler(arg0 *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWorkspaceHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// DeleteWorkspaceHandler indicates an expected call of DeleteWorkspaceHandler.
+func (mr *MockRestApiWorkerMockRecorder) DeleteWorkspaceHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWorkspaceHandler", reflect.TypeOf((*MockRestApiWorker)(nil).DeleteWorkspaceHandler), arg0)
+}
+
+// ExportCommandsHandler mocks base method.
+func (m *MockRestApiWorker) ExportCommandsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportCommandsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// ExportCommandsHandler indicates an expected call of ExportCommandsHandler.
+func (mr *MockRestApiWorkerMockRecorder) ExportCommandsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportCommandsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).ExportCommandsHandler), arg0)
+}
+
+// GettingArtifactHandler mocks base method.
+func (m *MockRestApiWorker) GettingArtifactHandler(arg0 database.DBWorker, arg1 *artifacts.Store) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingArtifactHandler", arg0, arg1)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingArtifactHandler indicates an expected call of GettingArtifactHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingArtifactHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingArtifactHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingArtifactHandler), arg0, arg1)
+}
+
+// GettingArtifactsZipHandler mocks base method.
+func (m *MockRestApiWorker) GettingArtifactsZipHandler(arg0 database.DBWorker, arg1 *artifacts.Store) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingArtifactsZipHandler", arg0, arg1)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingArtifactsZipHandler indicates an expected call of GettingArtifactsZipHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingArtifactsZipHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingArtifactsZipHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingArtifactsZipHandler), arg0, arg1)
+}
+
+// GettingAuditLogHandler mocks base method.
+func (m *MockRestApiWorker) GettingAuditLogHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingAuditLogHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingAuditLogHandler indicates an expected call of GettingAuditLogHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingAuditLogHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingAuditLogHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingAuditLogHandler), arg0)
+}
+
+// GettingBatchGroupsHandler mocks base method.
+func (m *MockRestApiWorker) GettingBatchGroupsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingBatchGroupsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingBatchGroupsHandler indicates an expected call of GettingBatchGroupsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingBatchGroupsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingBatchGroupsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingBatchGroupsHandler), arg0)
+}
+
+// GettingBatchHandler mocks base method.
+func (m *MockRestApiWorker) GettingBatchHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingBatchHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingBatchHandler indicates an expected call of GettingBatchHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingBatchHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingBatchHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingBatchHandler), arg0)
+}
+
+// GettingCommandArtifactsHandler mocks base method.
+func (m *MockRestApiWorker) GettingCommandArtifactsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingCommandArtifactsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingCommandArtifactsHandler indicates an expected call of GettingCommandArtifactsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingCommandArtifactsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingCommandArtifactsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingCommandArtifactsHandler), arg0)
+}
+
+// GettingCommandAttemptsHandler mocks base method.
+func (m *MockRestApiWorker) GettingCommandAttemptsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingCommandAttemptsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingCommandAttemptsHandler indicates an expected call of GettingCommandAttemptsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingCommandAttemptsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingCommandAttemptsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingCommandAttemptsHandler), arg0)
+}
+
+// GettingCommandOutputHandler mocks base method.
+func (m *MockRestApiWorker) GettingCommandOutputHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingCommandOutputHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingCommandOutputHandler indicates an expected call of GettingCommandOutputHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingCommandOutputHandler(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewCommandHandler", reflect.TypeOf((*MockRestApiWorker)(nil).CreateNewCommandHandler), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingCommandOutputHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingCommandOutputHandler), arg0)
+}
+
+// GettingListApiKeysHandler mocks base method.
+func (m *MockRestApiWorker) GettingListApiKeysHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListApiKeysHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListApiKeysHandler indicates an expected call of GettingListApiKeysHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListApiKeysHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListApiKeysHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListApiKeysHandler), arg0)
 }
 
 // GettingListCommandsHandler mocks base method.
@@ -63,6 +374,174 @@ func (mr *MockRestApiWorkerMockRecorder) GettingListCommandsHandler(arg0 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListCommandsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListCommandsHandler), arg0)
 }
 
+// GettingListRolePoliciesHandler mocks base method.
+func (m *MockRestApiWorker) GettingListRolePoliciesHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListRolePoliciesHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListRolePoliciesHandler indicates an expected call of GettingListRolePoliciesHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListRolePoliciesHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListRolePoliciesHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListRolePoliciesHandler), arg0)
+}
+
+// GettingListRunsHandler mocks base method.
+func (m *MockRestApiWorker) GettingListRunsHandler(arg0 *bash.Runs) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListRunsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListRunsHandler indicates an expected call of GettingListRunsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListRunsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListRunsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListRunsHandler), arg0)
+}
+
+// GettingListSchedulesHandler mocks base method.
+func (m *MockRestApiWorker) GettingListSchedulesHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListSchedulesHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListSchedulesHandler indicates an expected call of GettingListSchedulesHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListSchedulesHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListSchedulesHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListSchedulesHandler), arg0)
+}
+
+// GettingListScriptsHandler mocks base method.
+func (m *MockRestApiWorker) GettingListScriptsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListScriptsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListScriptsHandler indicates an expected call of GettingListScriptsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListScriptsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListScriptsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListScriptsHandler), arg0)
+}
+
+// GettingListSecretsHandler mocks base method.
+func (m *MockRestApiWorker) GettingListSecretsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListSecretsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListSecretsHandler indicates an expected call of GettingListSecretsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListSecretsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListSecretsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListSecretsHandler), arg0)
+}
+
+// GettingListWebhooksHandler mocks base method.
+func (m *MockRestApiWorker) GettingListWebhooksHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListWebhooksHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListWebhooksHandler indicates an expected call of GettingListWebhooksHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListWebhooksHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListWebhooksHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListWebhooksHandler), arg0)
+}
+
+// GettingListWorkflowsHandler mocks base method.
+func (m *MockRestApiWorker) GettingListWorkflowsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListWorkflowsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListWorkflowsHandler indicates an expected call of GettingListWorkflowsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListWorkflowsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListWorkflowsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListWorkflowsHandler), arg0)
+}
+
+// GettingListWorkspacesHandler mocks base method.
+func (m *MockRestApiWorker) GettingListWorkspacesHandler(arg0 *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingListWorkspacesHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingListWorkspacesHandler indicates an expected call of GettingListWorkspacesHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingListWorkspacesHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingListWorkspacesHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingListWorkspacesHandler), arg0)
+}
+
+// GettingRunStreamHandler mocks base method.
+func (m *MockRestApiWorker) GettingRunStreamHandler(arg0 *bash.Runs) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingRunStreamHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingRunStreamHandler indicates an expected call of GettingRunStreamHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingRunStreamHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingRunStreamHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingRunStreamHandler), arg0)
+}
+
+// GettingScheduleBatchesHandler mocks base method.
+func (m *MockRestApiWorker) GettingScheduleBatchesHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingScheduleBatchesHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingScheduleBatchesHandler indicates an expected call of GettingScheduleBatchesHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingScheduleBatchesHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingScheduleBatchesHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingScheduleBatchesHandler), arg0)
+}
+
+// GettingScriptHandler mocks base method.
+func (m *MockRestApiWorker) GettingScriptHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingScriptHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingScriptHandler indicates an expected call of GettingScriptHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingScriptHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingScriptHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingScriptHandler), arg0)
+}
+
+// GettingScriptVersionsHandler mocks base method.
+func (m *MockRestApiWorker) GettingScriptVersionsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingScriptVersionsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingScriptVersionsHandler indicates an expected call of GettingScriptVersionsHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingScriptVersionsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingScriptVersionsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingScriptVersionsHandler), arg0)
+}
+
 // GettingSingleCommandHandler mocks base method.
 func (m *MockRestApiWorker) GettingSingleCommandHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
 	m.ctrl.T.Helper()
@@ -76,3 +555,269 @@ func (mr *MockRestApiWorkerMockRecorder) GettingSingleCommandHandler(arg0 interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSingleCommandHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingSingleCommandHandler), arg0)
 }
+
+// GettingSingleScheduleHandler mocks base method.
+func (m *MockRestApiWorker) GettingSingleScheduleHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingSingleScheduleHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingSingleScheduleHandler indicates an expected call of GettingSingleScheduleHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingSingleScheduleHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSingleScheduleHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingSingleScheduleHandler), arg0)
+}
+
+// GettingSingleWorkflowHandler mocks base method.
+func (m *MockRestApiWorker) GettingSingleWorkflowHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingSingleWorkflowHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingSingleWorkflowHandler indicates an expected call of GettingSingleWorkflowHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingSingleWorkflowHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingSingleWorkflowHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingSingleWorkflowHandler), arg0)
+}
+
+// GettingUsageHandler mocks base method.
+func (m *MockRestApiWorker) GettingUsageHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingUsageHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingUsageHandler indicates an expected call of GettingUsageHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingUsageHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingUsageHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingUsageHandler), arg0)
+}
+
+// GettingWebhookDeliveriesHandler mocks base method.
+func (m *MockRestApiWorker) GettingWebhookDeliveriesHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingWebhookDeliveriesHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingWebhookDeliveriesHandler indicates an expected call of GettingWebhookDeliveriesHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingWebhookDeliveriesHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingWebhookDeliveriesHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingWebhookDeliveriesHandler), arg0)
+}
+
+// GettingWorkspaceFileHandler mocks base method.
+func (m *MockRestApiWorker) GettingWorkspaceFileHandler(arg0 *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingWorkspaceFileHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingWorkspaceFileHandler indicates an expected call of GettingWorkspaceFileHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingWorkspaceFileHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingWorkspaceFileHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingWorkspaceFileHandler), arg0)
+}
+
+// GettingWorkspaceFilesHandler mocks base method.
+func (m *MockRestApiWorker) GettingWorkspaceFilesHandler(arg0 *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingWorkspaceFilesHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingWorkspaceFilesHandler indicates an expected call of GettingWorkspaceFilesHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingWorkspaceFilesHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingWorkspaceFilesHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingWorkspaceFilesHandler), arg0)
+}
+
+// GettingWorkspaceSnapshotHandler mocks base method.
+func (m *MockRestApiWorker) GettingWorkspaceSnapshotHandler(arg0 *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GettingWorkspaceSnapshotHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// GettingWorkspaceSnapshotHandler indicates an expected call of GettingWorkspaceSnapshotHandler.
+func (mr *MockRestApiWorkerMockRecorder) GettingWorkspaceSnapshotHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GettingWorkspaceSnapshotHandler", reflect.TypeOf((*MockRestApiWorker)(nil).GettingWorkspaceSnapshotHandler), arg0)
+}
+
+// ImportCommandsHandler mocks base method.
+func (m *MockRestApiWorker) ImportCommandsHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportCommandsHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// ImportCommandsHandler indicates an expected call of ImportCommandsHandler.
+func (mr *MockRestApiWorkerMockRecorder) ImportCommandsHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportCommandsHandler", reflect.TypeOf((*MockRestApiWorker)(nil).ImportCommandsHandler), arg0)
+}
+
+// PauseScheduleHandler mocks base method.
+func (m *MockRestApiWorker) PauseScheduleHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseScheduleHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// PauseScheduleHandler indicates an expected call of PauseScheduleHandler.
+func (mr *MockRestApiWorkerMockRecorder) PauseScheduleHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseScheduleHandler", reflect.TypeOf((*MockRestApiWorker)(nil).PauseScheduleHandler), arg0)
+}
+
+// RerunBatchHandler mocks base method.
+func (m *MockRestApiWorker) RerunBatchHandler(arg0 database.DBWorker, arg1 bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RerunBatchHandler", arg0, arg1)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// RerunBatchHandler indicates an expected call of RerunBatchHandler.
+func (mr *MockRestApiWorkerMockRecorder) RerunBatchHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RerunBatchHandler", reflect.TypeOf((*MockRestApiWorker)(nil).RerunBatchHandler), arg0, arg1)
+}
+
+// RerunCommandHandler mocks base method.
+func (m *MockRestApiWorker) RerunCommandHandler(arg0 database.DBWorker, arg1 bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RerunCommandHandler", arg0, arg1)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// RerunCommandHandler indicates an expected call of RerunCommandHandler.
+func (mr *MockRestApiWorkerMockRecorder) RerunCommandHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RerunCommandHandler", reflect.TypeOf((*MockRestApiWorker)(nil).RerunCommandHandler), arg0, arg1)
+}
+
+// ResumeScheduleHandler mocks base method.
+func (m *MockRestApiWorker) ResumeScheduleHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeScheduleHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// ResumeScheduleHandler indicates an expected call of ResumeScheduleHandler.
+func (mr *MockRestApiWorkerMockRecorder) ResumeScheduleHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeScheduleHandler", reflect.TypeOf((*MockRestApiWorker)(nil).ResumeScheduleHandler), arg0)
+}
+
+// RevokeApiKeyHandler mocks base method.
+func (m *MockRestApiWorker) RevokeApiKeyHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKeyHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// RevokeApiKeyHandler indicates an expected call of RevokeApiKeyHandler.
+func (mr *MockRestApiWorkerMockRecorder) RevokeApiKeyHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKeyHandler", reflect.TypeOf((*MockRestApiWorker)(nil).RevokeApiKeyHandler), arg0)
+}
+
+// RunScriptHandler mocks base method.
+func (m *MockRestApiWorker) RunScriptHandler(arg0 database.DBWorker, arg1 bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunScriptHandler", arg0, arg1)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// RunScriptHandler indicates an expected call of RunScriptHandler.
+func (mr *MockRestApiWorkerMockRecorder) RunScriptHandler(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunScriptHandler", reflect.TypeOf((*MockRestApiWorker)(nil).RunScriptHandler), arg0, arg1)
+}
+
+// SaveScriptHandler mocks base method.
+func (m *MockRestApiWorker) SaveScriptHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveScriptHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// SaveScriptHandler indicates an expected call of SaveScriptHandler.
+func (mr *MockRestApiWorkerMockRecorder) SaveScriptHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveScriptHandler", reflect.TypeOf((*MockRestApiWorker)(nil).SaveScriptHandler), arg0)
+}
+
+// SaveSecretHandler mocks base method.
+func (m *MockRestApiWorker) SaveSecretHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSecretHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// SaveSecretHandler indicates an expected call of SaveSecretHandler.
+func (mr *MockRestApiWorkerMockRecorder) SaveSecretHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSecretHandler", reflect.TypeOf((*MockRestApiWorker)(nil).SaveSecretHandler), arg0)
+}
+
+// SaveWorkspaceFileHandler mocks base method.
+func (m *MockRestApiWorker) SaveWorkspaceFileHandler(arg0 *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWorkspaceFileHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// SaveWorkspaceFileHandler indicates an expected call of SaveWorkspaceFileHandler.
+func (mr *MockRestApiWorkerMockRecorder) SaveWorkspaceFileHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWorkspaceFileHandler", reflect.TypeOf((*MockRestApiWorker)(nil).SaveWorkspaceFileHandler), arg0)
+}
+
+// UpdateRolePolicyHandler mocks base method.
+func (m *MockRestApiWorker) UpdateRolePolicyHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRolePolicyHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// UpdateRolePolicyHandler indicates an expected call of UpdateRolePolicyHandler.
+func (mr *MockRestApiWorkerMockRecorder) UpdateRolePolicyHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRolePolicyHandler", reflect.TypeOf((*MockRestApiWorker)(nil).UpdateRolePolicyHandler), arg0)
+}
+
+// VerifyAuditLogHandler mocks base method.
+func (m *MockRestApiWorker) VerifyAuditLogHandler(arg0 database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAuditLogHandler", arg0)
+	ret0, _ := ret[0].(func(http.ResponseWriter, *http.Request))
+	return ret0
+}
+
+// VerifyAuditLogHandler indicates an expected call of VerifyAuditLogHandler.
+func (mr *MockRestApiWorkerMockRecorder) VerifyAuditLogHandler(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAuditLogHandler", reflect.TypeOf((*MockRestApiWorker)(nil).VerifyAuditLogHandler), arg0)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	// headers of the output endpoint, the served output may be only the head
+	// and the tail of the stream, or the first stored bytes of it
+	OUTPUT_TRUNCATED_HEADER string = "X-Output-Truncated"
+	OUTPUT_BYTES_HEADER     string = "X-Output-Bytes"
+)
+
+// chunkedOutput reads the stream of a command stored in chunks one chunk at a
+// time, http.ServeContent seeks it to the requested range.
+type chunkedOutput struct {
+	db        database.DBWorker
+	commandId uint
+	size      int64
+	offset    int64
+	// the chunk read last and its number
+	chunk []byte
+	seq   int
+}
+
+func (output *chunkedOutput) Read(buf []byte) (int, error) {
+	if output.offset >= output.size {
+		return 0, io.EOF
+	}
+	seq := int(output.offset / int64(database.OUTPUT_CHUNK_BYTES))
+	if output.chunk == nil || output.seq != seq {
+		chunk, err := output.db.GettingOutputChunkQuery(output.commandId, seq, context.Background())
+		if err != nil {
+			return 0, err
+		}
+		output.chunk, output.seq = chunk, seq
+	}
+	start := output.offset - int64(seq)*int64(database.OUTPUT_CHUNK_BYTES)
+	if start >= int64(len(output.chunk)) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(buf, output.chunk[start:])
+	output.offset += int64(n)
+	return n, nil
+}
+
+func (output *chunkedOutput) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += output.offset
+	case io.SeekEnd:
+		offset += output.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek before the start of the output")
+	}
+	output.offset = offset
+	return offset, nil
+}
+
+// outputRange returns the part of the log of the offset and limit query
+// values, the whole log without them.
+func outputRange(r *http.Request, size int) (int, int, error) {
+	start, end := 0, size
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", value)
+		}
+		start = min(offset, size)
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return 0, 0, fmt.Errorf("invalid limit %q", value)
+		}
+		end = min(start+limit, size)
+	}
+	return start, end, nil
+}
+
+// GettingCommandOutputHandler returns the stored output of a command as it
+// is: the whole stream when it is stored in chunks, the log otherwise. A part
+// of it is selected by the Range header or the offset and limit query values
+// in bytes.
+//
+//	@Tags		/bash/
+//	@Produce	plain,octet-stream
+//	@Param		id		path	uint	true	"uint without 0"	minimum(1)
+//	@Param		offset	query	uint	false	"first byte"
+//	@Param		limit	query	uint	false	"number of bytes"
+//	@Param		Range	header	string	false	"bytes=start-end"
+//	@Router		/bash/commands/{id}/output [get]
+func (restApi RestApi) GettingCommandOutputHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		command, err := db.GettingSingleCommandQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		// the log is served as it is stored, binary output keeps its bytes
+		contentType := command.ContentType
+		if contentType == "" {
+			contentType = models.TEXT_CONTENT_TYPE
+		}
+		var output io.ReadSeeker = strings.NewReader(command.Log)
+		size, truncated := len(command.Log), command.Truncated
+		if command.StoredOutputBytes != 0 {
+			output = &chunkedOutput{db: db, commandId: command.Id, size: command.StoredOutputBytes}
+			size, truncated = int(command.StoredOutputBytes), command.StoredOutputBytes < command.OutputBytes
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set(OUTPUT_TRUNCATED_HEADER, strconv.FormatBool(truncated))
+		w.Header().Set(OUTPUT_BYTES_HEADER, strconv.FormatInt(command.OutputBytes, 10))
+		if r.URL.Query().Has("offset") || r.URL.Query().Has("limit") {
+			start, end, err := outputRange(r, size)
+			if err != nil {
+				closeHandlerWithErr(w, err)
+				return
+			}
+			output.Seek(int64(start), io.SeekStart)
+			w.WriteHeader(http.StatusOK)
+			if _, err := io.CopyN(w, output, int64(end-start)); err != nil {
+				log.Printf("output of command %v: %v\n", command.Id, err)
+			}
+			return
+		}
+		// answers Range requests with 206 and unsatisfiable ones with 416
+		http.ServeContent(w, r, "", time.Time{}, output)
+	}
+}
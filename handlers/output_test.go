@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_GettingCommandOutputHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	command := &models.Commands{Id: 6, Log: "0123456789", Truncated: true, OutputBytes: 4096}
+	testTable := []struct {
+		name string
+		query string
+		rangeHeader string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+		expectedBody string
+	} {
+		{
+			name: `whole output`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(command, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: "0123456789",
+		},
+		{
+			name: `offset and limit`,
+			query: "?offset=2&limit=3",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(command, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: "234",
+		},
+		{
+			name: `offset past the end`,
+			query: "?offset=20",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(command, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: "",
+		},
+		{
+			name: `range header`,
+			rangeHeader: "bytes=-4",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(command, nil)
+			},
+			expectedStatusCode: http.StatusPartialContent,
+			expectedBody: "6789",
+		},
+		{
+			name: `unsatisfiable range`,
+			rangeHeader: "bytes=20-30",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(command, nil)
+			},
+			expectedStatusCode: http.StatusRequestedRangeNotSatisfiable,
+		},
+		{
+			name: `invalid limit`,
+			query: "?limit=-1",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(command, nil)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(nil, fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/commands/6/output" + testCase.query, nil)
+			r.SetPathValue("id", "6")
+			if testCase.rangeHeader != "" {
+				r.Header.Set("Range", testCase.rangeHeader)
+			}
+			handleFunc := restApi.GettingCommandOutputHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			body, _ := io.ReadAll(w.Result().Body)
+			if testCase.expectedBody != "" && string(body) != testCase.expectedBody {
+				t.Errorf("expected body %q but got %q", testCase.expectedBody, body)
+			}
+			if w.Result().StatusCode == http.StatusOK && (w.Result().Header.Get(OUTPUT_TRUNCATED_HEADER) != "true" ||
+				w.Result().Header.Get(OUTPUT_BYTES_HEADER) != "4096") {
+				t.Errorf("unexpected truncation headers %v", w.Result().Header)
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_GettingCommandOutputHandlerChunks(t *testing.T) {
+	// the stream is stored in two whole chunks and a part of one
+	stream := make([]byte, 2*database.OUTPUT_CHUNK_BYTES+100)
+	for i := range stream {
+		stream[i] = byte('a' + i%26)
+	}
+	command := &models.Commands{Id: 6, Log: "head\n[... truncated ...]\ntail", Truncated: true,
+		OutputBytes: int64(len(stream)), StoredOutputBytes: int64(len(stream))}
+	boundary := database.OUTPUT_CHUNK_BYTES
+
+	testTable := []struct {
+		name string
+		query string
+		rangeHeader string
+		expectedStatusCode int
+		expectedBody []byte
+	} {
+		{
+			name: `whole output`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody: stream,
+		},
+		{
+			name: `offset and limit across chunks`,
+			query: fmt.Sprintf("?offset=%v&limit=10", boundary-5),
+			expectedStatusCode: http.StatusOK,
+			expectedBody: stream[boundary-5:boundary+5],
+		},
+		{
+			name: `range header in the last chunk`,
+			rangeHeader: "bytes=-4",
+			expectedStatusCode: http.StatusPartialContent,
+			expectedBody: stream[len(stream)-4:],
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			mDatabase.EXPECT().GettingSingleCommandQuery(uint(6), nil, context.Background()).Return(command, nil)
+			mDatabase.EXPECT().GettingOutputChunkQuery(uint(6), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(commandId uint, seq int, ctx context.Context) ([]byte, error) {
+					return stream[seq*boundary:min((seq+1)*boundary, len(stream))], nil
+				},
+			).AnyTimes()
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/commands/6/output" + testCase.query, nil)
+			r.SetPathValue("id", "6")
+			if testCase.rangeHeader != "" {
+				r.Header.Set("Range", testCase.rangeHeader)
+			}
+			handleFunc := restApi.GettingCommandOutputHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			body, _ := io.ReadAll(w.Result().Body)
+			if !bytes.Equal(body, testCase.expectedBody) {
+				t.Errorf("expected %v bytes of the stream but got %v bytes", len(testCase.expectedBody), len(body))
+			}
+			if w.Result().Header.Get(OUTPUT_TRUNCATED_HEADER) != "false" {
+				t.Errorf("the whole stream is served as truncated")
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
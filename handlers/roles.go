@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+type ReqUpdateRolePolicyBody struct {
+	// programs the role may run, any program when empty
+	AllowedBinaries []string `json:"allowed_binaries"`
+	// the keys of the role see only the commands they submitted
+	OwnCommandsOnly bool `json:"own_commands_only"`
+	// quotas of every key of the role, 0 is no limit
+	CommandsPerDay int `json:"commands_per_day"`
+	CpuSecondsPerDay int `json:"cpu_seconds_per_day"`
+	MaxConcurrentCommands int `json:"max_concurrent_commands"`
+}
+
+//	@Tags		/bash/roles/
+//	@Produce	json
+//	@Router		/bash/roles [get]
+func (restApi RestApi) GettingListRolePoliciesHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policies, err := db.GettingListRolePoliciesQuery(context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, policies)
+	}
+}
+
+// UpdateRolePolicyHandler replaces the command policy and the quotas of a
+// role, they apply to the next requests of the keys of the role.
+//
+//	@Tags		/bash/roles/
+//	@Accept		json
+//	@Produce	json
+//	@Param		role	path	string					true	"viewer, operator or admin"
+//	@Param		policy	body	ReqUpdateRolePolicyBody	true	"command policy"
+//	@Router		/bash/roles/{role} [put]
+func (restApi RestApi) UpdateRolePolicyHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role := r.PathValue("role")
+		if _, ok := models.RoleScopes[role]; !ok {
+			closeHandlerWithErr(w, fmt.Errorf("unknown role %q", role))
+			return
+		}
+		var inputStruct ReqUpdateRolePolicyBody
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		if err := json.Unmarshal(buf, &inputStruct); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("json unmarshal error: %v", err))
+			return
+		}
+
+		if inputStruct.AllowedBinaries == nil {
+			inputStruct.AllowedBinaries = []string{}
+		}
+		for _, binary := range inputStruct.AllowedBinaries {
+			if binary == "" {
+				closeHandlerWithErr(w, fmt.Errorf("allowed binary is empty"))
+				return
+			}
+		}
+		if inputStruct.CommandsPerDay < 0 || inputStruct.CpuSecondsPerDay < 0 || inputStruct.MaxConcurrentCommands < 0 {
+			closeHandlerWithErr(w, fmt.Errorf("quotas can't be negative"))
+			return
+		}
+
+		policy := models.RolePolicies{
+			Role: role,
+			AllowedBinaries: inputStruct.AllowedBinaries,
+			OwnCommandsOnly: inputStruct.OwnCommandsOnly,
+			CommandsPerDay: inputStruct.CommandsPerDay,
+			CpuSecondsPerDay: inputStruct.CpuSecondsPerDay,
+			MaxConcurrentCommands: inputStruct.MaxConcurrentCommands,
+		}
+		if err := db.UpdateRolePolicyQuery(&policy, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, policy)
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_UpdateRolePolicyHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		pathValue string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `new policy`,
+			pathValue: "operator",
+			inputBody: `{"allowed_binaries": ["ls", "cat"], "own_commands_only": true}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().UpdateRolePolicyQuery(gomock.Any(), context.Background()).DoAndReturn(
+					func(policy *models.RolePolicies, ctx context.Context) error {
+						if policy.Role != "operator" || len(policy.AllowedBinaries) != 2 || !policy.OwnCommandsOnly {
+							t.Errorf("unexpected policy %+v", policy)
+						}
+						return nil
+					},
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `unknown role`,
+			pathValue: "root",
+			inputBody: `{}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `empty binary`,
+			pathValue: "operator",
+			inputBody: `{"allowed_binaries": [""]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			pathValue: "viewer",
+			inputBody: `{}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().UpdateRolePolicyQuery(gomock.Any(), context.Background()).Return(fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/bash/roles/role", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			r.SetPathValue("role", testCase.pathValue)
+			handleFunc := restApi.UpdateRolePolicyHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_CreateNewCommandHandlerPolicy(t *testing.T) {
+	type mockBehavior func(*mock_database.MockDBWorker, *mock_bash.MockBashCommandsWorker)
+
+	operator := &models.RolePolicies{Role: models.ROLE_OPERATOR, AllowedBinaries: []string{"ls", "echo"}}
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockBehavior mockBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `allowed binaries`,
+			inputBody: `{"bash_strings": ["ls -la", "echo ok"]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {
+				sh.EXPECT().ExecCommands(gomock.Any(), gomock.Any()).Return(&[]models.CommandsWithoutID{{Command: "ls -la"}, {Command: "echo ok"}}, nil)
+				m.EXPECT().CreateNewCommandsQuery(gomock.Any(), gomock.Any(), context.Background()).Return(uint(1), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `binary isn't allowed`,
+			inputBody: `{"bash_strings": ["ls -la", "ls && rm -rf /tmp/x"]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name: `template with a binary that isn't allowed`,
+			inputBody: `{"templates": [{"template": "{{cmd}} /", "matrix": {"cmd": ["ls", "rm"]}}]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name: `env loading a library`,
+			inputBody: `{"commands": [{"bash_string": "ls", "env": {"LD_PRELOAD": "./uploaded.so"}}]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name: `template env changing the search path`,
+			inputBody: `{"templates": [{"template": "ls {{dir}}", "matrix": {"dir": ["/"]}, "env": {"PATH": "."}}]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name: `secret exported as a startup file`,
+			inputBody: `{"commands": [{"bash_string": "ls", "secrets": {"BASH_ENV": "token"}}]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name: `assignment loading a library`,
+			inputBody: `{"bash_strings": ["LD_PRELOAD=./uploaded.so ls"]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+			testCase.mockBehavior(mDatabase, mBash)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/create-command", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR})
+			r = r.WithContext(auth.WithPolicy(ctx, operator))
+			handleFunc := restApi.CreateNewCommandHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_GettingListCommandsHandlerOwnCommands(t *testing.T) {
+	// init dependences
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mDatabase := mock_database.NewMockDBWorker(ctrl)
+
+	keyId := uint(3)
+	mDatabase.EXPECT().GettingListCommandsQuery(models.CommandFilter{ApiKeyId: &keyId}, context.Background()).Return(&[]models.Commands{}, nil)
+
+	restApi := RestApi{}
+
+	// test request
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/bash/get-commands", nil)
+	ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: keyId, Role: models.ROLE_OPERATOR})
+	r = r.WithContext(auth.WithPolicy(ctx, &models.RolePolicies{Role: models.ROLE_OPERATOR, OwnCommandsOnly: true}))
+	handleFunc := restApi.GettingListCommandsHandler(mDatabase)
+	handleFunc(w, r)
+
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("expected status code %v but got %v", http.StatusOK, w.Result().StatusCode)
+	}
+	defer w.Result().Body.Close()
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	// std
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const NDJSON_CONTENT_TYPE string = "application/x-ndjson"
+
+// runStream writes the events of a run as NDJSON lines, each of them is
+// flushed so that the client sees the output while the commands run.
+type runStream struct {
+	w       http.ResponseWriter
+	mutex   sync.Mutex
+	started bool
+	runId   uint
+}
+
+func (stream *runStream) write(event models.RunEvents) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	if !stream.started {
+		stream.w.Header().Set("Content-Type", NDJSON_CONTENT_TYPE)
+		stream.w.WriteHeader(http.StatusOK)
+		stream.started = true
+	}
+	if event.Event == models.RUN_EVENT_STARTED {
+		stream.runId = event.RunId
+	}
+	if err := json.NewEncoder(stream.w).Encode(event); err != nil {
+		log.Printf("json encode error: %v\n", err)
+	}
+	if flusher, ok := stream.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func checkRuns(runs *bash.Runs) error {
+	if runs == nil {
+		return fmt.Errorf("runs aren't tracked")
+	}
+	return nil
+}
+
+// closeHandlerRunErr answers 404 if the run isn't running or isn't visible to
+// the request, other errors are internal errors.
+func closeHandlerRunErr(w http.ResponseWriter, err error) {
+	if !errors.Is(err, bash.ErrRunNotFound) {
+		closeHandlerWithErr(w, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusNotFound)
+}
+
+// GettingListRunsHandler returns the requests whose commands are running.
+//
+//	@Tags		/bash/runs/
+//	@Produce	json
+//	@Success	200	{array}	models.Runs
+//	@Router		/bash/runs [get]
+func (restApi RestApi) GettingListRunsHandler(runs *bash.Runs) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkRuns(runs); err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		writeJsonResponse(w, http.StatusOK, runs.List(auth.CommandsOwner(r.Context())))
+	}
+}
+
+// CancelRunHandler interrupts the commands of a run, the request that
+// started them answers once they exit.
+//
+//	@Tags		/bash/runs/
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/runs/{id}/cancel [post]
+func (restApi RestApi) CancelRunHandler(runs *bash.Runs) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkRuns(runs); err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := runs.Cancel(pathVal, auth.CommandsOwner(r.Context())); err != nil {
+			closeHandlerRunErr(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// GettingRunStreamHandler streams the output of a running run as NDJSON
+// events, starting with its recent output. The stream ends with a finished
+// event when the commands of the run have exited, they are stored right after
+// that, so the history may not have them yet.
+//
+//	@Tags		/bash/runs/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/runs/{id}/stream [get]
+func (restApi RestApi) GettingRunStreamHandler(runs *bash.Runs) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkRuns(runs); err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		events, unsubscribe, err := runs.Subscribe(pathVal, auth.CommandsOwner(r.Context()))
+		if err != nil {
+			closeHandlerRunErr(w, err)
+			return
+		}
+		defer unsubscribe()
+
+		stream := runStream{w: w}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				stream.write(event)
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_CreateNewCommandHandlerStream(t *testing.T) {
+	// init dependences
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mDatabase := mock_database.NewMockDBWorker(ctrl)
+	mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+	mBash.EXPECT().ExecCommands(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(inputStruct *bash.ReqCreateNewCommandBody, ctx context.Context) (*[]models.CommandsWithoutID, error) {
+			inputStruct.Output(models.RunEvents{Event: models.RUN_EVENT_STARTED, RunId: 7})
+			inputStruct.Output(models.RunEvents{Event: models.RUN_EVENT_OUTPUT, RunId: 7, Stream: "stdout", Data: []byte("hello\n")})
+			return &[]models.CommandsWithoutID{{Command: "echo hello", Log: "hello\n"}}, nil
+		},
+	)
+	mDatabase.EXPECT().CreateNewCommandsQuery(gomock.Any(), models.NewBatch{}, context.Background()).Return(uint(3), nil)
+
+	restApi := RestApi{}
+
+	// test request
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/bash/create-command?stream=true", bytes.NewBufferString(`{"bash_strings": ["echo hello"]}`))
+	handleFunc := restApi.CreateNewCommandHandler(mDatabase, mBash)
+	handleFunc(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != NDJSON_CONTENT_TYPE {
+		t.Errorf("expected content type %v but got %v", NDJSON_CONTENT_TYPE, contentType)
+	}
+	events := []models.RunEvents{}
+	decoder := json.NewDecoder(w.Body)
+	for decoder.More() {
+		var event models.RunEvents
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 3 || events[1].Event != models.RUN_EVENT_OUTPUT || string(events[1].Data) != "hello\n" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	result := events[2]
+	if result.Event != models.RUN_EVENT_RESULT || result.RunId != 7 || len(result.Commands) != 1 || result.Commands[0].BatchId != 3 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestRestApi_CancelRunHandler(t *testing.T) {
+	testTable := []struct {
+		name string
+		runs *bash.Runs
+		target string
+		expectedStatusCode int
+	} {
+		{
+			name: `unknown run`,
+			runs: bash.NewRuns(),
+			target: "/bash/runs/1/cancel",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name: `invalid id`,
+			runs: bash.NewRuns(),
+			target: "/bash/runs/first/cancel",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `runs aren't tracked`,
+			target: "/bash/runs/1/cancel",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			restApi := RestApi{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /bash/runs/{id}/cancel", restApi.CancelRunHandler(testCase.runs))
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, testCase.target, nil)
+			mux.ServeHTTP(w, r)
+
+			if w.Code != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/scheduler"
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
+)
+
+type ReqCreateScheduleBody struct {
+	Name string `json:"name"`
+	// five fields, or six with seconds in front
+	CronExpression string `json:"cron_expression"`
+	// IANA time zone name, UTC by default
+	Timezone string `json:"timezone"`
+	// skip (default), run_once or catch_up
+	MisfirePolicy string `json:"misfire_policy"`
+	Paused bool `json:"paused"`
+	Commands bash.ReqCreateNewCommandBody `json:"commands"`
+}
+
+// writeJsonResponse writes v as the json body of the response.
+func writeJsonResponse(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("json encode error: %v\n", err)
+	}
+}
+
+//	@Tags		/bash/schedules/
+//	@Accept		json
+//	@Produce	json
+//	@Param		new_schedule	body	ReqCreateScheduleBody	true	"cron expression and commands"
+//	@Router		/bash/schedules [post]
+func (restApi RestApi) CreateNewScheduleHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var inputStruct ReqCreateScheduleBody
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		if err := json.Unmarshal(buf, &inputStruct); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("json unmarshal error: %v", err))
+			return
+		}
+
+		if inputStruct.Timezone == "" {
+			inputStruct.Timezone = "UTC"
+		}
+		if inputStruct.MisfirePolicy == "" {
+			inputStruct.MisfirePolicy = models.MISFIRE_SKIP
+		}
+		if !scheduler.IsValidMisfirePolicy(inputStruct.MisfirePolicy) {
+			closeHandlerWithErr(w, fmt.Errorf("unknown misfire policy %q", inputStruct.MisfirePolicy))
+			return
+		}
+		options, err := inputStruct.Commands.CommandOptions()
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if len(options) == 0 {
+			closeHandlerWithErr(w, fmt.Errorf("schedule has no commands"))
+			return
+		}
+		if !validateCommandOptions(w, options) {
+			return
+		}
+		// every run is checked again with the policy of its time
+		if err := checkCommandPolicy(r, options); err != nil {
+			closeHandlerForbidden(w, err)
+			return
+		}
+		nextRunAt, err := scheduler.NextRunAt(inputStruct.CronExpression, inputStruct.Timezone, time.Now())
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		commands, err := json.Marshal(inputStruct.Commands)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("json marshal error: %v", err))
+			return
+		}
+
+		schedule := models.Schedules{
+			Name: inputStruct.Name,
+			CronExpression: inputStruct.CronExpression,
+			Timezone: inputStruct.Timezone,
+			Commands: commands,
+			MisfirePolicy: inputStruct.MisfirePolicy,
+			Paused: inputStruct.Paused,
+			NextRunAt: nextRunAt,
+			CreatedAt: time.Now(),
+			ApiKeyId: auth.ApiKeyId(r.Context()),
+		}
+		if schedule.Id, err = db.CreateNewScheduleQuery(&schedule, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		if err := redactScheduleEnv(&schedule); err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, schedule)
+	}
+}
+
+// redactScheduleEnv masks the env values of the commands and templates of the
+// schedule in responses. They are stored in clear for the runs, values that
+// must stay hidden belong in secrets.
+func redactScheduleEnv(schedule *models.Schedules) error {
+	var commands bash.ReqCreateNewCommandBody
+	if err := json.Unmarshal(schedule.Commands, &commands); err != nil {
+		return fmt.Errorf("json unmarshal error: %v", err)
+	}
+	envs := []map[string]string{}
+	for _, option := range commands.Commands {
+		envs = append(envs, option.Env)
+	}
+	for _, commandTemplate := range commands.Templates {
+		envs = append(envs, commandTemplate.Env)
+	}
+	for _, env := range envs {
+		for name := range env {
+			env[name] = secrets.MASK
+		}
+	}
+	redacted, err := json.Marshal(commands)
+	if err != nil {
+		return fmt.Errorf("json marshal error: %v", err)
+	}
+	schedule.Commands = redacted
+	return nil
+}
+
+//	@Tags		/bash/schedules/
+//	@Produce	json
+//	@Router		/bash/schedules [get]
+func (restApi RestApi) GettingListSchedulesHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		schedules, err := db.GettingListSchedulesQuery(auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		for i := range *schedules {
+			if err := redactScheduleEnv(&(*schedules)[i]); err != nil {
+				closeHandlerWithErr(w, err)
+				return
+			}
+		}
+
+		writeJsonResponse(w, http.StatusOK, schedules)
+	}
+}
+
+//	@Tags		/bash/schedules/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/schedules/{id} [get]
+func (restApi RestApi) GettingSingleScheduleHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		schedule, err := db.GettingSingleScheduleQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		if err := redactScheduleEnv(schedule); err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, schedule)
+	}
+}
+
+//	@Tags		/bash/schedules/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/schedules/{id}/batches [get]
+func (restApi RestApi) GettingScheduleBatchesHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		batches, err := db.GettingScheduleBatchesQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, batches)
+	}
+}
+
+//	@Tags		/bash/schedules/
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/schedules/{id}/pause [post]
+func (restApi RestApi) PauseScheduleHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := db.SetSchedulePausedQuery(pathVal, auth.CommandsOwner(r.Context()), true, time.Time{}, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ResumeScheduleHandler resumes a paused schedule starting from its next run
+// after now, the runs missed while it was paused are not started.
+//
+//	@Tags		/bash/schedules/
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/schedules/{id}/resume [post]
+func (restApi RestApi) ResumeScheduleHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		schedule, err := db.GettingSingleScheduleQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		nextRunAt, err := scheduler.NextRunAt(schedule.CronExpression, schedule.Timezone, time.Now())
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := db.SetSchedulePausedQuery(pathVal, auth.CommandsOwner(r.Context()), false, nextRunAt, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+//	@Tags		/bash/schedules/
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/schedules/{id} [delete]
+func (restApi RestApi) DeleteScheduleHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := db.DeleteScheduleQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
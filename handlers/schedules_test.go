@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_CreateNewScheduleHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `valid schedule`,
+			inputBody: `{"name": "health", "cron_expression": "*/30 * * * * *", "timezone": "Europe/Moscow",
+				"misfire_policy": "catch_up", "commands": {"bash_strings": ["echo ok"]}}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewScheduleQuery(gomock.Any(), context.Background()).DoAndReturn(
+					func(schedule *models.Schedules, ctx context.Context) (uint, error) {
+						if schedule.NextRunAt.IsZero() || schedule.MisfirePolicy != models.MISFIRE_CATCH_UP {
+							t.Errorf("unexpected schedule %+v", schedule)
+						}
+						return 1, nil
+					},
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `invalid cron expression`,
+			inputBody: `{"cron_expression": "every minute", "commands": {"bash_strings": ["echo ok"]}}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `unknown time zone`,
+			inputBody: `{"cron_expression": "* * * * *", "timezone": "Mars/Olympus", "commands": {"bash_strings": ["echo ok"]}}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `unknown misfire policy`,
+			inputBody: `{"cron_expression": "* * * * *", "misfire_policy": "sometimes", "commands": {"bash_strings": ["echo ok"]}}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `invalid assertion regex`,
+			inputBody: `{"cron_expression": "* * * * *", "commands": {"commands": [{"bash_string": "true", "assertions": {"stdout_regex": "("}}]}}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: `without commands`,
+			inputBody: `{"cron_expression": "* * * * *"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			inputBody: `{"cron_expression": "@daily", "commands": {"bash_strings": ["echo ok"]}}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewScheduleQuery(gomock.Any(), context.Background()).Return(uint(0), fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/schedules", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			handleFunc := restApi.CreateNewScheduleHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		}) 
+	}
+}
+
+func TestRestApi_ResumeScheduleHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	ownKeyId := uint(3)
+	testTable := []struct {
+		name string
+		policy *models.RolePolicies
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `resume`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleScheduleQuery(uint(4), nil, context.Background()).Return(
+					&models.Schedules{Id: 4, CronExpression: "@hourly", Timezone: "UTC", Paused: true},
+					nil,
+				)
+				m.EXPECT().SetSchedulePausedQuery(uint(4), nil, false, gomock.Any(), context.Background()).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `schedule not found`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleScheduleQuery(uint(4), nil, context.Background()).Return(nil, fmt.Errorf("no rows in result set"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `schedule of another key`,
+			policy: &models.RolePolicies{Role: models.ROLE_OPERATOR, OwnCommandsOnly: true},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleScheduleQuery(uint(4), &ownKeyId, context.Background()).Return(nil, fmt.Errorf("no rows in result set"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/schedules/4/resume", nil)
+			r.SetPathValue("id", "4")
+			if testCase.policy != nil {
+				ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: ownKeyId, Role: testCase.policy.Role})
+				r = r.WithContext(auth.WithPolicy(ctx, testCase.policy))
+			}
+			handleFunc := restApi.ResumeScheduleHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		}) 
+	}
+}
+
+func TestRestApi_GettingSingleScheduleHandler(t *testing.T) {
+	// init dependences
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mDatabase := mock_database.NewMockDBWorker(ctrl)
+	mDatabase.EXPECT().GettingSingleScheduleQuery(uint(4), nil, context.Background()).Return(
+		&models.Schedules{Id: 4, CronExpression: "@hourly", Timezone: "UTC", Commands: []byte(`{"commands": [
+			{"bash_string": "deploy", "env": {"TOKEN": "plain-token"}, "secrets": {"KEY": "deploy-key"}}],
+			"templates": [{"template": "ping {{host}}", "matrix": {"host": ["a"]}, "env": {"PASSWORD": "plain-password"}}]}`)},
+		nil,
+	)
+
+	restApi := RestApi{}
+
+	// test request
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/bash/schedules/4", nil)
+	r.SetPathValue("id", "4")
+	handleFunc := restApi.GettingSingleScheduleHandler(mDatabase)
+	handleFunc(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "plain-") {
+		t.Errorf("env values aren't redacted in %v", body)
+	}
+	for _, want := range []string{`"TOKEN":"***"`, `"PASSWORD":"***"`, `"KEY":"deploy-key"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %v in %v", want, body)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+var scriptNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+type ReqSaveScriptBody struct {
+	Description string `json:"description"`
+	// bash string with {{name}} placeholders of the parameters
+	Body string `json:"body"`
+	Parameters []models.ScriptParameter `json:"parameters"`
+}
+
+type ReqRunScriptBody struct {
+	// 0 or no version runs the latest one
+	Version int `json:"version"`
+	Parameters map[string]string `json:"parameters"`
+	Assertions *models.Assertions `json:"assertions,omitempty"`
+}
+
+// scriptCommand returns the template of a script run with the given values of
+// its parameters.
+func scriptCommand(script *models.Scripts, parameters map[string]string) (*bash.CommandTemplate, error) {
+	declared := map[string]bool{}
+	matrix := map[string][]string{}
+	for _, parameter := range script.Parameters {
+		declared[parameter.Name] = true
+		value, ok := parameters[parameter.Name]
+		switch {
+		case ok:
+		case parameter.Default != nil:
+			value = *parameter.Default
+		case parameter.Required:
+			return nil, fmt.Errorf("parameter %q of script %q is required", parameter.Name, script.Name)
+		}
+		matrix[parameter.Name] = []string{value}
+	}
+	for name := range parameters {
+		if !declared[name] {
+			return nil, fmt.Errorf("script %q has no parameter %q", script.Name, name)
+		}
+	}
+	return &bash.CommandTemplate{Template: script.Body, Matrix: matrix}, nil
+}
+
+// parseScriptName returns the script name path value.
+func parseScriptName(r *http.Request) (string, error) {
+	name := r.PathValue("name")
+	if !scriptNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid script name %q", name)
+	}
+	return name, nil
+}
+
+// SaveScriptHandler stores a new version of a script, the first version
+// creates the script.
+//
+//	@Tags		/bash/scripts/
+//	@Accept		json
+//	@Produce	json
+//	@Param		name	path	string				true	"script name"
+//	@Param		script	body	ReqSaveScriptBody	true	"script body and parameters"
+//	@Router		/bash/scripts/{name} [put]
+func (restApi RestApi) SaveScriptHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, err := parseScriptName(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		var inputStruct ReqSaveScriptBody
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		if err := json.Unmarshal(buf, &inputStruct); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("json unmarshal error: %v", err))
+			return
+		}
+
+		if inputStruct.Body == "" {
+			closeHandlerWithErr(w, fmt.Errorf("script %q has no body", name))
+			return
+		}
+		if inputStruct.Parameters == nil {
+			inputStruct.Parameters = []models.ScriptParameter{}
+		}
+		declared := map[string]bool{}
+		for _, parameter := range inputStruct.Parameters {
+			if declared[parameter.Name] {
+				closeHandlerWithErr(w, fmt.Errorf("duplicate parameter %q", parameter.Name))
+				return
+			}
+			declared[parameter.Name] = true
+		}
+		if err := bash.ValidatePlaceholders(inputStruct.Body); err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		used := map[string]bool{}
+		for _, placeholder := range bash.Placeholders(inputStruct.Body) {
+			if !declared[placeholder] {
+				closeHandlerWithErr(w, fmt.Errorf("parameter %q isn't declared", placeholder))
+				return
+			}
+			used[placeholder] = true
+		}
+		for name := range declared {
+			if !used[name] {
+				closeHandlerWithErr(w, fmt.Errorf("parameter %q isn't used in the body", name))
+				return
+			}
+		}
+
+		script := models.Scripts{
+			Name: name,
+			Description: inputStruct.Description,
+			Body: inputStruct.Body,
+			Parameters: inputStruct.Parameters,
+		}
+		if _, err := db.CreateNewScriptVersionQuery(&script, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, script)
+	}
+}
+
+// GettingListScriptsHandler returns the latest version of every script.
+//
+//	@Tags		/bash/scripts/
+//	@Produce	json
+//	@Router		/bash/scripts [get]
+func (restApi RestApi) GettingListScriptsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scripts, err := db.GettingListScriptsQuery(context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, scripts)
+	}
+}
+
+//	@Tags		/bash/scripts/
+//	@Produce	json
+//	@Param		name	path	string	true	"script name"
+//	@Param		version	query	int		false	"script version, the latest by default"
+//	@Router		/bash/scripts/{name} [get]
+func (restApi RestApi) GettingScriptHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, err := parseScriptName(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		version := 0
+		if query := r.URL.Query().Get("version"); query != "" {
+			if version, err = strconv.Atoi(query); err != nil || version <= 0 {
+				closeHandlerWithErr(w, fmt.Errorf("version isn't a positive number: %q", query))
+				return
+			}
+		}
+		script, err := db.GettingScriptQuery(name, version, context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, script)
+	}
+}
+
+//	@Tags		/bash/scripts/
+//	@Produce	json
+//	@Param		name	path	string	true	"script name"
+//	@Router		/bash/scripts/{name}/versions [get]
+func (restApi RestApi) GettingScriptVersionsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, err := parseScriptName(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		scripts, err := db.GettingScriptVersionsQuery(name, context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, scripts)
+	}
+}
+
+// RunScriptHandler runs a version of a script with the values of its
+// parameters, the batch of the run records the script version.
+//
+//	@Tags		/bash/scripts/
+//	@Accept		json
+//	@Produce	json
+//	@Param		name	path	string				true	"script name"
+//	@Param		run		body	ReqRunScriptBody	true	"version and parameters"
+//	@Param		stream	query	bool				false	"stream the output as NDJSON events"
+//	@Router		/bash/scripts/{name}/run [post]
+func (restApi RestApi) RunScriptHandler(db database.DBWorker, sh bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, err := parseScriptName(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		var inputStruct ReqRunScriptBody
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		if len(buf) != 0 {
+			if err := json.Unmarshal(buf, &inputStruct); err != nil {
+				closeHandlerWithErr(w, fmt.Errorf("json unmarshal error: %v", err))
+				return
+			}
+		}
+
+		script, err := db.GettingScriptQuery(name, inputStruct.Version, context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+		commandTemplate, err := scriptCommand(script, inputStruct.Parameters)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		commandTemplate.Assertions = inputStruct.Assertions
+
+		commands := bash.ReqCreateNewCommandBody{Templates: []bash.CommandTemplate{*commandTemplate}}
+		execAndStoreCommands(w, r, db, sh, &commands, nil, models.NewBatch{ScriptId: &script.Id})
+	}
+}
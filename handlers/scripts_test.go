@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_SaveScriptHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		pathValue string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `new version`,
+			pathValue: "ping",
+			inputBody: `{"description": "ping a host", "body": "ping -c {{count}} {{host}}",
+				"parameters": [{"name": "host", "required": true}, {"name": "count", "default": "1"}]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewScriptVersionQuery(gomock.Any(), context.Background()).DoAndReturn(
+					func(script *models.Scripts, ctx context.Context) (uint, error) {
+						if script.Name != "ping" || len(script.Parameters) != 2 {
+							t.Errorf("unexpected script %+v", script)
+						}
+						script.Id, script.Version = 3, 2
+						return 3, nil
+					},
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `undeclared parameter`,
+			pathValue: "ping",
+			inputBody: `{"body": "ping {{host}}"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `parameter inside quotes`,
+			pathValue: "ping",
+			inputBody: `{"body": "ping '{{host}}'", "parameters": [{"name": "host"}]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `unused parameter`,
+			pathValue: "ping",
+			inputBody: `{"body": "ping localhost", "parameters": [{"name": "host"}]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `invalid name`,
+			pathValue: "ping me",
+			inputBody: `{"body": "ping localhost"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			pathValue: "ping",
+			inputBody: `{"body": "ping localhost"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewScriptVersionQuery(gomock.Any(), context.Background()).Return(uint(0), fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/bash/scripts/name", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			r.SetPathValue("name", testCase.pathValue)
+			handleFunc := restApi.SaveScriptHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		}) 
+	}
+}
+
+func TestRestApi_RunScriptHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+	type mockBashBehavior func(*mock_bash.MockBashCommandsWorker)
+
+	defaultCount := "1"
+	scriptId := uint(3)
+	script := &models.Scripts{
+		Id: scriptId, Name: "ping", Version: 2, Body: "ping -c {{count}} {{host}}",
+		Parameters: []models.ScriptParameter{{Name: "host", Required: true}, {Name: "count", Default: &defaultCount}},
+	}
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		mockBashBehavior mockBashBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `run latest version`,
+			inputBody: `{"parameters": {"host": "example.com"}}`,
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{
+					Templates: []bash.CommandTemplate{{
+						Template: "ping -c {{count}} {{host}}",
+						Matrix: map[string][]string{"host": {"example.com"}, "count": {"1"}},
+					}},
+				}, context.Background()).Return(
+					&[]models.CommandsWithoutID{
+						{Command: "ping -c 1 example.com", Log: "ok"},
+					},
+					nil,
+				)
+			},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingScriptQuery("ping", 0, context.Background()).Return(script, nil)
+				m.EXPECT().CreateNewCommandsQuery(gomock.Any(), models.NewBatch{ScriptId: &scriptId}, context.Background()).Return(uint(9), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `required parameter is missing`,
+			inputBody: `{"version": 2}`,
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingScriptQuery("ping", 2, context.Background()).Return(script, nil)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `unknown parameter`,
+			inputBody: `{"parameters": {"host": "example.com", "port": "80"}}`,
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingScriptQuery("ping", 0, context.Background()).Return(script, nil)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `script not found`,
+			inputBody: ``,
+			mockBashBehavior: func(m *mock_bash.MockBashCommandsWorker) {},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingScriptQuery("ping", 0, context.Background()).Return(nil, fmt.Errorf("no rows in result set"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+			testCase.mockBashBehavior(mBash)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/scripts/ping/run", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			r.SetPathValue("name", "ping")
+			handleFunc := restApi.RunScriptHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		}) 
+	}
+}
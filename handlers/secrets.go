@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+type ReqSaveSecretBody struct {
+	Value string `json:"value"`
+}
+
+// parseSecretName returns the secret name path value, secrets are named like
+// scripts.
+func parseSecretName(r *http.Request) (string, error) {
+	name := r.PathValue("name")
+	if !scriptNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	return name, nil
+}
+
+// SaveSecretHandler stores the value of a secret, replacing the previous one.
+// The value is never returned by the api.
+//
+//	@Tags		/bash/secrets/
+//	@Accept		json
+//	@Produce	json
+//	@Param		name	path	string				true	"secret name"
+//	@Param		secret	body	ReqSaveSecretBody	true	"secret value"
+//	@Router		/bash/secrets/{name} [put]
+func (restApi RestApi) SaveSecretHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, err := parseSecretName(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		var inputStruct ReqSaveSecretBody
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		if err := json.Unmarshal(buf, &inputStruct); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("json unmarshal error: %v", err))
+			return
+		}
+		if inputStruct.Value == "" {
+			closeHandlerWithErr(w, fmt.Errorf("secret %q has no value", name))
+			return
+		}
+
+		secret := models.Secrets{Name: name, ApiKeyId: auth.ApiKeyId(r.Context())}
+		if err := db.SaveSecretQuery(&secret, inputStruct.Value, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, secret)
+	}
+}
+
+// GettingListSecretsHandler returns the names of the secrets without their
+// values.
+//
+//	@Tags		/bash/secrets/
+//	@Produce	json
+//	@Router		/bash/secrets [get]
+func (restApi RestApi) GettingListSecretsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secrets, err := db.GettingListSecretsQuery(context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, secrets)
+	}
+}
+
+//	@Tags		/bash/secrets/
+//	@Param		name	path	string	true	"secret name"
+//	@Router		/bash/secrets/{name} [delete]
+func (restApi RestApi) DeleteSecretHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, err := parseSecretName(r)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := db.DeleteSecretQuery(name, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_SaveSecretHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		pathName string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `save`,
+			pathName: "api-token",
+			inputBody: `{"value": "s3cr3t"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				id := uint(3)
+				m.EXPECT().SaveSecretQuery(&models.Secrets{Name: "api-token", ApiKeyId: &id}, "s3cr3t", context.Background()).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `empty value`,
+			pathName: "api-token",
+			inputBody: `{"value": ""}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `invalid name`,
+			pathName: "api%20token",
+			inputBody: `{"value": "s3cr3t"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			pathName: "api-token",
+			inputBody: `{"value": "s3cr3t"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().SaveSecretQuery(gomock.Any(), "s3cr3t", context.Background()).Return(fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+			mux := http.NewServeMux()
+			mux.HandleFunc("PUT /bash/secrets/{name}", restApi.SaveSecretHandler(mDatabase))
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/bash/secrets/" + testCase.pathName, bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			r = r.WithContext(auth.WithApiKey(r.Context(), &models.ApiKeys{Id: 3, Role: models.ROLE_ADMIN}))
+			mux.ServeHTTP(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			if strings.Contains(w.Body.String(), "s3cr3t") {
+				t.Errorf("the response contains the value of the secret: %v", w.Body.String())
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_CreateNewCommandHandlerSecrets(t *testing.T) {
+	type mockBehavior func(*mock_database.MockDBWorker, *mock_bash.MockBashCommandsWorker)
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockBehavior mockBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `secrets are read`,
+			inputBody: `{"commands": [{"bash_string": "curl -H \"$AUTH\" host", "secrets": {"AUTH": "api-token"}}]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().GettingSecretValuesQuery([]string{"api-token"}, gomock.Any()).Return(map[string]string{"api-token": "s3cr3t"}, nil)
+				sh.EXPECT().ExecCommands(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(inputStruct *bash.ReqCreateNewCommandBody, ctx context.Context) (*[]models.CommandsWithoutID, error) {
+						if inputStruct.Commands[0].SecretEnv["AUTH"] != "s3cr3t" {
+							t.Errorf("the secret isn't set: %+v", inputStruct.Commands[0])
+						}
+						return &[]models.CommandsWithoutID{{Command: inputStruct.Commands[0].BashString}}, nil
+					})
+				m.EXPECT().CreateNewCommandsQuery(gomock.Any(), gomock.Any(), context.Background()).Return(uint(1), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `db querry error`,
+			inputBody: `{"commands": [{"bash_string": "curl -H \"$AUTH\" host", "secrets": {"AUTH": "api-token"}}]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().GettingSecretValuesQuery([]string{"api-token"}, gomock.Any()).Return(nil, fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+			testCase.mockBehavior(mDatabase, mBash)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/create-command", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			handleFunc := restApi.CreateNewCommandHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	// std
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+)
+
+const (
+	// fields of a multipart create-command request
+	COMMAND_REQUEST_FIELD string = "request"
+	COMMAND_FILES_FIELD   string = "files"
+)
+
+// uploadPath returns the file name of the Content-Disposition of a part as it
+// is sent. FileName of the part drops the directories, the path is checked by
+// the uploads.
+func uploadPath(disposition string) string {
+	_, params, err := mime.ParseMediaType(disposition)
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+// readMultipartCommand reads the commands of a multipart request and stores
+// its files. The uploads are returned to be removed even with an error.
+func readMultipartCommand(w http.ResponseWriter, r *http.Request, inputStruct *bash.ReqCreateNewCommandBody) (*bash.Uploads, error) {
+	// the request field and the headers of the parts take little room
+	r.Body = http.MaxBytesReader(w, r.Body, bash.UPLOAD_MAX_TOTAL_BYTES+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("multipart error: %v", err)
+	}
+	uploads, err := bash.NewUploads()
+	if err != nil {
+		return nil, err
+	}
+
+	hasRequest := false
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return uploads, fmt.Errorf("multipart error: %v", err)
+		}
+		switch part.FormName() {
+		case COMMAND_REQUEST_FIELD:
+			if err := json.NewDecoder(part).Decode(inputStruct); err != nil {
+				return uploads, fmt.Errorf("json unmarshal error: %v", err)
+			}
+			hasRequest = true
+		case COMMAND_FILES_FIELD:
+			if err := uploads.Add(uploadPath(part.Header.Get("Content-Disposition")), part); err != nil {
+				return uploads, err
+			}
+		default:
+			return uploads, fmt.Errorf("unknown multipart field %q", part.FormName())
+		}
+		part.Close()
+	}
+	if !hasRequest {
+		return uploads, fmt.Errorf("multipart request has no %v field", COMMAND_REQUEST_FIELD)
+	}
+	inputStruct.Uploads = uploads
+	return uploads, nil
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+// multipartCommand returns a multipart create-command request body with the
+// files by their paths.
+func multipartCommand(t *testing.T, request string, files map[string]string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if request != "" {
+		writer.WriteField(COMMAND_REQUEST_FIELD, request)
+	}
+	for name, content := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="files"; filename="`+name+`"`)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func TestRestApi_CreateNewCommandHandlerMultipart(t *testing.T) {
+	testTable := []struct {
+		name string
+		request string
+		files map[string]string
+		expectedStatusCode int
+	} {
+		{
+			name: `upload`,
+			request: `{"bash_strings": ["cat data/input.csv"]}`,
+			files: map[string]string{"data/input.csv": "a,b\n"},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `path traversal`,
+			request: `{"bash_strings": ["cat input.csv"]}`,
+			files: map[string]string{"../../input.csv": "a,b\n"},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `no request`,
+			files: map[string]string{"input.csv": "a,b\n"},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+			var uploadsDir string
+			if testCase.expectedStatusCode == http.StatusOK {
+				mBash.EXPECT().ExecCommands(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(inputStruct *bash.ReqCreateNewCommandBody, ctx context.Context) (*[]models.CommandsWithoutID, error) {
+						uploadsDir = inputStruct.Uploads.Dir
+						content, err := os.ReadFile(filepath.Join(uploadsDir, "data", "input.csv"))
+						if err != nil || string(content) != "a,b\n" {
+							t.Errorf("unexpected upload %q %v", content, err)
+						}
+						return &[]models.CommandsWithoutID{{Command: "cat data/input.csv", Log: "a,b\n"}}, nil
+					},
+				)
+				mDatabase.EXPECT().CreateNewCommandsQuery(gomock.Any(), models.NewBatch{}, context.Background()).Return(uint(1), nil)
+			}
+
+			restApi := RestApi{}
+
+			// test request
+			body, contentType := multipartCommand(t, testCase.request, testCase.files)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/create-command", body)
+			r.Header.Set("Content-Type", contentType)
+			handleFunc := restApi.CreateNewCommandHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Code != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Code)
+			}
+			// the uploads are removed once the commands are stored
+			if uploadsDir != "" {
+				if _, err := os.Stat(uploadsDir); !os.IsNotExist(err) {
+					t.Errorf("the uploads %v aren't removed", uploadsDir)
+				}
+			}
+		})
+	}
+}
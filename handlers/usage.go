@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	// std
+	"fmt"
+	"net/http"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+)
+
+// GettingUsageHandler returns what the key of the request used of the quotas
+// of its role today.
+//
+//	@Tags		/bash/me/
+//	@Produce	json
+//	@Router		/bash/me/usage [get]
+func (restApi RestApi) GettingUsageHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotas := limits.QuotasFromContext(r.Context())
+		if quotas == nil {
+			closeHandlerWithErr(w, fmt.Errorf("quotas aren't enforced"))
+			return
+		}
+		usage, err := quotas.Usage(db, r.Context())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, usage)
+	}
+}
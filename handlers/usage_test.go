@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_GettingUsageHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `usage`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingUsageQuery(uint(3), gomock.Any(), gomock.Any()).Return(&models.Usage{ApiKeyId: 3, Commands: 4, CpuMs: 1500}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `db querry error`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingUsageQuery(uint(3), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/me/usage", nil)
+			ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR})
+			ctx = auth.WithPolicy(ctx, &models.RolePolicies{Role: models.ROLE_OPERATOR, CommandsPerDay: 100})
+			r = r.WithContext(limits.WithQuotas(ctx, limits.NewQuotas()))
+			handleFunc := restApi.GettingUsageHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			if w.Result().StatusCode == http.StatusOK {
+				var usage models.Usage
+				if err := json.NewDecoder(w.Result().Body).Decode(&usage); err != nil || usage.Commands != 4 || usage.CommandsPerDay != 100 {
+					t.Errorf("unexpected usage %+v: %v", usage, err)
+				}
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
+
+func TestRestApi_CreateNewCommandHandlerQuotas(t *testing.T) {
+	type mockBehavior func(*mock_database.MockDBWorker, *mock_bash.MockBashCommandsWorker)
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockBehavior mockBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `under the quotas`,
+			inputBody: `{"bash_strings": ["ls", "pwd"]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().GettingUsageQuery(uint(3), gomock.Any(), gomock.Any()).Return(&models.Usage{Commands: 8}, nil)
+				sh.EXPECT().ExecCommands(gomock.Any(), gomock.Any()).Return(&[]models.CommandsWithoutID{{Command: "ls"}, {Command: "pwd"}}, nil)
+				m.EXPECT().CreateNewCommandsQuery(gomock.Any(), gomock.Any(), context.Background()).Return(uint(1), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `commands per day`,
+			inputBody: `{"bash_strings": ["ls", "pwd", "date"]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().GettingUsageQuery(uint(3), gomock.Any(), gomock.Any()).Return(&models.Usage{Commands: 8}, nil)
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			name: `db querry error`,
+			inputBody: `{"bash_strings": ["ls"]}`,
+			mockBehavior: func(m *mock_database.MockDBWorker, sh *mock_bash.MockBashCommandsWorker) {
+				m.EXPECT().GettingUsageQuery(uint(3), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+			testCase.mockBehavior(mDatabase, mBash)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/create-command", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR})
+			ctx = auth.WithPolicy(ctx, &models.RolePolicies{Role: models.ROLE_OPERATOR, CommandsPerDay: 10})
+			r = r.WithContext(limits.WithQuotas(ctx, limits.NewQuotas()))
+			handleFunc := restApi.CreateNewCommandHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			if w.Result().StatusCode == http.StatusTooManyRequests && w.Result().Header.Get("Retry-After") == "" {
+				t.Errorf("expected the Retry-After header")
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// WEBHOOK_SECRET_BYTES is the size of a generated webhook secret.
+const WEBHOOK_SECRET_BYTES int = 32
+
+var webhookEvents = map[string]bool{
+	models.EVENT_COMMAND_SUCCEEDED: true,
+	models.EVENT_COMMAND_FAILED: true,
+	models.EVENT_COMMAND_TIMED_OUT: true,
+	models.EVENT_BATCH_SUCCEEDED: true,
+	models.EVENT_BATCH_FAILED: true,
+	models.EVENT_ALL: true,
+}
+
+type ReqCreateWebhookBody struct {
+	// http or https url that receives the events
+	Url string `json:"url"`
+	// command.succeeded, command.failed, command.timed_out, batch.succeeded,
+	// batch.failed or * for all of them
+	Events []string `json:"events"`
+	// key of the X-Webhook-Signature HMAC, generated when empty
+	Secret string `json:"secret"`
+}
+
+// CreateNewWebhookHandler registers a webhook. The secret is returned only
+// in this response.
+//
+//	@Tags		/bash/webhooks/
+//	@Accept		json
+//	@Produce	json
+//	@Param		new_webhook	body	ReqCreateWebhookBody	true	"url and events"
+//	@Router		/bash/webhooks [post]
+func (restApi RestApi) CreateNewWebhookHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var inputStruct ReqCreateWebhookBody
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		if err := json.Unmarshal(buf, &inputStruct); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("json unmarshal error: %v", err))
+			return
+		}
+
+		webhookUrl, err := url.Parse(inputStruct.Url)
+		if err != nil || (webhookUrl.Scheme != "http" && webhookUrl.Scheme != "https") || webhookUrl.Host == "" {
+			closeHandlerWithErr(w, fmt.Errorf("webhook url must be an absolute http or https url: %q", inputStruct.Url))
+			return
+		}
+		if len(inputStruct.Events) == 0 {
+			closeHandlerWithErr(w, fmt.Errorf("webhook has no events"))
+			return
+		}
+		for _, event := range inputStruct.Events {
+			if !webhookEvents[event] {
+				closeHandlerWithErr(w, fmt.Errorf("unknown webhook event %q", event))
+				return
+			}
+		}
+		if inputStruct.Secret == "" {
+			secret := make([]byte, WEBHOOK_SECRET_BYTES)
+			if _, err := rand.Read(secret); err != nil {
+				closeHandlerWithErr(w, fmt.Errorf("generate secret error: %v", err))
+				return
+			}
+			inputStruct.Secret = hex.EncodeToString(secret)
+		}
+
+		webhook := models.Webhooks{
+			Url: inputStruct.Url,
+			Secret: inputStruct.Secret,
+			Events: inputStruct.Events,
+			CreatedAt: time.Now(),
+		}
+		if webhook.Id, err = db.CreateNewWebhookQuery(&webhook, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, webhook)
+	}
+}
+
+//	@Tags		/bash/webhooks/
+//	@Produce	json
+//	@Router		/bash/webhooks [get]
+func (restApi RestApi) GettingListWebhooksHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhooks, err := db.GettingListWebhooksQuery(context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, webhooks)
+	}
+}
+
+//	@Tags		/bash/webhooks/
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/webhooks/{id} [delete]
+func (restApi RestApi) DeleteWebhookHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		if err := db.DeleteWebhookQuery(pathVal, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// GettingWebhookDeliveriesHandler returns the delivery log of a webhook, the
+// newest deliveries first.
+//
+//	@Tags		/bash/webhooks/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/webhooks/{id}/deliveries [get]
+func (restApi RestApi) GettingWebhookDeliveriesHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		deliveries, err := db.GettingWebhookDeliveriesQuery(pathVal, context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, deliveries)
+	}
+}
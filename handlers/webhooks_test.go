@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_CreateNewWebhookHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `generated secret`,
+			inputBody: `{"url": "https://example.com/hook", "events": ["command.failed", "batch.failed"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewWebhookQuery(gomock.Any(), context.Background()).DoAndReturn(
+					func(webhook *models.Webhooks, ctx context.Context) (uint, error) {
+						if len(webhook.Secret) != 2*WEBHOOK_SECRET_BYTES {
+							t.Errorf("unexpected secret %q", webhook.Secret)
+						}
+						return 1, nil
+					},
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `all events`,
+			inputBody: `{"url": "http://localhost:9000", "events": ["*"], "secret": "s3cret"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewWebhookQuery(gomock.Any(), context.Background()).DoAndReturn(
+					func(webhook *models.Webhooks, ctx context.Context) (uint, error) {
+						if webhook.Secret != "s3cret" {
+							t.Errorf("unexpected secret %q", webhook.Secret)
+						}
+						return 1, nil
+					},
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `unknown event`,
+			inputBody: `{"url": "https://example.com/hook", "events": ["command.started"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `no events`,
+			inputBody: `{"url": "https://example.com/hook"}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `invalid url`,
+			inputBody: `{"url": "ftp://example.com/hook", "events": ["*"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			inputBody: `{"url": "https://example.com/hook", "events": ["*"]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewWebhookQuery(gomock.Any(), context.Background()).Return(uint(0), fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/webhooks", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			handleFunc := restApi.CreateNewWebhookHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		})
+	}
+}
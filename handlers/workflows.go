@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	// std
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/workflow"
+)
+
+// CreateNewWorkflowHandler stores a workflow and starts it in the background,
+// the response holds the workflow with its pending steps.
+//
+//	@Tags		/bash/workflows/
+//	@Accept		json
+//	@Accept		plain
+//	@Produce	json
+//	@Param		definition	body	workflow.Definition	true	"workflow definition in JSON or YAML"
+//	@Router		/bash/workflows [post]
+func (restApi RestApi) CreateNewWorkflowHandler(db database.DBWorker, sh bash.BashCommandsWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("read request body error: %v", err))
+			return
+		}
+		definition, err := workflow.ParseDefinition(buf)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		options := make([]bash.CommandOptions, len(definition.Steps))
+		for i, step := range definition.Steps {
+			options[i].BashString, options[i].Env = step.Run, step.Env
+		}
+		if err := checkCommandPolicy(r, options); err != nil {
+			closeHandlerForbidden(w, err)
+			return
+		}
+		newWorkflow, err := workflow.NewWorkflow(definition)
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		newWorkflow.ApiKeyId = auth.ApiKeyId(r.Context())
+		if _, err := db.CreateNewWorkflowQuery(newWorkflow, context.Background()); err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		// the response is written before the runner starts changing the steps
+		writeJsonResponse(w, http.StatusOK, newWorkflow)
+		go workflow.NewRunner(db, sh, limits.QuotasFromContext(r.Context())).Run(newWorkflow, definition, context.Background())
+	}
+}
+
+//	@Tags		/bash/workflows/
+//	@Produce	json
+//	@Router		/bash/workflows [get]
+func (restApi RestApi) GettingListWorkflowsHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		workflows, err := db.GettingListWorkflowsQuery(auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, workflows)
+	}
+}
+
+//	@Tags		/bash/workflows/
+//	@Produce	json
+//	@Param		id	path	uint	true	"uint without 0"	minimum(1)
+//	@Router		/bash/workflows/{id} [get]
+func (restApi RestApi) GettingSingleWorkflowHandler(db database.DBWorker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pathVal, err := parsePathId(r, "id")
+		if err != nil {
+			closeHandlerWithErr(w, err)
+			return
+		}
+		workflow, err := db.GettingSingleWorkflowQuery(pathVal, auth.CommandsOwner(r.Context()), context.Background())
+		if err != nil {
+			closeHandlerWithErr(w, fmt.Errorf("database query error: %v", err))
+			return
+		}
+
+		writeJsonResponse(w, http.StatusOK, workflow)
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRestApi_CreateNewWorkflowHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	testTable := []struct {
+		name string
+		inputBody string
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `invalid yaml`,
+			inputBody: "steps: [",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `dependency cycle`,
+			inputBody: "steps:\n  - {name: a, run: 'true', needs: [b]}\n  - {name: b, run: 'true', needs: [a]}\n",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `db querry error`,
+			inputBody: `{"steps": [{"name": "a", "run": "true"}]}`,
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().CreateNewWorkflowQuery(gomock.Any(), context.Background()).Return(uint(0), fmt.Errorf("some db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+			mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/bash/workflows", bytes.NewBufferString(
+				testCase.inputBody,
+			))
+			handleFunc := restApi.CreateNewWorkflowHandler(mDatabase, mBash)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		}) 
+	}
+}
+
+func TestRestApi_GettingSingleWorkflowHandler(t *testing.T) {
+	type mockDBBehavior func(*mock_database.MockDBWorker)
+
+	ownKeyId := uint(3)
+	testTable := []struct {
+		name string
+		pathValue string
+		policy *models.RolePolicies
+		mockDBBehavior mockDBBehavior
+		expectedStatusCode int
+	} {
+		{
+			name: `existing workflow`,
+			pathValue: "2",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleWorkflowQuery(uint(2), nil, context.Background()).Return(
+					&models.Workflows{Id: 2, Status: models.STATUS_RUNNING, Steps: []models.WorkflowSteps{
+						{Id: 1, WorkflowId: 2, Name: "a", Needs: []string{}, Status: models.STATUS_SUCCEEDED},
+					}},
+					nil,
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: `invalid id`,
+			pathValue: "0",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `workflow not found`,
+			pathValue: "2",
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleWorkflowQuery(uint(2), nil, context.Background()).Return(nil, fmt.Errorf("no rows in result set"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name: `workflow of another key`,
+			pathValue: "2",
+			policy: &models.RolePolicies{Role: models.ROLE_VIEWER, OwnCommandsOnly: true},
+			mockDBBehavior: func(m *mock_database.MockDBWorker) {
+				m.EXPECT().GettingSingleWorkflowQuery(uint(2), &ownKeyId, context.Background()).Return(nil, fmt.Errorf("no rows in result set"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// init dependences
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mDatabase := mock_database.NewMockDBWorker(ctrl)
+			testCase.mockDBBehavior(mDatabase)
+
+			restApi := RestApi{}
+
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/bash/workflows/" + testCase.pathValue, nil)
+			r.SetPathValue("id", testCase.pathValue)
+			if testCase.policy != nil {
+				ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: ownKeyId, Role: testCase.policy.Role})
+				r = r.WithContext(auth.WithPolicy(ctx, testCase.policy))
+			}
+			handleFunc := restApi.GettingSingleWorkflowHandler(mDatabase)
+			handleFunc(w, r)
+
+			if w.Result().StatusCode != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Result().StatusCode)
+			}
+			defer w.Result().Body.Close()
+		}) 
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	// std
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/workspaces"
+)
+
+type ResWorkspaceFile struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+func checkWorkspaces(manager *workspaces.Manager) error {
+	if manager == nil {
+		return fmt.Errorf("workspaces aren't configured")
+	}
+	return nil
+}
+
+// closeHandlerWorkspaceErr answers 400 for an invalid name or path, 404 for a
+// missing workspace or file and 413 for a workspace over its quota, with the
+// reason. Other errors are internal errors.
+func closeHandlerWorkspaceErr(w http.ResponseWriter, err error) {
+	var status int
+	switch {
+	case errors.Is(err, workspaces.ErrInvalidName), errors.Is(err, workspaces.ErrInvalidPath):
+		status = http.StatusBadRequest
+	case errors.Is(err, workspaces.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, workspaces.ErrOverQuota):
+		status = http.StatusRequestEntityTooLarge
+	default:
+		closeHandlerWithErr(w, err)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// GettingListWorkspacesHandler returns every workspace with the size of its
+// files. Keys that see only their own commands see only the workspaces they
+// created, the workspaces of other keys are answered as missing by all the
+// handlers.
+//
+//	@Tags		/bash/workspaces/
+//	@Produce	json
+//	@Success	200	{array}	models.Workspaces
+//	@Router		/bash/workspaces [get]
+func (restApi RestApi) GettingListWorkspacesHandler(manager *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkWorkspaces(manager); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		list, err := manager.List(auth.CommandsOwner(r.Context()))
+		if err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		writeJsonResponse(w, http.StatusOK, list)
+	}
+}
+
+// GettingWorkspaceFilesHandler returns the files of a workspace.
+//
+//	@Tags		/bash/workspaces/
+//	@Produce	json
+//	@Param		name	path	string	true	"workspace name"
+//	@Success	200		{array}	models.WorkspaceFiles
+//	@Router		/bash/workspaces/{name}/files [get]
+func (restApi RestApi) GettingWorkspaceFilesHandler(manager *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkWorkspaces(manager); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		files, err := manager.ListFiles(r.PathValue("name"), auth.CommandsOwner(r.Context()))
+		if err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		writeJsonResponse(w, http.StatusOK, files)
+	}
+}
+
+// GettingWorkspaceFileHandler returns a file of a workspace.
+//
+//	@Tags		/bash/workspaces/
+//	@Produce	octet-stream
+//	@Param		name	path	string	true	"workspace name"
+//	@Param		path	path	string	true	"path of the file in the workspace"
+//	@Router		/bash/workspaces/{name}/files/{path} [get]
+func (restApi RestApi) GettingWorkspaceFileHandler(manager *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkWorkspaces(manager); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		file, err := manager.Open(r.PathValue("name"), r.PathValue("path"), auth.CommandsOwner(r.Context()))
+		if err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(r.PathValue("path")))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		http.ServeContent(w, r, "", info.ModTime(), file)
+	}
+}
+
+// SaveWorkspaceFileHandler stores the request body as a file of a workspace,
+// the workspace is created if there is none. The file can't take the
+// workspace over its quota.
+//
+//	@Tags		/bash/workspaces/
+//	@Accept		octet-stream
+//	@Produce	json
+//	@Param		name	path	string	true	"workspace name"
+//	@Param		path	path	string	true	"path of the file in the workspace"
+//	@Success	200		{object}	ResWorkspaceFile
+//	@Router		/bash/workspaces/{name}/files/{path} [put]
+func (restApi RestApi) SaveWorkspaceFileHandler(manager *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkWorkspaces(manager); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		defer r.Body.Close()
+		size, err := manager.WriteFile(r.PathValue("name"), r.PathValue("path"), r.Body,
+			auth.ApiKeyId(r.Context()), auth.CommandsOwner(r.Context()))
+		if err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		writeJsonResponse(w, http.StatusOK, ResWorkspaceFile{Path: r.PathValue("path"), SizeBytes: size})
+	}
+}
+
+// DeleteWorkspaceFileHandler deletes a file or a directory of a workspace.
+//
+//	@Tags		/bash/workspaces/
+//	@Param		name	path	string	true	"workspace name"
+//	@Param		path	path	string	true	"path of the file in the workspace"
+//	@Router		/bash/workspaces/{name}/files/{path} [delete]
+func (restApi RestApi) DeleteWorkspaceFileHandler(manager *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkWorkspaces(manager); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		if err := manager.RemoveFile(r.PathValue("name"), r.PathValue("path"), auth.CommandsOwner(r.Context())); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// GettingWorkspaceSnapshotHandler streams the files of a workspace as a tar
+// archive.
+//
+//	@Tags		/bash/workspaces/
+//	@Produce	octet-stream
+//	@Param		name	path	string	true	"workspace name"
+//	@Router		/bash/workspaces/{name}/snapshot [get]
+func (restApi RestApi) GettingWorkspaceSnapshotHandler(manager *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkWorkspaces(manager); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		name, owner := r.PathValue("name"), auth.CommandsOwner(r.Context())
+		if _, err := manager.Usage(name, owner); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".tar"}))
+		if err := manager.Snapshot(name, w, owner); err != nil {
+			// the status is sent already, the archive is cut short
+			log.Printf("snapshot error: %v\n", err)
+		}
+	}
+}
+
+// DeleteWorkspaceHandler deletes a workspace with its files.
+//
+//	@Tags		/bash/workspaces/
+//	@Param		name	path	string	true	"workspace name"
+//	@Router		/bash/workspaces/{name} [delete]
+func (restApi RestApi) DeleteWorkspaceHandler(manager *workspaces.Manager) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checkWorkspaces(manager); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		if err := manager.Remove(r.PathValue("name"), auth.CommandsOwner(r.Context())); err != nil {
+			closeHandlerWorkspaceErr(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
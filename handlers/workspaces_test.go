@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/workspaces"
+)
+
+func TestRestApi_WorkspaceFileHandlers(t *testing.T) {
+	manager := &workspaces.Manager{Dir: t.TempDir(), MaxBytes: 16}
+	restApi := RestApi{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bash/workspaces/{name}/files/{path...}", restApi.GettingWorkspaceFileHandler(manager))
+	mux.HandleFunc("PUT /bash/workspaces/{name}/files/{path...}", restApi.SaveWorkspaceFileHandler(manager))
+	mux.HandleFunc("GET /bash/workspaces/{name}/snapshot", restApi.GettingWorkspaceSnapshotHandler(manager))
+
+	testTable := []struct {
+		name string
+		method string
+		target string
+		inputBody string
+		expectedStatusCode int
+		expectedBody string
+	} {
+		{
+			name: `write`,
+			method: http.MethodPut,
+			target: "/bash/workspaces/deploy/files/conf/app.env",
+			inputBody: "A=1\n",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `{"path":"conf/app.env","size_bytes":4}` + "\n",
+		},
+		{
+			name: `read`,
+			method: http.MethodGet,
+			target: "/bash/workspaces/deploy/files/conf/app.env",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: "A=1\n",
+		},
+		{
+			name: `over quota`,
+			method: http.MethodPut,
+			target: "/bash/workspaces/deploy/files/big.bin",
+			inputBody: "more than the quota",
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedBody: `workspace "deploy" is over its quota of 16 bytes` + "\n",
+		},
+		{
+			name: `path traversal`,
+			method: http.MethodPut,
+			target: "/bash/workspaces/deploy/files/conf%2F..%2F..%2Fescape",
+			inputBody: "x",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: `invalid path "conf/../../escape", it must be a file of the workspace` + "\n",
+		},
+		{
+			name: `missing file`,
+			method: http.MethodGet,
+			target: "/bash/workspaces/deploy/files/none.txt",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody: `"none.txt" of workspace "deploy" isn't found` + "\n",
+		},
+		{
+			name: `invalid name`,
+			method: http.MethodGet,
+			target: "/bash/workspaces/.hidden/snapshot",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: `invalid workspace name ".hidden"` + "\n",
+		},
+		{
+			name: `unknown workspace`,
+			method: http.MethodGet,
+			target: "/bash/workspaces/other/snapshot",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody: `workspace "other" isn't found` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(*testing.T){
+			// test request
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(testCase.method, testCase.target, bytes.NewBufferString(testCase.inputBody))
+			mux.ServeHTTP(w, r)
+
+			if w.Code != testCase.expectedStatusCode {
+				t.Errorf("expected status code %v but got %v", testCase.expectedStatusCode, w.Code)
+			}
+			if testCase.expectedBody != "" && w.Body.String() != testCase.expectedBody {
+				t.Errorf("expected body %q but got %q", testCase.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRestApi_GettingListWorkspacesHandlerNotConfigured(t *testing.T) {
+	restApi := RestApi{}
+
+	// test request
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/bash/workspaces", nil)
+	handleFunc := restApi.GettingListWorkspacesHandler(nil)
+	handleFunc(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %v but got %v", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestRestApi_WorkspaceOwners(t *testing.T) {
+	manager := &workspaces.Manager{Dir: t.TempDir(), MaxBytes: 1024}
+	restApi := RestApi{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bash/workspaces/{name}/files/{path...}", restApi.GettingWorkspaceFileHandler(manager))
+	mux.HandleFunc("PUT /bash/workspaces/{name}/files/{path...}", restApi.SaveWorkspaceFileHandler(manager))
+	mux.HandleFunc("DELETE /bash/workspaces/{name}", restApi.DeleteWorkspaceHandler(manager))
+
+	request := func(method string, target string, keyId uint, ownOnly bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, target, bytes.NewBufferString("A=1\n"))
+		ctx := auth.WithApiKey(r.Context(), &models.ApiKeys{Id: keyId})
+		ctx = auth.WithPolicy(ctx, &models.RolePolicies{OwnCommandsOnly: ownOnly})
+		mux.ServeHTTP(w, r.WithContext(ctx))
+		return w
+	}
+
+	if w := request(http.MethodPut, "/bash/workspaces/deploy/files/app.env", 3, true); w.Code != http.StatusOK {
+		t.Fatalf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+	// the workspace of another key is missing for a key that sees only its
+	// own commands
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		if w := request(method, "/bash/workspaces/deploy/files/app.env", 4, true); w.Code != http.StatusNotFound {
+			t.Errorf("%v: expected status code %v but got %v", method, http.StatusNotFound, w.Code)
+		}
+	}
+	if w := request(http.MethodDelete, "/bash/workspaces/deploy", 4, true); w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %v but got %v", http.StatusNotFound, w.Code)
+	}
+	if w := request(http.MethodGet, "/bash/workspaces/deploy/files/app.env", 4, false); w.Code != http.StatusOK {
+		t.Errorf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+}
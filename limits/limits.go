@@ -0,0 +1,178 @@
+package limits
+
+import (
+	// std
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+)
+
+// environment variables of the rate limits
+const (
+	RATE_LIMIT_PER_SECOND_ENV string = "RATE_LIMIT_PER_SECOND"
+	RATE_LIMIT_BURST_ENV      string = "RATE_LIMIT_BURST"
+	// the limit of every ip address to all the routes, checked before the
+	// authentication
+	IP_RATE_LIMIT_PER_SECOND_ENV string = "IP_RATE_LIMIT_PER_SECOND"
+	IP_RATE_LIMIT_BURST_ENV      string = "IP_RATE_LIMIT_BURST"
+)
+
+const (
+	DEFAULT_RATE_PER_SECOND float64 = 5
+	DEFAULT_BURST           int     = 20
+
+	DEFAULT_IP_RATE_PER_SECOND float64 = 20
+	DEFAULT_IP_BURST           int     = 100
+	// full buckets are dropped after this long, a full bucket is the same as
+	// a missing one
+	SWEEP_INTERVAL time.Duration = time.Minute
+)
+
+type quotasContextKey struct{}
+
+// bucket is a token bucket, tokens are added at the rate of the limiter up to
+// its burst and every request takes one.
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// Limiter keeps a token bucket per client and route.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+}
+
+// NewLimiter returns a limiter allowing rate requests per second with bursts
+// of burst requests to every client and route.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: burst, buckets: map[string]*bucket{}}
+}
+
+// LimiterFromEnv returns the limiter of the keys of the environment, the
+// defaults are used for the variables that aren't set.
+func LimiterFromEnv() (*Limiter, error) {
+	return limiterFromEnv(RATE_LIMIT_PER_SECOND_ENV, RATE_LIMIT_BURST_ENV, DEFAULT_RATE_PER_SECOND, DEFAULT_BURST)
+}
+
+// IpLimiterFromEnv returns the limiter of the ip addresses of the
+// environment, the defaults are used for the variables that aren't set.
+func IpLimiterFromEnv() (*Limiter, error) {
+	return limiterFromEnv(IP_RATE_LIMIT_PER_SECOND_ENV, IP_RATE_LIMIT_BURST_ENV, DEFAULT_IP_RATE_PER_SECOND, DEFAULT_IP_BURST)
+}
+
+func limiterFromEnv(rateEnv string, burstEnv string, rate float64, burst int) (*Limiter, error) {
+	if value := os.Getenv(rateEnv); value != "" {
+		var err error
+		if rate, err = strconv.ParseFloat(value, 64); err != nil || rate <= 0 {
+			return nil, fmt.Errorf("%v isn't a positive number: %q", rateEnv, value)
+		}
+	}
+	if value := os.Getenv(burstEnv); value != "" {
+		var err error
+		if burst, err = strconv.Atoi(value); err != nil || burst <= 0 {
+			return nil, fmt.Errorf("%v isn't a positive integer: %q", burstEnv, value)
+		}
+	}
+	return NewLimiter(rate, burst), nil
+}
+
+// Allow takes a token from the bucket of the key at the given time. It
+// returns 0 if there was one, otherwise how long until there is.
+func (limiter *Limiter) Allow(key string, now time.Time) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.sweep(now)
+
+	b, ok := limiter.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limiter.burst), updatedAt: now}
+		limiter.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limiter.burst), b.tokens+now.Sub(b.updatedAt).Seconds()*limiter.rate)
+	b.updatedAt = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / limiter.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+func (limiter *Limiter) sweep(now time.Time) {
+	if now.Sub(limiter.sweptAt) < SWEEP_INTERVAL {
+		return
+	}
+	limiter.sweptAt = now
+	refill := time.Duration(float64(limiter.burst) / limiter.rate * float64(time.Second))
+	for key, b := range limiter.buckets {
+		if now.Sub(b.updatedAt) >= refill {
+			delete(limiter.buckets, key)
+		}
+	}
+}
+
+// client returns the client a request is limited as, its api key or its ip
+// address for the public routes.
+func client(r *http.Request) string {
+	if keyId := auth.ApiKeyId(r.Context()); keyId != nil {
+		return fmt.Sprintf("key:%v", *keyId)
+	}
+	return "ip:" + auth.RemoteIp(r)
+}
+
+// WriteTooManyRequests answers 429 with the Retry-After header in whole
+// seconds.
+func WriteTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, message, http.StatusTooManyRequests)
+}
+
+// Middleware limits the requests of every client to every route of the mux
+// and passes the quotas on to the handlers. It serves the requests that
+// auth.Middleware let through.
+func Middleware(limiter *Limiter, quotas *Quotas, mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		if retryAfter := limiter.Allow(client(r)+" "+pattern, time.Now()); retryAfter > 0 {
+			WriteTooManyRequests(w, retryAfter, fmt.Sprintf("rate limit of %v exceeded", pattern))
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(WithQuotas(r.Context(), quotas)))
+	})
+}
+
+// IpMiddleware limits the requests of every ip address to all the routes
+// before next. It goes outside the authentication and the audit log, so that
+// requests without a key or with a wrong one cost nothing over the limit.
+func IpMiddleware(limiter *Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter := limiter.Allow("ip:"+auth.RemoteIp(r), time.Now()); retryAfter > 0 {
+			WriteTooManyRequests(w, retryAfter, "rate limit of the address exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithQuotas returns a context carrying the quotas.
+func WithQuotas(ctx context.Context, quotas *Quotas) context.Context {
+	return context.WithValue(ctx, quotasContextKey{}, quotas)
+}
+
+// QuotasFromContext returns the quotas of the request, nil if they aren't
+// enforced.
+func QuotasFromContext(ctx context.Context) *Quotas {
+	quotas, _ := ctx.Value(quotasContextKey{}).(*Quotas)
+	return quotas
+}
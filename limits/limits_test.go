@@ -0,0 +1,224 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter := NewLimiter(2, 3)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if retryAfter := limiter.Allow("a", now); retryAfter != 0 {
+			t.Fatalf("request %v of the burst isn't allowed, retry after %v", i+1, retryAfter)
+		}
+	}
+	if retryAfter := limiter.Allow("a", now); retryAfter != 500*time.Millisecond {
+		t.Errorf("expected to retry after 500ms but got %v", retryAfter)
+	}
+	if retryAfter := limiter.Allow("b", now); retryAfter != 0 {
+		t.Errorf("the bucket of another key is empty, retry after %v", retryAfter)
+	}
+	if retryAfter := limiter.Allow("a", now.Add(500*time.Millisecond)); retryAfter != 0 {
+		t.Errorf("a token isn't added after 500ms, retry after %v", retryAfter)
+	}
+
+	// full buckets are dropped
+	limiter.Allow("c", now.Add(time.Hour))
+	if _, ok := limiter.buckets["a"]; ok {
+		t.Errorf("a full bucket isn't dropped")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	quotas := NewQuotas()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bash/get-commands", func(w http.ResponseWriter, r *http.Request) {
+		if QuotasFromContext(r.Context()) != quotas {
+			t.Errorf("the quotas aren't passed on")
+		}
+	})
+	handler := Middleware(NewLimiter(1, 2), quotas, mux)
+
+	request := func(keyId uint, remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/bash/get-commands", nil)
+		r.RemoteAddr = remoteAddr
+		if keyId != 0 {
+			r = r.WithContext(auth.WithApiKey(r.Context(), &models.ApiKeys{Id: keyId}))
+		}
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := request(1, "10.0.0.1:1000"); w.Code != http.StatusOK {
+			t.Fatalf("expected status code %v but got %v", http.StatusOK, w.Code)
+		}
+	}
+	w := request(1, "10.0.0.2:1000")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected status code 429 with Retry-After 1 but got %v %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// another key from the same address and requests without a key have
+	// their own buckets
+	if w := request(2, "10.0.0.1:1000"); w.Code != http.StatusOK {
+		t.Errorf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+	if w := request(0, "10.0.0.1:1000"); w.Code != http.StatusOK {
+		t.Errorf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+}
+
+func TestIpMiddleware(t *testing.T) {
+	served := 0
+	handler := IpMiddleware(NewLimiter(1, 2), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+
+	request := func(target string, remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.RemoteAddr = remoteAddr
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// the bucket of an address is shared by all the routes
+	request("/bash/get-commands", "10.0.0.1:1000")
+	request("/bash/audit", "10.0.0.1:2000")
+	w := request("/bash/api-keys", "10.0.0.1:3000")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected status code 429 with Retry-After 1 but got %v %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := request("/bash/get-commands", "10.0.0.2:1000"); w.Code != http.StatusOK {
+		t.Errorf("expected status code %v but got %v", http.StatusOK, w.Code)
+	}
+	if served != 3 {
+		t.Errorf("expected 3 served requests but got %v", served)
+	}
+}
+
+func TestQuotas_Acquire(t *testing.T) {
+	key := &models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR}
+	withPolicy := func(policy models.RolePolicies) context.Context {
+		return auth.WithPolicy(auth.WithApiKey(context.Background(), key), &policy)
+	}
+
+	var tests = []struct {
+		testName  string
+		policy    models.RolePolicies
+		running   int
+		commands  int
+		usage     *models.Usage
+		wantQuota string
+		wantErr   bool
+		// the request can never fit into the quotas
+		wantTooMany bool
+	}{
+		{"no quotas", models.RolePolicies{}, 5, 5, nil, "", false, false},
+		{"under all quotas", models.RolePolicies{CommandsPerDay: 10, CpuSecondsPerDay: 60, MaxConcurrentCommands: 4}, 2, 2,
+			&models.Usage{Commands: 6, CpuMs: 59000}, "", false, false},
+		{"concurrent commands", models.RolePolicies{MaxConcurrentCommands: 4}, 3, 2, nil, "max_concurrent_commands", true, false},
+		{"request over the concurrency", models.RolePolicies{MaxConcurrentCommands: 4}, 0, 5, nil, "", true, true},
+		{"commands per day", models.RolePolicies{CommandsPerDay: 10}, 1, 2, &models.Usage{Commands: 8}, "commands_per_day", true, false},
+		{"cpu seconds per day", models.RolePolicies{CpuSecondsPerDay: 60}, 0, 1, &models.Usage{CpuMs: 60000}, "cpu_seconds_per_day", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			if tt.usage != nil {
+				db.EXPECT().GettingUsageQuery(key.Id, gomock.Any(), gomock.Any()).Return(tt.usage, nil)
+			}
+
+			quotas := NewQuotas()
+			if tt.running != 0 {
+				quotas.running[key.Id] = tt.running
+			}
+			release, err := quotas.Acquire(db, tt.commands, withPolicy(tt.policy))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Acquire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var quotaErr *QuotaError
+			if errors.As(err, &quotaErr) != (tt.wantQuota != "") || (quotaErr != nil && quotaErr.Quota != tt.wantQuota) {
+				t.Fatalf("expected quota %q but got %v", tt.wantQuota, err)
+			}
+			if tt.wantTooMany != errors.Is(err, ErrTooManyCommands) {
+				t.Errorf("expected ErrTooManyCommands only for a request over the concurrency but got %v", err)
+			}
+			if quotaErr != nil && quotaErr.RetryAfter <= 0 {
+				t.Errorf("expected a positive retry after but got %v", quotaErr.RetryAfter)
+			}
+			if err != nil {
+				if quotas.running[key.Id] != tt.running {
+					t.Errorf("rejected commands are counted as running")
+				}
+				return
+			}
+
+			if quotas.running[key.Id] != tt.running+tt.commands {
+				t.Errorf("expected %v running commands but got %v", tt.running+tt.commands, quotas.running[key.Id])
+			}
+			release()
+			release()
+			if quotas.running[key.Id] != tt.running {
+				t.Errorf("expected %v running commands after the release but got %v", tt.running, quotas.running[key.Id])
+			}
+		})
+	}
+}
+
+func TestQuotas_AcquireForKey(t *testing.T) {
+	revokedAt := time.Now()
+	options := []bash.CommandOptions{{BashString: "rm -rf build"}}
+
+	var tests = []struct {
+		testName string
+		key      models.ApiKeys
+		policy   *models.RolePolicies
+		wantErr  string
+	}{
+		{"allowed", models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR}, &models.RolePolicies{AllowedBinaries: []string{"rm"}}, ""},
+		{"revoked key", models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR, RevokedAt: &revokedAt}, nil, "revoked"},
+		{"tightened policy", models.ApiKeys{Id: 3, Role: models.ROLE_OPERATOR}, &models.RolePolicies{AllowedBinaries: []string{"ls"}}, `may not run "rm"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			db.EXPECT().GettingApiKeyQuery(tt.key.Id, gomock.Any()).Return(&tt.key, nil)
+			if tt.policy != nil {
+				db.EXPECT().GettingRolePolicyQuery(tt.key.Role, gomock.Any()).Return(tt.policy, nil)
+			}
+
+			_, _, err := NewQuotas().AcquireForKey(db, &tt.key.Id, options, context.Background())
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("AcquireForKey() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	now := time.Date(2026, 10, 19, 1, 30, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	if day := StartOfDay(now); !day.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the start of the UTC day but got %v", day)
+	}
+}
@@ -0,0 +1,155 @@
+package limits
+
+import (
+	// std
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// CONCURRENCY_RETRY_AFTER is the Retry-After of requests over the concurrent
+// commands quota, the running commands may take any time.
+const CONCURRENCY_RETRY_AFTER time.Duration = 5 * time.Second
+
+// ErrTooManyCommands is returned for a request with more commands than its
+// role may run at once, unlike a QuotaError repeating it doesn't help.
+var ErrTooManyCommands = errors.New("too many commands")
+
+// QuotaError is returned when a request would go over a quota of the role of
+// its key, it may be repeated after RetryAfter.
+type QuotaError struct {
+	Quota      string
+	RetryAfter time.Duration
+}
+
+func (err *QuotaError) Error() string {
+	return fmt.Sprintf("%v quota exceeded", err.Quota)
+}
+
+// Quotas enforces the daily and concurrent command quotas of the roles. The
+// daily usage is counted from the stored commands, the running commands are
+// counted in memory.
+type Quotas struct {
+	mu      sync.Mutex
+	running map[uint]int
+}
+
+func NewQuotas() *Quotas {
+	return &Quotas{running: map[uint]int{}}
+}
+
+// StartOfDay returns the start of the UTC day of the time, the daily quotas
+// are reset then.
+func StartOfDay(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Usage returns what the key of the request used of its quotas today.
+func (quotas *Quotas) Usage(db database.DBWorker, ctx context.Context) (*models.Usage, error) {
+	key, policy := auth.ApiKeyFromContext(ctx), auth.PolicyFromContext(ctx)
+	if key == nil || policy == nil {
+		return nil, fmt.Errorf("request has no api key")
+	}
+	usage, err := db.GettingUsageQuery(key.Id, StartOfDay(time.Now()), ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage.Role = policy.Role
+	usage.CommandsPerDay, usage.CpuSecondsPerDay = policy.CommandsPerDay, policy.CpuSecondsPerDay
+	usage.MaxConcurrentCommands = policy.MaxConcurrentCommands
+	quotas.mu.Lock()
+	usage.RunningCommands = quotas.running[key.Id]
+	quotas.mu.Unlock()
+	return usage, nil
+}
+
+// Acquire reserves the given number of commands for the key of the request
+// and returns the function that releases them once they are stored. It
+// returns a *QuotaError if the commands would go over a quota of the role and
+// ErrTooManyCommands if they never fit into it.
+// Requests without a key have no quotas.
+func (quotas *Quotas) Acquire(db database.DBWorker, commands int, ctx context.Context) (func(), error) {
+	key, policy := auth.ApiKeyFromContext(ctx), auth.PolicyFromContext(ctx)
+	if key == nil || policy == nil {
+		return func() {}, nil
+	}
+	if policy.MaxConcurrentCommands > 0 && commands > policy.MaxConcurrentCommands {
+		return nil, fmt.Errorf("%w: request has %v commands, role %v may run %v at once",
+			ErrTooManyCommands, commands, policy.Role, policy.MaxConcurrentCommands)
+	}
+
+	now := time.Now()
+	var usage *models.Usage
+	if policy.CommandsPerDay > 0 || policy.CpuSecondsPerDay > 0 {
+		var err error
+		if usage, err = db.GettingUsageQuery(key.Id, StartOfDay(now), ctx); err != nil {
+			return nil, err
+		}
+	}
+	untilTomorrow := StartOfDay(now).Add(24 * time.Hour).Sub(now)
+
+	quotas.mu.Lock()
+	defer quotas.mu.Unlock()
+	running := quotas.running[key.Id]
+	switch {
+	case policy.MaxConcurrentCommands > 0 && running+commands > policy.MaxConcurrentCommands:
+		return nil, &QuotaError{Quota: "max_concurrent_commands", RetryAfter: CONCURRENCY_RETRY_AFTER}
+	// the running commands aren't stored yet
+	case policy.CommandsPerDay > 0 && usage.Commands+running+commands > policy.CommandsPerDay:
+		return nil, &QuotaError{Quota: "commands_per_day", RetryAfter: untilTomorrow}
+	case policy.CpuSecondsPerDay > 0 && usage.CpuMs >= int64(policy.CpuSecondsPerDay)*1000:
+		return nil, &QuotaError{Quota: "cpu_seconds_per_day", RetryAfter: untilTomorrow}
+	}
+	quotas.running[key.Id] = running + commands
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			quotas.mu.Lock()
+			defer quotas.mu.Unlock()
+			if quotas.running[key.Id] -= commands; quotas.running[key.Id] <= 0 {
+				delete(quotas.running, key.Id)
+			}
+		})
+	}, nil
+}
+
+// AcquireForKey reserves the commands like Acquire for the key that owns a
+// schedule or a workflow, with the current policy of its role. The key has to
+// be active and the policy has to allow the commands, it may have changed
+// since they were stored. It returns the owner of auth.CommandsOwner for the
+// key as well. A nil key is neither checked nor limited, nil quotas only
+// check.
+func (quotas *Quotas) AcquireForKey(db database.DBWorker, apiKeyId *uint, options []bash.CommandOptions, ctx context.Context) (func(), *uint, error) {
+	if apiKeyId == nil {
+		return func() {}, nil, nil
+	}
+	key, err := db.GettingApiKeyQuery(*apiKeyId, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, nil, fmt.Errorf("api key %v is revoked", key.Id)
+	}
+	policy, err := db.GettingRolePolicyQuery(key.Role, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = auth.WithPolicy(auth.WithApiKey(ctx, key), policy)
+	if err := auth.CheckOptions(ctx, options); err != nil {
+		return nil, nil, err
+	}
+	if quotas == nil {
+		return func() {}, auth.CommandsOwner(ctx), nil
+	}
+	release, err := quotas.Acquire(db, len(options), ctx)
+	return release, auth.CommandsOwner(ctx), err
+}
@@ -2,15 +2,29 @@ package main
 
 import (
 	// std
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	// time zones of schedules don't depend on the tzdata of the image
+	_ "time/tzdata"
 
 	// local
+	"github.com/Vy4cheSlave/test-task-postgres/artifacts"
+	"github.com/Vy4cheSlave/test-task-postgres/audit"
+	"github.com/Vy4cheSlave/test-task-postgres/auth"
 	"github.com/Vy4cheSlave/test-task-postgres/bash"
 	"github.com/Vy4cheSlave/test-task-postgres/database"
 	_ "github.com/Vy4cheSlave/test-task-postgres/docs"
 	"github.com/Vy4cheSlave/test-task-postgres/handlers"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/reencrypt"
+	"github.com/Vy4cheSlave/test-task-postgres/retention"
+	"github.com/Vy4cheSlave/test-task-postgres/scheduler"
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
+	"github.com/Vy4cheSlave/test-task-postgres/webhook"
+	"github.com/Vy4cheSlave/test-task-postgres/workspaces"
 
 	// web
 	"github.com/swaggo/http-swagger/v2"
@@ -21,22 +35,114 @@ const (
 	DATABASE_URL string = "user=psql password=psql host=test-task-db port=5432 dbname=test-task-db"
 	NUMBER_ATTEMPTS_TO_CONNECT_TO_DB uint = 5
 	PORT string = "8080"
+	// environment variable with a key that is stored as an admin key on start
+	ADMIN_API_KEY_ENV string = "ADMIN_API_KEY"
 )
 
 //	@title			bash API
 //	@version		1.0
 //	@license.name	Apache 2.0
 func main() {
+	secretsBox, err := secrets.BoxFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if secretsBox == nil {
+		log.Printf("%v isn't set, secrets are disabled\n", secrets.SECRETS_KEY_FILE_ENV)
+	}
+
+	outputKey, previousOutputKeys, err := secrets.MasterKeysFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	outputKeyMaxAge, err := reencrypt.MaxAgeFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	var dbInstance database.DBWorker
-	dbInstance, err := database.ConnectToDB(DATABASE_URL, NUMBER_ATTEMPTS_TO_CONNECT_TO_DB)
+	dbInstance, err = database.ConnectToDB(DATABASE_URL, NUMBER_ATTEMPTS_TO_CONNECT_TO_DB,
+		database.WithSecretsBox(secretsBox), database.WithOutputKeys(outputKey, previousOutputKeys...))
 	if err != nil {
 		log.Fatalln(err)
 	}
 	defer dbInstance.Close()
 	log.Println("succsesfully connect to database")
 
+	if adminKey := os.Getenv(ADMIN_API_KEY_ENV); adminKey != "" {
+		if err := auth.Bootstrap(dbInstance, adminKey, context.Background()); err != nil {
+			log.Printf("unable to store the admin api key: %v\n", err)
+		}
+	}
+
+	var verifier *auth.Verifier
+	jwtConfig, err := auth.JwtConfigFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if jwtConfig != nil {
+		if verifier, err = auth.NewVerifier(*jwtConfig); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("accepting tokens of %v\n", jwtConfig.Issuer)
+	}
+
+	retentionPolicy, err := retention.PolicyFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if retentionPolicy != nil {
+		// the archive of sealed logs is sealed too
+		retentionPolicy.ArchiveKey = outputKey
+	}
+
+	limiter, err := limits.LimiterFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	ipLimiter, err := limits.IpLimiterFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	maxOutputBytes, err := bash.MaxOutputBytesFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	maxStoredOutputBytes, err := bash.MaxStoredOutputBytesFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	artifactStore, err := artifacts.StoreFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	workspaceManager, err := workspaces.ManagerFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	restApi := handlers.RestApi{}
-	sh := bash.BashCommands{}
+	runs := bash.NewRuns()
+	// shared by the requests, the schedules and the workflows
+	quotas := limits.NewQuotas()
+	sh := bash.BashCommands{MaxOutputBytes: maxOutputBytes, MaxStoredOutputBytes: maxStoredOutputBytes, Artifacts: artifactStore,
+		Workspaces: workspaceManager, Runs: runs}
+
+	go scheduler.NewScheduler(dbInstance, sh, quotas).Run(context.Background())
+	go webhook.NewDispatcher(dbInstance).Run(context.Background())
+	if retentionPolicy != nil {
+		go retention.NewJanitor(dbInstance, *retentionPolicy).Run(context.Background())
+	}
+	if artifactStore != nil {
+		go artifacts.NewSweeper(dbInstance, artifactStore).Run(context.Background())
+	}
+	if outputKey != nil {
+		log.Printf("output of commands is sealed, master key %v\n", outputKey.Id)
+		go reencrypt.NewJob(dbInstance, outputKeyMaxAge).Run(context.Background())
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /swagger/*", httpSwagger.Handler(
@@ -48,9 +154,130 @@ func main() {
 		restApi.GettingSingleCommandHandler(dbInstance))
 	mux.HandleFunc("GET /bash/get-commands", 
 		restApi.GettingListCommandsHandler(dbInstance))
+	mux.HandleFunc("DELETE /bash/commands/{id}", 
+		restApi.DeleteCommandHandler(dbInstance))
+	mux.HandleFunc("GET /bash/commands/{id}/output", 
+		restApi.GettingCommandOutputHandler(dbInstance))
+	mux.HandleFunc("GET /bash/commands/export", 
+		restApi.ExportCommandsHandler(dbInstance))
+	mux.HandleFunc("GET /api/v1/commands/export", 
+		restApi.ExportCommandsHandler(dbInstance))
+	mux.HandleFunc("POST /bash/commands/import", 
+		restApi.ImportCommandsHandler(dbInstance))
+	mux.HandleFunc("GET /bash/commands/{id}/artifacts", 
+		restApi.GettingCommandArtifactsHandler(dbInstance))
+	mux.HandleFunc("GET /bash/commands/{id}/artifacts/{artifactId}", 
+		restApi.GettingArtifactHandler(dbInstance, artifactStore))
+	mux.HandleFunc("GET /bash/commands/{id}/artifacts.zip", 
+		restApi.GettingArtifactsZipHandler(dbInstance, artifactStore))
+	mux.HandleFunc("GET /bash/workspaces", 
+		restApi.GettingListWorkspacesHandler(workspaceManager))
+	mux.HandleFunc("DELETE /bash/workspaces/{name}", 
+		restApi.DeleteWorkspaceHandler(workspaceManager))
+	mux.HandleFunc("GET /bash/workspaces/{name}/files", 
+		restApi.GettingWorkspaceFilesHandler(workspaceManager))
+	mux.HandleFunc("GET /bash/workspaces/{name}/files/{path...}", 
+		restApi.GettingWorkspaceFileHandler(workspaceManager))
+	mux.HandleFunc("PUT /bash/workspaces/{name}/files/{path...}", 
+		restApi.SaveWorkspaceFileHandler(workspaceManager))
+	mux.HandleFunc("DELETE /bash/workspaces/{name}/files/{path...}", 
+		restApi.DeleteWorkspaceFileHandler(workspaceManager))
+	mux.HandleFunc("GET /bash/workspaces/{name}/snapshot", 
+		restApi.GettingWorkspaceSnapshotHandler(workspaceManager))
+	mux.HandleFunc("GET /bash/runs", 
+		restApi.GettingListRunsHandler(runs))
+	mux.HandleFunc("POST /bash/runs/{id}/cancel", 
+		restApi.CancelRunHandler(runs))
+	mux.HandleFunc("GET /bash/runs/{id}/stream", 
+		restApi.GettingRunStreamHandler(runs))
+	mux.HandleFunc("POST /bash/commands/{id}/rerun", 
+		restApi.RerunCommandHandler(dbInstance, sh))
+	mux.HandleFunc("GET /bash/commands/{id}/attempts", 
+		restApi.GettingCommandAttemptsHandler(dbInstance))
+	mux.HandleFunc("GET /bash/commands/{id}/diff/{otherId}", 
+		restApi.CommandsDiffHandler(dbInstance))
+	mux.HandleFunc("GET /bash/batches/{id}", 
+		restApi.GettingBatchHandler(dbInstance))
+	mux.HandleFunc("GET /bash/batches/{id}/groups", 
+		restApi.GettingBatchGroupsHandler(dbInstance))
+	mux.HandleFunc("POST /bash/batches/{id}/rerun", 
+		restApi.RerunBatchHandler(dbInstance, sh))
+
+	mux.HandleFunc("POST /bash/schedules", 
+		restApi.CreateNewScheduleHandler(dbInstance))
+	mux.HandleFunc("GET /bash/schedules", 
+		restApi.GettingListSchedulesHandler(dbInstance))
+	mux.HandleFunc("GET /bash/schedules/{id}", 
+		restApi.GettingSingleScheduleHandler(dbInstance))
+	mux.HandleFunc("DELETE /bash/schedules/{id}", 
+		restApi.DeleteScheduleHandler(dbInstance))
+	mux.HandleFunc("GET /bash/schedules/{id}/batches", 
+		restApi.GettingScheduleBatchesHandler(dbInstance))
+	mux.HandleFunc("POST /bash/schedules/{id}/pause", 
+		restApi.PauseScheduleHandler(dbInstance))
+	mux.HandleFunc("POST /bash/schedules/{id}/resume", 
+		restApi.ResumeScheduleHandler(dbInstance))
+
+	mux.HandleFunc("POST /bash/workflows", 
+		restApi.CreateNewWorkflowHandler(dbInstance, sh))
+	mux.HandleFunc("GET /bash/workflows", 
+		restApi.GettingListWorkflowsHandler(dbInstance))
+	mux.HandleFunc("GET /bash/workflows/{id}", 
+		restApi.GettingSingleWorkflowHandler(dbInstance))
+
+	mux.HandleFunc("GET /bash/scripts", 
+		restApi.GettingListScriptsHandler(dbInstance))
+	mux.HandleFunc("PUT /bash/scripts/{name}", 
+		restApi.SaveScriptHandler(dbInstance))
+	mux.HandleFunc("GET /bash/scripts/{name}", 
+		restApi.GettingScriptHandler(dbInstance))
+	mux.HandleFunc("GET /bash/scripts/{name}/versions", 
+		restApi.GettingScriptVersionsHandler(dbInstance))
+	mux.HandleFunc("POST /bash/scripts/{name}/run", 
+		restApi.RunScriptHandler(dbInstance, sh))
+
+	mux.HandleFunc("POST /bash/webhooks", 
+		restApi.CreateNewWebhookHandler(dbInstance))
+	mux.HandleFunc("GET /bash/webhooks", 
+		restApi.GettingListWebhooksHandler(dbInstance))
+	mux.HandleFunc("DELETE /bash/webhooks/{id}", 
+		restApi.DeleteWebhookHandler(dbInstance))
+	mux.HandleFunc("GET /bash/webhooks/{id}/deliveries", 
+		restApi.GettingWebhookDeliveriesHandler(dbInstance))
+
+	mux.HandleFunc("POST /bash/api-keys", 
+		restApi.CreateNewApiKeyHandler(dbInstance))
+	mux.HandleFunc("GET /bash/api-keys", 
+		restApi.GettingListApiKeysHandler(dbInstance))
+	mux.HandleFunc("DELETE /bash/api-keys/{id}", 
+		restApi.RevokeApiKeyHandler(dbInstance))
+	mux.HandleFunc("GET /bash/roles", 
+		restApi.GettingListRolePoliciesHandler(dbInstance))
+	mux.HandleFunc("PUT /bash/roles/{role}", 
+		restApi.UpdateRolePolicyHandler(dbInstance))
+	mux.HandleFunc("GET /bash/me/usage", 
+		restApi.GettingUsageHandler(dbInstance))
+	mux.HandleFunc("GET /bash/audit", 
+		restApi.GettingAuditLogHandler(dbInstance))
+	mux.HandleFunc("GET /bash/audit/verify", 
+		restApi.VerifyAuditLogHandler(dbInstance))
+	mux.HandleFunc("GET /bash/secrets", 
+		restApi.GettingListSecretsHandler(dbInstance))
+	mux.HandleFunc("PUT /bash/secrets/{name}", 
+		restApi.SaveSecretHandler(dbInstance))
+	mux.HandleFunc("DELETE /bash/secrets/{name}", 
+		restApi.DeleteSecretHandler(dbInstance))
+
+	// the limit of the addresses, the audit log around the authentication,
+	// then the rate limits of the keys and the quotas
+	authenticate := func(next http.Handler) http.Handler {
+		return auth.Middleware(dbInstance, verifier, mux, next)
+	}
+	handler := limits.IpMiddleware(ipLimiter,
+		audit.Middleware(dbInstance, mux, authenticate, limits.Middleware(limiter, quotas, mux)))
 
 	log.Printf("starting listen and serve Url = localhost:%v\n", PORT)
-	if err := http.ListenAndServe(":" + PORT, mux); err != nil {
+	if err := http.ListenAndServe(":" + PORT, handler); err != nil {
 		log.Fatalln(err)
 	}
 }
\ No newline at end of file
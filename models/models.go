@@ -1,14 +1,785 @@
 package models
 
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
 type Commands struct {
 	Id uint `json:"id"`
+	BatchId *uint `json:"batch_id"`
+	RerunOf *uint `json:"rerun_of"`
 	Command string `json:"command"`
 	IsError bool `json:"is_error"`
+	ExitCode int `json:"exit_code"`
+	DurationMs int64 `json:"duration_ms"`
+	// user and system CPU time of the command and the processes it waited for
+	CpuMs int64 `json:"cpu_ms"`
 	Log string `json:"log"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	AssertionsPassed *bool `json:"assertions_passed,omitempty"`
+	// the template the command was expanded from and its parameters
+	Template string `json:"template,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// number of attempts of a command with a retry policy and their outcome,
+	// the other fields hold the last attempt
+	Attempts int `json:"attempts,omitempty"`
+	RetrySummary string `json:"retry_summary,omitempty"`
+	// the command was interrupted by its timeout
+	TimedOut bool `json:"timed_out,omitempty"`
+	// the log is the head and the tail of the stream, OutputBytes is the
+	// size of the whole stream
+	Truncated bool `json:"truncated,omitempty"`
+	OutputBytes int64 `json:"output_bytes"`
+	// bytes of the stream of a truncated log stored in chunks, they are
+	// served by /bash/commands/{id}/output. 0 when only the log is stored.
+	StoredOutputBytes int64 `json:"stored_output_bytes,omitempty"`
+	// detected content type of the log, the log may be any bytes
+	ContentType string `json:"content_type,omitempty"`
+	// LOG_ENCODING_BASE64 when the log is the base64 of its bytes, the log
+	// is UTF-8 text otherwise
+	LogEncoding string `json:"log_encoding,omitempty"`
+	// the api key that submitted the command
+	ApiKeyId *uint `json:"api_key_id,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// JSON of the options the command was submitted with, they are replayed
+	// by its reruns. It holds the env of the command and isn't returned.
+	Options []byte `json:"-"`
 }
 
 type CommandsWithoutID struct {
+	BatchId uint `json:"batch_id,omitempty"`
+	RerunOf *uint `json:"rerun_of,omitempty"`
 	Command string `json:"command"`
 	IsError bool `json:"is_error"`
+	ExitCode int `json:"exit_code"`
+	DurationMs int64 `json:"duration_ms"`
+	CpuMs int64 `json:"cpu_ms"`
 	Log string `json:"log"`
-}
\ No newline at end of file
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	AssertionsPassed *bool `json:"assertions_passed,omitempty"`
+	Template string `json:"template,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Attempts int `json:"attempts,omitempty"`
+	RetrySummary string `json:"retry_summary,omitempty"`
+	AttemptHistory []CommandAttempts `json:"attempt_history,omitempty"`
+	// files collected from the working directory of the command
+	Artifacts []Artifacts `json:"artifacts,omitempty"`
+	TimedOut bool `json:"timed_out,omitempty"`
+	Truncated bool `json:"truncated,omitempty"`
+	OutputBytes int64 `json:"output_bytes"`
+	ContentType string `json:"content_type,omitempty"`
+	// both streams are kept for evaluating assertions, only Log is stored
+	Stdout string `json:"-"`
+	Stderr string `json:"-"`
+	// JSON of the options the command was submitted with
+	Options []byte `json:"-"`
+	// temporary file with the stream of a truncated log, it is stored in
+	// chunks and removed with the command
+	OutputFile string `json:"-"`
+}
+
+// WithId returns the stored command with the given id.
+func (command CommandsWithoutID) WithId(id uint) Commands {
+	var batchId *uint
+	if command.BatchId != 0 {
+		batchId = &command.BatchId
+	}
+	return Commands{
+		Id: id,
+		BatchId: batchId,
+		RerunOf: command.RerunOf,
+		Command: command.Command,
+		IsError: command.IsError,
+		ExitCode: command.ExitCode,
+		DurationMs: command.DurationMs,
+		CpuMs: command.CpuMs,
+		Log: command.Log,
+		Assertions: command.Assertions,
+		AssertionsPassed: command.AssertionsPassed,
+		Template: command.Template,
+		Parameters: command.Parameters,
+		Attempts: command.Attempts,
+		RetrySummary: command.RetrySummary,
+		TimedOut: command.TimedOut,
+		Truncated: command.Truncated,
+		OutputBytes: command.OutputBytes,
+		ContentType: command.ContentType,
+		Options: command.Options,
+	}
+}
+
+const (
+	// content type of output that is UTF-8 text without NUL bytes
+	TEXT_CONTENT_TYPE string = "text/plain; charset=utf-8"
+	// encodings of the log in the api
+	LOG_ENCODING_TEXT string = "text"
+	LOG_ENCODING_BASE64 string = "base64"
+)
+
+// DetectContentType returns the content type of the output of a command.
+func DetectContentType(output string) string {
+	if utf8.ValidString(output) && !strings.ContainsRune(output, 0) {
+		return TEXT_CONTENT_TYPE
+	}
+	return http.DetectContentType([]byte(output))
+}
+
+// EncodeLog encodes the log for the api. Text is UTF-8 with invalid bytes
+// replaced, base64 keeps the bytes as they are.
+func (command *Commands) EncodeLog(encoding string) {
+	if encoding == LOG_ENCODING_BASE64 {
+		command.Log, command.LogEncoding = base64.StdEncoding.EncodeToString([]byte(command.Log)), LOG_ENCODING_BASE64
+		return
+	}
+	command.Log = strings.ToValidUTF8(command.Log, "\uFFFD")
+}
+
+// DecodeLog returns the bytes of a log encoded by EncodeLog, a text log is
+// taken as it is.
+func (command *Commands) DecodeLog() error {
+	if command.LogEncoding != LOG_ENCODING_BASE64 {
+		return nil
+	}
+	log, err := base64.StdEncoding.DecodeString(command.Log)
+	if err != nil {
+		return fmt.Errorf("command %v: invalid base64 log: %w", command.Id, err)
+	}
+	command.Log, command.LogEncoding = string(log), ""
+	return nil
+}
+
+// CommandFilter selects the commands of the list and of the export, the zero
+// value selects all of them.
+type CommandFilter struct {
+	// commands of the key only, see auth.CommandsOwner
+	ApiKeyId *uint
+	Since *time.Time
+	Until *time.Time
+	BatchId *uint
+	IsError *bool
+}
+
+// ExportedCommands is a command of an export with the batch it belongs to.
+type ExportedCommands struct {
+	Commands
+	Batch *ExportedBatch `json:"batch,omitempty"`
+}
+
+// ExportedBatch is the part of a batch an import needs to recreate it.
+type ExportedBatch struct {
+	Id uint `json:"id"`
+	RerunOf *uint `json:"rerun_of,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Verdict string `json:"verdict,omitempty"`
+}
+
+// ImportSummary counts what an import stored.
+type ImportSummary struct {
+	Batches int `json:"batches"`
+	Commands int `json:"commands"`
+}
+
+// Batches groups the commands submitted by one request.
+type Batches struct {
+	Id uint `json:"id"`
+	RerunOf *uint `json:"rerun_of"`
+	ScheduleId *uint `json:"schedule_id,omitempty"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	// the script version the batch ran
+	ScriptId *uint `json:"script_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Verdict is passed or failed when some command of the batch has
+	// assertions, empty otherwise
+	Verdict string `json:"verdict,omitempty"`
+	// why a scheduled run started no commands, such as an exceeded quota
+	Skipped string `json:"skipped,omitempty"`
+	Commands []Commands `json:"commands"`
+}
+
+// NewBatch describes where a new batch of commands comes from.
+type NewBatch struct {
+	// the batch being rerun
+	RerunOf *uint
+	// the schedule that started the batch and the time it was due
+	ScheduleId *uint
+	ScheduledFor *time.Time
+	// the script version that was run
+	ScriptId *uint
+	// the api key that submitted the commands
+	ApiKeyId *uint
+	// why the batch has no commands
+	Skipped string
+}
+
+const (
+	VERDICT_PASSED string = "passed"
+	VERDICT_FAILED string = "failed"
+)
+
+// Assertions are checks of a finished command, every set field is checked.
+type Assertions struct {
+	ExitCode *int `json:"exit_code,omitempty"`
+	StdoutRegex string `json:"stdout_regex,omitempty"`
+	StdoutContains string `json:"stdout_contains,omitempty"`
+	StderrEmpty bool `json:"stderr_empty,omitempty"`
+	MaxDurationMs int64 `json:"max_duration_ms,omitempty"`
+	JsonPath []JsonPathAssertion `json:"json_path,omitempty"`
+}
+
+// JsonPathAssertion checks a value of the stdout parsed as JSON, for example
+// $.items[0].status. Without Equals the value only has to exist.
+type JsonPathAssertion struct {
+	Path string `json:"path"`
+	Equals any `json:"equals,omitempty"`
+}
+
+type AssertionResult struct {
+	Name string `json:"name"`
+	Passed bool `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// AssertionsPassed reports whether every assertion passed.
+func AssertionsPassed(results []AssertionResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// BatchVerdict returns the verdict of a batch with the given commands.
+func BatchVerdict(commands []CommandsWithoutID) string {
+	verdict := ""
+	for _, command := range commands {
+		if command.AssertionsPassed == nil {
+			continue
+		}
+		if !*command.AssertionsPassed {
+			return VERDICT_FAILED
+		}
+		verdict = VERDICT_PASSED
+	}
+	return verdict
+}
+
+// IsFailed reports whether the command didn't succeed.
+func (command Commands) IsFailed() bool {
+	return command.IsError || command.ExitCode != 0
+}
+
+const (
+	BACKOFF_FIXED string = "fixed"
+	BACKOFF_EXPONENTIAL string = "exponential"
+)
+
+// RetryPolicy reruns a failed command. Without OnExitCodes and OnStderrRegex
+// every failure is retried, otherwise a failure is retried when its exit
+// code is listed or its stderr matches.
+type RetryPolicy struct {
+	// attempts including the first one
+	MaxAttempts int `json:"max_attempts"`
+	// fixed (default) or exponential
+	Backoff string `json:"backoff,omitempty"`
+	// delay before the second attempt, exponential backoff doubles it for
+	// each next one up to MaxDelayMs
+	DelayMs int64 `json:"delay_ms,omitempty"`
+	MaxDelayMs int64 `json:"max_delay_ms,omitempty"`
+	// randomizes every delay between half and all of it
+	Jitter bool `json:"jitter,omitempty"`
+	OnExitCodes []int `json:"on_exit_codes,omitempty"`
+	OnStderrRegex string `json:"on_stderr_regex,omitempty"`
+}
+
+// CommandAttempts is a single attempt of a command with a retry policy.
+type CommandAttempts struct {
+	Id uint `json:"id"`
+	CommandId uint `json:"command_id"`
+	Attempt int `json:"attempt"`
+	IsError bool `json:"is_error"`
+	ExitCode int `json:"exit_code"`
+	DurationMs int64 `json:"duration_ms"`
+	// how long the attempt waited after the previous one
+	DelayMs int64 `json:"delay_ms"`
+	Log string `json:"log"`
+}
+
+// Artifacts is a file collected from the working directory of a command. It
+// is stored by its checksum, a skipped file isn't stored.
+type Artifacts struct {
+	Id uint `json:"id"`
+	CommandId uint `json:"command_id"`
+	// path relative to the working directory, with slashes
+	Path string `json:"path"`
+	SizeBytes int64 `json:"size_bytes"`
+	Sha256 string `json:"sha256,omitempty"`
+	// why the file isn't stored, for example a size limit
+	Skipped string `json:"skipped,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Workspaces is a directory of the server that commands run in by its name,
+// the files are kept between the commands.
+type Workspaces struct {
+	Name string `json:"name"`
+	SizeBytes int64 `json:"size_bytes"`
+	Files int `json:"files"`
+	ModifiedAt time.Time `json:"modified_at"`
+	// the key that created the workspace
+	ApiKeyId *uint `json:"api_key_id,omitempty"`
+}
+
+// WorkspaceFiles is a file of a workspace.
+type WorkspaceFiles struct {
+	// path relative to the workspace, with slashes
+	Path string `json:"path"`
+	SizeBytes int64 `json:"size_bytes"`
+	ModifiedAt time.Time `json:"modified_at"`
+	// the target of a symbolic link
+	LinkTarget string `json:"link_target,omitempty"`
+}
+
+// CommandsGroup is the commands with the same value of a template parameter.
+type CommandsGroup struct {
+	Parameter string `json:"parameter"`
+	Value string `json:"value"`
+	FailedCount int `json:"failed_count"`
+	Commands []Commands `json:"commands"`
+}
+
+// GroupByParameter groups the commands by the value of a template parameter
+// in the order the values first appear, commands without the parameter are
+// left out.
+func GroupByParameter(commands []Commands, parameter string) []CommandsGroup {
+	groups := []CommandsGroup{}
+	indexes := map[string]int{}
+	for _, command := range commands {
+		value, ok := command.Parameters[parameter]
+		if !ok {
+			continue
+		}
+		i, ok := indexes[value]
+		if !ok {
+			i = len(groups)
+			indexes[value] = i
+			groups = append(groups, CommandsGroup{Parameter: parameter, Value: value, Commands: []Commands{}})
+		}
+		if command.IsFailed() {
+			groups[i].FailedCount++
+		}
+		groups[i].Commands = append(groups[i].Commands, command)
+	}
+	return groups
+}
+
+// CommandsDiff compares two executions of a command.
+type CommandsDiff struct {
+	Id uint `json:"id"`
+	OtherId uint `json:"other_id"`
+	Command string `json:"command"`
+	OtherCommand string `json:"other_command"`
+	// which stream the stored log holds, stdout or stderr
+	Stream string `json:"stream"`
+	OtherStream string `json:"other_stream"`
+	LogDiff string `json:"log_diff"`
+	IsLogEqual bool `json:"is_log_equal"`
+	ExitCode int `json:"exit_code"`
+	OtherExitCode int `json:"other_exit_code"`
+	ExitCodeDelta int `json:"exit_code_delta"`
+	DurationMs int64 `json:"duration_ms"`
+	OtherDurationMs int64 `json:"other_duration_ms"`
+	DurationDeltaMs int64 `json:"duration_delta_ms"`
+}
+
+// Stream returns the name of the stream stored in the log.
+func (command Commands) Stream() string {
+	if command.IsError {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+const (
+	// missed runs are skipped, only a run that is due now is started
+	MISFIRE_SKIP string = "skip"
+	// all missed runs are replaced by a single run
+	MISFIRE_RUN_ONCE string = "run_once"
+	// every missed run is started
+	MISFIRE_CATCH_UP string = "catch_up"
+)
+
+// Schedules runs its commands whenever the cron expression fires.
+type Schedules struct {
+	Id uint `json:"id"`
+	Name string `json:"name"`
+	CronExpression string `json:"cron_expression"`
+	Timezone string `json:"timezone"`
+	// Commands is the body of a create-command request
+	Commands json.RawMessage `json:"commands" swaggertype:"object"`
+	MisfirePolicy string `json:"misfire_policy"`
+	Paused bool `json:"paused"`
+	NextRunAt time.Time `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time `json:"created_at"`
+	// the api key that created the schedule, its runs are recorded with it
+	ApiKeyId *uint `json:"api_key_id,omitempty"`
+}
+
+const (
+	STATUS_PENDING string = "pending"
+	STATUS_RUNNING string = "running"
+	STATUS_SUCCEEDED string = "succeeded"
+	STATUS_FAILED string = "failed"
+	// the condition of the step was false
+	STATUS_SKIPPED string = "skipped"
+)
+
+// Workflows is a run of a workflow definition.
+type Workflows struct {
+	Id uint `json:"id"`
+	Name string `json:"name"`
+	Status string `json:"status"`
+	Definition json.RawMessage `json:"definition" swaggertype:"object"`
+	CreatedAt time.Time `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	// the api key that submitted the workflow, its steps are recorded with it
+	ApiKeyId *uint `json:"api_key_id,omitempty"`
+	Steps []WorkflowSteps `json:"steps,omitempty"`
+}
+
+type WorkflowSteps struct {
+	Id uint `json:"id"`
+	WorkflowId uint `json:"workflow_id"`
+	Name string `json:"name"`
+	Needs []string `json:"needs"`
+	Status string `json:"status"`
+	// the stored command of the step, nil until it has run
+	CommandId *uint `json:"command_id"`
+	Outputs map[string]string `json:"outputs,omitempty"`
+	StartedAt *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	// why a failed step didn't run its command
+	Error string `json:"error,omitempty"`
+}
+
+// Scripts is a version of a saved script, a new version is stored on every
+// update of the script.
+type Scripts struct {
+	Id uint `json:"id"`
+	Name string `json:"name"`
+	Version int `json:"version"`
+	Description string `json:"description"`
+	// bash string with {{name}} placeholders of the parameters
+	Body string `json:"body"`
+	Parameters []ScriptParameter `json:"parameters"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScriptParameter struct {
+	Name string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// used when the run doesn't set the parameter
+	Default *string `json:"default,omitempty"`
+	Required bool `json:"required,omitempty"`
+}
+
+const (
+	EVENT_COMMAND_SUCCEEDED string = "command.succeeded"
+	EVENT_COMMAND_FAILED string = "command.failed"
+	EVENT_COMMAND_TIMED_OUT string = "command.timed_out"
+	EVENT_BATCH_SUCCEEDED string = "batch.succeeded"
+	EVENT_BATCH_FAILED string = "batch.failed"
+	// subscribes a webhook to every event
+	EVENT_ALL string = "*"
+)
+
+// Webhooks receives the events it is subscribed to as signed JSON callbacks.
+type Webhooks struct {
+	Id uint `json:"id"`
+	Url string `json:"url"`
+	// key of the HMAC-SHA256 signature, only returned when the webhook is
+	// created
+	Secret string `json:"secret,omitempty"`
+	Events []string `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	DELIVERY_PENDING string = "pending"
+	DELIVERY_DELIVERED string = "delivered"
+	// the delivery gave up after the last attempt
+	DELIVERY_FAILED string = "failed"
+)
+
+// WebhookDeliveries is an event waiting in the outbox or sent to a webhook.
+type WebhookDeliveries struct {
+	Id uint `json:"id"`
+	WebhookId uint `json:"webhook_id"`
+	Event string `json:"event"`
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	Status string `json:"status"`
+	Attempts int `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastStatusCode *int `json:"last_status_code"`
+	LastError string `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	// url and secret of the webhook, set when the delivery is claimed
+	Url string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookEvent is the body of a webhook callback.
+type WebhookEvent struct {
+	Event string `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Command *Commands `json:"command,omitempty"`
+	Batch *Batches `json:"batch,omitempty"`
+}
+
+// CommandEvent returns the event of a finished command.
+func CommandEvent(command Commands) string {
+	switch {
+	case command.TimedOut:
+		return EVENT_COMMAND_TIMED_OUT
+	// an empty stdout isn't a failure here, only the exit code and the
+	// assertions are
+	case command.ExitCode != 0 || (command.AssertionsPassed != nil && !*command.AssertionsPassed):
+		return EVENT_COMMAND_FAILED
+	default:
+		return EVENT_COMMAND_SUCCEEDED
+	}
+}
+
+// BatchEvent returns the event of a finished batch.
+func BatchEvent(batch Batches) string {
+	if batch.Skipped != "" {
+		return EVENT_BATCH_FAILED
+	}
+	for _, command := range batch.Commands {
+		if CommandEvent(command) != EVENT_COMMAND_SUCCEEDED {
+			return EVENT_BATCH_FAILED
+		}
+	}
+	return EVENT_BATCH_SUCCEEDED
+}
+
+const (
+	SCOPE_COMMANDS_READ string = "commands:read"
+	SCOPE_COMMANDS_EXECUTE string = "commands:execute"
+	// admin grants every other scope and the management of api keys and
+	// webhooks
+	SCOPE_ADMIN string = "admin"
+)
+
+// ApiKeys are the keys clients authenticate with. Only the hash of a key is
+// stored, Key is set once when the key is created.
+type ApiKeys struct {
+	Id uint `json:"id"`
+	Name string `json:"name"`
+	// the role whose command policy applies to the key
+	Role string `json:"role"`
+	// the first characters of the key, to tell keys apart
+	Prefix string `json:"prefix"`
+	// the subject of the identity provider for users signing in with tokens,
+	// such entries have no key
+	Subject *string `json:"subject,omitempty"`
+	Scopes []string `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Key string `json:"key,omitempty"`
+}
+
+const (
+	ROLE_VIEWER string = "viewer"
+	ROLE_OPERATOR string = "operator"
+	ROLE_ADMIN string = "admin"
+)
+
+// RoleScopes are the scopes each role may have, a key gets all of them
+// unless it is created with fewer.
+var RoleScopes = map[string][]string{
+	ROLE_VIEWER: {SCOPE_COMMANDS_READ},
+	ROLE_OPERATOR: {SCOPE_COMMANDS_READ, SCOPE_COMMANDS_EXECUTE},
+	ROLE_ADMIN: {SCOPE_ADMIN},
+}
+
+// RolePolicies limit what the keys of a role may run and see.
+type RolePolicies struct {
+	Role string `json:"role"`
+	// programs the role may run, any program when empty
+	AllowedBinaries []string `json:"allowed_binaries"`
+	// the keys of the role see only the commands they submitted
+	OwnCommandsOnly bool `json:"own_commands_only"`
+	// quotas of every key of the role, 0 is no limit; days are UTC days
+	CommandsPerDay int `json:"commands_per_day"`
+	CpuSecondsPerDay int `json:"cpu_seconds_per_day"`
+	MaxConcurrentCommands int `json:"max_concurrent_commands"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HasScope reports whether the key grants the scope.
+func (key ApiKeys) HasScope(scope string) bool {
+	for _, granted := range key.Scopes {
+		if granted == scope || granted == SCOPE_ADMIN {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage is what a key used of the quotas of its role on the current UTC day.
+type Usage struct {
+	ApiKeyId uint `json:"api_key_id"`
+	Role string `json:"role"`
+	// the start of the UTC day
+	Day time.Time `json:"day"`
+	Commands int `json:"commands"`
+	CommandsPerDay int `json:"commands_per_day"`
+	CpuMs int64 `json:"cpu_ms"`
+	CpuSecondsPerDay int `json:"cpu_seconds_per_day"`
+	// commands of the key that are running now
+	RunningCommands int `json:"running_commands"`
+	MaxConcurrentCommands int `json:"max_concurrent_commands"`
+}
+
+// AuditEntries is an entry of the audit log. Every entry holds the hash of the
+// previous one, so changing or removing an entry breaks the chain after it.
+type AuditEntries struct {
+	Id uint `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// the api key or token user of the request and its name
+	ApiKeyId *uint `json:"api_key_id"`
+	Actor string `json:"actor"`
+	SourceIp string `json:"source_ip"`
+	// the route of the request, like POST /bash/create-command
+	Action string `json:"action"`
+	// the path of the request
+	Target string `json:"target"`
+	Status int `json:"status"`
+	// sha256 of the method, the url and the body of the request
+	RequestHash string `json:"request_hash"`
+	PrevHash string `json:"prev_hash"`
+	Hash string `json:"hash"`
+}
+
+// ChainHash returns the hash of the entry linked to the previous hash, every
+// field but the id and the hash is covered.
+func (entry AuditEntries) ChainHash() string {
+	var apiKeyId string
+	if entry.ApiKeyId != nil {
+		apiKeyId = strconv.FormatUint(uint64(*entry.ApiKeyId), 10)
+	}
+	fields := []string{
+		entry.PrevHash,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		apiKeyId,
+		entry.Actor,
+		entry.SourceIp,
+		entry.Action,
+		entry.Target,
+		strconv.Itoa(entry.Status),
+		entry.RequestHash,
+	}
+	// the fields are quoted, so they can't run into each other
+	hash := sha256.New()
+	for _, field := range fields {
+		hash.Write([]byte(strconv.Quote(field)))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// AuditFilter selects the entries of the audit log, the zero values select
+// all of them.
+type AuditFilter struct {
+	ApiKeyId *uint
+	Action string
+	Since *time.Time
+	Until *time.Time
+	// entries with smaller ids, for paging back from the newest
+	BeforeId *uint
+	Limit int
+}
+
+// AuditVerification is the result of checking the hash chain of the audit
+// log.
+type AuditVerification struct {
+	Entries int `json:"entries"`
+	Valid bool `json:"valid"`
+	// the first entry that doesn't match its hash or the previous one
+	FirstInvalidId *uint `json:"first_invalid_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// the hash of the newest entry, removing the newest entries is only
+	// noticed by comparing it with a copy kept elsewhere
+	LastHash string `json:"last_hash"`
+}
+
+// Secrets is a stored secret, its value is never returned.
+type Secrets struct {
+	Name string `json:"name"`
+	// the api key that last set the value
+	ApiKeyId *uint `json:"api_key_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Retention selects the commands to prune, the zero values keep everything.
+// The commands kept by the count and output limits are the newest ones,
+// failed commands before succeeded ones.
+type Retention struct {
+	// succeeded and failed commands created before these times are pruned
+	SucceededBefore *time.Time
+	FailedBefore *time.Time
+	MaxCommands int
+	// bytes of the stored logs of the kept commands
+	MaxOutputBytes int64
+}
+
+const (
+	// the first event of a run, it has the id to cancel the run with
+	RUN_EVENT_STARTED string = "started"
+	// output a command of the run wrote to one of its streams
+	RUN_EVENT_OUTPUT string = "output"
+	// the last event of the stream of a create-command request, it has the
+	// stored commands
+	RUN_EVENT_RESULT string = "result"
+	// the last event of a stream that is attached to a running run
+	RUN_EVENT_FINISHED string = "finished"
+)
+
+// Runs is a request whose commands are running.
+type Runs struct {
+	Id uint `json:"id"`
+	Commands []string `json:"commands"`
+	// the api key that submitted the commands
+	ApiKeyId *uint `json:"api_key_id,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	// the output of commands with secrets isn't streamed, it is only masked
+	// once they exit
+	Streamed bool `json:"streamed"`
+}
+
+// RunEvents are the NDJSON lines of the stream of a run.
+type RunEvents struct {
+	Event string `json:"event"`
+	RunId uint `json:"run_id,omitempty"`
+	// the index of the command in the request and its stream, stdout or
+	// stderr, with the bytes it wrote; the index is always sent, the first
+	// command has 0
+	Index int `json:"index"`
+	Stream string `json:"stream,omitempty"`
+	Data []byte `json:"data,omitempty"`
+	Commands []CommandsWithoutID `json:"commands,omitempty"`
+	Error string `json:"error,omitempty"`
+}
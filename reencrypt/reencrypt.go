@@ -0,0 +1,89 @@
+package reencrypt
+
+import (
+	// std
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+)
+
+const (
+	REENCRYPT_INTERVAL time.Duration = time.Minute
+	// logs sealed again in one transaction
+	REENCRYPT_BATCH_SIZE int = 100
+	// environment variable with the age after which a new data key is created
+	OUTPUT_KEY_MAX_AGE_ENV     string        = "OUTPUT_KEY_MAX_AGE"
+	DEFAULT_OUTPUT_KEY_MAX_AGE time.Duration = 30 * 24 * time.Hour
+)
+
+// Job rotates the data key of the output of commands and seals the logs that
+// are in clear or sealed with an older data key with the current one.
+type Job struct {
+	db     database.DBWorker
+	maxAge time.Duration
+}
+
+func NewJob(db database.DBWorker, maxAge time.Duration) *Job {
+	return &Job{db: db, maxAge: maxAge}
+}
+
+// MaxAgeFromEnv returns the duration of OUTPUT_KEY_MAX_AGE, 30 days if it
+// isn't set.
+func MaxAgeFromEnv() (time.Duration, error) {
+	value := os.Getenv(OUTPUT_KEY_MAX_AGE_ENV)
+	if value == "" {
+		return DEFAULT_OUTPUT_KEY_MAX_AGE, nil
+	}
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge <= 0 {
+		return 0, fmt.Errorf("invalid %v %q", OUTPUT_KEY_MAX_AGE_ENV, value)
+	}
+	return maxAge, nil
+}
+
+// Run rotates and re-encrypts until ctx is cancelled.
+func (job *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(REENCRYPT_INTERVAL)
+	defer ticker.Stop()
+	for {
+		job.reencrypt(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reencrypt creates a new data key when the current one is too old and seals
+// the logs again batch by batch until none is left.
+func (job *Job) reencrypt(ctx context.Context) {
+	rotated, err := job.db.RotateOutputKeyQuery(job.maxAge, ctx)
+	if err != nil {
+		log.Printf("reencrypt: database query error: %v\n", err)
+		return
+	}
+	if rotated {
+		log.Println("reencrypt: new data key is created")
+	}
+
+	total := 0
+	for ctx.Err() == nil {
+		n, err := job.db.ReencryptOutputQuery(REENCRYPT_BATCH_SIZE, ctx)
+		total += n
+		if err != nil {
+			log.Printf("reencrypt: database query error: %v\n", err)
+			break
+		}
+		if n < REENCRYPT_BATCH_SIZE {
+			break
+		}
+	}
+	if total != 0 {
+		log.Printf("reencrypt: %v logs are sealed with the current data key\n", total)
+	}
+}
@@ -0,0 +1,67 @@
+package reencrypt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/golang/mock/gomock"
+)
+
+func TestJob_reencrypt(t *testing.T) {
+	var tests = []struct {
+		testName string
+		behavior func(*mock_database.MockDBWorker)
+	}{
+		{"batches until the last one isn't full", func(m *mock_database.MockDBWorker) {
+			gomock.InOrder(
+				m.EXPECT().RotateOutputKeyQuery(time.Hour, gomock.Any()).Return(true, nil),
+				m.EXPECT().ReencryptOutputQuery(REENCRYPT_BATCH_SIZE, gomock.Any()).Return(REENCRYPT_BATCH_SIZE, nil),
+				m.EXPECT().ReencryptOutputQuery(REENCRYPT_BATCH_SIZE, gomock.Any()).Return(3, nil),
+			)
+		}},
+		{"reencrypt error", func(m *mock_database.MockDBWorker) {
+			m.EXPECT().RotateOutputKeyQuery(time.Hour, gomock.Any()).Return(false, nil)
+			m.EXPECT().ReencryptOutputQuery(REENCRYPT_BATCH_SIZE, gomock.Any()).Return(0, fmt.Errorf("some db error"))
+		}},
+		{"rotate error", func(m *mock_database.MockDBWorker) {
+			m.EXPECT().RotateOutputKeyQuery(time.Hour, gomock.Any()).Return(false, fmt.Errorf("some db error"))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			tt.behavior(db)
+
+			NewJob(db, time.Hour).reencrypt(context.Background())
+		})
+	}
+}
+
+func TestMaxAgeFromEnv(t *testing.T) {
+	var tests = []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", DEFAULT_OUTPUT_KEY_MAX_AGE, false},
+		{"240h", 240 * time.Hour, false},
+		{"-1h", 0, true},
+		{"month", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(OUTPUT_KEY_MAX_AGE_ENV, tt.value)
+			got, err := MaxAgeFromEnv()
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("MaxAgeFromEnv() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
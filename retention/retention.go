@@ -0,0 +1,328 @@
+package retention
+
+import (
+	// std
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
+)
+
+const (
+	JANITOR_INTERVAL time.Duration = time.Minute
+	// commands deleted in one transaction
+	JANITOR_BATCH_SIZE int = 100
+)
+
+const (
+	// environment variables of the policy, none of them set keeps everything
+	MAX_AGE_ENV          string = "RETENTION_MAX_AGE"
+	FAILED_MAX_AGE_ENV   string = "RETENTION_FAILED_MAX_AGE"
+	MAX_COMMANDS_ENV     string = "RETENTION_MAX_COMMANDS"
+	MAX_OUTPUT_BYTES_ENV string = "RETENTION_MAX_OUTPUT_BYTES"
+	// directory the pruned commands are archived to before they are deleted
+	ARCHIVE_DIR_ENV string = "RETENTION_ARCHIVE_DIR"
+)
+
+// SEALED_ARCHIVE_SUFFIX is appended to the names of the archives sealed with
+// the master key of the output.
+const SEALED_ARCHIVE_SUFFIX string = ".sealed"
+
+// the archives are sealed with this additional data, a sealed log of the
+// database can't be passed off as an archive
+var archiveAdditionalData = []byte("retention archive")
+
+// archiveHeader is the first line of a sealed archive, the rest of the file
+// is the gzipped NDJSON sealed with the key it wraps.
+type archiveHeader struct {
+	MasterKeyId string `json:"master_key_id"`
+	WrappedKey  []byte `json:"wrapped_key"`
+}
+
+// Policy is the retention of the history of commands, the zero values keep
+// everything.
+type Policy struct {
+	MaxAge time.Duration
+	// age of failed commands, they are usually kept longer, MaxAge if 0
+	FailedMaxAge   time.Duration
+	MaxCommands    int
+	MaxOutputBytes int64
+	// no archive if empty. The archive keeps the commands as they are listed,
+	// with the head and the tail of a truncated log, the whole streams stored
+	// in chunks are deleted with the commands
+	ArchiveDir string
+	// the master key of the output, the archives are sealed with it when it
+	// is set, like the logs in the database
+	ArchiveKey *secrets.MasterKey
+}
+
+// PolicyFromEnv reads the policy of the RETENTION_ variables, nil if none of
+// them is set.
+func PolicyFromEnv() (*Policy, error) {
+	policy := Policy{ArchiveDir: os.Getenv(ARCHIVE_DIR_ENV)}
+	durations := []struct {
+		env   string
+		value *time.Duration
+	}{
+		{MAX_AGE_ENV, &policy.MaxAge},
+		{FAILED_MAX_AGE_ENV, &policy.FailedMaxAge},
+	}
+	for _, duration := range durations {
+		if value := os.Getenv(duration.env); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid %v %q", duration.env, value)
+			}
+			*duration.value = parsed
+		}
+	}
+	if value := os.Getenv(MAX_COMMANDS_ENV); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %v %q", MAX_COMMANDS_ENV, value)
+		}
+		policy.MaxCommands = parsed
+	}
+	if value := os.Getenv(MAX_OUTPUT_BYTES_ENV); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %v %q", MAX_OUTPUT_BYTES_ENV, value)
+		}
+		policy.MaxOutputBytes = parsed
+	}
+
+	if policy.MaxAge == 0 && policy.FailedMaxAge == 0 && policy.MaxCommands == 0 && policy.MaxOutputBytes == 0 {
+		return nil, nil
+	}
+	return &policy, nil
+}
+
+// Retention returns the commands the policy prunes at the given time.
+func (policy Policy) Retention(now time.Time) models.Retention {
+	retention := models.Retention{MaxCommands: policy.MaxCommands, MaxOutputBytes: policy.MaxOutputBytes}
+	failedMaxAge := policy.FailedMaxAge
+	if failedMaxAge == 0 {
+		failedMaxAge = policy.MaxAge
+	}
+	if policy.MaxAge != 0 {
+		before := now.Add(-policy.MaxAge)
+		retention.SucceededBefore = &before
+	}
+	if failedMaxAge != 0 {
+		before := now.Add(-failedMaxAge)
+		retention.FailedBefore = &before
+	}
+	return retention
+}
+
+// Janitor prunes the history of commands by the policy in small batches.
+type Janitor struct {
+	db     database.DBWorker
+	policy Policy
+}
+
+func NewJanitor(db database.DBWorker, policy Policy) *Janitor {
+	return &Janitor{db: db, policy: policy}
+}
+
+// Run prunes until ctx is cancelled.
+func (janitor *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(JANITOR_INTERVAL)
+	defer ticker.Stop()
+	for {
+		janitor.prune(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune deletes the expired commands batch by batch until none is left. A
+// batch that can't be archived isn't deleted.
+func (janitor *Janitor) prune(ctx context.Context, now time.Time) {
+	retention := janitor.policy.Retention(now)
+	total := 0
+	for ctx.Err() == nil {
+		commands, err := janitor.db.GettingExpiredCommandsQuery(retention, JANITOR_BATCH_SIZE, ctx)
+		if err != nil {
+			log.Printf("retention: database query error: %v\n", err)
+			break
+		}
+		if len(*commands) == 0 {
+			break
+		}
+		if janitor.policy.ArchiveDir != "" {
+			if err := Archive(janitor.policy.ArchiveDir, *commands, now, janitor.policy.ArchiveKey); err != nil {
+				log.Printf("retention: archive error: %v\n", err)
+				break
+			}
+			if truncated := countTruncated(*commands); truncated != 0 {
+				log.Printf("retention: %v commands are archived with truncated logs, their whole output isn't archived\n", truncated)
+			}
+		}
+
+		ids := make([]uint, len(*commands))
+		for i, command := range *commands {
+			ids[i] = command.Id
+		}
+		deleted, err := janitor.db.DeleteCommandsQuery(ids, ctx)
+		total += deleted
+		if err != nil {
+			log.Printf("retention: database query error: %v\n", err)
+			break
+		}
+		if len(*commands) < JANITOR_BATCH_SIZE {
+			break
+		}
+	}
+	if total != 0 {
+		log.Printf("retention: %v commands are pruned\n", total)
+	}
+}
+
+// countTruncated returns how many of the commands have their whole output
+// stored in chunks.
+func countTruncated(commands []models.Commands) int {
+	truncated := 0
+	for _, command := range commands {
+		if command.StoredOutputBytes != 0 {
+			truncated++
+		}
+	}
+	return truncated
+}
+
+// Archive writes the commands as gzipped NDJSON to a new file of the
+// directory, named by the time and the ids of the commands. Only the log of a
+// command is archived: a truncated command keeps the head and the tail of its
+// output and stored_output_bytes says how much of it there was. With a master key
+// the file is sealed with a new key wrapped by it and gets the .sealed
+// suffix, OpenArchive reads it back. The file is complete once it has its
+// name.
+func Archive(dir string, commands []models.Commands, now time.Time, master *secrets.MasterKey) error {
+	if len(commands) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("commands-%v-%v-%v.ndjson.gz", now.UTC().Format("20060102T150405Z"),
+		commands[0].Id, commands[len(commands)-1].Id)
+	if master != nil {
+		name += SEALED_ARCHIVE_SUFFIX
+	}
+	file, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// a sealed archive is sealed as a whole, a batch of commands fits in memory
+	var buf bytes.Buffer
+	var out io.Writer = file
+	if master != nil {
+		out = &buf
+	}
+	compressed := gzip.NewWriter(out)
+	encoder := json.NewEncoder(compressed)
+	for _, command := range commands {
+		if err := encoder.Encode(command); err != nil {
+			return err
+		}
+	}
+	if err := compressed.Close(); err != nil {
+		return err
+	}
+	if master != nil {
+		if err := sealArchive(file, buf.Bytes(), master); err != nil {
+			return err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filepath.Join(dir, name))
+}
+
+// sealArchive writes the header and the sealed archive.
+func sealArchive(w io.Writer, archive []byte, master *secrets.MasterKey) error {
+	key, err := secrets.NewDataKey()
+	if err != nil {
+		return err
+	}
+	box, err := secrets.NewBox(key)
+	if err != nil {
+		return err
+	}
+	header := archiveHeader{MasterKeyId: master.Id}
+	if header.WrappedKey, err = master.Wrap(key); err != nil {
+		return err
+	}
+	sealed, err := box.Seal(archive, archiveAdditionalData)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(w).Encode(header); err != nil {
+		return err
+	}
+	_, err = w.Write(sealed)
+	return err
+}
+
+// OpenArchive returns the NDJSON of a sealed archive. The archive is opened
+// with the current or a previous master key, whichever wrapped its key.
+func OpenArchive(r io.Reader, masters ...*secrets.MasterKey) (io.Reader, error) {
+	reader := bufio.NewReader(r)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive header: %w", err)
+	}
+	var header archiveHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, fmt.Errorf("invalid archive header: %w", err)
+	}
+	var master *secrets.MasterKey
+	for _, candidate := range masters {
+		if candidate != nil && candidate.Id == header.MasterKeyId {
+			master = candidate
+		}
+	}
+	if master == nil {
+		return nil, fmt.Errorf("master key %v isn't configured", header.MasterKeyId)
+	}
+	key, err := master.Unwrap(header.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	box, err := secrets.NewBox(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := box.Open(sealed, archiveAdditionalData)
+	if err != nil {
+		return nil, err
+	}
+	return gzip.NewReader(bytes.NewReader(archive))
+}
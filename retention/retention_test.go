@@ -0,0 +1,208 @@
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/Vy4cheSlave/test-task-postgres/secrets"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPolicyFromEnv(t *testing.T) {
+	var tests = []struct {
+		testName string
+		env      map[string]string
+		want     *Policy
+		wantErr  bool
+	}{
+		{"nothing set", map[string]string{}, nil, false},
+		{"archive only", map[string]string{ARCHIVE_DIR_ENV: "/archive"}, nil, false},
+		{"all set", map[string]string{MAX_AGE_ENV: "720h", FAILED_MAX_AGE_ENV: "2160h", MAX_COMMANDS_ENV: "1000",
+			MAX_OUTPUT_BYTES_ENV: "1048576", ARCHIVE_DIR_ENV: "/archive"},
+			&Policy{MaxAge: 720 * time.Hour, FailedMaxAge: 2160 * time.Hour, MaxCommands: 1000, MaxOutputBytes: 1 << 20,
+				ArchiveDir: "/archive"}, false},
+		{"invalid age", map[string]string{MAX_AGE_ENV: "month"}, nil, true},
+		{"invalid count", map[string]string{MAX_COMMANDS_ENV: "-1"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			for _, env := range []string{MAX_AGE_ENV, FAILED_MAX_AGE_ENV, MAX_COMMANDS_ENV, MAX_OUTPUT_BYTES_ENV, ARCHIVE_DIR_ENV} {
+				t.Setenv(env, tt.env[env])
+			}
+			got, err := PolicyFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PolicyFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("PolicyFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Retention(t *testing.T) {
+	now := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	retention := Policy{MaxAge: time.Hour, FailedMaxAge: 3 * time.Hour, MaxCommands: 5}.Retention(now)
+	if !retention.SucceededBefore.Equal(now.Add(-time.Hour)) || !retention.FailedBefore.Equal(now.Add(-3*time.Hour)) ||
+		retention.MaxCommands != 5 {
+		t.Errorf("unexpected retention %+v", retention)
+	}
+	// failed commands are kept as long as the others by default
+	retention = Policy{MaxAge: time.Hour}.Retention(now)
+	if !retention.FailedBefore.Equal(now.Add(-time.Hour)) {
+		t.Errorf("unexpected retention of failed commands %v", retention.FailedBefore)
+	}
+	if retention = (Policy{MaxCommands: 5}).Retention(now); retention.SucceededBefore != nil || retention.FailedBefore != nil {
+		t.Errorf("commands are pruned by age without an age %+v", retention)
+	}
+}
+
+func commandsWithIds(from, to uint) *[]models.Commands {
+	commands := []models.Commands{}
+	for id := from; id <= to; id++ {
+		commands = append(commands, models.Commands{Id: id, Command: "echo", Log: fmt.Sprint(id)})
+	}
+	return &commands
+}
+
+func TestJanitor_prune(t *testing.T) {
+	now := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	full := commandsWithIds(1, uint(JANITOR_BATCH_SIZE))
+
+	var tests = []struct {
+		testName     string
+		archive      bool
+		behavior     func(*mock_database.MockDBWorker)
+		wantArchives int
+	}{
+		{"batches until the last one isn't full", true, func(m *mock_database.MockDBWorker) {
+			gomock.InOrder(
+				m.EXPECT().GettingExpiredCommandsQuery(gomock.Any(), JANITOR_BATCH_SIZE, gomock.Any()).Return(full, nil),
+				m.EXPECT().DeleteCommandsQuery(gomock.Len(JANITOR_BATCH_SIZE), gomock.Any()).Return(JANITOR_BATCH_SIZE, nil),
+				m.EXPECT().GettingExpiredCommandsQuery(gomock.Any(), JANITOR_BATCH_SIZE, gomock.Any()).Return(commandsWithIds(101, 102), nil),
+				m.EXPECT().DeleteCommandsQuery([]uint{101, 102}, gomock.Any()).Return(2, nil),
+			)
+		}, 2},
+		{"nothing expired", false, func(m *mock_database.MockDBWorker) {
+			m.EXPECT().GettingExpiredCommandsQuery(gomock.Any(), JANITOR_BATCH_SIZE, gomock.Any()).Return(&[]models.Commands{}, nil)
+		}, 0},
+		{"db querry error", false, func(m *mock_database.MockDBWorker) {
+			m.EXPECT().GettingExpiredCommandsQuery(gomock.Any(), JANITOR_BATCH_SIZE, gomock.Any()).Return(nil, fmt.Errorf("some db error"))
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+			tt.behavior(db)
+
+			policy := Policy{MaxAge: time.Hour}
+			if tt.archive {
+				policy.ArchiveDir = t.TempDir()
+			}
+			NewJanitor(db, policy).prune(context.Background(), now)
+
+			if tt.archive {
+				archives, _ := filepath.Glob(filepath.Join(policy.ArchiveDir, "*"))
+				if len(archives) != tt.wantArchives {
+					t.Errorf("expected %v archives but got %v", tt.wantArchives, archives)
+				}
+			}
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	now := time.Date(2026, 10, 20, 1, 2, 3, 0, time.UTC)
+	if err := Archive(dir, *commandsWithIds(3, 5), now, nil); err != nil {
+		t.Fatalf("Archive error: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "commands-20261020T010203Z-3-5.ndjson.gz"))
+	if err != nil {
+		t.Fatalf("the archive isn't found: %v", err)
+	}
+	defer file.Close()
+	decompressed, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(decompressed)
+	ids := []uint{}
+	for scanner.Scan() {
+		var command models.Commands
+		if err := json.Unmarshal(scanner.Bytes(), &command); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, command.Id)
+	}
+	if fmt.Sprint(ids) != "[3 4 5]" {
+		t.Errorf("unexpected archived commands %v", ids)
+	}
+
+	// nothing is left behind
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(files) != 0 {
+		t.Errorf("temporary files are left %v", files)
+	}
+}
+
+func TestArchiveSealed(t *testing.T) {
+	master, err := secrets.NewMasterKey(bytes.Repeat([]byte{1}, secrets.KEY_BYTES))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := secrets.NewMasterKey(bytes.Repeat([]byte{2}, secrets.KEY_BYTES))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := *commandsWithIds(3, 5)
+	commands[0].Log = "password=hunter2\n"
+
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 20, 1, 2, 3, 0, time.UTC)
+	if err := Archive(dir, commands, now, master); err != nil {
+		t.Fatalf("Archive error: %v", err)
+	}
+	sealed, err := os.ReadFile(filepath.Join(dir, "commands-20261020T010203Z-3-5.ndjson.gz"+SEALED_ARCHIVE_SUFFIX))
+	if err != nil {
+		t.Fatalf("the archive isn't found: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("hunter2")) {
+		t.Errorf("the archive has the log in clear")
+	}
+
+	if _, err := OpenArchive(bytes.NewReader(sealed), other); err == nil {
+		t.Errorf("the archive is opened with another master key")
+	}
+	archive, err := OpenArchive(bytes.NewReader(sealed), other, master)
+	if err != nil {
+		t.Fatalf("OpenArchive error: %v", err)
+	}
+	scanner := bufio.NewScanner(archive)
+	ids := []uint{}
+	for scanner.Scan() {
+		var command models.Commands
+		if err := json.Unmarshal(scanner.Bytes(), &command); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, command.Id)
+	}
+	if fmt.Sprint(ids) != "[3 4 5]" {
+		t.Errorf("unexpected archived commands %v", ids)
+	}
+}
@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	// std
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/cron"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	TICK_INTERVAL time.Duration = time.Second
+	// a run that was due longer ago than this has been missed
+	MISFIRE_THRESHOLD time.Duration = time.Minute
+	// upper bound of runs started at once by the catch_up policy
+	MAX_CATCH_UP_RUNS int = 100
+)
+
+// Scheduler starts the runs of the schedules stored in the database through
+// the same ExecCommands path the create-command handler uses. The runs count
+// against the quotas of the key that created the schedule.
+type Scheduler struct {
+	db     database.DBWorker
+	sh     bash.BashCommandsWorker
+	quotas *limits.Quotas
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(db database.DBWorker, sh bash.BashCommandsWorker, quotas *limits.Quotas) *Scheduler {
+	return &Scheduler{db: db, sh: sh, quotas: quotas}
+}
+
+// Run polls for due schedules until ctx is cancelled, then waits for the
+// started runs to finish.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(TICK_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	schedules, err := s.db.GettingDueSchedulesQuery(now, ctx)
+	if err != nil {
+		log.Printf("scheduler: database query error: %v\n", err)
+		return
+	}
+
+	for _, schedule := range *schedules {
+		cronSchedule, loc, err := ParseSchedule(schedule.CronExpression, schedule.Timezone)
+		if err != nil {
+			log.Printf("scheduler: schedule %v: %v\n", schedule.Id, err)
+			continue
+		}
+		runs, next := planRuns(cronSchedule, loc, schedule.NextRunAt, now, schedule.MisfirePolicy)
+		if next.IsZero() {
+			log.Printf("scheduler: schedule %v has no next run\n", schedule.Id)
+			continue
+		}
+		claimed, err := s.db.ClaimScheduleRunQuery(schedule.Id, schedule.NextRunAt, next, ctx)
+		if err != nil {
+			log.Printf("scheduler: database query error: %v\n", err)
+			continue
+		}
+		if !claimed || len(runs) == 0 {
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.execute(ctx, schedule, runs)
+	}
+}
+
+// execute starts the runs of a schedule one after another. A run over the
+// quotas of the key, of a revoked key or not allowed by the current policy of
+// its role is stored as a skipped batch without commands.
+func (s *Scheduler) execute(ctx context.Context, schedule models.Schedules, runs []time.Time) {
+	defer s.wg.Done()
+
+	var inputStruct bash.ReqCreateNewCommandBody
+	if err := json.Unmarshal(schedule.Commands, &inputStruct); err != nil {
+		log.Printf("scheduler: schedule %v: json unmarshal error: %v\n", schedule.Id, err)
+		return
+	}
+	options, err := inputStruct.CommandOptions()
+	if err != nil {
+		log.Printf("scheduler: schedule %v: %v\n", schedule.Id, err)
+		return
+	}
+	if err := inputStruct.ReadSecrets(s.db, ctx); err != nil {
+		log.Printf("scheduler: schedule %v: %v\n", schedule.Id, err)
+		return
+	}
+	inputStruct.ApiKeyId = schedule.ApiKeyId
+	for _, scheduledFor := range runs {
+		newBatch := models.NewBatch{ScheduleId: &schedule.Id, ScheduledFor: &scheduledFor, ApiKeyId: schedule.ApiKeyId}
+		release, owner, err := s.quotas.AcquireForKey(s.db, schedule.ApiKeyId, options, ctx)
+		if err != nil {
+			log.Printf("scheduler: schedule %v: run skipped: %v\n", schedule.Id, err)
+			newBatch.Skipped = err.Error()
+			if _, err := s.db.CreateNewCommandsQuery([]models.CommandsWithoutID{}, newBatch, context.Background()); err != nil {
+				log.Printf("scheduler: database query error: %v\n", err)
+			}
+			continue
+		}
+		inputStruct.WorkspaceOwner = owner
+		s.run(ctx, schedule.Id, &inputStruct, newBatch)
+		release()
+	}
+}
+
+// run starts a run of a schedule and stores its commands.
+func (s *Scheduler) run(ctx context.Context, scheduleId uint, inputStruct *bash.ReqCreateNewCommandBody, newBatch models.NewBatch) {
+	sliceCommands, err := s.sh.ExecCommands(inputStruct, ctx)
+	if err != nil {
+		log.Printf("scheduler: schedule %v: %v\n", scheduleId, err)
+	}
+	if sliceCommands == nil {
+		return
+	}
+	if _, err := s.db.CreateNewCommandsQuery(*sliceCommands, newBatch, context.Background()); err != nil {
+		log.Printf("scheduler: database query error: %v\n", err)
+	}
+}
+
+// ParseSchedule parses a cron expression and the name of its time zone, an
+// empty name means UTC.
+func ParseSchedule(expression string, timezone string) (*cron.Schedule, *time.Location, error) {
+	cronSchedule, err := cron.Parse(expression)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown time zone %q: %w", timezone, err)
+	}
+	return cronSchedule, loc, nil
+}
+
+// NextRunAt returns the first run of a schedule after the given time.
+func NextRunAt(expression string, timezone string, after time.Time) (time.Time, error) {
+	cronSchedule, loc, err := ParseSchedule(expression, timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := cronSchedule.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression %q never fires", expression)
+	}
+	return next, nil
+}
+
+func IsValidMisfirePolicy(policy string) bool {
+	switch policy {
+	case models.MISFIRE_SKIP, models.MISFIRE_RUN_ONCE, models.MISFIRE_CATCH_UP:
+		return true
+	}
+	return false
+}
+
+// planRuns returns the scheduled times to run now according to the misfire
+// policy, and the first run time after now.
+func planRuns(cronSchedule *cron.Schedule, loc *time.Location, nextRunAt time.Time, now time.Time, policy string) ([]time.Time, time.Time) {
+	start := nextRunAt.In(loc)
+	recent := now.Add(-MISFIRE_THRESHOLD)
+	missed := false
+	if policy != models.MISFIRE_CATCH_UP && start.Before(recent) {
+		// only catch_up needs every missed run, the others start walking at
+		// the misfire threshold
+		missed = true
+		start = cronSchedule.Next(recent.Add(-time.Second).In(loc))
+	}
+
+	due := []time.Time{}
+	for t := start; !t.IsZero() && !t.After(now); t = cronSchedule.Next(t) {
+		if len(due) == MAX_CATCH_UP_RUNS {
+			// keep the latest runs
+			due = due[1:]
+		}
+		due = append(due, t)
+	}
+	next := cronSchedule.Next(now.In(loc))
+
+	switch {
+	case policy == models.MISFIRE_CATCH_UP:
+		return due, next
+	case len(due) != 0:
+		return due[len(due)-1:], next
+	case missed && policy == models.MISFIRE_RUN_ONCE:
+		return []time.Time{nextRunAt.In(loc)}, next
+	default:
+		return []time.Time{}, next
+	}
+}
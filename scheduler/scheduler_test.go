@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	mock_bash "github.com/Vy4cheSlave/test-task-postgres/bash/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/cron"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestPlanRuns(t *testing.T) {
+	everyMinute, err := cron.Parse("* * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily, err := cron.Parse("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, second, 0, time.UTC)
+	}
+
+	var tests = []struct {
+		testName string
+		schedule *cron.Schedule
+		nextRunAt time.Time
+		now time.Time
+		policy string
+		wantRuns []time.Time
+		wantNext time.Time
+	}{
+		{
+			"due now",
+			everyMinute, at(9, 10, 0, 0), at(9, 10, 0, 1), models.MISFIRE_SKIP,
+			[]time.Time{at(9, 10, 0, 0)}, at(9, 10, 1, 0),
+		},
+		{
+			"skip missed runs",
+			daily, at(9, 3, 0, 0), at(11, 12, 0, 0), models.MISFIRE_SKIP,
+			[]time.Time{}, at(12, 3, 0, 0),
+		},
+		{
+			"skip keeps the latest recent run",
+			everyMinute, at(9, 10, 0, 0), at(9, 12, 30, 30), models.MISFIRE_SKIP,
+			[]time.Time{at(9, 12, 30, 0)}, at(9, 12, 31, 0),
+		},
+		{
+			"run once",
+			daily, at(9, 3, 0, 0), at(11, 12, 0, 0), models.MISFIRE_RUN_ONCE,
+			[]time.Time{at(9, 3, 0, 0)}, at(12, 3, 0, 0),
+		},
+		{
+			"catch up",
+			daily, at(9, 3, 0, 0), at(11, 12, 0, 0), models.MISFIRE_CATCH_UP,
+			[]time.Time{at(9, 3, 0, 0), at(10, 3, 0, 0), at(11, 3, 0, 0)}, at(12, 3, 0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			runs, next := planRuns(tt.schedule, time.UTC, tt.nextRunAt, tt.now, tt.policy)
+			if !next.Equal(tt.wantNext) {
+				t.Errorf("next run: got %v, want %v", next, tt.wantNext)
+			}
+			if len(runs) != len(tt.wantRuns) {
+				t.Fatalf("runs: got %v, want %v", runs, tt.wantRuns)
+			}
+			for i := range runs {
+				if !runs[i].Equal(tt.wantRuns[i]) {
+					t.Errorf("runs: got %v, want %v", runs, tt.wantRuns)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRunsCatchUpLimit(t *testing.T) {
+	everyMinute, err := cron.Parse("* * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextRunAt := time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC)
+	now := nextRunAt.Add(24 * time.Hour)
+
+	runs, _ := planRuns(everyMinute, time.UTC, nextRunAt, now, models.MISFIRE_CATCH_UP)
+	if len(runs) != MAX_CATCH_UP_RUNS {
+		t.Fatalf("got %v runs, want %v", len(runs), MAX_CATCH_UP_RUNS)
+	}
+	if !runs[len(runs)-1].Equal(now) {
+		t.Errorf("the latest run is %v, want %v", runs[len(runs)-1], now)
+	}
+}
+
+func TestSchedulerTick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mDatabase := mock_database.NewMockDBWorker(ctrl)
+	mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+
+	now := time.Date(2024, 5, 9, 10, 0, 30, 0, time.UTC)
+	due := models.Schedules{
+		Id: 1,
+		CronExpression: "* * * * *",
+		Timezone: "UTC",
+		Commands: []byte(`{"bash_strings": ["echo scheduled"]}`),
+		MisfirePolicy: models.MISFIRE_SKIP,
+		NextRunAt: time.Date(2024, 5, 9, 10, 0, 0, 0, time.UTC),
+	}
+	claimedElsewhere := due
+	claimedElsewhere.Id = 2
+
+	mDatabase.EXPECT().GettingDueSchedulesQuery(now, gomock.Any()).Return(&[]models.Schedules{due, claimedElsewhere}, nil)
+	mDatabase.EXPECT().ClaimScheduleRunQuery(uint(1), due.NextRunAt, time.Date(2024, 5, 9, 10, 1, 0, 0, time.UTC), gomock.Any()).Return(true, nil)
+	mDatabase.EXPECT().ClaimScheduleRunQuery(uint(2), due.NextRunAt, gomock.Any(), gomock.Any()).Return(false, nil)
+	mBash.EXPECT().ExecCommands(&bash.ReqCreateNewCommandBody{BashStrings: []string{"echo scheduled"}}, gomock.Any()).Return(
+		&[]models.CommandsWithoutID{{Command: "echo scheduled", Log: "scheduled\n"}},
+		nil,
+	)
+	scheduleId := uint(1)
+	mDatabase.EXPECT().CreateNewCommandsQuery(
+		[]models.CommandsWithoutID{{Command: "echo scheduled", Log: "scheduled\n"}},
+		models.NewBatch{ScheduleId: &scheduleId, ScheduledFor: &due.NextRunAt},
+		context.Background(),
+	).Return(uint(1), nil)
+
+	s := NewScheduler(mDatabase, mBash, nil)
+	s.tick(context.Background(), now)
+	s.wg.Wait()
+}
+
+func TestSchedulerTickOverQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mDatabase := mock_database.NewMockDBWorker(ctrl)
+	mBash := mock_bash.NewMockBashCommandsWorker(ctrl)
+
+	now := time.Date(2024, 5, 9, 10, 0, 30, 0, time.UTC)
+	ownKeyId := uint(3)
+	due := models.Schedules{
+		Id: 1,
+		CronExpression: "* * * * *",
+		Timezone: "UTC",
+		Commands: []byte(`{"bash_strings": ["echo scheduled"]}`),
+		MisfirePolicy: models.MISFIRE_SKIP,
+		NextRunAt: time.Date(2024, 5, 9, 10, 0, 0, 0, time.UTC),
+		ApiKeyId: &ownKeyId,
+	}
+
+	mDatabase.EXPECT().GettingDueSchedulesQuery(now, gomock.Any()).Return(&[]models.Schedules{due}, nil)
+	mDatabase.EXPECT().ClaimScheduleRunQuery(uint(1), due.NextRunAt, gomock.Any(), gomock.Any()).Return(true, nil)
+	mDatabase.EXPECT().GettingApiKeyQuery(ownKeyId, gomock.Any()).Return(&models.ApiKeys{Id: ownKeyId, Role: models.ROLE_OPERATOR}, nil)
+	mDatabase.EXPECT().GettingRolePolicyQuery(models.ROLE_OPERATOR, gomock.Any()).Return(&models.RolePolicies{CommandsPerDay: 10}, nil)
+	mDatabase.EXPECT().GettingUsageQuery(ownKeyId, gomock.Any(), gomock.Any()).Return(&models.Usage{Commands: 10}, nil)
+	var skipped models.NewBatch
+	mDatabase.EXPECT().CreateNewCommandsQuery([]models.CommandsWithoutID{}, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(commands []models.CommandsWithoutID, batch models.NewBatch, ctx context.Context) (uint, error) {
+			skipped = batch
+			return uint(1), nil
+		},
+	)
+
+	s := NewScheduler(mDatabase, mBash, limits.NewQuotas())
+	s.tick(context.Background(), now)
+	s.wg.Wait()
+
+	if skipped.ScheduleId == nil || *skipped.ScheduleId != due.Id || !strings.Contains(skipped.Skipped, "commands_per_day") {
+		t.Errorf("expected a skipped run but got %+v", skipped)
+	}
+}
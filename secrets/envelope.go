@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	// std
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// environment variable with the path of the file with the master key of
+	// the output of commands
+	OUTPUT_KEY_FILE_ENV string = "OUTPUT_KEY_FILE"
+	// environment variable with the comma separated paths of the previous
+	// master keys, data keys wrapped by them are wrapped again on start
+	OUTPUT_PREVIOUS_KEY_FILES_ENV string = "OUTPUT_PREVIOUS_KEY_FILES"
+)
+
+// data keys are wrapped with this additional data, so that a master key box
+// can't be used to open anything else
+var wrapAdditionalData = []byte("data key")
+
+// MasterKey wraps the data keys the output of commands is sealed with. Only
+// wrapped data keys are stored, the master key stays in its file.
+type MasterKey struct {
+	// fingerprint of the key stored with the data keys it wraps
+	Id  string
+	box *Box
+}
+
+func NewMasterKey(key []byte) (*MasterKey, error) {
+	box, err := NewBox(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &MasterKey{Id: hex.EncodeToString(sum[:8]), box: box}, nil
+}
+
+func (master *MasterKey) Wrap(dataKey []byte) ([]byte, error) {
+	return master.box.Seal(dataKey, wrapAdditionalData)
+}
+
+func (master *MasterKey) Unwrap(wrapped []byte) ([]byte, error) {
+	return master.box.Open(wrapped, wrapAdditionalData)
+}
+
+// NewDataKey returns a random key for a Box.
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, KEY_BYTES)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate data key error: %v", err)
+	}
+	return key, nil
+}
+
+func loadMasterKey(path string) (*MasterKey, error) {
+	key, err := LoadKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMasterKey(key)
+}
+
+// MasterKeysFromEnv returns the master key of OUTPUT_KEY_FILE and the previous
+// ones of OUTPUT_PREVIOUS_KEY_FILES, a nil master key if the first isn't set.
+func MasterKeysFromEnv() (*MasterKey, []*MasterKey, error) {
+	path := os.Getenv(OUTPUT_KEY_FILE_ENV)
+	if path == "" {
+		return nil, nil, nil
+	}
+	master, err := loadMasterKey(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := []*MasterKey{}
+	for _, path := range strings.Split(os.Getenv(OUTPUT_PREVIOUS_KEY_FILES_ENV), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := loadMasterKey(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		previous = append(previous, key)
+	}
+	return master, previous, nil
+}
@@ -0,0 +1,216 @@
+package secrets
+
+import (
+	// std
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// environment variable with the path of the file with the server key
+	SECRETS_KEY_FILE_ENV string = "SECRETS_KEY_FILE"
+	// AES-256
+	KEY_BYTES int = 32
+	// what secret values are replaced with in the output of commands
+	MASK string = "***"
+)
+
+// Box seals values with AES-GCM under a server key, a sealed value is its
+// random nonce followed by the ciphertext.
+type Box struct {
+	aead cipher.AEAD
+}
+
+func NewBox(key []byte) (*Box, error) {
+	if len(key) != KEY_BYTES {
+		return nil, fmt.Errorf("key must have %v bytes, got %v", KEY_BYTES, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// LoadKey reads a key from a file, in hex, in base64 or as raw bytes.
+func LoadKey(path string) ([]byte, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key: %v", err)
+	}
+	text := string(bytes.TrimSpace(buf))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KEY_BYTES {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KEY_BYTES {
+		return key, nil
+	}
+	if len(buf) == KEY_BYTES {
+		return buf, nil
+	}
+	return nil, fmt.Errorf("key in %v isn't %v bytes in hex, base64 or raw", path, KEY_BYTES)
+}
+
+// BoxFromEnv returns the box of the key file of SECRETS_KEY_FILE, nil if it
+// isn't set.
+func BoxFromEnv() (*Box, error) {
+	path := os.Getenv(SECRETS_KEY_FILE_ENV)
+	if path == "" {
+		return nil, nil
+	}
+	key, err := LoadKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewBox(key)
+}
+
+// Seal encrypts the value, additionalData is authenticated along with it so
+// a sealed value can't be moved to another row.
+func (box *Box) Seal(value, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, box.aead.NonceSize(), box.aead.NonceSize()+len(value)+box.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce error: %v", err)
+	}
+	return box.aead.Seal(nonce, nonce, value, additionalData), nil
+}
+
+// Open decrypts a value sealed with the same key and additional data.
+func (box *Box) Open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < box.aead.NonceSize() {
+		return nil, fmt.Errorf("sealed value is too short")
+	}
+	nonce, ciphertext := sealed[:box.aead.NonceSize()], sealed[box.aead.NonceSize():]
+	value, err := box.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open sealed value: %v", err)
+	}
+	return value, nil
+}
+
+// encodings returns the value and the forms it may take in the output of a
+// command.
+func encodings(value string) []string {
+	return []string{
+		value,
+		base64.StdEncoding.EncodeToString([]byte(value)),
+		base64.RawStdEncoding.EncodeToString([]byte(value)),
+		base64.URLEncoding.EncodeToString([]byte(value)),
+		base64.RawURLEncoding.EncodeToString([]byte(value)),
+		url.QueryEscape(value),
+		url.PathEscape(value),
+	}
+}
+
+// MASK_STREAM_BLOCK_BYTES is how much of a stream MaskStream reads at once.
+const MASK_STREAM_BLOCK_BYTES int = 64 << 10
+
+// Masker replaces secret values and their base64 and url encoded forms.
+type Masker struct {
+	replacer *strings.Replacer
+	// the forms, the longest first
+	forms []string
+}
+
+// NewMasker returns a masker of the values, empty values are skipped.
+func NewMasker(values []string) *Masker {
+	forms := map[string]bool{}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, form := range encodings(value) {
+			forms[form] = true
+		}
+	}
+	if len(forms) == 0 {
+		return &Masker{}
+	}
+	// strings.Replacer prefers the earlier of two forms starting at the same
+	// position, the longest ones go first
+	sorted := make([]string, 0, len(forms))
+	for form := range forms {
+		sorted = append(sorted, form)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	oldnew := make([]string, 0, 2*len(sorted))
+	for _, form := range sorted {
+		oldnew = append(oldnew, form, MASK)
+	}
+	return &Masker{replacer: strings.NewReplacer(oldnew...), forms: sorted}
+}
+
+func (masker *Masker) Mask(text string) string {
+	if masker == nil || masker.replacer == nil {
+		return text
+	}
+	return masker.replacer.Replace(text)
+}
+
+// MaskStream copies src to dst with the secret values replaced like Mask, a
+// value split between two reads is replaced too. It holds a block of the
+// stream at a time.
+func (masker *Masker) MaskStream(dst io.Writer, src io.Reader) error {
+	if masker == nil || masker.replacer == nil {
+		_, err := io.Copy(dst, src)
+		return err
+	}
+	block := make([]byte, MASK_STREAM_BLOCK_BYTES)
+	pending := []byte{}
+	for {
+		n, err := src.Read(block)
+		pending = append(pending, block[:n]...)
+		if err == io.EOF {
+			_, err = io.WriteString(dst, masker.replacer.Replace(string(pending)))
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		cut := masker.safeCut(pending)
+		if cut == 0 {
+			continue
+		}
+		if _, err := io.WriteString(dst, masker.replacer.Replace(string(pending[:cut]))); err != nil {
+			return err
+		}
+		pending = append(pending[:0], pending[cut:]...)
+	}
+}
+
+// safeCut returns how much of the stream read so far can be masked on its
+// own: the rest may be the start of a form, and no form crosses the cut.
+func (masker *Masker) safeCut(pending []byte) int {
+	cut := len(pending) - (len(masker.forms[0]) - 1)
+	for moved := true; moved && cut > 0; {
+		moved = false
+		for i := max(0, cut-len(masker.forms[0])+1); i < cut && !moved; i++ {
+			for _, form := range masker.forms {
+				if i+len(form) > cut && bytes.HasPrefix(pending[i:], []byte(form)) {
+					cut, moved = i, true
+					break
+				}
+			}
+		}
+	}
+	return max(cut, 0)
+}
@@ -0,0 +1,192 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBox(t *testing.T) {
+	box, err := NewBox(bytes.Repeat([]byte{1}, KEY_BYTES))
+	if err != nil {
+		t.Fatalf("NewBox error: %v", err)
+	}
+	sealed, err := box.Seal([]byte("value"), []byte("name"))
+	if err != nil {
+		t.Fatalf("Seal error: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("value")) {
+		t.Errorf("the sealed value contains the value")
+	}
+	if value, err := box.Open(sealed, []byte("name")); err != nil || string(value) != "value" {
+		t.Errorf("Open() = %q, %v", value, err)
+	}
+	if _, err := box.Open(sealed, []byte("other")); err == nil {
+		t.Errorf("a value is opened with other additional data")
+	}
+	other, _ := NewBox(bytes.Repeat([]byte{2}, KEY_BYTES))
+	if _, err := other.Open(sealed, []byte("name")); err == nil {
+		t.Errorf("a value is opened with another key")
+	}
+	if _, err := NewBox([]byte("short")); err == nil {
+		t.Errorf("a short key is accepted")
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KEY_BYTES)
+	dir := t.TempDir()
+
+	var tests = []struct {
+		testName string
+		content  []byte
+		wantErr  bool
+	}{
+		{"hex", []byte(hex.EncodeToString(key) + "\n"), false},
+		{"base64", []byte(base64.StdEncoding.EncodeToString(key)), false},
+		{"raw", key, false},
+		{"short", []byte("abc"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			path := filepath.Join(dir, tt.testName)
+			if err := os.WriteFile(path, tt.content, 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadKey(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !bytes.Equal(got, key) {
+				t.Errorf("LoadKey() = %x, want %x", got, key)
+			}
+		})
+	}
+}
+
+func TestMasker_Mask(t *testing.T) {
+	secret := "p@ss word/1?"
+	masker := NewMasker([]string{secret, ""})
+
+	var tests = []struct {
+		testName string
+		text     string
+	}{
+		{"plain", "pass=" + secret + "\n"},
+		{"base64", "pass=" + base64.StdEncoding.EncodeToString([]byte(secret)) + "\n"},
+		{"raw url base64", "pass=" + base64.RawURLEncoding.EncodeToString([]byte(secret)) + "\n"},
+		{"query escaped", "pass=" + url.QueryEscape(secret) + "\n"},
+		{"path escaped", "pass=" + url.PathEscape(secret) + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			if got := masker.Mask(tt.text); got != "pass="+MASK+"\n" {
+				t.Errorf("Mask() = %q", got)
+			}
+		})
+	}
+
+	var empty *Masker
+	if got := empty.Mask("text"); got != "text" {
+		t.Errorf("a nil masker changes the text: %q", got)
+	}
+	if got := NewMasker(nil).Mask("text"); got != "text" {
+		t.Errorf("a masker without values changes the text: %q", got)
+	}
+}
+
+func TestMasker_MaskStream(t *testing.T) {
+	secret := "p@ss word/1?"
+	masker := NewMasker([]string{secret})
+	// the secrets are split between the blocks of the stream
+	var text strings.Builder
+	for text.Len() < 3*MASK_STREAM_BLOCK_BYTES {
+		text.WriteString("line " + secret + " " + base64.StdEncoding.EncodeToString([]byte(secret)) + " end\n")
+	}
+
+	var masked bytes.Buffer
+	if err := masker.MaskStream(&masked, strings.NewReader(text.String())); err != nil {
+		t.Fatalf("MaskStream error: %v", err)
+	}
+	if masked.String() != masker.Mask(text.String()) {
+		t.Errorf("the masked stream isn't masked like the text")
+	}
+	if strings.Contains(masked.String(), secret) {
+		t.Errorf("the masked stream has the secret")
+	}
+
+	var copied bytes.Buffer
+	if err := NewMasker(nil).MaskStream(&copied, strings.NewReader("text")); err != nil || copied.String() != "text" {
+		t.Errorf("a masker without values changes the stream: %q, %v", copied.String(), err)
+	}
+}
+
+func TestMasterKey(t *testing.T) {
+	master, err := NewMasterKey(bytes.Repeat([]byte{1}, KEY_BYTES))
+	if err != nil {
+		t.Fatalf("NewMasterKey error: %v", err)
+	}
+	other, _ := NewMasterKey(bytes.Repeat([]byte{2}, KEY_BYTES))
+	if master.Id == other.Id || len(master.Id) != 16 {
+		t.Errorf("unexpected master key ids %q and %q", master.Id, other.Id)
+	}
+
+	dataKey, err := NewDataKey()
+	if err != nil || len(dataKey) != KEY_BYTES {
+		t.Fatalf("NewDataKey() = %x, %v", dataKey, err)
+	}
+	wrapped, err := master.Wrap(dataKey)
+	if err != nil {
+		t.Fatalf("Wrap error: %v", err)
+	}
+	if unwrapped, err := master.Unwrap(wrapped); err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Errorf("Unwrap() = %x, %v", unwrapped, err)
+	}
+	if _, err := other.Unwrap(wrapped); err == nil {
+		t.Errorf("a data key is unwrapped by another master key")
+	}
+	// a wrapped data key isn't a sealed value of the master key
+	if _, err := master.box.Open(wrapped, nil); err == nil {
+		t.Errorf("a wrapped data key is opened without its additional data")
+	}
+}
+
+func TestMasterKeysFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{}
+	for i := byte(1); i <= 3; i++ {
+		path := filepath.Join(dir, fmt.Sprint(i))
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(bytes.Repeat([]byte{i}, KEY_BYTES))), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	t.Setenv(OUTPUT_KEY_FILE_ENV, "")
+	if master, _, err := MasterKeysFromEnv(); master != nil || err != nil {
+		t.Errorf("expected no master key but got %v, %v", master, err)
+	}
+
+	t.Setenv(OUTPUT_KEY_FILE_ENV, paths[0])
+	t.Setenv(OUTPUT_PREVIOUS_KEY_FILES_ENV, paths[1]+", "+paths[2]+",")
+	master, previous, err := MasterKeysFromEnv()
+	if err != nil {
+		t.Fatalf("MasterKeysFromEnv error: %v", err)
+	}
+	if master == nil || len(previous) != 2 || previous[0].Id == master.Id || previous[0].Id == previous[1].Id {
+		t.Errorf("unexpected master keys %v %v", master, previous)
+	}
+
+	t.Setenv(OUTPUT_PREVIOUS_KEY_FILES_ENV, filepath.Join(dir, "missing"))
+	if _, _, err := MasterKeysFromEnv(); err == nil {
+		t.Errorf("a missing previous key file isn't reported")
+	}
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	// std
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	DISPATCH_INTERVAL time.Duration = time.Second
+	// deliveries claimed at once
+	DISPATCH_BATCH_SIZE int           = 50
+	DELIVERY_TIMEOUT    time.Duration = 10 * time.Second
+	// the delivery fails after this many attempts
+	MAX_DELIVERY_ATTEMPTS int = 8
+	// delay after the first failed attempt, it doubles for each next one
+	RETRY_BASE_DELAY time.Duration = 10 * time.Second
+	RETRY_MAX_DELAY  time.Duration = time.Hour
+)
+
+const (
+	EVENT_HEADER    string = "X-Webhook-Event"
+	DELIVERY_HEADER string = "X-Webhook-Delivery"
+	// sha256=<hex HMAC-SHA256 of the body keyed with the webhook secret>
+	SIGNATURE_HEADER string = "X-Webhook-Signature"
+)
+
+// Dispatcher sends the deliveries waiting in the outbox to their webhooks.
+type Dispatcher struct {
+	db     database.DBWorker
+	client *http.Client
+}
+
+func NewDispatcher(db database.DBWorker) *Dispatcher {
+	return &Dispatcher{db: db, client: &http.Client{Timeout: DELIVERY_TIMEOUT}}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (dispatcher *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(DISPATCH_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			dispatcher.dispatch(ctx, now)
+		}
+	}
+}
+
+// dispatch sends the due deliveries in parallel and waits for them.
+func (dispatcher *Dispatcher) dispatch(ctx context.Context, now time.Time) {
+	deliveries, err := dispatcher.db.ClaimWebhookDeliveriesQuery(now, DISPATCH_BATCH_SIZE, ctx)
+	if err != nil {
+		log.Printf("webhook: database query error: %v\n", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range *deliveries {
+		wg.Add(1)
+		go func(delivery *models.WebhookDeliveries) {
+			defer wg.Done()
+			dispatcher.deliver(ctx, delivery)
+			if err := dispatcher.db.UpdateWebhookDeliveryQuery(delivery, context.Background()); err != nil {
+				log.Printf("webhook: database query error: %v\n", err)
+			}
+		}(&(*deliveries)[i])
+	}
+	wg.Wait()
+}
+
+// deliver makes an attempt to send the delivery and sets its outcome.
+func (dispatcher *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDeliveries) {
+	delivery.Attempts++
+	statusCode, err := dispatcher.post(ctx, delivery)
+	delivery.LastStatusCode, delivery.LastError = nil, ""
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	switch {
+	case err != nil:
+		delivery.LastError = err.Error()
+	case statusCode < 200 || statusCode > 299:
+		delivery.LastError = fmt.Sprintf("unexpected status code %v", statusCode)
+	default:
+		now := time.Now()
+		delivery.Status, delivery.DeliveredAt = models.DELIVERY_DELIVERED, &now
+		return
+	}
+
+	if delivery.Attempts >= MAX_DELIVERY_ATTEMPTS {
+		delivery.Status = models.DELIVERY_FAILED
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(RetryDelay(delivery.Attempts))
+}
+
+func (dispatcher *Dispatcher) post(ctx context.Context, delivery *models.WebhookDeliveries) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EVENT_HEADER, delivery.Event)
+	request.Header.Set(DELIVERY_HEADER, strconv.FormatUint(uint64(delivery.Id), 10))
+	request.Header.Set(SIGNATURE_HEADER, Sign(delivery.Secret, delivery.Payload))
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	return response.StatusCode, nil
+}
+
+// Sign returns the signature header value of a body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay returns how long to wait after the given failed attempt.
+func RetryDelay(attempts int) time.Duration {
+	delay := RETRY_BASE_DELAY
+	for i := 1; i < attempts && delay < RETRY_MAX_DELAY; i++ {
+		delay *= 2
+	}
+	return min(delay, RETRY_MAX_DELAY)
+}
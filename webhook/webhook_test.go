@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDispatch(t *testing.T) {
+	payload := []byte(`{"event":"command.failed","command":{"id":1}}`)
+	received := make(chan *http.Request, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SIGNATURE_HEADER) != Sign("secret", body) {
+			t.Errorf("invalid signature %q", r.Header.Get(SIGNATURE_HEADER))
+		}
+		if string(body) != string(payload) {
+			t.Errorf("unexpected body %s", body)
+		}
+		received <- r
+		if r.Header.Get(DELIVERY_HEADER) == "2" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	var tests = []struct {
+		testName     string
+		delivery     models.WebhookDeliveries
+		wantStatus   string
+		wantAttempts int
+		wantRetry    bool
+	}{
+		{
+			"delivered",
+			models.WebhookDeliveries{Id: 1, Event: models.EVENT_COMMAND_FAILED, Payload: payload, Status: models.DELIVERY_PENDING,
+				Url: receiver.URL, Secret: "secret"},
+			models.DELIVERY_DELIVERED, 1, false,
+		},
+		{
+			"retried",
+			models.WebhookDeliveries{Id: 2, Event: models.EVENT_COMMAND_FAILED, Payload: payload, Status: models.DELIVERY_PENDING,
+				Attempts: 2, Url: receiver.URL, Secret: "secret"},
+			models.DELIVERY_PENDING, 3, true,
+		},
+		{
+			"gives up",
+			models.WebhookDeliveries{Id: 2, Event: models.EVENT_COMMAND_FAILED, Payload: payload, Status: models.DELIVERY_PENDING,
+				Attempts: MAX_DELIVERY_ATTEMPTS - 1, Url: receiver.URL, Secret: "secret"},
+			models.DELIVERY_FAILED, MAX_DELIVERY_ATTEMPTS, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			db := mock_database.NewMockDBWorker(ctrl)
+
+			now := time.Now()
+			db.EXPECT().ClaimWebhookDeliveriesQuery(now, DISPATCH_BATCH_SIZE, gomock.Any()).Return(
+				&[]models.WebhookDeliveries{tt.delivery}, nil,
+			)
+			db.EXPECT().UpdateWebhookDeliveryQuery(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(delivery *models.WebhookDeliveries, ctx context.Context) error {
+					if delivery.Status != tt.wantStatus || delivery.Attempts != tt.wantAttempts {
+						t.Errorf("got status %v after %v attempts, want %v after %v", delivery.Status, delivery.Attempts,
+							tt.wantStatus, tt.wantAttempts)
+					}
+					if tt.wantRetry && !delivery.NextAttemptAt.After(now) {
+						t.Errorf("next attempt isn't delayed: %v", delivery.NextAttemptAt)
+					}
+					return nil
+				},
+			)
+
+			NewDispatcher(db).dispatch(context.Background(), now)
+			select {
+			case r := <-received:
+				if r.Header.Get(EVENT_HEADER) != models.EVENT_COMMAND_FAILED {
+					t.Errorf("unexpected event header %q", r.Header.Get(EVENT_HEADER))
+				}
+			default:
+				t.Errorf("the receiver got no request")
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if got := RetryDelay(1); got != RETRY_BASE_DELAY {
+		t.Errorf("first retry: got %v, want %v", got, RETRY_BASE_DELAY)
+	}
+	if got := RetryDelay(3); got != 4*RETRY_BASE_DELAY {
+		t.Errorf("third retry: got %v, want %v", got, 4*RETRY_BASE_DELAY)
+	}
+	if got := RetryDelay(100); got != RETRY_MAX_DELAY {
+		t.Errorf("late retry: got %v, want %v", got, RETRY_MAX_DELAY)
+	}
+}
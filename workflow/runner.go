@@ -0,0 +1,243 @@
+package workflow
+
+import (
+	// std
+	"context"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/database"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+// OUTPUT_PREFIX starts a stdout line that sets an output of the step, for
+// example ::output version=1.2.3
+const OUTPUT_PREFIX string = "::output "
+
+// STEP_STDOUT_ENV_BYTES is how much of the stdout of a step the steps that
+// need it get in STEPS_<NAME>_STDOUT, linux limits a variable to 128 KiB.
+const STEP_STDOUT_ENV_BYTES int = 64 << 10
+
+var notEnvNameRegexp = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// Runner executes workflows through ExecCommands and stores the state of
+// their steps. The commands of the steps count against the quotas of the key
+// that submitted the workflow.
+type Runner struct {
+	db     database.DBWorker
+	sh     bash.BashCommandsWorker
+	quotas *limits.Quotas
+}
+
+func NewRunner(db database.DBWorker, sh bash.BashCommandsWorker, quotas *limits.Quotas) *Runner {
+	return &Runner{db: db, sh: sh, quotas: quotas}
+}
+
+type finishedStep struct {
+	index  int
+	result *stepResult
+}
+
+// Run executes the steps of a stored workflow, every step starts as soon as
+// the steps it needs have finished. It returns the status of the workflow
+// when all steps are done. Cancelling ctx interrupts the running steps.
+func (runner *Runner) Run(workflow *models.Workflows, definition *Definition, ctx context.Context) string {
+	steps := definition.Steps
+	dependencies := make([]int, len(steps))
+	dependents := make(map[string][]int, len(steps))
+	for i, step := range steps {
+		dependencies[i] = len(step.Needs)
+		for _, need := range step.Needs {
+			dependents[need] = append(dependents[need], i)
+		}
+	}
+
+	results := make(map[string]*stepResult, len(steps))
+	done := make(chan finishedStep)
+	start := func(i int) {
+		// the results of the needed steps don't change anymore, the step
+		// gets its own map of them
+		needed := make(map[string]*stepResult, len(steps[i].Needs))
+		for _, need := range steps[i].Needs {
+			needed[need] = results[need]
+		}
+		go func() {
+			done <- finishedStep{index: i, result: runner.runStep(&workflow.Steps[i], &steps[i], needed, workflow.ApiKeyId, ctx)}
+		}()
+	}
+
+	for i := range steps {
+		if dependencies[i] == 0 {
+			start(i)
+		}
+	}
+	for pending := len(steps); pending > 0; pending-- {
+		finished := <-done
+		name := steps[finished.index].Name
+		results[name] = finished.result
+		for _, i := range dependents[name] {
+			if dependencies[i]--; dependencies[i] == 0 {
+				start(i)
+			}
+		}
+	}
+
+	workflow.Status = models.STATUS_SUCCEEDED
+	for _, result := range results {
+		if result.status == models.STATUS_FAILED && !result.continueOnError {
+			workflow.Status = models.STATUS_FAILED
+		}
+	}
+	if err := runner.db.FinishWorkflowQuery(workflow.Id, workflow.Status, context.Background()); err != nil {
+		log.Printf("workflow %v: database query error: %v\n", workflow.Id, err)
+	}
+	return workflow.Status
+}
+
+// runStep runs a step whose needed steps have finished and stores its state.
+// The command is submitted by the api key of the workflow, a step over its
+// quotas, of a revoked key or not allowed by the current policy of its role
+// fails without running.
+func (runner *Runner) runStep(step *models.WorkflowSteps, definition *Step, needed map[string]*stepResult, apiKeyId *uint, ctx context.Context) *stepResult {
+	result := &stepResult{continueOnError: definition.ContinueOnError}
+	// the definition was validated, the condition compiles
+	isTrue, _ := compileCondition(definition.If, definition.Needs)
+	if !isTrue(needed) {
+		now := time.Now()
+		step.Status, step.FinishedAt = models.STATUS_SKIPPED, &now
+		runner.storeStep(step, nil)
+		result.status = step.Status
+		return result
+	}
+
+	inputStruct := bash.ReqCreateNewCommandBody{
+		Commands: []bash.CommandOptions{{
+			BashString: definition.Run,
+			Assertions: definition.Assertions,
+			Env:        definition.Env,
+			ProcessEnv: stepEnv(definition, needed),
+			Retry:      definition.Retry,
+		}},
+		ApiKeyId: apiKeyId,
+	}
+	startedAt := time.Now()
+	release, owner, err := runner.quotas.AcquireForKey(runner.db, apiKeyId, inputStruct.Commands, ctx)
+	if err != nil {
+		log.Printf("workflow %v: step %v: %v\n", step.WorkflowId, step.Name, err)
+		step.Status, step.StartedAt, step.FinishedAt, step.Error = models.STATUS_FAILED, &startedAt, &startedAt, err.Error()
+		runner.storeStep(step, nil)
+		result.status, result.exitCode = step.Status, -1
+		return result
+	}
+	// the command counts as running until it is stored
+	defer release()
+	inputStruct.WorkspaceOwner = owner
+	step.Status, step.StartedAt = models.STATUS_RUNNING, &startedAt
+	runner.storeStep(step, nil)
+
+	stepCtx := ctx
+	if definition.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, time.Duration(definition.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	sliceCommands, err := runner.sh.ExecCommands(&inputStruct, stepCtx)
+	if err != nil {
+		log.Printf("workflow %v: step %v: %v\n", step.WorkflowId, step.Name, err)
+	}
+
+	finishedAt := time.Now()
+	step.FinishedAt = &finishedAt
+	if sliceCommands == nil || len(*sliceCommands) == 0 {
+		step.Status = models.STATUS_FAILED
+		runner.storeStep(step, nil)
+		result.status, result.exitCode = step.Status, -1
+		return result
+	}
+
+	command := (*sliceCommands)[0]
+	result.exitCode, result.stdout = command.ExitCode, command.Stdout
+	result.outputs = parseOutputs(command.Stdout)
+	if command.ExitCode == 0 && (command.AssertionsPassed == nil || *command.AssertionsPassed) {
+		step.Status = models.STATUS_SUCCEEDED
+	} else {
+		step.Status = models.STATUS_FAILED
+	}
+	if len(result.outputs) != 0 {
+		step.Outputs = result.outputs
+	}
+	runner.storeStep(step, &command)
+	result.status = step.Status
+	return result
+}
+
+func (runner *Runner) storeStep(step *models.WorkflowSteps, command *models.CommandsWithoutID) {
+	if err := runner.db.UpdateWorkflowStepQuery(step, command, context.Background()); err != nil {
+		log.Printf("workflow %v: database query error: %v\n", step.WorkflowId, err)
+	}
+}
+
+// stepEnv returns the env of the process of a step: the exit code, stdout and
+// outputs of every needed step that ran as STEPS_<NAME>_EXIT_CODE,
+// STEPS_<NAME>_STDOUT and STEPS_<NAME>_OUTPUTS_<KEY>, except the variables
+// the env of the step sets.
+func stepEnv(definition *Step, needed map[string]*stepResult) map[string]string {
+	env := map[string]string{}
+	for name, result := range needed {
+		if result.status == models.STATUS_SKIPPED {
+			continue
+		}
+		prefix := "STEPS_" + envName(name) + "_"
+		env[prefix+"EXIT_CODE"] = strconv.Itoa(result.exitCode)
+		env[prefix+"STDOUT"] = stdoutEnv(result.stdout)
+		for key, value := range result.outputs {
+			env[prefix+"OUTPUTS_"+envName(key)] = value
+		}
+	}
+	for key := range definition.Env {
+		delete(env, key)
+	}
+	return env
+}
+
+// stdoutEnv returns the stdout of a step as the value of a variable: without
+// the last newline and the NUL bytes a variable can't hold, cut to the first
+// STEP_STDOUT_ENV_BYTES without cutting a character in two.
+func stdoutEnv(stdout string) string {
+	stdout = strings.ReplaceAll(strings.TrimSuffix(stdout, "\n"), "\x00", "")
+	if len(stdout) <= STEP_STDOUT_ENV_BYTES {
+		return stdout
+	}
+	end := STEP_STDOUT_ENV_BYTES
+	for end > 0 && !utf8.RuneStart(stdout[end]) {
+		end--
+	}
+	return stdout[:end]
+}
+
+// parseOutputs returns the outputs set by the ::output lines of stdout.
+func parseOutputs(stdout string) map[string]string {
+	outputs := map[string]string{}
+	for _, line := range strings.Split(stdout, "\n") {
+		assignment, ok := strings.CutPrefix(line, OUTPUT_PREFIX)
+		if !ok {
+			continue
+		}
+		key, value, ok := strings.Cut(assignment, "=")
+		if !ok || key == "" {
+			continue
+		}
+		outputs[key] = value
+	}
+	return outputs
+}
+
+func envName(name string) string {
+	return notEnvNameRegexp.ReplaceAllString(strings.ToUpper(name), "_")
+}
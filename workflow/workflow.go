@@ -0,0 +1,289 @@
+package workflow
+
+import (
+	// std
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	// web
+	"gopkg.in/yaml.v3"
+)
+
+// Definition is a workflow of named steps, a step starts when the steps it
+// needs have finished.
+type Definition struct {
+	Name  string `json:"name"`
+	Steps []Step `json:"steps"`
+}
+
+type Step struct {
+	Name string `json:"name"`
+	Run  string `json:"run"`
+	// names of the steps that have to finish first
+	Needs []string `json:"needs,omitempty"`
+	// condition on the needed steps, success() by default
+	If         string             `json:"if,omitempty"`
+	Env        map[string]string  `json:"env,omitempty"`
+	Assertions *models.Assertions `json:"assertions,omitempty"`
+	TimeoutMs  int64              `json:"timeout_ms,omitempty"`
+	// the timeout covers all attempts
+	Retry *models.RetryPolicy `json:"retry,omitempty"`
+	// a failure of the step doesn't fail the workflow and counts as success
+	// for the conditions of the next steps
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+}
+
+var (
+	stepNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// build.exit_code != 0, build.status == skipped
+	comparisonRegexp = regexp.MustCompile(`^([A-Za-z0-9_-]+)\.(exit_code|status)\s*(==|!=|<=|>=|<|>)\s*(\S+)$`)
+)
+
+// ParseDefinition parses and validates a workflow definition written in YAML
+// or JSON.
+func ParseDefinition(data []byte) (*Definition, error) {
+	// YAML is a superset of JSON, the document is converted to JSON so that
+	// the json tags of the models apply to both
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("workflow definition is invalid: %w", err)
+	}
+	buf, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("workflow definition is invalid: %w", err)
+	}
+	var definition Definition
+	if err := json.Unmarshal(buf, &definition); err != nil {
+		return nil, fmt.Errorf("workflow definition is invalid: %w", err)
+	}
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// Validate checks the step names, their dependencies and conditions.
+func (definition *Definition) Validate() error {
+	if len(definition.Steps) == 0 {
+		return fmt.Errorf("workflow has no steps")
+	}
+	steps := make(map[string]*Step, len(definition.Steps))
+	for i := range definition.Steps {
+		step := &definition.Steps[i]
+		if !stepNameRegexp.MatchString(step.Name) {
+			return fmt.Errorf("invalid step name %q", step.Name)
+		}
+		if _, ok := steps[step.Name]; ok {
+			return fmt.Errorf("duplicate step name %q", step.Name)
+		}
+		if step.Run == "" {
+			return fmt.Errorf("step %q has nothing to run", step.Name)
+		}
+		if _, err := bash.ScriptWithEnv(step.Run, step.Env); err != nil {
+			return fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		if err := bash.ValidateOptions(bash.CommandOptions{Retry: step.Retry, Assertions: step.Assertions}); err != nil {
+			return fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		if step.TimeoutMs < 0 {
+			return fmt.Errorf("step %q has negative timeout", step.Name)
+		}
+		steps[step.Name] = step
+	}
+	for _, step := range definition.Steps {
+		for _, need := range step.Needs {
+			if _, ok := steps[need]; !ok {
+				return fmt.Errorf("step %q needs unknown step %q", step.Name, need)
+			}
+		}
+		if _, err := compileCondition(step.If, step.Needs); err != nil {
+			return fmt.Errorf("step %q: %w", step.Name, err)
+		}
+	}
+
+	// Kahn's algorithm, the steps left over are on a cycle
+	dependencies := make(map[string]int, len(steps))
+	for _, step := range definition.Steps {
+		dependencies[step.Name] = len(step.Needs)
+	}
+	ready := []string{}
+	for name, count := range dependencies {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+	visited := 0
+	for len(ready) != 0 {
+		name := ready[0]
+		ready = ready[1:]
+		visited++
+		for _, step := range definition.Steps {
+			for _, need := range step.Needs {
+				if need != name {
+					continue
+				}
+				if dependencies[step.Name]--; dependencies[step.Name] == 0 {
+					ready = append(ready, step.Name)
+				}
+			}
+		}
+	}
+	if visited != len(steps) {
+		return fmt.Errorf("workflow steps have a dependency cycle")
+	}
+	return nil
+}
+
+// NewWorkflow returns a workflow run of the definition with pending steps.
+func NewWorkflow(definition *Definition) (*models.Workflows, error) {
+	buf, err := json.Marshal(definition)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal error: %v", err)
+	}
+	workflow := &models.Workflows{
+		Name:       definition.Name,
+		Status:     models.STATUS_RUNNING,
+		Definition: buf,
+		Steps:      make([]models.WorkflowSteps, len(definition.Steps)),
+	}
+	for i, step := range definition.Steps {
+		needs := step.Needs
+		if needs == nil {
+			needs = []string{}
+		}
+		workflow.Steps[i] = models.WorkflowSteps{Name: step.Name, Needs: needs, Status: models.STATUS_PENDING}
+	}
+	return workflow, nil
+}
+
+// stepResult is what the conditions of the next steps see of a finished step.
+type stepResult struct {
+	status   string
+	exitCode int
+	// continue_on_error was set
+	continueOnError bool
+	stdout          string
+	outputs         map[string]string
+}
+
+func (result *stepResult) succeeded() bool {
+	return result.status == models.STATUS_SUCCEEDED ||
+		(result.status == models.STATUS_FAILED && result.continueOnError)
+}
+
+type condition func(results map[string]*stepResult) bool
+
+// compileCondition compiles the if expression of a step. An expression is
+// success(), failure(), always() or a comparison of the exit_code or status
+// of a needed step, combined with && and ||.
+func compileCondition(expression string, needs []string) (condition, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		expression = "success()"
+	}
+
+	anyOf := []condition{}
+	for _, orPart := range strings.Split(expression, "||") {
+		allOf := []condition{}
+		for _, term := range strings.Split(orPart, "&&") {
+			compiled, err := compileTerm(strings.TrimSpace(term), needs)
+			if err != nil {
+				return nil, err
+			}
+			allOf = append(allOf, compiled)
+		}
+		anyOf = append(anyOf, func(results map[string]*stepResult) bool {
+			for _, compiled := range allOf {
+				if !compiled(results) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	return func(results map[string]*stepResult) bool {
+		for _, compiled := range anyOf {
+			if compiled(results) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func compileTerm(term string, needs []string) (condition, error) {
+	switch term {
+	case "always()":
+		return func(map[string]*stepResult) bool { return true }, nil
+	case "success()":
+		return func(results map[string]*stepResult) bool {
+			for _, need := range needs {
+				if !results[need].succeeded() {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "failure()":
+		return func(results map[string]*stepResult) bool {
+			for _, need := range needs {
+				if results[need].status == models.STATUS_FAILED {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	match := comparisonRegexp.FindStringSubmatch(term)
+	if match == nil {
+		return nil, fmt.Errorf("invalid condition %q", term)
+	}
+	name, field, operator, value := match[1], match[2], match[3], match[4]
+	isNeeded := false
+	for _, need := range needs {
+		isNeeded = isNeeded || need == name
+	}
+	if !isNeeded {
+		return nil, fmt.Errorf("condition %q refers to step %q that isn't in needs", term, name)
+	}
+
+	if field == "status" {
+		if operator != "==" && operator != "!=" {
+			return nil, fmt.Errorf("condition %q compares status with %v", term, operator)
+		}
+		return func(results map[string]*stepResult) bool {
+			return (results[name].status == value) == (operator == "==")
+		}, nil
+	}
+	expected, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("condition %q compares exit_code with %q", term, value)
+	}
+	return func(results map[string]*stepResult) bool {
+		result := results[name]
+		// a skipped step has no exit code
+		if result.status == models.STATUS_SKIPPED {
+			return false
+		}
+		switch operator {
+		case "==":
+			return result.exitCode == expected
+		case "!=":
+			return result.exitCode != expected
+		case "<":
+			return result.exitCode < expected
+		case ">":
+			return result.exitCode > expected
+		case "<=":
+			return result.exitCode <= expected
+		default:
+			return result.exitCode >= expected
+		}
+	}, nil
+}
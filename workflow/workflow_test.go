@@ -0,0 +1,254 @@
+package workflow
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Vy4cheSlave/test-task-postgres/bash"
+	mock_database "github.com/Vy4cheSlave/test-task-postgres/database/mock"
+	"github.com/Vy4cheSlave/test-task-postgres/limits"
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+	"github.com/golang/mock/gomock"
+)
+
+func TestParseDefinition(t *testing.T) {
+	var tests = []struct {
+		testName string
+		input    string
+		wantErr  string
+	}{
+		{
+			"yaml",
+			"name: build\nsteps:\n  - name: a\n    run: echo a\n  - name: b\n    run: echo b\n    needs: [a]\n    if: a.exit_code == 0\n",
+			"",
+		},
+		{
+			"json",
+			`{"name": "build", "steps": [{"name": "a", "run": "echo a"}, {"name": "b", "run": "echo b", "needs": ["a"]}]}`,
+			"",
+		},
+		{"no steps", `{"name": "build"}`, "no steps"},
+		{"duplicate", `{"steps": [{"name": "a", "run": "true"}, {"name": "a", "run": "true"}]}`, "duplicate"},
+		{"unknown need", `{"steps": [{"name": "a", "run": "true", "needs": ["b"]}]}`, "unknown step"},
+		{
+			"cycle",
+			`{"steps": [{"name": "a", "run": "true", "needs": ["b"]}, {"name": "b", "run": "true", "needs": ["a"]}]}`,
+			"cycle",
+		},
+		{"invalid condition", `{"steps": [{"name": "a", "run": "true", "if": "maybe"}]}`, "invalid condition"},
+		{
+			"condition on a step not in needs",
+			`{"steps": [{"name": "a", "run": "true"}, {"name": "b", "run": "true", "if": "a.exit_code == 0"}]}`,
+			"isn't in needs",
+		},
+		{"invalid env", `{"steps": [{"name": "a", "run": "true", "env": {"A-B": "1"}}]}`, "invalid env"},
+		{
+			"invalid assertion regex",
+			`{"steps": [{"name": "a", "run": "true", "assertions": {"stdout_regex": "("}}]}`,
+			"invalid assertion stdout_regex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			_, err := ParseDefinition([]byte(tt.input))
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileCondition(t *testing.T) {
+	results := map[string]*stepResult{
+		"ok":      {status: models.STATUS_SUCCEEDED},
+		"broken":  {status: models.STATUS_FAILED, exitCode: 2},
+		"ignored": {status: models.STATUS_FAILED, exitCode: 1, continueOnError: true},
+		"skipped": {status: models.STATUS_SKIPPED},
+	}
+
+	var tests = []struct {
+		expression string
+		needs      []string
+		want       bool
+	}{
+		{"", []string{}, true},
+		{"", []string{"ok"}, true},
+		{"", []string{"ok", "broken"}, false},
+		{"", []string{"ok", "ignored"}, true},
+		{"", []string{"skipped"}, false},
+		{"failure()", []string{"ok", "broken"}, true},
+		{"failure()", []string{"ok"}, false},
+		{"always()", []string{"broken"}, true},
+		{"broken.exit_code == 2", []string{"broken"}, true},
+		{"broken.exit_code >= 3", []string{"broken"}, false},
+		{"skipped.exit_code != 0", []string{"skipped"}, false},
+		{"skipped.status == skipped", []string{"skipped"}, true},
+		{"ok.exit_code != 0 || broken.exit_code == 2", []string{"ok", "broken"}, true},
+		{"ok.exit_code == 0 && broken.exit_code == 0", []string{"ok", "broken"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			isTrue, err := compileCondition(tt.expression, tt.needs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := isTrue(results); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := mock_database.NewMockDBWorker(ctrl)
+
+	definition, err := ParseDefinition([]byte(`
+name: release
+steps:
+  - name: version
+    run: echo "::output tag=v1.2.3"
+  - name: build
+    run: echo "building $STEPS_VERSION_OUTPUTS_TAG"; exit 3
+    needs: [version]
+  - name: notify
+    run: echo "build failed with $STEPS_BUILD_EXIT_CODE"
+    needs: [build]
+    if: failure()
+  - name: publish
+    run: echo publish
+    needs: [build]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow, err := NewWorkflow(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow.Id = 1
+
+	logs := map[string]string{}
+	db.EXPECT().UpdateWorkflowStepQuery(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(step *models.WorkflowSteps, command *models.CommandsWithoutID, ctx context.Context) error {
+			if command != nil {
+				logs[step.Name] = command.Log
+			}
+			return nil
+		}).AnyTimes()
+	db.EXPECT().FinishWorkflowQuery(uint(1), models.STATUS_FAILED, gomock.Any()).Return(nil)
+
+	status := NewRunner(db, bash.BashCommands{}, nil).Run(workflow, definition, context.Background())
+	if status != models.STATUS_FAILED {
+		t.Errorf("workflow status: got %v, want %v", status, models.STATUS_FAILED)
+	}
+
+	wantStatuses := map[string]string{
+		"version": models.STATUS_SUCCEEDED,
+		"build":   models.STATUS_FAILED,
+		"notify":  models.STATUS_SUCCEEDED,
+		"publish": models.STATUS_SKIPPED,
+	}
+	for _, step := range workflow.Steps {
+		if step.Status != wantStatuses[step.Name] {
+			t.Errorf("step %v: got status %v, want %v", step.Name, step.Status, wantStatuses[step.Name])
+		}
+	}
+	if workflow.Steps[0].Outputs["tag"] != "v1.2.3" {
+		t.Errorf("outputs: got %v", workflow.Steps[0].Outputs)
+	}
+	if logs["build"] != "building v1.2.3\n" {
+		t.Errorf("build log: got %q", logs["build"])
+	}
+	if logs["notify"] != "build failed with 3\n" {
+		t.Errorf("notify log: got %q", logs["notify"])
+	}
+}
+
+func TestRunnerRunLargeStdout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := mock_database.NewMockDBWorker(ctrl)
+
+	// 256 KiB of stdout is more than a single argument of a process may take
+	definition, err := ParseDefinition([]byte(`
+name: large
+steps:
+  - name: dump
+    run: yes x | head -c 262144
+  - name: count
+    run: printf %s "$STEPS_DUMP_STDOUT" | wc -c
+    needs: [dump]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow, err := NewWorkflow(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow.Id = 1
+
+	logs := map[string]string{}
+	db.EXPECT().UpdateWorkflowStepQuery(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(step *models.WorkflowSteps, command *models.CommandsWithoutID, ctx context.Context) error {
+			if command != nil {
+				logs[step.Name] = command.Log
+			}
+			return nil
+		}).AnyTimes()
+	db.EXPECT().FinishWorkflowQuery(uint(1), models.STATUS_SUCCEEDED, gomock.Any()).Return(nil)
+
+	status := NewRunner(db, bash.BashCommands{}, nil).Run(workflow, definition, context.Background())
+	if status != models.STATUS_SUCCEEDED {
+		t.Errorf("workflow status: got %v, want %v", status, models.STATUS_SUCCEEDED)
+	}
+	if strings.TrimSpace(logs["count"]) != strconv.Itoa(STEP_STDOUT_ENV_BYTES) {
+		t.Errorf("count log: got %q, want %v", logs["count"], STEP_STDOUT_ENV_BYTES)
+	}
+}
+
+func TestRunnerRunOverQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := mock_database.NewMockDBWorker(ctrl)
+
+	definition, err := ParseDefinition([]byte(`
+name: quota
+steps:
+  - name: build
+    run: echo build
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow, err := NewWorkflow(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownKeyId := uint(3)
+	workflow.Id, workflow.ApiKeyId = 1, &ownKeyId
+
+	db.EXPECT().GettingApiKeyQuery(ownKeyId, gomock.Any()).Return(&models.ApiKeys{Id: ownKeyId, Role: models.ROLE_OPERATOR}, nil)
+	db.EXPECT().GettingRolePolicyQuery(models.ROLE_OPERATOR, gomock.Any()).Return(&models.RolePolicies{CommandsPerDay: 10}, nil)
+	db.EXPECT().GettingUsageQuery(ownKeyId, gomock.Any(), gomock.Any()).Return(&models.Usage{Commands: 10}, nil)
+	db.EXPECT().UpdateWorkflowStepQuery(gomock.Any(), nil, gomock.Any()).Return(nil)
+	db.EXPECT().FinishWorkflowQuery(uint(1), models.STATUS_FAILED, gomock.Any()).Return(nil)
+
+	status := NewRunner(db, bash.BashCommands{}, limits.NewQuotas()).Run(workflow, definition, context.Background())
+	if status != models.STATUS_FAILED {
+		t.Errorf("workflow status: got %v, want %v", status, models.STATUS_FAILED)
+	}
+	step := workflow.Steps[0]
+	if step.Status != models.STATUS_FAILED || !strings.Contains(step.Error, "commands_per_day") {
+		t.Errorf("step: got status %v and error %q", step.Status, step.Error)
+	}
+}
@@ -0,0 +1,504 @@
+package workspaces
+
+import (
+	// std
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	// local
+	"github.com/Vy4cheSlave/test-task-postgres/models"
+)
+
+const (
+	// environment variables of the workspaces, commands can target
+	// workspaces only when WORKSPACES_DIR is set
+	DIR_ENV       string = "WORKSPACES_DIR"
+	MAX_BYTES_ENV string = "WORKSPACE_MAX_BYTES"
+
+	DEFAULT_MAX_BYTES int64 = 1 << 30
+)
+
+// names don't start with a dot, so they are never . or .. and don't clash
+// with the temporary files of the manager
+var nameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]{0,63}$`)
+
+// errors of the manager the callers can tell apart, the paths in the
+// messages are relative to the workspace
+var (
+	ErrInvalidName = errors.New("invalid workspace name")
+	ErrInvalidPath = errors.New("invalid path")
+	ErrNotFound    = errors.New("isn't found")
+	ErrOverQuota   = errors.New("over its quota")
+)
+
+// Manager keeps the workspaces as directories of Dir by their names. The
+// files of a workspace take up to MaxBytes, commands can go over it while they
+// run, a workspace over its quota only accepts deletions.
+//
+// The id of the api key that created a workspace is kept next to it in a
+// .<name>.owner file. The methods take the owner of auth.CommandsOwner: a
+// non-nil owner sees only the workspaces of its key, like the commands.
+type Manager struct {
+	Dir      string
+	MaxBytes int64
+
+	// a write through the api checks the quota and stores the file at once,
+	// a new workspace is created with its owner at once
+	mutex sync.Mutex
+}
+
+// ManagerFromEnv returns the manager of WORKSPACES_DIR, nil if it isn't set.
+func ManagerFromEnv() (*Manager, error) {
+	dir := os.Getenv(DIR_ENV)
+	if dir == "" {
+		return nil, nil
+	}
+	manager := &Manager{Dir: dir, MaxBytes: DEFAULT_MAX_BYTES}
+	if value := os.Getenv(MAX_BYTES_ENV); value != "" {
+		maxBytes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || maxBytes <= 0 {
+			return nil, fmt.Errorf("invalid %v %q, it must be a positive number of bytes", MAX_BYTES_ENV, value)
+		}
+		manager.MaxBytes = maxBytes
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("unable to create workspaces dir: %w", err)
+	}
+	return manager, nil
+}
+
+// ValidateName returns an error if the name can't be a workspace name.
+func ValidateName(name string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// root returns the directory of an existing workspace the owner may see.
+func (manager *Manager) root(name string, owner *uint) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	root := filepath.Join(manager.Dir, name)
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("workspace %q %w", name, ErrNotFound)
+	} else if err != nil {
+		return "", fmt.Errorf("workspace %q: %w", name, err)
+	}
+	if err := manager.checkOwner(name, owner); err != nil {
+		return "", err
+	}
+	return root, nil
+}
+
+func (manager *Manager) ownerPath(name string) string {
+	return filepath.Join(manager.Dir, "."+name+".owner")
+}
+
+// readOwner returns the id of the api key that created a workspace, nil if it
+// was created without a key.
+func (manager *Manager) readOwner(name string) (*uint, error) {
+	data, err := os.ReadFile(manager.ownerPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read the owner of workspace %q: %w", name, err)
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid owner of workspace %q: %w", name, err)
+	}
+	keyId := uint(id)
+	return &keyId, nil
+}
+
+// checkOwner returns ErrNotFound if the owner may not see the workspace, the
+// workspace of another key is the same as a missing one.
+func (manager *Manager) checkOwner(name string, owner *uint) error {
+	if owner == nil {
+		return nil
+	}
+	keyId, err := manager.readOwner(name)
+	if err != nil {
+		return err
+	}
+	if keyId == nil || *keyId != *owner {
+		return fmt.Errorf("workspace %q %w", name, ErrNotFound)
+	}
+	return nil
+}
+
+// Prepare returns the directory a command runs in. If there is no workspace,
+// it is created for the api key apiKeyId. A workspace over its quota can't be
+// used.
+func (manager *Manager) Prepare(name string, apiKeyId *uint, owner *uint) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	root := filepath.Join(manager.Dir, name)
+	if err := manager.create(name, root, apiKeyId); err != nil {
+		return "", err
+	}
+	if err := manager.checkOwner(name, owner); err != nil {
+		return "", err
+	}
+	usage, err := manager.usage(root)
+	if err != nil {
+		return "", err
+	}
+	if usage.SizeBytes > manager.MaxBytes {
+		return "", fmt.Errorf("workspace %q takes %v bytes, %w of %v bytes", name, usage.SizeBytes, ErrOverQuota, manager.MaxBytes)
+	}
+	return root, nil
+}
+
+// create creates a workspace that doesn't exist with its owner.
+func (manager *Manager) create(name string, root string, apiKeyId *uint) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if _, err := os.Stat(root); err == nil {
+		return nil
+	}
+	// the owner is stored first, a workspace without it would have none
+	ownerPath := manager.ownerPath(name)
+	if apiKeyId != nil {
+		if err := os.WriteFile(ownerPath, []byte(strconv.FormatUint(uint64(*apiKeyId), 10)), 0o644); err != nil {
+			return fmt.Errorf("unable to create workspace %q: %w", name, err)
+		}
+	} else if err := os.Remove(ownerPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to create workspace %q: %w", name, err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return fmt.Errorf("unable to create workspace %q: %w", name, err)
+	}
+	return nil
+}
+
+// usage returns the size and the number of the files of a workspace and when
+// it was last modified.
+func (manager *Manager) usage(root string) (models.Workspaces, error) {
+	usage := models.Workspaces{Name: filepath.Base(root)}
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(usage.ModifiedAt) {
+			usage.ModifiedAt = info.ModTime()
+		}
+		if entry.Type().IsRegular() {
+			usage.SizeBytes += info.Size()
+			usage.Files++
+		}
+		return nil
+	})
+	if err != nil {
+		return usage, fmt.Errorf("unable to read workspace %q: %w", usage.Name, err)
+	}
+	return usage, nil
+}
+
+// Usage returns the size and the number of the files of a workspace.
+func (manager *Manager) Usage(name string, owner *uint) (models.Workspaces, error) {
+	root, err := manager.root(name, owner)
+	if err != nil {
+		return models.Workspaces{}, err
+	}
+	usage, err := manager.usage(root)
+	if err != nil {
+		return usage, err
+	}
+	usage.ApiKeyId, err = manager.readOwner(name)
+	return usage, err
+}
+
+// List returns every workspace the owner may see by name.
+func (manager *Manager) List(owner *uint) ([]models.Workspaces, error) {
+	entries, err := os.ReadDir(manager.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read workspaces: %w", err)
+	}
+	workspaces := []models.Workspaces{}
+	for _, entry := range entries {
+		if !entry.IsDir() || ValidateName(entry.Name()) != nil {
+			continue
+		}
+		keyId, err := manager.readOwner(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil && (keyId == nil || *keyId != *owner) {
+			continue
+		}
+		usage, err := manager.usage(filepath.Join(manager.Dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		usage.ApiKeyId = keyId
+		workspaces = append(workspaces, usage)
+	}
+	return workspaces, nil
+}
+
+// ListFiles returns the files and the symbolic links of a workspace by path.
+func (manager *Manager) ListFiles(name string, owner *uint) ([]models.WorkspaceFiles, error) {
+	root, err := manager.root(name, owner)
+	if err != nil {
+		return nil, err
+	}
+	files := []models.WorkspaceFiles{}
+	err = filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		workspaceFile := models.WorkspaceFiles{Path: filepath.ToSlash(rel), SizeBytes: info.Size(), ModifiedAt: info.ModTime()}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if workspaceFile.LinkTarget, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		files = append(files, workspaceFile)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read workspace %q: %w", name, err)
+	}
+	return files, nil
+}
+
+// resolve returns the path of a file of a workspace. The path has to stay in
+// the workspace, also through the symbolic links commands may have created.
+func (manager *Manager) resolve(name string, file string, owner *uint) (string, string, error) {
+	root, err := manager.root(name, owner)
+	if err != nil {
+		return "", "", err
+	}
+	if file == "" || strings.Contains(file, `\`) || !filepath.IsLocal(file) || filepath.Clean(file) == "." {
+		return "", "", fmt.Errorf("%w %q, it must be a file of the workspace", ErrInvalidPath, file)
+	}
+	return root, filepath.Join(root, filepath.Clean(file)), nil
+}
+
+// checkInside returns an error if the deepest existing directory of the path
+// is outside the root once the symbolic links are followed.
+func checkInside(root string, path string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	existing := path
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+	if real != realRoot && !strings.HasPrefix(real, realRoot+string(filepath.Separator)) {
+		rel, _ := filepath.Rel(root, path)
+		return fmt.Errorf("%w %q, it leaves the workspace", ErrInvalidPath, rel)
+	}
+	return nil
+}
+
+// Open returns a file of a workspace, a link is followed only inside it.
+func (manager *Manager) Open(name string, file string, owner *uint) (*os.File, error) {
+	root, path, err := manager.resolve(name, file, owner)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%q of workspace %q %w", file, name, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := checkInside(root, path); err != nil {
+		return nil, err
+	}
+	opened, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := opened.Stat(); err != nil || !info.Mode().IsRegular() {
+		opened.Close()
+		return nil, fmt.Errorf("%w %q, it isn't a file of workspace %q", ErrInvalidPath, file, name)
+	}
+	return opened, nil
+}
+
+// WriteFile stores the reader as a file of a workspace, replacing the file
+// that is there. The workspace is created for the api key apiKeyId if there is
+// none, the file fails if the workspace goes over its quota.
+func (manager *Manager) WriteFile(name string, file string, reader io.Reader, apiKeyId *uint, owner *uint) (int64, error) {
+	if _, err := manager.Prepare(name, apiKeyId, owner); err != nil {
+		return 0, err
+	}
+	root, path, err := manager.resolve(name, file, owner)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkInside(root, filepath.Dir(path)); err != nil {
+		return 0, err
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	usage, err := manager.usage(root)
+	if err != nil {
+		return 0, err
+	}
+	room := manager.MaxBytes - usage.SizeBytes
+	if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+		room += info.Size()
+	}
+
+	temp, err := os.CreateTemp(manager.Dir, ".tmp-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+	size, err := io.Copy(temp, io.LimitReader(reader, max(room, 0)+1))
+	if err != nil {
+		return 0, err
+	}
+	if size > room {
+		return 0, fmt.Errorf("workspace %q is %w of %v bytes", name, ErrOverQuota, manager.MaxBytes)
+	}
+	if err := temp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(temp.Name(), 0o644); err != nil {
+		return 0, err
+	}
+	// the rename replaces a symbolic link instead of writing through it
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// RemoveFile removes a file or a directory with its files from a workspace.
+func (manager *Manager) RemoveFile(name string, file string, owner *uint) error {
+	root, path, err := manager.resolve(name, file, owner)
+	if err != nil {
+		return err
+	}
+	if err := checkInside(root, filepath.Dir(path)); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%q of workspace %q %w", file, name, ErrNotFound)
+	} else if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// Remove removes a workspace with its files and its owner.
+func (manager *Manager) Remove(name string, owner *uint) error {
+	root, err := manager.root(name, owner)
+	if err != nil {
+		return err
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if err := os.RemoveAll(root); err != nil {
+		return err
+	}
+	if err := os.Remove(manager.ownerPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Snapshot writes the files of a workspace as a tar archive, symbolic links
+// are archived as links.
+func (manager *Manager) Snapshot(name string, writer io.Writer, owner *uint) error {
+	root, err := manager.root(name, owner)
+	if err != nil {
+		return err
+	}
+	archive := tar.NewWriter(writer)
+	err = filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		case !entry.IsDir() && !entry.Type().IsRegular():
+			// sockets and pipes have no content to archive
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(archive, file)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to snapshot workspace %q: %w", name, err)
+	}
+	return archive.Close()
+}
+
+func copyFile(writer io.Writer, file string) error {
+	reader, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = io.Copy(writer, reader)
+	return err
+}
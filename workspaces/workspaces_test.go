@@ -0,0 +1,233 @@
+package workspaces
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManagerFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	var tests = []struct {
+		testName string
+		env      map[string]string
+		want     *Manager
+		wantErr  bool
+	}{
+		{"nothing set", map[string]string{}, nil, false},
+		{"default quota", map[string]string{DIR_ENV: dir}, &Manager{Dir: dir, MaxBytes: DEFAULT_MAX_BYTES}, false},
+		{"quota", map[string]string{DIR_ENV: dir, MAX_BYTES_ENV: "1024"}, &Manager{Dir: dir, MaxBytes: 1024}, false},
+		{"invalid quota", map[string]string{DIR_ENV: dir, MAX_BYTES_ENV: "1GB"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			for _, env := range []string{DIR_ENV, MAX_BYTES_ENV} {
+				t.Setenv(env, tt.env[env])
+			}
+			got, err := ManagerFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ManagerFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && (got.Dir != tt.want.Dir || got.MaxBytes != tt.want.MaxBytes)) {
+				t.Errorf("ManagerFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_Files(t *testing.T) {
+	manager := &Manager{Dir: t.TempDir(), MaxBytes: 16}
+
+	if _, err := manager.WriteFile("deploy", "conf/app.env", strings.NewReader("A=1\n"), nil, nil); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	// a file is replaced, its old size doesn't count against the quota
+	if _, err := manager.WriteFile("deploy", "conf/app.env", strings.NewReader("A=2\nB=3\n"), nil, nil); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := manager.WriteFile("deploy", "big.bin", strings.NewReader(strings.Repeat("x", 9)), nil, nil); err == nil {
+		t.Errorf("WriteFile() over the quota isn't an error")
+	}
+	for _, name := range []string{"../escape", "/etc/passwd", "."} {
+		if _, err := manager.WriteFile("deploy", name, strings.NewReader("x"), nil, nil); err == nil {
+			t.Errorf("WriteFile(%q) isn't an error", name)
+		}
+	}
+	if _, err := manager.WriteFile("../deploy", "x", strings.NewReader("x"), nil, nil); err == nil {
+		t.Errorf("WriteFile() of an invalid workspace isn't an error")
+	}
+
+	file, err := manager.Open("deploy", "conf/app.env", nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "A=2\nB=3\n" {
+		t.Errorf("unexpected content %q", content)
+	}
+
+	files, err := manager.ListFiles("deploy", nil)
+	if err != nil || len(files) != 1 || files[0].Path != "conf/app.env" || files[0].SizeBytes != 8 {
+		t.Errorf("unexpected files %+v %v", files, err)
+	}
+	list, err := manager.List(nil)
+	if err != nil || len(list) != 1 || list[0].Name != "deploy" || list[0].SizeBytes != 8 || list[0].Files != 1 {
+		t.Errorf("unexpected workspaces %+v %v", list, err)
+	}
+
+	if err := manager.RemoveFile("deploy", "conf", nil); err != nil {
+		t.Fatalf("RemoveFile() error = %v", err)
+	}
+	if _, err := manager.Open("deploy", "conf/app.env", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the file to be removed but got %v", err)
+	}
+	if err := manager.Remove("deploy", nil); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := manager.ListFiles("deploy", nil); err == nil {
+		t.Errorf("ListFiles() of a removed workspace isn't an error")
+	}
+}
+
+func TestManager_Symlinks(t *testing.T) {
+	manager := &Manager{Dir: t.TempDir(), MaxBytes: 1024}
+	root, err := manager.Prepare("deploy", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o644)
+	// links a command may create
+	os.Symlink(outside, filepath.Join(root, "out"))
+	os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "secret"))
+	os.WriteFile(filepath.Join(root, "inside"), []byte("inside"), 0o644)
+	os.Symlink("inside", filepath.Join(root, "link"))
+
+	for _, name := range []string{"out/secret", "secret"} {
+		if _, err := manager.Open("deploy", name, nil); err == nil {
+			t.Errorf("Open(%q) through a link out of the workspace isn't an error", name)
+		}
+	}
+	if _, err := manager.WriteFile("deploy", "out/new", strings.NewReader("x"), nil, nil); err == nil {
+		t.Errorf("WriteFile() through a link out of the workspace isn't an error")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new")); err == nil {
+		t.Errorf("a file is written out of the workspace")
+	}
+	file, err := manager.Open("deploy", "link", nil)
+	if err != nil {
+		t.Fatalf("Open() of a link inside the workspace error = %v", err)
+	}
+	file.Close()
+	// a write replaces the link instead of writing through it
+	if _, err := manager.WriteFile("deploy", "secret", strings.NewReader("replaced"), nil, nil); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(outside, "secret")); string(content) != "secret" {
+		t.Errorf("a file out of the workspace is overwritten: %q", content)
+	}
+}
+
+func TestManager_Snapshot(t *testing.T) {
+	manager := &Manager{Dir: t.TempDir(), MaxBytes: 1024}
+	manager.WriteFile("deploy", "conf/app.env", strings.NewReader("A=1\n"), nil, nil)
+	root, _ := manager.Prepare("deploy", nil, nil)
+	os.Symlink("conf/app.env", filepath.Join(root, "app.env"))
+
+	snapshot := &bytes.Buffer{}
+	if err := manager.Snapshot("deploy", snapshot, nil); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	entries := []string{}
+	archive := tar.NewReader(snapshot)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(archive)
+		entries = append(entries, header.Name+" "+header.Linkname+" "+string(content))
+	}
+	want := []string{"app.env conf/app.env ", "conf/  ", "conf/app.env  A=1\n"}
+	if strings.Join(entries, "|") != strings.Join(want, "|") {
+		t.Errorf("expected entries %q but got %q", want, entries)
+	}
+}
+
+func TestManager_Prepare(t *testing.T) {
+	manager := &Manager{Dir: t.TempDir(), MaxBytes: 4}
+	root, err := manager.Prepare("deploy", nil, nil)
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	// commands can go over the quota, the workspace isn't used until files
+	// are deleted
+	os.WriteFile(filepath.Join(root, "big"), []byte("too large"), 0o644)
+	if _, err := manager.Prepare("deploy", nil, nil); err == nil {
+		t.Errorf("Prepare() of a workspace over its quota isn't an error")
+	}
+	if _, err := manager.WriteFile("deploy", "small", strings.NewReader("x"), nil, nil); err == nil {
+		t.Errorf("WriteFile() to a workspace over its quota isn't an error")
+	}
+	if err := manager.RemoveFile("deploy", "big", nil); err != nil {
+		t.Fatalf("RemoveFile() error = %v", err)
+	}
+	if _, err := manager.Prepare("deploy", nil, nil); err != nil {
+		t.Errorf("Prepare() error = %v", err)
+	}
+}
+
+func TestManager_Owners(t *testing.T) {
+	manager := &Manager{Dir: t.TempDir(), MaxBytes: 1024}
+	ownKeyId, otherKeyId := uint(3), uint(4)
+	if _, err := manager.WriteFile("deploy", "app.env", strings.NewReader("A=1\n"), &ownKeyId, &ownKeyId); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	// a workspace created without a key has no owner
+	if _, err := manager.Prepare("shared", nil, nil); err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	// the other key sees neither workspace, as if they were missing
+	if _, err := manager.Open("deploy", "app.env", &otherKeyId); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the workspace of another key to be missing but got %v", err)
+	}
+	if _, err := manager.WriteFile("deploy", "app.env", strings.NewReader("B=2\n"), &otherKeyId, &otherKeyId); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the workspace of another key to be missing but got %v", err)
+	}
+	if _, err := manager.Prepare("shared", &otherKeyId, &otherKeyId); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a workspace without an owner to be missing but got %v", err)
+	}
+	if err := manager.Remove("deploy", &otherKeyId); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the workspace of another key to be missing but got %v", err)
+	}
+	if list, err := manager.List(&otherKeyId); err != nil || len(list) != 0 {
+		t.Errorf("expected no workspaces but got %+v %v", list, err)
+	}
+
+	list, err := manager.List(&ownKeyId)
+	if err != nil || len(list) != 1 || list[0].Name != "deploy" || list[0].ApiKeyId == nil || *list[0].ApiKeyId != ownKeyId {
+		t.Errorf("unexpected workspaces %+v %v", list, err)
+	}
+	if list, err := manager.List(nil); err != nil || len(list) != 2 {
+		t.Errorf("expected every workspace but got %+v %v", list, err)
+	}
+
+	// a removed workspace can be created again by another key
+	if err := manager.Remove("deploy", &ownKeyId); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := manager.Prepare("deploy", &otherKeyId, &otherKeyId); err != nil {
+		t.Errorf("Prepare() error = %v", err)
+	}
+}